@@ -22,6 +22,7 @@ import (
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/artic"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/cleveland"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/getty"
+	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/iiif"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/metmuseum"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/npm"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/rijksmuseum"
@@ -76,17 +77,58 @@ func generateIndexHTML(dir string, galleries map[string][]galleryLink) {
 	}
 }
 
+// generateIIIFGallery renders a salon wall for an arbitrary IIIF manifest or collection,
+// which has no curated list to drive it.
+func generateIIIFGallery(ctx context.Context, providers []provider.ImageProvider, manifestURL string, cfg *wallpaper.Config, baseDestDir string) {
+	var prov provider.ImageProvider
+	for _, p := range providers {
+		if p.ID() == "IIIF" {
+			prov = p
+		}
+	}
+	if prov == nil {
+		fmt.Println("Error: IIIF provider is not registered.")
+		os.Exit(1)
+	}
+
+	parsed, err := prov.ParseURL(manifestURL)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	hash := sha256.Sum256([]byte(parsed))
+	entry := curation.CollectionEntry{
+		Name: parsed,
+		Key:  "iiif_" + hex.EncodeToString(hash[:4]),
+		Type: "curated",
+		IDs:  []string{parsed},
+	}
+
+	destDir := filepath.Join(baseDestDir, "iiif")
+	if err := wallpaper.GenerateGalleryForProvider(ctx, prov, entry, cfg, &http.Client{}, destDir, 0); err != nil {
+		fmt.Printf("IIIF: Failed to generate gallery for %s: %v\n", parsed, err)
+		os.Exit(1)
+	}
+	fmt.Printf("IIIF: Wrote %s\n", filepath.Join(destDir, entry.Key+".html"))
+}
+
 func main() {
 	debugFlag := flag.Bool("debug", false, "Enable debug logging")
 	fullFlag := flag.Bool("full", false, "Generate full galleries and index.html")
 	limitFlag := flag.Int("limit", 12, "Maximum number of items per gallery (ignored if --full is used)")
-	outFlag := flag.String("out", "", "Output directory (mandatory if --full is used)")
+	outFlag := flag.String("out", "", "Output directory (mandatory if --full or --iiif is used)")
+	iiifFlag := flag.String("iiif", "", "Generate a single gallery from a IIIF manifest or collection URL")
 	flag.Parse()
 
 	if *fullFlag && *outFlag == "" {
 		fmt.Println("Error: --out is mandatory when using --full to prevent clobbering embedded app galleries.")
 		os.Exit(1)
 	}
+	if *iiifFlag != "" && *outFlag == "" {
+		fmt.Println("Error: --out is mandatory when using --iiif to prevent clobbering embedded app galleries.")
+		os.Exit(1)
+	}
 
 	log.SetDebugEnabled(*debugFlag)
 
@@ -137,6 +179,11 @@ func main() {
 		providers = append(providers, factory(cfg, client))
 	}
 
+	if *iiifFlag != "" {
+		generateIIIFGallery(ctx, providers, *iiifFlag, cfg, baseDestDir)
+		return
+	}
+
 	for _, prov := range providers {
 		if _, ok := prov.(provider.ThumbnailProvider); !ok {
 			fmt.Printf("%s: Provider does not support thumbnails, skipping\n", prov.ID())
@@ -5,15 +5,25 @@ package main
 import (
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/artic"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/cleveland"
+	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/customapi"
+	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/daily"
+	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/europeana"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/favorites"
+	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/fediverse"
+	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/feed"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/getty"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/googlephotos"
+	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/iiif"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/localfolder"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/metmuseum"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/npm"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/pexels"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/rijksmuseum"
+	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/script"
+	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/selfhosted"
+	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/smithsonian"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/smk"
+	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/unsplash"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/wallhaven"
 	_ "github.com/dixieflatline76/Spice/v2/pkg/wallpaper/providers/wikimedia"
 )
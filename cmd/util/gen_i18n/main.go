@@ -41,7 +41,23 @@ var allowIdenticalToEnglish = map[string]bool{
 	"Statens Museum for Kunst": true,
 	"Copenhagen, Denmark":      true,
 
+	// Smithsonian Proper Nouns
+	"Smithsonian Institution": true,
+	"Washington, DC, USA":     true,
+
+	// Europeana Proper Nouns
+	"Europeana": true,
+
+	// Self-hosted photo servers
+	"Immich":     true,
+	"PhotoPrism": true,
+
+	// Fediverse
+	"Mastodon & Pixelfed": true,
+
 	// International loanwords / identical across many languages
+	"Album":     true,
+	"Person":    true,
 	"App":       true,
 	"Community": true,
 	"General":   true,
@@ -57,6 +73,7 @@ var allowIdenticalToEnglish = map[string]bool{
 
 	// Brand names / tech terms kept in English
 	"Pexels":                  true,
+	"Unsplash":                true,
 	"Wikimedia":               true,
 	"Wikimedia Commons":       true,
 	"Google Photos":           true,
@@ -32,6 +32,10 @@ var knownProvidersConfig = map[string]struct {
 		ConstPath: "pkg/wallpaper/providers/wikimedia/const.go",
 		ConstName: "WikimediaURLRegexp",
 	},
+	"europeana": {
+		ConstPath: "pkg/wallpaper/providers/europeana/const.go",
+		ConstName: "EuropeanaURLRegexp",
+	},
 }
 
 const mainAppPath = "cmd/spice/main.go"
@@ -136,7 +140,7 @@ func generateJSBlock(regexMap map[string]string) string {
 	// Actually, iterating map is random. Let's iterate over knownProvidersConfig keys in fixed order
 	// and check if they exist in regexMap.
 
-	orderedKeys := []string{"wallhaven", "pexels", "unsplash", "wikimedia"}
+	orderedKeys := []string{"wallhaven", "pexels", "unsplash", "wikimedia", "europeana"}
 
 	for i, key := range orderedKeys {
 		regex, exists := regexMap[key]
@@ -144,8 +144,12 @@ const SUPPORTED_PATTERNS = [
     /^https:\/\/wallhaven\.cc\/(?:latest|toplist|hot|random|search|api\/v1\/search|api\/v1\/collections\/[a-zA-Z0-9_]+\/[0-9]+|user\/[a-zA-Z0-9_]+\/favorites\/[0-9]+|favorites\/[0-9]+)(?:\?[a-zA-Z0-9_\-.~!$&'()*+,;=:@\/?%]*|)$/,
     // Pexels
     /^https:\/\/(?:www\.|api\.)?pexels\.com\/(?:search\/|collections\/|v1\/).*$/,
+    // Unsplash
+    /^https:\/\/(?:www\.|api\.)?unsplash\.com\/(?:s\/photos\/|collections\/|t\/|@[^\/]+\/likes|search\/photos|topics\/|users\/).*$/,
     // Wikimedia
     /^(https:\/\/commons\.wikimedia\.org\/(?:wiki\/|w\/index\.php\?)|category:|search:|file:|page:).*$/,
+    // Europeana
+    /^https:\/\/(?:www\.europeana\.eu\/(?:[a-z]{2}\/)?search\?|api\.europeana\.eu\/record\/v2\/search\.json\?).*$/,
 ];
 // REGEX_END
 
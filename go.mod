@@ -26,7 +26,7 @@ require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/disintegration/imaging v1.6.2
 	github.com/fredbi/uri v1.1.1 // indirect
-	github.com/fsnotify/fsnotify v1.9.0 // indirect
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/fyne-io/gl-js v0.2.0 // indirect
 	github.com/fyne-io/glfw-js v0.3.0 // indirect
 	github.com/fyne-io/image v0.1.1 // indirect
@@ -59,6 +59,7 @@ require (
 	github.com/harry1453/go-common-file-dialog v1.2.1-0.20250428222125-566edcc205d7
 	github.com/piprate/json-gold v0.8.0
 	github.com/zalando/go-keyring v0.2.8
+	go.etcd.io/bbolt v1.4.3
 	golang.design/x/hotkey v0.4.1
 	golang.org/x/image v0.36.0
 	golang.org/x/mod v0.36.0
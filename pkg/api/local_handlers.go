@@ -6,6 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/imagecodec"
 )
 
 // resolveCollectionPath resolves a collection path relative to a namespace root and
@@ -36,6 +39,7 @@ func (s *Server) resolveCollectionPath(rootPath, collectionID string) (string, e
 // Supported patterns:
 // 1. list: /local/{namespace}/{collectionID}/images?page=1&per_page=20
 // 2. asset: /local/{namespace}/{collectionID}/assets/{filename}
+// The asset filename may be a relative path into subfolders, e.g. assets/2024/07/beach.jpg.
 func (s *Server) handleLocal(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/local/")
 	parts := strings.Split(path, "/")
@@ -74,7 +78,7 @@ func (s *Server) handleLocal(w http.ResponseWriter, r *http.Request) {
 						http.Error(w, "Missing filename", http.StatusBadRequest)
 						return
 					}
-					s.handleLocalAsset(w, r, directPath, parts[3])
+					s.handleLocalAsset(w, r, directPath, strings.Join(parts[3:], "/"))
 				default:
 					http.Error(w, "Unknown action", http.StatusNotFound)
 				}
@@ -101,7 +105,7 @@ func (s *Server) handleLocal(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Missing filename", http.StatusBadRequest)
 			return
 		}
-		filename := parts[3]
+		filename := strings.Join(parts[3:], "/")
 		s.handleLocalAsset(w, r, collectionPath, filename)
 	default:
 		http.Error(w, "Unknown action", http.StatusNotFound)
@@ -109,10 +113,16 @@ func (s *Server) handleLocal(w http.ResponseWriter, r *http.Request) {
 }
 
 type LocalImage struct {
-	ID          string `json:"id"`
-	URL         string `json:"url"`
-	Attribution string `json:"attribution,omitempty"`
-	ProductURL  string `json:"product_url,omitempty"`
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Attribution string    `json:"attribution,omitempty"`
+	ProductURL  string    `json:"product_url,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Artist      string    `json:"artist,omitempty"`
+	Year        string    `json:"year,omitempty"`
+	Camera      string    `json:"camera,omitempty"`
+	Caption     string    `json:"caption,omitempty"`
+	Taken       time.Time `json:"taken,omitzero"` // Capture date from the image's metadata, else its modification time
 }
 
 func (s *Server) handleLocalListing(w http.ResponseWriter, r *http.Request, rootPath, namespace, collectionID string) {
@@ -121,15 +131,16 @@ func (s *Server) handleLocalListing(w http.ResponseWriter, r *http.Request, root
 }
 
 func (s *Server) handleLocalAsset(w http.ResponseWriter, r *http.Request, collectionPath, filename string) {
-	// Security: validate filename - must be a single path component with no traversal
-	if strings.Contains(filename, "/") || strings.Contains(filename, "\\") || strings.Contains(filename, "..") {
+	// Security: validate filename - a slash-separated relative path with no traversal or empty segments
+	if strings.Contains(filename, "\\") || strings.Contains(filename, "..") {
 		http.Error(w, "Invalid filename", http.StatusBadRequest)
 		return
 	}
-	cleanParams := filepath.Base(filename)
-	if cleanParams != filename {
-		http.Error(w, "Invalid filename", http.StatusBadRequest)
-		return
+	for _, segment := range strings.Split(filename, "/") {
+		if segment == "" || segment == "." || filepath.Base(segment) != segment {
+			http.Error(w, "Invalid filename", http.StatusBadRequest)
+			return
+		}
 	}
 
 	// Double-check containment using absolute, cleaned paths to satisfy CodeQL and prevent traversal
@@ -141,7 +152,7 @@ func (s *Server) handleLocalAsset(w http.ResponseWriter, r *http.Request, collec
 	absCollectionPath = filepath.Clean(absCollectionPath)
 
 	// Build the asset path relative to the normalized collection root
-	fullPath := filepath.Join(absCollectionPath, filename)
+	fullPath := filepath.Join(absCollectionPath, filepath.FromSlash(filename))
 	absFullPath, err := filepath.Abs(fullPath)
 	if err != nil {
 		http.Error(w, "Invalid asset path", http.StatusBadRequest)
@@ -160,5 +171,9 @@ func (s *Server) handleLocalAsset(w http.ResponseWriter, r *http.Request, collec
 		return
 	}
 
+	// ServeFile's extension table lacks HEIC, and AVIF on older systems
+	if ct := imagecodec.ContentType(absFullPath); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
 	http.ServeFile(w, r, absFullPath)
 }
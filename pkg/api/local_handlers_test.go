@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -209,3 +212,136 @@ func TestLocalHandler_Pagination(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, images4, 0)
 }
+
+func TestLocalHandler_RecursiveListing(t *testing.T) {
+	tempDir := t.TempDir()
+	colPath := filepath.Join(tempDir, "library", "photos")
+	nested := filepath.Join(colPath, "2024", "Summer Trip")
+	assert.NoError(t, os.MkdirAll(nested, 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(colPath, "2024", "raw"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(colPath, "cover.jpg"), []byte("cover"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(nested, "beach #1.jpg"), []byte("beach"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(colPath, "2024", "raw", "dsc001.jpg"), []byte("raw"), 0600))
+
+	s := NewServer()
+	s.RegisterNamespace("library", filepath.Join(tempDir, "library"))
+	handler := s.Handler()
+
+	list := func(query string) []LocalImage {
+		req := httptest.NewRequest("GET", "/local/library/photos/images?"+query, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var images []LocalImage
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &images))
+		return images
+	}
+
+	flat := list("page=1")
+	assert.Len(t, flat, 1, "subfolders are not scanned by default")
+
+	images := list("page=1&depth=2&exclude=*/raw/*")
+	if assert.Len(t, images, 2) {
+		assert.Regexp(t, `^LocalFolder_photos_[0-9a-f]{8}_beach #1$`, images[0].ID)
+		assert.Contains(t, images[0].URL, "/local/library/photos/assets/2024/Summer%20Trip/beach%20%231.jpg")
+		assert.Equal(t, flat[0].ID, images[1].ID, "top-level IDs do not change when recursion is enabled")
+	}
+
+	// Nested assets are served through the escaped URL.
+	u, err := url.Parse(images[0].URL)
+	assert.NoError(t, err)
+	req := httptest.NewRequest("GET", u.RequestURI(), nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "beach", w.Body.String())
+
+	// Empty segments and traversal are still rejected.
+	for _, p := range []string{
+		"/local/library/photos/assets/2024//raw/dsc001.jpg",
+		"/local/library/photos/assets/2024/../../outside.jpg",
+	} {
+		w := httptest.NewRecorder()
+		s.handleLocal(w, httptest.NewRequest("GET", p, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, p)
+	}
+}
+
+func TestLocalHandler_SourceFormats(t *testing.T) {
+	tempDir := t.TempDir()
+	colPath := filepath.Join(tempDir, "library", "photos")
+	assert.NoError(t, os.MkdirAll(colPath, 0755))
+	for _, name := range []string{"phone.HEIC", "scan.tif", "old.bmp", "next.avif", "anim.gif"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(colPath, name), []byte(name), 0600))
+	}
+	modified := time.Date(2020, time.May, 4, 10, 30, 0, 0, time.UTC)
+	assert.NoError(t, os.Chtimes(filepath.Join(colPath, "scan.tif"), modified, modified))
+
+	s := NewServer()
+	s.RegisterNamespace("library", filepath.Join(tempDir, "library"))
+	handler := s.Handler()
+
+	req := httptest.NewRequest("GET", "/local/library/photos/images?page=1", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var images []LocalImage
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &images))
+	assert.Len(t, images, 4, "GIFs are not listed")
+	for _, img := range images {
+		if strings.HasSuffix(img.URL, "/scan.tif") {
+			assert.True(t, modified.Equal(img.Taken), "files without a capture date are dated by their modification time")
+		}
+	}
+
+	// Assets are served with their image content type, even where the system MIME table lacks it.
+	req = httptest.NewRequest("GET", "/local/library/photos/assets/phone.HEIC", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/heic", w.Header().Get("Content-Type"))
+}
+
+func TestLocalHandler_EmbeddedMetadata(t *testing.T) {
+	tempDir := t.TempDir()
+	colPath := filepath.Join(tempDir, "library", "photos")
+	assert.NoError(t, os.MkdirAll(colPath, 0755))
+
+	// A JPEG with only an XMP segment; the listing never decodes pixels.
+	xmp := `http://ns.adobe.com/xap/1.0/` + "\x00" +
+		`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:exif="http://ns.adobe.com/exif/1.0/" exif:DateTimeOriginal="2021-08-15T19:02:00">` +
+		`<dc:title><rdf:Alt><rdf:li xml:lang="x-default">Harbor Lights</rdf:li></rdf:Alt></dc:title>` +
+		`<dc:creator><rdf:Seq><rdf:li>Ann Smith</rdf:li></rdf:Seq></dc:creator>` +
+		`<dc:description><rdf:Alt><rdf:li xml:lang="x-default">Boats at dusk</rdf:li></rdf:Alt></dc:description>` +
+		`</rdf:Description></rdf:RDF></x:xmpmeta>`
+	jpeg := []byte{0xFF, 0xD8, 0xFF, 0xE1, byte((len(xmp) + 2) >> 8), byte(len(xmp) + 2)}
+	jpeg = append(jpeg, xmp...)
+	jpeg = append(jpeg, 0xFF, 0xD9)
+	assert.NoError(t, os.WriteFile(filepath.Join(colPath, "a_harbor.jpg"), jpeg, 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(colPath, "b_override.jpg"), jpeg, 0600))
+
+	meta := `{"files": {"b_override.jpg": {"title": "Renamed", "caption": "", "year": 1999}}}`
+	assert.NoError(t, os.WriteFile(filepath.Join(colPath, "metadata.json"), []byte(meta), 0600))
+
+	s := NewServer()
+	s.RegisterNamespace("library", filepath.Join(tempDir, "library"))
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/local/library/photos/images?page=1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var images []LocalImage
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &images))
+	if assert.Len(t, images, 2) {
+		assert.Equal(t, "Harbor Lights", images[0].Title)
+		assert.Equal(t, "Ann Smith", images[0].Artist)
+		assert.Equal(t, "2021", images[0].Year)
+		assert.Equal(t, "Boats at dusk", images[0].Caption)
+		assert.True(t, time.Date(2021, time.August, 15, 19, 2, 0, 0, time.Local).Equal(images[0].Taken))
+
+		assert.Equal(t, "Renamed", images[1].Title, "metadata.json overrides embedded fields")
+		assert.Equal(t, "Ann Smith", images[1].Artist, "fields missing from metadata.json keep the embedded value")
+		assert.Equal(t, "1999", images[1].Year)
+		assert.Empty(t, images[1].Caption, "an empty string hides the embedded value")
+	}
+}
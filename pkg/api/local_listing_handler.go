@@ -8,7 +8,8 @@ import (
 	"path/filepath"
 	"sort"
 	"strconv"
-	"strings"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/imagemeta"
 )
 
 // LocalListingHandler encapsulates the logic for listing local images.
@@ -16,8 +17,8 @@ import (
 // 1. Parse Request
 // 2. Resolve Path
 // 3. Read Metadata
-// 4. Scan Directory
-// 5. Filter & Sort
+// 4. Scan Directory (optionally recursive, see ScanOptions)
+// 5. Sort
 // 6. Paginate
 // 7. Format Response
 type LocalListingHandler struct {
@@ -32,6 +33,7 @@ type LocalListingHandler struct {
 	collectionPath string
 	page           int
 	perPage        int
+	scan           ScanOptions
 	attribution    string
 	filesMeta      map[string]interface{}
 	allImages      []string
@@ -89,6 +91,7 @@ func (h *LocalListingHandler) parsePagination() {
 	if pp, err := strconv.Atoi(perPageStr); err == nil && pp > 0 {
 		h.perPage = pp
 	}
+	h.scan = ParseScanOptions(h.r.URL.Query())
 }
 
 func (h *LocalListingHandler) readMetadata() {
@@ -129,7 +132,7 @@ func (h *LocalListingHandler) readMetadata() {
 }
 
 func (h *LocalListingHandler) scanDirectory() bool {
-	entries, err := os.ReadDir(h.collectionPath)
+	images, err := ScanImages(h.collectionPath, h.scan, 0)
 	if err != nil {
 		if os.IsNotExist(err) {
 			h.w.Header().Set("Content-Type", "application/json")
@@ -139,25 +142,11 @@ func (h *LocalListingHandler) scanDirectory() bool {
 		http.Error(h.w, "Failed to read directory", http.StatusInternalServerError)
 		return false
 	}
-
-	for _, e := range entries {
-		if e.IsDir() {
-			continue
-		}
-		h.allImages = append(h.allImages, e.Name())
-	}
+	h.allImages = images
 	return true
 }
 
 func (h *LocalListingHandler) filterAndSort() {
-	var filtered []string
-	for _, name := range h.allImages {
-		ext := strings.ToLower(filepath.Ext(name))
-		if ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".webp" {
-			filtered = append(filtered, name)
-		}
-	}
-	h.allImages = filtered
 	sort.Strings(h.allImages)
 }
 
@@ -187,40 +176,82 @@ func (h *LocalListingHandler) sendResponse() {
 	}
 
 	for _, name := range h.pagedImages {
-		url := fmt.Sprintf("%s://%s/local/%s/%s/assets/%s", scheme, host, h.namespace, h.collectionID, name)
-
-		imgAttribution := h.attribution
-		var pUrl string
-		if h.filesMeta != nil {
-			if v, ok := h.filesMeta[name]; ok {
-				if m, ok := v.(map[string]interface{}); ok {
-					if attr, ok := m["attribution"].(string); ok && attr != "" {
-						imgAttribution = attr
-					}
-					if purl, ok := m["product_url"].(string); ok {
-						pUrl = purl
+		result = append(result, h.localImage(fmt.Sprintf("%s://%s", scheme, host), name))
+	}
+
+	h.w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(h.w).Encode(result)
+}
+
+// localImage builds the listing entry of one image.
+// Image names are paths relative to the collection, e.g. "2024/07/beach.jpg" for recursive scans.
+// Descriptive fields come from the metadata embedded in the file; an entry for the file in
+// metadata.json overrides them field by field.
+func (h *LocalListingHandler) localImage(baseURL, name string) LocalImage {
+	url := fmt.Sprintf("%s/local/%s/%s/assets/%s", baseURL, h.namespace, h.collectionID, assetPath(name))
+	absPath := filepath.Join(h.collectionPath, filepath.FromSlash(name))
+
+	img := LocalImage{
+		ID:          localImageID(h.collectionID, name),
+		URL:         url,
+		Attribution: h.attribution,
+	}
+	if meta, err := imagemeta.ReadFile(absPath); err == nil {
+		img.Title = meta.Title
+		img.Artist = meta.Artist
+		img.Year = meta.Year()
+		img.Camera = meta.Camera
+		img.Caption = meta.Caption
+		img.Taken = meta.Taken
+	}
+	if img.Taken.IsZero() {
+		if info, err := os.Stat(absPath); err == nil {
+			img.Taken = info.ModTime()
+		}
+	}
+
+	if h.filesMeta != nil {
+		if v, ok := h.filesMeta[name]; ok {
+			if m, ok := v.(map[string]interface{}); ok {
+				if attr, ok := m["attribution"].(string); ok && attr != "" {
+					img.Attribution = attr
+				}
+				if purl, ok := m["product_url"].(string); ok {
+					img.ProductURL = purl
+				}
+				for key, field := range map[string]*string{
+					"title":   &img.Title,
+					"artist":  &img.Artist,
+					"year":    &img.Year,
+					"camera":  &img.Camera,
+					"caption": &img.Caption,
+				} {
+					if value, ok := m[key].(string); ok {
+						*field = value // An empty string hides an embedded value
 					}
-				} else {
-					pUrl, _ = v.(string)
 				}
+				if year, ok := m["year"].(float64); ok {
+					img.Year = strconv.Itoa(int(year))
+				}
+			} else {
+				img.ProductURL, _ = v.(string)
 			}
 		}
+	}
 
-		// For local folders, if no ProductURL is provided, use the absolute file path as a file:/// URI.
-		// This makes the attribution clickable and opens the local file.
-		if pUrl == "" && (h.namespace == "local_folders" || h.namespace == "favorites") {
-			absPath := filepath.Join(h.collectionPath, name)
-			pUrl = fmt.Sprintf("file:///%s", filepath.ToSlash(absPath))
-		}
-
-		result = append(result, LocalImage{
-			ID:          fmt.Sprintf("LocalFolder_%s_%s", h.collectionID, strings.TrimSuffix(name, filepath.Ext(name))),
-			URL:         url,
-			Attribution: imgAttribution,
-			ProductURL:  pUrl,
-		})
+	// For local folders, if no ProductURL is provided, use the absolute file path as a file:/// URI.
+	// This makes the attribution clickable and opens the local file.
+	if img.ProductURL == "" && (h.namespace == "local_folders" || h.namespace == "favorites") {
+		img.ProductURL = fmt.Sprintf("file:///%s", filepath.ToSlash(absPath))
 	}
+	return img
+}
 
-	h.w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(h.w).Encode(result)
+// DescribeLocalImage returns the entry the images endpoint lists for a single image, without scanning
+// the collection. baseURL is the scheme and host of the API server, e.g. "http://127.0.0.1:49452", and
+// name is the image path relative to the collection folder.
+func DescribeLocalImage(baseURL, namespace, collectionID, collectionPath, name string) LocalImage {
+	h := &LocalListingHandler{namespace: namespace, collectionID: collectionID, collectionPath: collectionPath}
+	h.readMetadata()
+	return h.localImage(baseURL, name)
 }
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/imagecodec"
+)
+
+// MaxScanDepth caps how many subfolder levels a local collection scan descends into.
+const MaxScanDepth = 16
+
+// errStopScan ends a walk early once enough images were found.
+var errStopScan = errors.New("stop scan")
+
+// ScanOptions controls how a local collection folder is scanned for images.
+// Patterns are shell globs matched case-insensitively against slash-separated paths relative
+// to the collection. A pattern without a leading "/" may match at any depth, so "*_thumb.jpg"
+// matches thumbnails everywhere and "*/raw/*" matches everything inside a "raw" subfolder.
+type ScanOptions struct {
+	Depth   int      // Subfolder levels to scan; 0 scans only the collection folder itself
+	Include []string // When set, only images matching at least one pattern are listed
+	Exclude []string // Images and subfolders matching any pattern are skipped
+}
+
+// ValidatePattern reports whether a glob pattern is well formed.
+func ValidatePattern(pattern string) error {
+	if _, err := path.Match(strings.TrimPrefix(pattern, "/"), ""); err != nil {
+		return fmt.Errorf("invalid pattern %q", pattern)
+	}
+	return nil
+}
+
+// Encode adds the options to the query of a listing request.
+func (o ScanOptions) Encode(v url.Values) {
+	if o.Depth > 0 {
+		v.Set("depth", strconv.Itoa(o.Depth))
+	}
+	for _, p := range o.Include {
+		v.Add("include", p)
+	}
+	for _, p := range o.Exclude {
+		v.Add("exclude", p)
+	}
+}
+
+// ParseScanOptions reads the options encoded by Encode. Malformed patterns are dropped.
+func ParseScanOptions(v url.Values) ScanOptions {
+	var o ScanOptions
+	if d, err := strconv.Atoi(v.Get("depth")); err == nil && d > 0 {
+		o.Depth = min(d, MaxScanDepth)
+	}
+	o.Include = validPatterns(v["include"])
+	o.Exclude = validPatterns(v["exclude"])
+	return o
+}
+
+func validPatterns(patterns []string) []string {
+	var valid []string
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p != "" && ValidatePattern(p) == nil {
+			valid = append(valid, p)
+		}
+	}
+	return valid
+}
+
+// IsLocalImage reports whether a file name has an image extension that local collections list.
+func IsLocalImage(name string) bool {
+	return imagecodec.IsSourceExt(filepath.Ext(name))
+}
+
+// ScanImages walks a collection folder and returns the slash-separated relative paths of its images.
+// A positive limit stops the walk once that many images were found. Hidden subfolders and symbolic
+// links to folders are not descended into, and unreadable subfolders are skipped.
+func ScanImages(root string, opts ScanOptions, limit int) ([]string, error) {
+	// WalkDir does not follow a symbolic link given as the root, so resolve it first.
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	var images []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			return nil
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if strings.Count(rel, "/") >= opts.Depth || strings.HasPrefix(d.Name(), ".") || matchAny(opts.Exclude, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !IsLocalImage(rel) || matchAny(opts.Exclude, rel) {
+			return nil
+		}
+		if len(opts.Include) > 0 && !includes(opts.Include, rel) {
+			return nil
+		}
+		images = append(images, rel)
+		if limit > 0 && len(images) >= limit {
+			return errStopScan
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopScan) {
+		return nil, err
+	}
+	return images, nil
+}
+
+// Lists reports whether a scan with these options lists the image at a relative path, without
+// touching the file system. It agrees with ScanImages for files that exist.
+func (o ScanOptions) Lists(rel string) bool {
+	rel = strings.Trim(rel, "/")
+	if rel == "" || !IsLocalImage(rel) || strings.Count(rel, "/") > o.Depth || matchAny(o.Exclude, rel) {
+		return false
+	}
+	for i := strings.IndexByte(rel, '/'); i >= 0; {
+		dir := rel[:i]
+		if strings.HasPrefix(path.Base(dir), ".") || matchAny(o.Exclude, dir) {
+			return false
+		}
+		next := strings.IndexByte(rel[i+1:], '/')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return len(o.Include) == 0 || includes(o.Include, rel)
+}
+
+// includes reports whether a file, or one of the folders it is in, matches an include pattern.
+func includes(patterns []string, rel string) bool {
+	for p := rel; ; {
+		if matchAny(patterns, p) {
+			return true
+		}
+		i := strings.LastIndexByte(p, '/')
+		if i < 0 {
+			return false
+		}
+		p = p[:i]
+	}
+}
+
+// matchAny reports whether any pattern matches a relative path.
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if matchPattern(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern matches a pattern against a relative path. A leading "/" anchors the pattern to the
+// collection folder; otherwise it may match the trailing segments of the path.
+func matchPattern(pattern, rel string) bool {
+	pattern = strings.ToLower(pattern)
+	rel = strings.ToLower(rel)
+	if anchored, ok := strings.CutPrefix(pattern, "/"); ok {
+		m, _ := path.Match(anchored, rel)
+		return m
+	}
+	for {
+		if m, _ := path.Match(pattern, rel); m {
+			return true
+		}
+		i := strings.IndexByte(rel, '/')
+		if i < 0 {
+			return false
+		}
+		rel = rel[i+1:]
+	}
+}
+
+// localImageID builds the stable ID of a local image from its path relative to the collection.
+// Images in the collection folder keep the plain "LocalFolder_<collection>_<name>" form; images in
+// subfolders add a short hash of their folder so equal names in different folders stay distinct.
+func localImageID(collectionID, rel string) string {
+	dir, name := path.Split(rel)
+	name = strings.TrimSuffix(name, path.Ext(name))
+	if dir == "" {
+		return fmt.Sprintf("LocalFolder_%s_%s", collectionID, name)
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSuffix(dir, "/")))
+	return fmt.Sprintf("LocalFolder_%s_%s_%s", collectionID, hex.EncodeToString(sum[:4]), name)
+}
+
+// assetPath escapes each segment of a relative path for use in an asset URL.
+func assetPath(rel string) string {
+	segments := strings.Split(rel, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		rel     string
+		want    bool
+	}{
+		{"*_thumb.jpg", "beach_thumb.jpg", true},
+		{"*_thumb.jpg", "2024/07/beach_thumb.JPG", true},
+		{"*/raw/*", "2024/raw/dsc001.jpg", true},
+		{"*/raw/*", "2024/07/raw/dsc001.jpg", true},
+		{"*/raw/*", "raw/dsc001.jpg", false},
+		{"raw", "2024/raw", true},
+		{"/raw", "2024/raw", false},
+		{"/2024/*", "2024/beach.jpg", true},
+		{"/2024/*", "old/2024/beach.jpg", false},
+		{"*.png", "2024/beach.jpg", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.rel, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchPattern(tt.pattern, tt.rel))
+		})
+	}
+}
+
+func TestScanImages(t *testing.T) {
+	root := t.TempDir()
+	files := []string{
+		"top.jpg",
+		"notes.txt",
+		"2024/07/beach.jpg",
+		"2024/07/beach_thumb.jpg",
+		"2024/raw/dsc001.jpg",
+		"2024/party.png",
+		"2025/01/02/deep.jpg",
+		".thumbnails/hidden.jpg",
+	}
+	for _, f := range files {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte("img"), 0600))
+	}
+
+	scan := func(opts ScanOptions) []string {
+		images, err := ScanImages(root, opts, 0)
+		require.NoError(t, err)
+		return images
+	}
+
+	assert.ElementsMatch(t, []string{"top.jpg"}, scan(ScanOptions{}))
+	assert.ElementsMatch(t, []string{"top.jpg", "2024/party.png"}, scan(ScanOptions{Depth: 1}))
+	assert.ElementsMatch(t, []string{"top.jpg", "2024/party.png", "2024/07/beach.jpg", "2024/07/beach_thumb.jpg", "2024/raw/dsc001.jpg"},
+		scan(ScanOptions{Depth: 2}))
+	assert.ElementsMatch(t, []string{"top.jpg", "2024/party.png", "2024/07/beach.jpg", "2025/01/02/deep.jpg"},
+		scan(ScanOptions{Depth: MaxScanDepth, Exclude: []string{"*/raw/*", "*_thumb.jpg"}}))
+	assert.ElementsMatch(t, []string{"2024/07/beach.jpg", "2024/07/beach_thumb.jpg"},
+		scan(ScanOptions{Depth: MaxScanDepth, Include: []string{"/2024/07"}}))
+
+	// Lists agrees with the walk for every file in the tree
+	for _, opts := range []ScanOptions{
+		{},
+		{Depth: 1},
+		{Depth: MaxScanDepth, Exclude: []string{"*/raw/*", "07"}},
+		{Depth: MaxScanDepth, Include: []string{"/2024/*"}, Exclude: []string{"*_thumb.jpg"}},
+	} {
+		listed := scan(opts)
+		for _, f := range files {
+			assert.Equal(t, slices.Contains(listed, f), opts.Lists(f), "%+v %s", opts, f)
+		}
+	}
+
+	images, err := ScanImages(root, ScanOptions{Depth: MaxScanDepth}, 2)
+	require.NoError(t, err)
+	assert.Len(t, images, 2, "the walk stops at the limit")
+
+	_, err = ScanImages(filepath.Join(root, "missing"), ScanOptions{}, 0)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestScanOptions_RoundTrip(t *testing.T) {
+	opts := ScanOptions{Depth: 3, Include: []string{"*.jpg"}, Exclude: []string{"*/raw/*", "*_thumb.jpg"}}
+	v := url.Values{}
+	opts.Encode(v)
+	assert.Equal(t, opts, ParseScanOptions(v))
+
+	v = url.Values{"depth": {"999"}, "exclude": {"[raw", " "}}
+	assert.Equal(t, ScanOptions{Depth: MaxScanDepth}, ParseScanOptions(v), "depth is capped and malformed patterns are dropped")
+}
+
+func TestLocalImageID(t *testing.T) {
+	assert.Equal(t, "LocalFolder_col_beach", localImageID("col", "beach.jpg"), "top-level IDs are unchanged")
+	nested := localImageID("col", "2024/07/beach.jpg")
+	assert.Regexp(t, `^LocalFolder_col_[0-9a-f]{8}_beach$`, nested)
+	assert.Equal(t, nested, localImageID("col", "2024/07/beach.jpg"), "IDs are stable across scans")
+	assert.NotEqual(t, nested, localImageID("col", "2024/08/beach.jpg"))
+}
@@ -7,9 +7,11 @@ package curation
 var ProviderIDToFilename = map[string]string{
 	"ArtInstituteChicago":   "artic.json",
 	"ClevelandMuseum":       "cleveland.json",
+	"Europeana":             "europeana.json",
 	"Getty":                 "getty.json",
 	"MetMuseum":             "metmuseum.json",
 	"NationalPalaceMuseum":  "npm.json",
 	"Rijksmuseum":           "rijksmuseum.json",
+	"Smithsonian":           "smithsonian.json",
 	"StatensMuseumForKunst": "smk.json",
 }
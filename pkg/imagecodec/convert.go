@@ -0,0 +1,97 @@
+package imagecodec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/imagemeta"
+)
+
+// convertTimeout bounds a single conversion; large HEIC files take a few seconds at most.
+const convertTimeout = time.Minute
+
+// ErrNoConverter is returned when no installed tool can convert a HEIF or AVIF image.
+var ErrNoConverter = errors.New("no HEIF/AVIF converter found")
+
+// converter is an external tool that converts an image file into a PNG file.
+type converter struct {
+	name    string                        // Executable, looked up on PATH
+	formats []string                      // Content types it converts; nil for all
+	args    func(in, out string) []string // Command line arguments
+}
+
+func (c converter) converts(contentType string) bool {
+	return c.formats == nil || slices.Contains(c.formats, contentType)
+}
+
+// magick is ImageMagick 7, which decodes both formats when built with libheif.
+var magick = converter{name: "magick", args: func(in, out string) []string { return []string{in, out} }}
+
+// convert decodes HEIF or AVIF data with the first installed converter that handles it.
+func convert(data []byte, contentType string) (image.Image, error) {
+	dir, err := os.MkdirTemp("", "spice-convert-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "source"+canonicalExt[contentType])
+	out := filepath.Join(dir, "converted.png")
+	if err := os.WriteFile(in, data, 0600); err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, c := range converters {
+		if !c.converts(contentType) {
+			continue
+		}
+		path, err := exec.LookPath(c.name)
+		if err != nil {
+			continue
+		}
+		if err := run(path, c.args(in, out)); err != nil {
+			lastErr = fmt.Errorf("%s: %w", c.name, err)
+			continue
+		}
+		converted, err := os.ReadFile(out)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", c.name, err)
+			continue
+		}
+		img, err := png.Decode(bytes.NewReader(converted))
+		if err != nil {
+			return nil, err
+		}
+		// Converters keep the source's color profile; the rotation is already applied.
+		meta, _ := imagemeta.Read(bytes.NewReader(converted))
+		return toSRGB(img, meta.ICCProfile), nil
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("%w for %s", ErrNoConverter, contentType)
+}
+
+func run(path string, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), convertTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, args...) //nolint:gosec // Fixed tool names; arguments are temporary file paths
+	hideWindow(cmd)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if len(output) > 0 {
+			return fmt.Errorf("%w: %s", err, bytes.TrimSpace(output))
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build darwin
+
+package imagecodec
+
+import "os/exec"
+
+// converters are tried in order. sips is part of macOS and decodes HEIC (and AVIF since macOS 13).
+var converters = []converter{
+	{name: "sips", args: func(in, out string) []string { return []string{"-s", "format", "png", in, "--out", out} }},
+	magick,
+}
+
+// hideWindow is a no-op outside Windows.
+func hideWindow(_ *exec.Cmd) {}
@@ -0,0 +1,16 @@
+//go:build !darwin && !windows
+
+package imagecodec
+
+import "os/exec"
+
+// converters are tried in order: the libheif and libavif command line tools, then ImageMagick.
+var converters = []converter{
+	{name: "heif-convert", args: func(in, out string) []string { return []string{in, out} }},
+	{name: "avifdec", formats: []string{AVIF}, args: func(in, out string) []string { return []string{in, out} }},
+	magick,
+	{name: "convert", args: func(in, out string) []string { return []string{in, out} }}, // ImageMagick 6
+}
+
+// hideWindow is a no-op outside Windows.
+func hideWindow(_ *exec.Cmd) {}
@@ -0,0 +1,41 @@
+//go:build windows
+
+package imagecodec
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// wicScript converts an image with the Windows Imaging Component, which decodes HEIC and AVIF once
+// the HEIF and AV1 extensions from the Microsoft Store are installed (they are on most PCs).
+const wicScript = `$ErrorActionPreference = 'Stop'
+Add-Type -AssemblyName PresentationCore
+$in = [IO.File]::OpenRead(%s)
+try {
+	$decoder = [Windows.Media.Imaging.BitmapDecoder]::Create($in, 'None', 'OnLoad')
+	$encoder = New-Object Windows.Media.Imaging.PngBitmapEncoder
+	$encoder.Frames.Add($decoder.Frames[0])
+	$out = [IO.File]::Create(%s)
+	try { $encoder.Save($out) } finally { $out.Close() }
+} finally { $in.Close() }`
+
+// converters are tried in order.
+var converters = []converter{
+	magick,
+	{name: "powershell", args: func(in, out string) []string {
+		return []string{"-NoProfile", "-NonInteractive", "-Command", fmt.Sprintf(wicScript, psQuote(in), psQuote(out))}
+	}},
+}
+
+// psQuote quotes a string as a PowerShell literal.
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// hideWindow prevents a console window from flashing up for every conversion.
+func hideWindow(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+}
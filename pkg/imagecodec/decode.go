@@ -0,0 +1,98 @@
+package imagecodec
+
+import (
+	"image"
+	"io"
+	"os"
+
+	"github.com/disintegration/imaging"
+	"github.com/dixieflatline76/Spice/v2/pkg/imagecodec/internal/jpeg"
+)
+
+// Decode decodes an image as it is meant to be seen. Colors are converted to sRGB when the file
+// embeds an ICC profile (wide-gamut RGB from phones and cameras, CMYK from print workflows), and
+// the EXIF orientation is applied so the pixels are upright. Cropping, face detection and color
+// sampling all rely on both.
+//
+// HEIF and AVIF images are turned upright by their converter, which applies the container's own
+// rotation; their EXIF orientation is informational only.
+func Decode(r io.ReadSeeker) (image.Image, string, error) {
+	img, format, _, err := decode(r, image.Point{})
+	return img, format, err
+}
+
+// DecodeFile decodes an image file with Decode.
+func DecodeFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := Decode(f)
+	return img, err
+}
+
+// DecodeFileScaled decodes an image file like DecodeFile, but decodes a JPEG at the smallest DCT
+// scale (1/2, 1/4 or 1/8) that still covers minW×minH, the largest displayed size the caller needs.
+// A 100-megapixel master then never exists as a full-size bitmap. Other formats are decoded at
+// full size.
+//
+// It also returns the full displayed size of the image, which resolution-based decisions (such as
+// Smart Fit's compatibility check) should use instead of the bounds of the scaled image.
+func DecodeFileScaled(path string, minW, minH int) (image.Image, image.Point, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, image.Point{}, err
+	}
+	defer f.Close()
+	img, _, size, err := decode(f, image.Pt(minW, minH))
+	return img, size, err
+}
+
+// decode implements Decode and DecodeFileScaled. A zero minSize decodes at full size.
+func decode(r io.ReadSeeker, minSize image.Point) (image.Image, string, image.Point, error) {
+	p, err := probe(r, minSize)
+	if err != nil {
+		return nil, "", image.Point{}, err
+	}
+	var img image.Image
+	format := "jpeg"
+	if p.shift > 0 {
+		img, err = jpeg.DecodeScaled(r, p.shift)
+	} else {
+		img, format, err = image.Decode(r)
+	}
+	if err != nil {
+		return nil, format, image.Point{}, err
+	}
+	size := p.size
+	if size == (image.Point{}) {
+		size = img.Bounds().Size()
+		if p.meta.Orientation >= 5 {
+			size.X, size.Y = size.Y, size.X
+		}
+	}
+	return Orient(toSRGB(img, p.meta.ICCProfile), p.meta.Orientation), format, size, nil
+}
+
+// Orient turns an image upright according to its EXIF orientation (1-8). Other values, including
+// 0 for unknown, leave the image as is.
+func Orient(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	}
+	return img
+}
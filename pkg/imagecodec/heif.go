@@ -0,0 +1,149 @@
+package imagecodec
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+// maxHEIFHeader caps how much of a HEIF file is read to find its size. The metadata box comes
+// right after the file type box in the files cameras and phones write.
+const maxHEIFHeader = 1 << 20
+
+// heifBrands are the ISOBMFF brands of HEIF still images, mapped to their content type.
+var heifBrands = map[string]string{
+	"heic": HEIC,
+	"heix": HEIC,
+	"heim": HEIC,
+	"heis": HEIC,
+	"hevc": HEIC,
+	"hevx": HEIC,
+	"mif1": HEIC,
+	"msf1": HEIC,
+	"avif": AVIF,
+	"avis": AVIF,
+}
+
+func init() {
+	for brand := range heifBrands {
+		image.RegisterFormat("heif", "????ftyp"+brand, decodeHEIF, decodeHEIFConfig)
+	}
+}
+
+// sniffISOBMFF returns the content type of a HEIF or AVIF file from its file type box.
+// Generic "mif1" files are AVIF if they list the avif brand as compatible.
+func sniffISOBMFF(head []byte) string {
+	if len(head) < 12 || string(head[4:8]) != "ftyp" {
+		return ""
+	}
+	ct, ok := heifBrands[string(head[8:12])]
+	if !ok {
+		return ""
+	}
+	size := min(int(binary.BigEndian.Uint32(head)), len(head))
+	for i := 16; i+4 <= size; i += 4 { // Compatible brands follow the minor version
+		if string(head[i:i+4]) == "avif" {
+			return AVIF
+		}
+	}
+	return ct
+}
+
+func decodeHEIF(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return convert(data, sniffISOBMFF(data))
+}
+
+func decodeHEIFConfig(r io.Reader) (image.Config, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxHEIFHeader))
+	if err != nil {
+		return image.Config{}, err
+	}
+	w, h, ok := heifSize(data)
+	if !ok {
+		return image.Config{}, errors.New("heif: image size not found")
+	}
+	return image.Config{ColorModel: color.RGBAModel, Width: w, Height: h}, nil
+}
+
+// heifSize reads the displayed size of a HEIF image from the spatial extent ("ispe") properties in
+// meta/iprp/ipco. The largest extent belongs to the primary image (or its grid), smaller ones to
+// thumbnails and tiles. A 90° or 270° rotation ("irot") swaps the dimensions.
+func heifSize(data []byte) (w, h int, ok bool) {
+	meta, ok := findBox(data, "meta")
+	if !ok || len(meta) < 4 {
+		return 0, 0, false
+	}
+	iprp, ok := findBox(meta[4:], "iprp") // meta is a full box: skip version and flags
+	if !ok {
+		return 0, 0, false
+	}
+	ipco, ok := findBox(iprp, "ipco")
+	if !ok {
+		return 0, 0, false
+	}
+	rotated := false
+	eachBox(ipco, func(typ string, body []byte) {
+		switch typ {
+		case "ispe":
+			if len(body) >= 12 {
+				bw, bh := int(binary.BigEndian.Uint32(body[4:])), int(binary.BigEndian.Uint32(body[8:]))
+				if bw*bh > w*h {
+					w, h = bw, bh
+				}
+			}
+		case "irot":
+			if len(body) >= 1 && body[0]&1 == 1 {
+				rotated = true
+			}
+		}
+	})
+	if w == 0 || h == 0 {
+		return 0, 0, false
+	}
+	if rotated {
+		w, h = h, w
+	}
+	return w, h, true
+}
+
+// eachBox calls fn for each box in data, stopping at the first malformed one.
+func eachBox(data []byte, fn func(typ string, body []byte)) {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data))
+		typ := string(data[4:8])
+		header := uint64(8)
+		switch size {
+		case 0: // Extends to the end of the data
+			size = uint64(len(data))
+		case 1: // 64-bit size
+			if len(data) < 16 {
+				return
+			}
+			size = binary.BigEndian.Uint64(data[8:])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return
+		}
+		fn(typ, data[header:size])
+		data = data[size:]
+	}
+}
+
+// findBox returns the body of the first box of a type in data.
+func findBox(data []byte, typ string) ([]byte, bool) {
+	var found []byte
+	ok := false
+	eachBox(data, func(t string, body []byte) {
+		if !ok && t == typ {
+			found, ok = body, true
+		}
+	})
+	return found, ok
+}
@@ -0,0 +1,659 @@
+package imagecodec
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/disintegration/imaging"
+)
+
+// errProfile is returned for ICC profiles that are malformed or use a model the converter lacks.
+var errProfile = errors.New("unsupported ICC profile")
+
+// maxCLUT caps the number of samples in a color lookup table.
+const maxCLUT = 1 << 22
+
+// d50ToSRGB converts CIE XYZ relative to the D50 white of the profile connection space (PCS) to
+// linear sRGB, including the Bradford adaptation to sRGB's D65 white.
+var d50ToSRGB = [3][3]float64{
+	{3.1338561, -1.6168667, -0.4906146},
+	{-0.9787684, 1.9161415, 0.0334540},
+	{0.0719453, -0.2289914, 1.4052427},
+}
+
+// d50White is the PCS white point, used to decode Lab.
+var d50White = [3]float64{0.9642, 1.0, 0.8249}
+
+// curve maps a normalized channel value (0-1) to another.
+type curve func(x float64) float64
+
+// profile is the device-to-PCS half of an ICC profile: the matrix/TRC model of most RGB
+// profiles (Display P3, Adobe RGB, ProPhoto) or the A2B0 lookup table of print (CMYK) profiles.
+type profile struct {
+	colorSpace string        // Device color space signature: "RGB " or "CMYK"
+	trc        [3]curve      // Tone curves of the red, green and blue channels
+	matrix     [3][3]float64 // The red, green and blue colorants in PCS XYZ, as columns
+	lut        *lut          // Used instead of the matrix model when set
+}
+
+// lut is an A2B lookup table pipeline: input curves, a multidimensional color table, optional
+// intermediate curves and matrix, and output curves.
+type lut struct {
+	a      []curve
+	clut   *clut
+	m      []curve
+	matrix []float64 // 3x3 matrix followed by three offsets, or nil
+	b      []curve
+	pcs    func(v [3]float64) [3]float64 // Decodes the normalized output into PCS XYZ
+}
+
+// clut is a color lookup table sampled on a grid, the first input varying slowest.
+type clut struct {
+	grid   []int     // Grid points per input channel
+	stride []int     // Table offset between neighboring grid points per input channel
+	out    int       // Output channels
+	table  []float64 // Normalized samples
+}
+
+// parseProfile reads the parts of an ICC profile needed to convert its colors to sRGB.
+func parseProfile(data []byte) (*profile, error) {
+	if len(data) < 132 || string(data[36:40]) != "acsp" {
+		return nil, errProfile
+	}
+	p := &profile{colorSpace: string(data[16:20])}
+	pcs := string(data[20:24])
+	if pcs != "XYZ " && pcs != "Lab " {
+		return nil, errProfile
+	}
+
+	tags := make(map[string][]byte)
+	count := binary.BigEndian.Uint32(data[128:])
+	if uint64(count)*12+132 > uint64(len(data)) {
+		return nil, errProfile
+	}
+	for i := range int(count) {
+		entry := data[132+i*12:]
+		off, size := uint64(binary.BigEndian.Uint32(entry[4:])), uint64(binary.BigEndian.Uint32(entry[8:]))
+		if off+size <= uint64(len(data)) {
+			tags[string(entry[:4])] = data[off : off+size]
+		}
+	}
+
+	switch p.colorSpace {
+	case "RGB ":
+		if p.parseMatrixTRC(tags) {
+			return p, nil
+		}
+		if l, err := parseLut(tags["A2B0"], 3, pcs); err == nil {
+			p.lut = l
+			return p, nil
+		}
+	case "CMYK":
+		if l, err := parseLut(tags["A2B0"], 4, pcs); err == nil {
+			p.lut = l
+			return p, nil
+		}
+	}
+	return nil, errProfile
+}
+
+// parseMatrixTRC reads the colorant and tone curve tags of a matrix/TRC profile.
+func (p *profile) parseMatrixTRC(tags map[string][]byte) bool {
+	for i, ch := range []string{"r", "g", "b"} {
+		xyz, ok := parseXYZ(tags[ch+"XYZ"])
+		if !ok {
+			return false
+		}
+		trc, _, ok := parseCurve(tags[ch+"TRC"])
+		if !ok {
+			return false
+		}
+		for j := range 3 {
+			p.matrix[j][i] = xyz[j]
+		}
+		p.trc[i] = trc
+	}
+	return true
+}
+
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+func parseXYZ(data []byte) ([3]float64, bool) {
+	if len(data) < 20 || string(data[:4]) != "XYZ " {
+		return [3]float64{}, false
+	}
+	return [3]float64{s15Fixed16(data[8:]), s15Fixed16(data[12:]), s15Fixed16(data[16:])}, true
+}
+
+// parseCurve reads a "curv" or "para" tag and returns the curve and the size of its data.
+func parseCurve(data []byte) (curve, int, bool) {
+	if len(data) < 12 {
+		return nil, 0, false
+	}
+	switch string(data[:4]) {
+	case "curv":
+		n := int(binary.BigEndian.Uint32(data[8:]))
+		size := 12 + 2*n
+		if n > len(data) || size > len(data) {
+			return nil, 0, false
+		}
+		switch n {
+		case 0:
+			return func(x float64) float64 { return x }, size, true
+		case 1:
+			g := float64(binary.BigEndian.Uint16(data[12:])) / 256
+			return func(x float64) float64 { return math.Pow(max(x, 0), g) }, size, true
+		}
+		samples := make([]float64, n)
+		for i := range samples {
+			samples[i] = float64(binary.BigEndian.Uint16(data[12+2*i:])) / 65535
+		}
+		return table(samples), size, true
+	case "para":
+		fn := int(binary.BigEndian.Uint16(data[8:]))
+		counts := [...]int{1, 3, 4, 5, 7}
+		if fn >= len(counts) || 12+4*counts[fn] > len(data) {
+			return nil, 0, false
+		}
+		var v [7]float64
+		for i := range counts[fn] {
+			v[i] = s15Fixed16(data[12+4*i:])
+		}
+		return parametric(fn, v), 12 + 4*counts[fn], true
+	}
+	return nil, 0, false
+}
+
+// parametric returns one of the ICC parametric curve functions with parameters g, a, b, c, d, e, f.
+func parametric(fn int, v [7]float64) curve {
+	g, a, b, c, d, e, f := v[0], v[1], v[2], v[3], v[4], v[5], v[6]
+	pow := func(x float64) float64 { return math.Pow(max(a*x+b, 0), g) }
+	switch fn {
+	case 0:
+		return func(x float64) float64 { return math.Pow(max(x, 0), g) }
+	case 1:
+		return func(x float64) float64 {
+			if x >= -b/a {
+				return pow(x)
+			}
+			return 0
+		}
+	case 2:
+		return func(x float64) float64 {
+			if x >= -b/a {
+				return pow(x) + c
+			}
+			return c
+		}
+	case 3:
+		return func(x float64) float64 {
+			if x >= d {
+				return pow(x)
+			}
+			return c * x
+		}
+	default:
+		return func(x float64) float64 {
+			if x >= d {
+				return pow(x) + e
+			}
+			return c*x + f
+		}
+	}
+}
+
+// table returns a curve that interpolates evenly spaced samples.
+func table(samples []float64) curve {
+	last := len(samples) - 1
+	return func(x float64) float64 {
+		pos := min(max(x, 0), 1) * float64(last)
+		i := min(int(pos), last-1)
+		f := pos - float64(i)
+		return samples[i]*(1-f) + samples[i+1]*f
+	}
+}
+
+// parseLut reads an A2B tag: lut8 ("mft1"), lut16 ("mft2") or lutAtoB ("mAB ").
+func parseLut(data []byte, inputs int, pcs string) (*lut, error) {
+	if len(data) < 32 || int(data[8]) != inputs || data[9] != 3 {
+		return nil, errProfile
+	}
+	switch string(data[:4]) {
+	case "mft1":
+		return parseLutN(data, inputs, 1, pcsDecoder(pcs, false))
+	case "mft2":
+		// lut16 keeps the ICC v2 Lab encoding, where 0xFF00 is the maximum, in every version.
+		return parseLutN(data, inputs, 2, pcsDecoder(pcs, true))
+	case "mAB ":
+		return parseLutAtoB(data, inputs, pcsDecoder(pcs, false))
+	}
+	return nil, errProfile
+}
+
+// parseLutN reads a lut8 or lut16 tag, whose samples are size bytes wide.
+func parseLutN(data []byte, inputs, size int, pcs func([3]float64) [3]float64) (*lut, error) {
+	grid := int(data[10])
+	inN, outN, off := 256, 256, 48
+	if size == 2 {
+		if len(data) < 52 {
+			return nil, errProfile
+		}
+		inN, outN, off = int(binary.BigEndian.Uint16(data[48:])), int(binary.BigEndian.Uint16(data[50:])), 52
+	}
+	if grid < 2 || inN < 2 || outN < 2 {
+		return nil, errProfile
+	}
+	clutN := 3
+	for range inputs {
+		clutN *= grid
+	}
+	if clutN > maxCLUT || off+(inputs*inN+clutN+3*outN)*size > len(data) {
+		return nil, errProfile
+	}
+	read := func(n int) []float64 {
+		v := make([]float64, n)
+		for i := range v {
+			if size == 2 {
+				v[i] = float64(binary.BigEndian.Uint16(data[off+2*i:])) / 65535
+			} else {
+				v[i] = float64(data[off+i]) / 255
+			}
+		}
+		off += n * size
+		return v
+	}
+
+	l := &lut{pcs: pcs}
+	for range inputs {
+		l.a = append(l.a, table(read(inN)))
+	}
+	grids := make([]int, inputs)
+	for i := range grids {
+		grids[i] = grid
+	}
+	l.clut = newCLUT(grids, 3, read(clutN))
+	for range 3 {
+		l.b = append(l.b, table(read(outN)))
+	}
+	return l, nil
+}
+
+// parseLutAtoB reads an ICC v4 lutAtoB tag. Its elements are found by offset and each is optional
+// except the output ("B") curves.
+func parseLutAtoB(data []byte, inputs int, pcs func([3]float64) [3]float64) (*lut, error) {
+	offset := func(at int) int { return int(binary.BigEndian.Uint32(data[at:])) }
+	offB, offMatrix, offM, offCLUT, offA := offset(12), offset(16), offset(20), offset(24), offset(28)
+
+	l := &lut{pcs: pcs}
+	var err error
+	if l.b, err = parseCurves(data, offB, 3); err != nil {
+		return nil, err
+	}
+	if offM != 0 {
+		if l.m, err = parseCurves(data, offM, 3); err != nil {
+			return nil, err
+		}
+		if offMatrix != 0 {
+			if offMatrix+48 > len(data) {
+				return nil, errProfile
+			}
+			for i := range 12 {
+				l.matrix = append(l.matrix, s15Fixed16(data[offMatrix+4*i:]))
+			}
+		}
+	}
+	if offA != 0 {
+		if l.a, err = parseCurves(data, offA, inputs); err != nil {
+			return nil, err
+		}
+	}
+	if offCLUT == 0 {
+		if inputs != 3 {
+			return nil, errProfile
+		}
+		return l, nil
+	}
+
+	if offCLUT+20 > len(data) {
+		return nil, errProfile
+	}
+	grids := make([]int, inputs)
+	n := 3
+	for i := range grids {
+		grids[i] = int(data[offCLUT+i])
+		if grids[i] < 2 {
+			return nil, errProfile
+		}
+		n *= grids[i]
+		if n > maxCLUT {
+			return nil, errProfile
+		}
+	}
+	size := int(data[offCLUT+16])
+	start := offCLUT + 20
+	if (size != 1 && size != 2) || start+n*size > len(data) {
+		return nil, errProfile
+	}
+	samples := make([]float64, n)
+	for i := range samples {
+		if size == 2 {
+			samples[i] = float64(binary.BigEndian.Uint16(data[start+2*i:])) / 65535
+		} else {
+			samples[i] = float64(data[start+i]) / 255
+		}
+	}
+	l.clut = newCLUT(grids, 3, samples)
+	return l, nil
+}
+
+// parseCurves reads n consecutive curves, each padded to a four-byte boundary.
+func parseCurves(data []byte, off, n int) ([]curve, error) {
+	curves := make([]curve, n)
+	for i := range curves {
+		if off <= 0 || off >= len(data) {
+			return nil, errProfile
+		}
+		c, size, ok := parseCurve(data[off:])
+		if !ok {
+			return nil, errProfile
+		}
+		curves[i] = c
+		off += (size + 3) &^ 3
+	}
+	return curves, nil
+}
+
+func newCLUT(grid []int, out int, table []float64) *clut {
+	c := &clut{grid: grid, stride: make([]int, len(grid)), out: out, table: table}
+	stride := out
+	for i := len(grid) - 1; i >= 0; i-- {
+		c.stride[i] = stride
+		stride *= grid[i]
+	}
+	return c
+}
+
+// eval interpolates the table multilinearly between the grid points surrounding in.
+func (c *clut) eval(in []float64, out []float64) {
+	var frac [8]float64
+	base := 0
+	for i, g := range c.grid {
+		pos := min(max(in[i], 0), 1) * float64(g-1)
+		p := min(int(pos), g-2)
+		frac[i] = pos - float64(p)
+		base += p * c.stride[i]
+	}
+	clear(out)
+	for corner := range 1 << len(c.grid) {
+		w, off := 1.0, base
+		for i := range c.grid {
+			if corner&(1<<i) != 0 {
+				w *= frac[i]
+				off += c.stride[i]
+			} else {
+				w *= 1 - frac[i]
+			}
+		}
+		if w == 0 {
+			continue
+		}
+		for o := range out {
+			out[o] += w * c.table[off+o]
+		}
+	}
+}
+
+// eval converts normalized device values to PCS XYZ. in is used as scratch space.
+func (l *lut) eval(in []float64) [3]float64 {
+	for i, c := range l.a {
+		in[i] = c(in[i])
+	}
+	var v [3]float64
+	if l.clut != nil {
+		l.clut.eval(in, v[:])
+	} else {
+		copy(v[:], in)
+	}
+	for i, c := range l.m {
+		v[i] = c(v[i])
+	}
+	if m := l.matrix; m != nil {
+		v = [3]float64{
+			m[0]*v[0] + m[1]*v[1] + m[2]*v[2] + m[9],
+			m[3]*v[0] + m[4]*v[1] + m[5]*v[2] + m[10],
+			m[6]*v[0] + m[7]*v[1] + m[8]*v[2] + m[11],
+		}
+	}
+	for i, c := range l.b {
+		v[i] = c(v[i])
+	}
+	return l.pcs(v)
+}
+
+// pcsDecoder returns the function that turns normalized lookup table output into PCS XYZ.
+func pcsDecoder(pcs string, legacyLab bool) func([3]float64) [3]float64 {
+	if pcs == "XYZ " {
+		// u1Fixed15: 0xFFFF is 1+32767/32768
+		return func(v [3]float64) [3]float64 {
+			const scale = 65535.0 / 32768
+			return [3]float64{v[0] * scale, v[1] * scale, v[2] * scale}
+		}
+	}
+	scale := 1.0
+	if legacyLab {
+		scale = 65535.0 / 65280
+	}
+	return func(v [3]float64) [3]float64 {
+		return labToXYZ(v[0]*scale*100, v[1]*scale*255-128, v[2]*scale*255-128)
+	}
+}
+
+func labToXYZ(l, a, b float64) [3]float64 {
+	finv := func(t float64) float64 {
+		if t > 6.0/29 {
+			return t * t * t
+		}
+		return 3 * (6.0 / 29) * (6.0 / 29) * (t - 4.0/29)
+	}
+	fy := (l + 16) / 116
+	return [3]float64{
+		d50White[0] * finv(fy+a/500),
+		d50White[1] * finv(fy),
+		d50White[2] * finv(fy-b/200),
+	}
+}
+
+// xyzToSRGB converts PCS XYZ to linear sRGB.
+func xyzToSRGB(v [3]float64) [3]float64 {
+	m := &d50ToSRGB
+	return [3]float64{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2],
+	}
+}
+
+// srgbMatrix combines the profile's colorants with the PCS-to-sRGB conversion, mapping linear
+// device values straight to linear sRGB.
+func (p *profile) srgbMatrix() [3][3]float64 {
+	var m [3][3]float64
+	for i := range 3 {
+		for j := range 3 {
+			for k := range 3 {
+				m[i][j] += d50ToSRGB[i][k] * p.matrix[k][j]
+			}
+		}
+	}
+	return m
+}
+
+// isSRGB reports whether the profile is sRGB or close enough that converting would change nothing.
+func (p *profile) isSRGB() bool {
+	if p.lut != nil || p.colorSpace != "RGB " {
+		return false
+	}
+	m := p.srgbMatrix()
+	for i := range 3 {
+		for j := range 3 {
+			want := 0.0
+			if i == j {
+				want = 1
+			}
+			if math.Abs(m[i][j]-want) > 0.01 {
+				return false
+			}
+		}
+	}
+	for _, trc := range p.trc {
+		for _, x := range []float64{0.02, 0.1, 0.25, 0.5, 0.75, 0.9} {
+			if math.Abs(trc(x)-srgbToLinear(x)) > 0.002 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// encodeSteps is the resolution of the linear-to-sRGB table; fine enough that dark tones don't band.
+const encodeSteps = 1 << 13
+
+var srgbEncoding = sync.OnceValue(func() []uint8 {
+	t := make([]uint8, encodeSteps+1)
+	for i := range t {
+		v := float64(i) / encodeSteps
+		if v <= 0.0031308 {
+			v *= 12.92
+		} else {
+			v = 1.055*math.Pow(v, 1/2.4) - 0.055
+		}
+		t[i] = uint8(math.Round(v * 255))
+	}
+	return t
+})
+
+// encodeSRGB gamma-encodes linear sRGB, clipping colors outside the sRGB gamut.
+func encodeSRGB(t []uint8, v float64) uint8 {
+	return t[int(min(max(v, 0), 1)*encodeSteps+0.5)]
+}
+
+// toSRGB converts an image to sRGB using its embedded ICC profile. The image is returned as is
+// when it has no profile, the profile is sRGB already, unsupported, or does not match the pixels
+// (an RGB profile on a CMYK image, say).
+func toSRGB(img image.Image, iccProfile []byte) image.Image {
+	if len(iccProfile) == 0 {
+		return img
+	}
+	p, err := parseProfile(iccProfile)
+	if err != nil {
+		return img
+	}
+	switch p.colorSpace {
+	case "RGB ":
+		switch img.(type) {
+		case *image.Gray, *image.Gray16, *image.CMYK:
+			return img
+		}
+		if p.isSRGB() {
+			return img
+		}
+		dst := imaging.Clone(img)
+		p.convertRGB(dst)
+		return dst
+	case "CMYK":
+		if src, ok := img.(*image.CMYK); ok {
+			return p.convertCMYK(src)
+		}
+	}
+	return img
+}
+
+// convertRGB converts the pixels of an RGB image to sRGB in place.
+func (p *profile) convertRGB(img *image.NRGBA) {
+	enc := srgbEncoding()
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if p.lut != nil {
+		parallelRows(h, func(y0, y1 int) {
+			in := make([]float64, 3)
+			for y := y0; y < y1; y++ {
+				row := img.Pix[y*img.Stride : y*img.Stride+w*4]
+				for i := 0; i < len(row); i += 4 {
+					for c := range 3 {
+						in[c] = float64(row[i+c]) / 255
+					}
+					rgb := xyzToSRGB(p.lut.eval(in))
+					for c := range 3 {
+						row[i+c] = encodeSRGB(enc, rgb[c])
+					}
+				}
+			}
+		})
+		return
+	}
+
+	var linear [3][256]float64
+	for c := range 3 {
+		for v := range 256 {
+			linear[c][v] = p.trc[c](float64(v) / 255)
+		}
+	}
+	m := p.srgbMatrix()
+	parallelRows(h, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := img.Pix[y*img.Stride : y*img.Stride+w*4]
+			for i := 0; i < len(row); i += 4 {
+				r, g, b := linear[0][row[i]], linear[1][row[i+1]], linear[2][row[i+2]]
+				row[i] = encodeSRGB(enc, m[0][0]*r+m[0][1]*g+m[0][2]*b)
+				row[i+1] = encodeSRGB(enc, m[1][0]*r+m[1][1]*g+m[1][2]*b)
+				row[i+2] = encodeSRGB(enc, m[2][0]*r+m[2][1]*g+m[2][2]*b)
+			}
+		}
+	})
+}
+
+// convertCMYK converts a CMYK image to sRGB through the profile's lookup table.
+func (p *profile) convertCMYK(src *image.CMYK) *image.NRGBA {
+	enc := srgbEncoding()
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	parallelRows(h, func(y0, y1 int) {
+		in := make([]float64, 4)
+		for y := y0; y < y1; y++ {
+			s := src.Pix[y*src.Stride : y*src.Stride+w*4]
+			d := dst.Pix[y*dst.Stride : y*dst.Stride+w*4]
+			for i := 0; i < len(s); i += 4 {
+				for c := range 4 {
+					in[c] = float64(s[i+c]) / 255
+				}
+				rgb := xyzToSRGB(p.lut.eval(in))
+				for c := range 3 {
+					d[i+c] = encodeSRGB(enc, rgb[c])
+				}
+				d[i+3] = 0xFF
+			}
+		}
+	})
+	return dst
+}
+
+// parallelRows splits rows [0, h) into bands and calls fn for each band on its own goroutine.
+func parallelRows(h int, fn func(y0, y1 int)) {
+	band := max((h+runtime.GOMAXPROCS(0)-1)/runtime.GOMAXPROCS(0), 1)
+	var wg sync.WaitGroup
+	for y := 0; y < h; y += band {
+		wg.Go(func() { fn(y, min(y+band, h)) })
+	}
+	wg.Wait()
+}
@@ -0,0 +1,129 @@
+// Package imagecodec registers the image formats Spice reads and maps between file extensions,
+// content types and the formats desktop backends accept.
+//
+// JPEG, PNG, WebP, TIFF and BMP are decoded in pure Go. HEIF (HEIC) and AVIF images are sized in
+// pure Go, but decoding their pixels is handed to a converter that ships with the operating system
+// or is commonly installed (see converters). Decode returns pixels upright and in sRGB.
+package imagecodec
+
+import (
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	// Register the pure-Go decoders with the image package.
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// Content types of the supported formats.
+const (
+	JPEG = "image/jpeg"
+	PNG  = "image/png"
+	WebP = "image/webp"
+	TIFF = "image/tiff"
+	BMP  = "image/bmp"
+	HEIC = "image/heic"
+	AVIF = "image/avif"
+)
+
+// extensions maps the file extensions of source images to their content type.
+var extensions = map[string]string{
+	".jpg":  JPEG,
+	".jpeg": JPEG,
+	".png":  PNG,
+	".webp": WebP,
+	".tif":  TIFF,
+	".tiff": TIFF,
+	".bmp":  BMP,
+	".heic": HEIC,
+	".heif": HEIC,
+	".avif": AVIF,
+}
+
+// canonicalExt is the extension used when saving a file of a content type.
+var canonicalExt = map[string]string{
+	JPEG: ".jpg",
+	PNG:  ".png",
+	WebP: ".webp",
+	TIFF: ".tif",
+	BMP:  ".bmp",
+	HEIC: ".heic",
+	AVIF: ".avif",
+}
+
+// IsSourceExt reports whether a file extension (e.g. ".HEIC") belongs to a supported source format.
+func IsSourceExt(ext string) bool {
+	_, ok := extensions[strings.ToLower(ext)]
+	return ok
+}
+
+// SourceExts returns the canonical extensions of the supported source formats.
+func SourceExts() []string {
+	return []string{".jpg", ".jpeg", ".png", ".webp", ".tif", ".tiff", ".bmp", ".heic", ".heif", ".avif"}
+}
+
+// ContentType returns the content type of a file name's extension, or "" if it is not supported.
+func ContentType(name string) string {
+	return extensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// Normalize returns the supported content type a content type stands for, resolving aliases such
+// as "image/jpg" and dropping parameters such as "; charset=binary", or "" if it is not supported.
+func Normalize(contentType string) string {
+	ct, _, _ := strings.Cut(contentType, ";")
+	ct = strings.ToLower(strings.TrimSpace(ct))
+	switch ct {
+	case "image/jpg", "image/pjpeg":
+		ct = JPEG
+	case "image/heif", "image/heic-sequence", "image/heif-sequence":
+		ct = HEIC
+	case "image/x-ms-bmp":
+		ct = BMP
+	}
+	if _, ok := canonicalExt[ct]; !ok {
+		return ""
+	}
+	return ct
+}
+
+// ExtensionFor returns the extension to save a file of a content type with, or "" if the content
+// type is not a supported image format.
+func ExtensionFor(contentType string) string {
+	return canonicalExt[Normalize(contentType)]
+}
+
+// IsDesktopExt reports whether every desktop backend accepts wallpapers with this extension.
+func IsDesktopExt(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg", ".png":
+		return true
+	}
+	return false
+}
+
+// DesktopExt returns the extension derivatives of a source with the given extension are saved
+// with: the source's own for JPEG and PNG, and JPEG for everything else.
+func DesktopExt(ext string) string {
+	if IsDesktopExt(ext) {
+		return strings.ToLower(ext)
+	}
+	return ".jpg"
+}
+
+// Sniff returns the content type of image data from its first bytes, or "" if the data is not an
+// image. It recognizes the formats http.DetectContentType misses (TIFF, HEIF and AVIF).
+func Sniff(head []byte) string {
+	if ct := sniffISOBMFF(head); ct != "" {
+		return ct
+	}
+	if bytes.HasPrefix(head, []byte("II*\x00")) || bytes.HasPrefix(head, []byte("MM\x00*")) {
+		return TIFF
+	}
+	return Normalize(http.DetectContentType(head))
+}
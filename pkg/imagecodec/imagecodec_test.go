@@ -0,0 +1,343 @@
+package imagecodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+func box(typ string, body ...[]byte) []byte {
+	data := bytes.Join(body, nil)
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(data)))
+	b = append(b, typ...)
+	return append(b, data...)
+}
+
+func ftyp(major string, compatible ...string) []byte {
+	body := []byte(major + "\x00\x00\x00\x00")
+	for _, c := range compatible {
+		body = append(body, c...)
+	}
+	return box("ftyp", body)
+}
+
+func ispe(w, h uint32) []byte {
+	body := make([]byte, 4) // Version and flags
+	body = binary.BigEndian.AppendUint32(body, w)
+	return box("ispe", binary.BigEndian.AppendUint32(body, h))
+}
+
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	img.Set(1, 1, color.RGBA{R: 200, A: 255})
+	return img
+}
+
+func TestSniff(t *testing.T) {
+	var pngData, bmpData, tiffData bytes.Buffer
+	require.NoError(t, png.Encode(&pngData, testImage()))
+	require.NoError(t, bmp.Encode(&bmpData, testImage()))
+	require.NoError(t, tiff.Encode(&tiffData, testImage(), nil))
+
+	assert.Equal(t, PNG, Sniff(pngData.Bytes()))
+	assert.Equal(t, BMP, Sniff(bmpData.Bytes()))
+	assert.Equal(t, TIFF, Sniff(tiffData.Bytes()))
+	assert.Equal(t, JPEG, Sniff([]byte("\xff\xd8\xff\xe0\x00\x10JFIF")))
+	assert.Equal(t, WebP, Sniff([]byte("RIFF\x24\x00\x00\x00WEBPVP8 ")))
+	assert.Equal(t, HEIC, Sniff(ftyp("heic", "mif1", "heic")))
+	assert.Equal(t, AVIF, Sniff(ftyp("avif", "mif1")))
+	assert.Equal(t, AVIF, Sniff(ftyp("mif1", "mif1", "avif")), "generic HEIF files with the avif brand are AVIF")
+	assert.Equal(t, HEIC, Sniff(ftyp("mif1", "mif1", "heic")))
+	assert.Empty(t, Sniff(ftyp("isom", "mp41")), "videos are not images")
+	assert.Empty(t, Sniff([]byte("<!DOCTYPE html><html>")))
+	assert.Empty(t, Sniff([]byte("GIF89a")), "GIF is not a supported source format")
+}
+
+func TestExtensions(t *testing.T) {
+	assert.Equal(t, ".jpg", ExtensionFor("image/jpeg; charset=binary"))
+	assert.Equal(t, ".heic", ExtensionFor("image/heif"))
+	assert.Equal(t, ".avif", ExtensionFor("IMAGE/AVIF"))
+	assert.Empty(t, ExtensionFor("text/html"))
+	assert.Equal(t, JPEG, Normalize("image/jpg"))
+	assert.Equal(t, HEIC, Normalize("image/heif; q=1"))
+	assert.Empty(t, Normalize("image/gif"))
+
+	assert.True(t, IsSourceExt(".HEIC"))
+	assert.False(t, IsSourceExt(".gif"))
+	assert.Equal(t, TIFF, ContentType("scan.TIFF"))
+	for _, ext := range SourceExts() {
+		assert.True(t, IsSourceExt(ext), ext)
+	}
+
+	assert.Equal(t, ".png", DesktopExt(".PNG"))
+	assert.Equal(t, ".jpeg", DesktopExt(".jpeg"))
+	for _, ext := range []string{".webp", ".tif", ".bmp", ".heic", ".avif"} {
+		assert.Equal(t, ".jpg", DesktopExt(ext), "%s derivatives are saved as JPEG", ext)
+	}
+}
+
+func TestDecode_PureGo(t *testing.T) {
+	var bmpData, tiffData bytes.Buffer
+	require.NoError(t, bmp.Encode(&bmpData, testImage()))
+	require.NoError(t, tiff.Encode(&tiffData, testImage(), nil))
+
+	for name, data := range map[string][]byte{"bmp": bmpData.Bytes(), "tiff": tiffData.Bytes()} {
+		img, format, err := image.Decode(bytes.NewReader(data))
+		require.NoError(t, err, name)
+		assert.Equal(t, name, format)
+		assert.Equal(t, image.Rect(0, 0, 4, 3), img.Bounds())
+	}
+}
+
+func TestDecodeConfig_HEIF(t *testing.T) {
+	ipco := box("ipco", ispe(320, 240), ispe(4032, 3024), box("irot", []byte{1}))
+	meta := box("meta", []byte{0, 0, 0, 0}, box("hdlr", make([]byte, 20)), box("iprp", ipco))
+	data := append(ftyp("heic", "mif1", "heic"), meta...)
+	data = append(data, box("mdat", make([]byte, 64))...)
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "heif", format)
+	assert.Equal(t, 3024, cfg.Width, "the rotation swaps the primary image's extent")
+	assert.Equal(t, 4032, cfg.Height)
+
+	_, _, err = image.DecodeConfig(bytes.NewReader(ftyp("heic")))
+	assert.Error(t, err)
+}
+
+func TestConvert(t *testing.T) {
+	saved := converters
+	defer func() { converters = saved }()
+
+	converters = nil
+	_, err := convert([]byte("data"), HEIC)
+	assert.ErrorIs(t, err, ErrNoConverter)
+
+	if runtime.GOOS == "windows" {
+		t.Skip("uses cp as a stand-in converter")
+	}
+	if _, err := exec.LookPath("cp"); err != nil {
+		t.Skip("cp not found")
+	}
+	// A converter that copies its input stands in for a real one when the input is already a PNG.
+	converters = []converter{
+		{name: "spice-missing-converter", args: func(in, out string) []string { return []string{in, out} }},
+		{name: "cp", formats: []string{AVIF}, args: func(in, out string) []string { return []string{in, out} }},
+	}
+	var pngData bytes.Buffer
+	require.NoError(t, png.Encode(&pngData, testImage()))
+
+	img, err := convert(pngData.Bytes(), AVIF)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 4, 3), img.Bounds())
+
+	_, err = convert(pngData.Bytes(), HEIC)
+	assert.ErrorIs(t, err, ErrNoConverter, "converters are only used for the formats they handle")
+}
+
+// iccProfile builds an ICC profile with the given device color space, PCS and tags.
+func iccProfile(colorSpace, pcs string, tags map[string][]byte) []byte {
+	header := make([]byte, 128)
+	copy(header[16:], colorSpace)
+	copy(header[20:], pcs)
+	copy(header[36:], "acsp")
+	table := binary.BigEndian.AppendUint32(nil, uint32(len(tags)))
+	var data []byte
+	at := 128 + 4 + 12*len(tags)
+	for sig, body := range tags {
+		table = append(table, sig...)
+		table = binary.BigEndian.AppendUint32(table, uint32(at+len(data)))
+		table = binary.BigEndian.AppendUint32(table, uint32(len(body)))
+		data = append(data, body...)
+		for len(data)%4 != 0 {
+			data = append(data, 0)
+		}
+	}
+	return append(append(header, table...), data...)
+}
+
+func s15(v float64) []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(int32(math.Round(v*65536))))
+}
+
+func xyzTag(x, y, z float64) []byte {
+	b := append([]byte("XYZ \x00\x00\x00\x00"), s15(x)...)
+	return append(append(b, s15(y)...), s15(z)...)
+}
+
+func gammaTag(g float64) []byte {
+	b := append([]byte("curv\x00\x00\x00\x00"), 0, 0, 0, 1)
+	return binary.BigEndian.AppendUint16(b, uint16(g*256))
+}
+
+// rgbProfile is a matrix/TRC profile with sRGB's primaries and a plain gamma curve.
+func rgbProfile(gamma float64) []byte {
+	return iccProfile("RGB ", "XYZ ", map[string][]byte{
+		"rXYZ": xyzTag(0.4361, 0.2225, 0.0139),
+		"gXYZ": xyzTag(0.3851, 0.7169, 0.0971),
+		"bXYZ": xyzTag(0.1431, 0.0606, 0.7141),
+		"rTRC": gammaTag(gamma),
+		"gTRC": gammaTag(gamma),
+		"bTRC": gammaTag(gamma),
+	})
+}
+
+// cmykProfile is a lut16 profile whose ink darkens the Lab lightness without adding color:
+// only the grid corner without ink is white.
+func cmykProfile() []byte {
+	lut := []byte("mft2\x00\x00\x00\x00\x04\x03\x02\x00")
+	for i := range 9 {
+		if i%4 == 0 {
+			lut = append(lut, s15(1)...)
+		} else {
+			lut = append(lut, s15(0)...)
+		}
+	}
+	lut = binary.BigEndian.AppendUint16(lut, 2)
+	lut = binary.BigEndian.AppendUint16(lut, 2)
+	for range 4 {
+		lut = binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint16(lut, 0), 0xFFFF)
+	}
+	for corner := range 16 {
+		l := uint16(0)
+		if corner == 0 {
+			l = 0xFF00
+		}
+		lut = binary.BigEndian.AppendUint16(lut, l)
+		lut = binary.BigEndian.AppendUint16(lut, 0x8000)
+		lut = binary.BigEndian.AppendUint16(lut, 0x8000)
+	}
+	for range 3 {
+		lut = binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint16(lut, 0), 0xFFFF)
+	}
+	return iccProfile("CMYK", "Lab ", map[string][]byte{"A2B0": lut})
+}
+
+func TestToSRGB(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Pix = []uint8{128, 128, 128, 255, 255, 0, 0, 128}
+
+	linear := toSRGB(img, rgbProfile(1)).(*image.NRGBA)
+	assert.InDelta(t, 188, int(linear.Pix[0]), 1, "linear mid-gray is light in sRGB")
+	assert.Equal(t, linear.Pix[0], linear.Pix[1])
+	assert.Equal(t, linear.Pix[1], linear.Pix[2])
+	assert.Equal(t, []uint8{255, 0, 0, 128}, linear.Pix[4:], "the primaries match and alpha is kept")
+
+	assert.Same(t, img, toSRGB(img, nil))
+	assert.Same(t, img, toSRGB(img, []byte("not a profile")))
+	srgb := iccProfile("RGB ", "XYZ ", map[string][]byte{
+		"rXYZ": xyzTag(0.4361, 0.2225, 0.0139),
+		"gXYZ": xyzTag(0.3851, 0.7169, 0.0971),
+		"bXYZ": xyzTag(0.1431, 0.0606, 0.7141),
+		"rTRC": srgbCurve(),
+		"gTRC": srgbCurve(),
+		"bTRC": srgbCurve(),
+	})
+	assert.Same(t, img, toSRGB(img, srgb), "sRGB images are left alone")
+	assert.Same(t, img, toSRGB(img, cmykProfile()), "a CMYK profile does not apply to RGB pixels")
+
+	cmyk := image.NewCMYK(image.Rect(0, 0, 3, 1))
+	cmyk.Pix = []uint8{0, 0, 0, 0, 0, 0, 0, 255, 0, 0, 0, 128}
+	rgb := toSRGB(cmyk, cmykProfile()).(*image.NRGBA)
+	assert.Equal(t, []uint8{255, 255, 255, 255}, rgb.Pix[:4], "paper white")
+	assert.Equal(t, []uint8{0, 0, 0, 255}, rgb.Pix[4:8], "full black ink")
+	assert.InDelta(t, 119, int(rgb.Pix[8]), 2, "half black ink is L*50")
+	assert.InDelta(t, int(rgb.Pix[8]), int(rgb.Pix[10]), 1, "neutral ink stays neutral")
+}
+
+func srgbCurve() []byte {
+	b := append([]byte("para\x00\x00\x00\x00"), 0, 3, 0, 0)
+	for _, v := range []float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045} {
+		b = append(b, s15(v)...)
+	}
+	return b
+}
+
+func pngChunk(typ string, data []byte) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	b = append(b, typ...)
+	b = append(b, data...)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(append([]byte(typ), data...)))
+}
+
+func TestDecode_Orientation(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 3))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	var encoded bytes.Buffer
+	require.NoError(t, png.Encode(&encoded, img))
+
+	// An eXIf chunk after IHDR holding a big-endian TIFF with orientation 6 (rotate 90° clockwise)
+	exif := []byte("MM\x00*\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00")
+	data := encoded.Bytes()
+	const ihdrEnd = 8 + 25
+	data = append(append(append([]byte{}, data[:ihdrEnd]...), pngChunk("eXIf", exif)...), data[ihdrEnd:]...)
+
+	decoded, format, err := Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "png", format)
+	assert.Equal(t, image.Rect(0, 0, 3, 4), decoded.Bounds(), "the image is upright")
+	r, _, _, _ := decoded.At(2, 0).RGBA()
+	assert.Equal(t, uint32(0xFFFF), r, "the top-left pixel moves to the top right")
+
+	assert.Same(t, img, Orient(img, 1))
+	assert.Same(t, img, Orient(img, 0))
+}
+
+func TestDecodeFileScaled(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewNRGBA(image.Rect(0, 0, 400, 200))
+	var jpegData, pngData bytes.Buffer
+	require.NoError(t, jpeg.Encode(&jpegData, img, nil))
+	require.NoError(t, png.Encode(&pngData, img))
+	jpegPath, pngPath := filepath.Join(dir, "master.jpg"), filepath.Join(dir, "master.png")
+	require.NoError(t, os.WriteFile(jpegPath, jpegData.Bytes(), 0600))
+	require.NoError(t, os.WriteFile(pngPath, pngData.Bytes(), 0600))
+
+	scaledImg, size, err := DecodeFileScaled(jpegPath, 90, 40)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 100, 50), scaledImg.Bounds(), "1/4 still covers 90x40; 1/8 would not")
+	assert.Equal(t, image.Pt(400, 200), size, "the full size is reported")
+
+	fullImg, size, err := DecodeFileScaled(jpegPath, 400, 201)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 400, 200), fullImg.Bounds(), "images smaller than the target are not reduced")
+	assert.Equal(t, image.Pt(400, 200), size)
+
+	fullImg, size, err = DecodeFileScaled(pngPath, 90, 40)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 400, 200), fullImg.Bounds(), "only JPEG has a scaled decoder")
+	assert.Equal(t, image.Pt(400, 200), size)
+
+	mem, err := DecodeMemory(jpegPath, 90, 40)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100*50*bytesPerPixel), mem)
+	mem, err = DecodeMemory(jpegPath, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(400*200*bytesPerPixel), mem)
+	mem, err = DecodeMemory(pngPath, 90, 40)
+	require.NoError(t, err)
+	assert.Equal(t, int64(400*200*bytesPerPixel), mem)
+}
+
+func TestScaleFor(t *testing.T) {
+	assert.Equal(t, uint(3), scaleFor(image.Pt(16000, 9000), image.Pt(1920, 1080)))
+	assert.Equal(t, uint(2), scaleFor(image.Pt(12000, 8000), image.Pt(2560, 1440)), "1/8 would be 1500 wide")
+	assert.Equal(t, uint(1), scaleFor(image.Pt(7680, 4320), image.Pt(3840, 2160)))
+	assert.Equal(t, uint(0), scaleFor(image.Pt(4000, 3000), image.Pt(3840, 2160)))
+	assert.Equal(t, uint(0), scaleFor(image.Pt(8000, 2000), image.Pt(1920, 1080)), "both dimensions must be covered")
+}
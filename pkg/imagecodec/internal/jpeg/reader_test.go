@@ -0,0 +1,100 @@
+// Spice: tests for the scaled decoding added to the fork. The test images are from the standard
+// library's image/testdata.
+
+package jpeg
+
+import (
+	"bytes"
+	"image"
+	stdjpeg "image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testImages = []string{
+	"video-001.q50.420.jpeg",
+	"video-001.q50.420.progressive.jpeg",
+	"video-001.q50.411.jpeg",
+	"video-001.221212.jpeg", // Non-standard subsampling (flex mode)
+	"video-001.cmyk.jpeg",
+	"video-001.rgb.jpeg",
+	"video-005.gray.q50.jpeg",
+}
+
+// meanDiff compares a scaled decode with the full decode averaged over the same squares.
+func meanDiff(full, scaled image.Image, shift uint) float64 {
+	s := 1 << shift
+	var total, n float64
+	b := scaled.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			var want [3]float64
+			count := 0.0
+			for yy := y * s; yy < min((y+1)*s, full.Bounds().Dy()); yy++ {
+				for xx := x * s; xx < min((x+1)*s, full.Bounds().Dx()); xx++ {
+					r, g, b, _ := full.At(xx, yy).RGBA()
+					want[0] += float64(r >> 8)
+					want[1] += float64(g >> 8)
+					want[2] += float64(b >> 8)
+					count++
+				}
+			}
+			r, g, b, _ := scaled.At(x, y).RGBA()
+			for c, v := range []uint32{r, g, b} {
+				diff := float64(v>>8) - want[c]/count
+				if diff < 0 {
+					diff = -diff
+				}
+				total += diff
+				n++
+			}
+		}
+	}
+	return total / n
+}
+
+func TestDecodeScaled(t *testing.T) {
+	for _, name := range testImages {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		require.NoError(t, err)
+		full, err := stdjpeg.Decode(bytes.NewReader(data))
+		require.NoError(t, err, name)
+
+		same, err := DecodeScaled(bytes.NewReader(data), 0)
+		require.NoError(t, err, name)
+		assert.Equal(t, full, same, "%s: unscaled decoding matches the standard library", name)
+
+		for shift := uint(1); shift <= MaxShift; shift++ {
+			scaled, err := DecodeScaled(bytes.NewReader(data), shift)
+			require.NoError(t, err, name)
+			s := 1 << shift
+			want := image.Rect(0, 0, (full.Bounds().Dx()+s-1)/s, (full.Bounds().Dy()+s-1)/s)
+			assert.Equal(t, want, scaled.Bounds(), "%s at 1/%d", name, s)
+			// Subsampled planes lose some detail at every scale (the RGB image subsamples green),
+			// so the bound is loose. Misplaced blocks are off by far more.
+			assert.Less(t, meanDiff(full, scaled, shift), 16.0, "%s at 1/%d", name, s)
+		}
+	}
+
+	_, err := DecodeScaled(bytes.NewReader(nil), MaxShift+1)
+	assert.Error(t, err)
+}
+
+func TestDecodeInfo(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "video-001.q50.420.progressive.jpeg"))
+	require.NoError(t, err)
+	info, err := DecodeInfo(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, Info{Width: 150, Height: 103, Components: 3, Samples: 1.5, Progressive: true}, info)
+
+	data, err = os.ReadFile(filepath.Join("testdata", "video-005.gray.q50.jpeg"))
+	require.NoError(t, err)
+	info, err = DecodeInfo(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, info.Samples)
+	assert.False(t, info.Progressive)
+}
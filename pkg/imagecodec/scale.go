@@ -0,0 +1,99 @@
+package imagecodec
+
+import (
+	"image"
+	"io"
+	"os"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/imagecodec/internal/jpeg"
+	"github.com/dixieflatline76/Spice/v2/pkg/imagemeta"
+)
+
+// bytesPerPixel estimates the memory a decoded pixel takes: the bitmap itself plus the full-size
+// copies made while converting, orienting and fitting it.
+const bytesPerPixel = 8
+
+// coefficientBytes is the size of one saved DCT coefficient of a progressive JPEG.
+const coefficientBytes = 4
+
+// probed is what the headers of a file tell about decoding it.
+type probed struct {
+	meta  imagemeta.Metadata
+	frame *jpeg.Info  // Frame header, for JPEG images
+	shift uint        // JPEG scale-down as a power of two
+	size  image.Point // Full displayed size if known from the headers
+}
+
+// probe reads the metadata and, for a JPEG, the frame header of an image, and picks the DCT scale
+// that covers minSize (a displayed size; zero for full size). r is left at the start.
+func probe(r io.ReadSeeker, minSize image.Point) (probed, error) {
+	var p probed
+	p.meta, _ = imagemeta.Read(r) // Without readable metadata the pixels are used as stored
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return p, err
+	}
+	info, err := jpeg.DecodeInfo(r)
+	if _, seekErr := r.Seek(0, io.SeekStart); seekErr != nil {
+		return p, seekErr
+	}
+	if err != nil {
+		return p, nil // Not a JPEG: decoded at full size
+	}
+	p.frame = &info
+	p.size = image.Pt(info.Width, info.Height)
+	if p.meta.Orientation >= 5 { // Stored sideways: compare against the stored axes
+		minSize.X, minSize.Y = minSize.Y, minSize.X
+		p.size.X, p.size.Y = p.size.Y, p.size.X
+	}
+	if minSize != (image.Point{}) {
+		p.shift = scaleFor(image.Pt(info.Width, info.Height), minSize)
+	}
+	return p, nil
+}
+
+// scaleFor returns the largest JPEG scale-down that keeps an image of the given size at least
+// minSize in both dimensions.
+func scaleFor(size, minSize image.Point) uint {
+	for shift := uint(jpeg.MaxShift); shift > 0; shift-- {
+		w, h := scaled(size.X, shift), scaled(size.Y, shift)
+		if w >= minSize.X && h >= minSize.Y {
+			return shift
+		}
+	}
+	return 0
+}
+
+func scaled(n int, shift uint) int {
+	return (n + 1<<shift - 1) >> shift
+}
+
+// DecodeMemory estimates the peak memory, in bytes, of decoding an image file with
+// DecodeFileScaled(path, minW, minH). Zero minW and minH estimate a full-size decode. Only the
+// headers are read.
+func DecodeMemory(path string, minW, minH int) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	p, err := probe(f, image.Pt(minW, minH))
+	if err != nil {
+		return 0, err
+	}
+	info := p.frame
+	if info == nil {
+		cfg, _, err := image.DecodeConfig(f)
+		if err != nil {
+			return 0, err
+		}
+		return int64(cfg.Width) * int64(cfg.Height) * bytesPerPixel, nil
+	}
+
+	n := int64(scaled(info.Width, p.shift)) * int64(scaled(info.Height, p.shift)) * bytesPerPixel
+	if info.Progressive {
+		// Progressive scans refine every block, so all coefficients stay in memory at any scale.
+		n += int64(float64(info.Width) * float64(info.Height) * info.Samples * coefficientBytes)
+	}
+	return n, nil
+}
@@ -0,0 +1,61 @@
+// Package imagehash computes perceptual hashes, which stay the same when an image is resized,
+// recompressed or slightly recoloured, so copies of one picture from different sources can be
+// recognised.
+package imagehash
+
+import (
+	"image"
+	"image/draw"
+	"math/bits"
+)
+
+// DHash returns the 64-bit difference hash of img: the image is averaged down to a 9x8 grid of
+// luma, and each bit records whether a cell is brighter than its right-hand neighbour.
+//
+// Flat images hash to 0, which callers can treat as "no hash".
+func DHash(img image.Image) uint64 {
+	b := img.Bounds()
+	g, ok := img.(*image.Gray)
+	if !ok {
+		g = image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(g, g.Rect, img, b.Min, draw.Src)
+	}
+
+	const cols, rows = 9, 8
+	var grid [rows][cols]float64
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	if w < cols || h < rows {
+		return 0
+	}
+	for r := range rows {
+		y0, y1 := r*h/rows, (r+1)*h/rows
+		for c := range cols {
+			x0, x1 := c*w/cols, (c+1)*w/cols
+			var sum int
+			for y := y0; y < y1; y++ {
+				row := g.Pix[g.PixOffset(g.Rect.Min.X, g.Rect.Min.Y+y):]
+				for _, v := range row[x0:x1] {
+					sum += int(v)
+				}
+			}
+			grid[r][c] = float64(sum) / float64((x1-x0)*(y1-y0))
+		}
+	}
+
+	var hash uint64
+	for r := range rows {
+		for c := range cols - 1 {
+			hash <<= 1
+			if grid[r][c] > grid[r][c+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// Distance returns the number of bits that differ between two hashes. Copies of one picture are
+// usually within a few bits; unrelated images differ in about half of the 64.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,71 @@
+package imagehash
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+// scene draws random rectangles over a gradient, so every seed gives a different picture.
+func scene(seed int64) *image.NRGBA {
+	rng := rand.New(rand.NewSource(seed))
+	img := image.NewNRGBA(image.Rect(0, 0, 640, 400))
+	for y := range 400 {
+		for x := range 640 {
+			v := uint8(x * 255 / 640)
+			img.Set(x, y, color.NRGBA{R: v, G: v / 2, B: 255 - v, A: 255})
+		}
+	}
+	for range 40 {
+		x, y := rng.Intn(560), rng.Intn(320)
+		c := color.NRGBA{R: uint8(rng.Intn(256)), G: uint8(rng.Intn(256)), B: uint8(rng.Intn(256)), A: 255}
+		for dy := range 20 + rng.Intn(60) {
+			for dx := range 20 + rng.Intn(80) {
+				if x+dx < 640 && y+dy < 400 {
+					img.Set(x+dx, y+dy, c)
+				}
+			}
+		}
+	}
+	return img
+}
+
+func TestDHash_Copies(t *testing.T) {
+	src := scene(1)
+	hash := DHash(src)
+	assert.NotZero(t, hash)
+
+	assert.Zero(t, Distance(hash, DHash(src)), "hashing is deterministic")
+	assert.LessOrEqual(t, Distance(hash, DHash(imaging.Resize(src, 200, 0, imaging.Lanczos))), 3, "a smaller copy")
+	assert.LessOrEqual(t, Distance(hash, DHash(imaging.AdjustBrightness(src, 8))), 3, "a brighter copy")
+	assert.LessOrEqual(t, Distance(hash, DHash(imaging.Grayscale(src))), 3, "a grayscale copy")
+}
+
+func TestDHash_DifferentImages(t *testing.T) {
+	hash := DHash(scene(1))
+	for seed := int64(2); seed < 6; seed++ {
+		assert.Greater(t, Distance(hash, DHash(scene(seed))), 8, "seed %d", seed)
+	}
+}
+
+func TestDHash_FlatAndTiny(t *testing.T) {
+	flat := image.NewGray(image.Rect(0, 0, 100, 100))
+	assert.Zero(t, DHash(flat))
+	assert.Zero(t, DHash(image.NewGray(image.Rect(0, 0, 4, 4))))
+
+	// Sub-images hash their own pixels
+	src := scene(3)
+	sub := src.SubImage(image.Rect(100, 50, 500, 350))
+	assert.Equal(t, DHash(imaging.Crop(src, image.Rect(100, 50, 500, 350))), DHash(sub))
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, Distance(0xF0, 0xF0))
+	assert.Equal(t, 4, Distance(0xF0, 0xFF))
+	assert.Equal(t, 8, Distance(0xF0, 0x0F))
+	assert.Equal(t, 64, Distance(0, ^uint64(0)))
+}
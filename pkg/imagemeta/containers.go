@@ -0,0 +1,320 @@
+package imagemeta
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+var (
+	exifHeader      = []byte("Exif\x00\x00")
+	xmpHeader       = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	photoshopHeader = []byte("Photoshop 3.0\x00")
+	iccHeader       = []byte("ICC_PROFILE\x00")
+)
+
+// readJPEG reads the APP1 (EXIF, XMP), APP2 (ICC) and APP13 (IPTC) segments before the image data.
+func readJPEG(r io.Reader, s *sources) error {
+	br := bufio.NewReader(r)
+	var icc iccChunks
+	if _, err := br.Discard(2); err != nil { // SOI
+		return err
+	}
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b != 0xFF {
+			return errors.New("malformed JPEG marker")
+		}
+		marker, err := br.ReadByte()
+		for err == nil && marker == 0xFF { // Fill bytes
+			marker, err = br.ReadByte()
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case marker == 0xDA || marker == 0xD9: // Start of scan or end of image: no more metadata
+			s.icc = icc.join()
+			return nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD8): // Markers without a payload
+			continue
+		}
+
+		var size [2]byte
+		if _, err := io.ReadFull(br, size[:]); err != nil {
+			return err
+		}
+		n := int(binary.BigEndian.Uint16(size[:])) - 2
+		if n < 0 {
+			return errors.New("malformed JPEG segment")
+		}
+		if marker != 0xE1 && marker != 0xE2 && marker != 0xED {
+			if _, err := br.Discard(n); err != nil {
+				return err
+			}
+			continue
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return err
+		}
+		switch {
+		case marker == 0xE1 && bytes.HasPrefix(data, exifHeader):
+			if err := parseTIFF(bytes.NewReader(data[len(exifHeader):]), s); err != nil {
+				return err
+			}
+		case marker == 0xE1 && bytes.HasPrefix(data, xmpHeader):
+			parseXMP(data[len(xmpHeader):], &s.xmp)
+		case marker == 0xE2 && bytes.HasPrefix(data, iccHeader):
+			icc.add(data[len(iccHeader):])
+		case marker == 0xED && bytes.HasPrefix(data, photoshopHeader):
+			parsePhotoshop(data[len(photoshopHeader):], &s.iptc)
+		}
+	}
+}
+
+// iccChunks collects the pieces of an ICC profile split across APP2 segments. Each piece starts
+// with its 1-based sequence number and the total number of pieces.
+type iccChunks struct {
+	parts [][]byte
+	size  int
+}
+
+func (c *iccChunks) add(data []byte) {
+	if len(data) < 2 || data[0] == 0 || data[0] > data[1] || c.size+len(data) > maxProfile {
+		return
+	}
+	if c.parts == nil {
+		c.parts = make([][]byte, data[1])
+	}
+	if int(data[1]) != len(c.parts) {
+		return
+	}
+	c.parts[data[0]-1] = data[2:]
+	c.size += len(data) - 2
+}
+
+// join returns the profile, or nil if a piece is missing.
+func (c *iccChunks) join() []byte {
+	if len(c.parts) == 0 || slices.ContainsFunc(c.parts, func(p []byte) bool { return p == nil }) {
+		return nil
+	}
+	return bytes.Join(c.parts, nil)
+}
+
+// parsePhotoshop finds the IPTC record among the Photoshop image resources of an APP13 segment.
+func parsePhotoshop(data []byte, f *fields) {
+	for len(data) >= 12 && string(data[:4]) == "8BIM" {
+		id := binary.BigEndian.Uint16(data[4:6])
+		nameLen := int(data[6])
+		// The Pascal name, including its length byte, is padded to an even size.
+		off := 6 + 1 + nameLen
+		if off%2 != 0 {
+			off++
+		}
+		if off+4 > len(data) {
+			return
+		}
+		size := int(binary.BigEndian.Uint32(data[off : off+4]))
+		off += 4
+		if size < 0 || off+size > len(data) {
+			return
+		}
+		if id == 0x0404 {
+			parseIPTC(data[off:off+size], f)
+		}
+		off += size
+		if off%2 != 0 {
+			off++
+		}
+		if off > len(data) {
+			return
+		}
+		data = data[off:]
+	}
+}
+
+// readPNG reads the eXIf, iCCP, iTXt, zTXt and tEXt chunks.
+func readPNG(r io.ReadSeeker, s *sources) error {
+	if _, err := r.Seek(8, io.SeekStart); err != nil {
+		return err
+	}
+	var hdr [8]byte
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		n := int64(binary.BigEndian.Uint32(hdr[:4]))
+		typ := string(hdr[4:])
+		if typ == "IEND" {
+			return nil
+		}
+		wanted := typ == "eXIf" || typ == "iTXt" || typ == "zTXt" || typ == "tEXt"
+		if !(wanted && n <= maxBlock) && !(typ == "iCCP" && n <= maxProfile) {
+			if _, err := r.Seek(n+4, io.SeekCurrent); err != nil { // Data and CRC
+				return err
+			}
+			continue
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return err
+		}
+		if _, err := r.Seek(4, io.SeekCurrent); err != nil {
+			return err
+		}
+		switch typ {
+		case "eXIf":
+			if err := parseTIFF(bytes.NewReader(data), s); err != nil {
+				return err
+			}
+		case "iCCP":
+			// Profile name, compression method and the zlib-compressed profile
+			_, rest, ok := bytes.Cut(data, []byte{0})
+			if !ok || len(rest) < 1 {
+				continue
+			}
+			if profile, err := inflate(rest[1:], maxProfile); err == nil {
+				s.icc = profile
+			}
+		case "iTXt":
+			keyword, text, ok := parseITXt(data)
+			if !ok {
+				continue
+			}
+			if keyword == "XML:com.adobe.xmp" {
+				parseXMP(text, &s.xmp)
+			} else {
+				setPNGText(&s.text, keyword, string(text))
+			}
+		case "zTXt":
+			keyword, rest, ok := bytes.Cut(data, []byte{0})
+			if !ok || len(rest) < 1 {
+				continue
+			}
+			if text, err := inflate(rest[1:], maxBlock); err == nil {
+				setPNGText(&s.text, string(keyword), latin1(text))
+			}
+		case "tEXt":
+			if keyword, text, ok := bytes.Cut(data, []byte{0}); ok {
+				setPNGText(&s.text, string(keyword), latin1(text))
+			}
+		}
+	}
+}
+
+// parseITXt splits an iTXt chunk into its keyword and UTF-8 text.
+func parseITXt(data []byte) (string, []byte, bool) {
+	keyword, rest, ok := bytes.Cut(data, []byte{0})
+	if !ok || len(rest) < 2 {
+		return "", nil, false
+	}
+	compressed := rest[0] == 1
+	rest = rest[2:]
+	for range 2 { // Language tag and translated keyword
+		if _, rest, ok = bytes.Cut(rest, []byte{0}); !ok {
+			return "", nil, false
+		}
+	}
+	if compressed {
+		text, err := inflate(rest, maxBlock)
+		if err != nil {
+			return "", nil, false
+		}
+		rest = text
+	}
+	return string(keyword), rest, true
+}
+
+func inflate(data []byte, limit int64) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(io.LimitReader(zr, limit))
+}
+
+// setPNGText maps the predefined PNG text keywords.
+func setPNGText(f *fields, keyword, text string) {
+	text = clean(text)
+	switch keyword {
+	case "Title":
+		f.title = text
+	case "Author":
+		f.artist = text
+	case "Description":
+		f.caption = text
+	case "Copyright":
+		f.copyright = text
+	case "Creation Time":
+		f.taken = text
+	}
+}
+
+// readWebP reads the EXIF, XMP and ICCP chunks of an extended WebP file.
+func readWebP(r io.ReadSeeker, s *sources) error {
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return err
+	}
+	var hdr [8]byte
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		n := int64(binary.LittleEndian.Uint32(hdr[4:]))
+		padded := n + n%2
+		typ := string(hdr[:4])
+		wanted := (typ == "EXIF" || typ == "XMP ") && n <= maxBlock
+		if !wanted && !(typ == "ICCP" && n <= maxProfile) {
+			if _, err := r.Seek(padded, io.SeekCurrent); err != nil {
+				return err
+			}
+			continue
+		}
+		data := make([]byte, padded)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return err
+		}
+		data = data[:n]
+		switch typ {
+		case "XMP ":
+			parseXMP(data, &s.xmp)
+			continue
+		case "ICCP":
+			s.icc = data
+			continue
+		}
+		// Some writers keep the JPEG APP1 header in the chunk.
+		if err := parseTIFF(bytes.NewReader(bytes.TrimPrefix(data, exifHeader)), s); err != nil {
+			return err
+		}
+	}
+}
+
+// readTIFF reads the tags of a TIFF file. The file is read in place rather than loaded, as TIFF
+// masters can be hundreds of megabytes.
+func readTIFF(r io.ReadSeeker, s *sources) error {
+	if ra, ok := r.(io.ReaderAt); ok {
+		return parseTIFF(ra, s)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxBlock))
+	if err != nil {
+		return fmt.Errorf("read TIFF: %w", err)
+	}
+	return parseTIFF(bytes.NewReader(data), s)
+}
@@ -0,0 +1,203 @@
+package imagemeta
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"unicode/utf16"
+)
+
+// TIFF tags read from IFD0 and the EXIF sub-IFD.
+const (
+	tagImageDescription = 0x010E
+	tagMake             = 0x010F
+	tagModel            = 0x0110
+	tagOrientation      = 0x0112
+	tagArtist           = 0x013B
+	tagXMP              = 0x02BC
+	tagCopyright        = 0x8298
+	tagIPTC             = 0x83BB
+	tagExifIFD          = 0x8769
+	tagICCProfile       = 0x8773
+	tagXPTitle          = 0x9C9B
+	tagXPAuthor         = 0x9C9D
+	tagDateTimeOriginal = 0x9003
+	tagDateTimeDigital  = 0x9004
+)
+
+// maxIFDEntries guards against corrupt entry counts.
+const maxIFDEntries = 1024
+
+// typeSizes are the byte sizes of the TIFF field types, indexed by type.
+var typeSizes = [...]int{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8, 4}
+
+type ifdEntry struct {
+	tag, typ uint16
+	count    uint32
+	raw      [4]byte // The value itself if it fits in four bytes, otherwise its offset
+}
+
+type tiffReader struct {
+	r  io.ReaderAt
+	bo binary.ByteOrder
+}
+
+// parseTIFF reads the EXIF tags of a TIFF structure, as found in JPEG APP1 segments, PNG eXIf
+// chunks, WebP EXIF chunks and TIFF files. XMP and IPTC blocks embedded in IFD0 are parsed too.
+func parseTIFF(r io.ReaderAt, s *sources) error {
+	var hdr [8]byte
+	if _, err := r.ReadAt(hdr[:], 0); err != nil {
+		return err
+	}
+	t := tiffReader{r: r}
+	switch string(hdr[:4]) {
+	case "II*\x00":
+		t.bo = binary.LittleEndian
+	case "MM\x00*":
+		t.bo = binary.BigEndian
+	default:
+		return errors.New("malformed TIFF header")
+	}
+
+	entries, err := t.readIFD(t.bo.Uint32(hdr[4:]))
+	if err != nil {
+		return err
+	}
+	f := &s.exif
+	var exifIFD uint32
+	for _, e := range entries {
+		switch e.tag {
+		case tagImageDescription:
+			f.caption = clean(t.ascii(e))
+		case tagMake:
+			f.make = clean(t.ascii(e))
+		case tagModel:
+			f.model = clean(t.ascii(e))
+		case tagOrientation:
+			if o := t.number(e); o >= 1 && o <= 8 {
+				f.orientation = int(o)
+			}
+		case tagArtist:
+			f.artist = clean(t.ascii(e))
+		case tagCopyright:
+			f.copyright = clean(t.ascii(e))
+		case tagExifIFD:
+			exifIFD = t.number(e)
+		case tagXMP:
+			if data, err := t.value(e); err == nil {
+				parseXMP(data, &s.xmp)
+			}
+		case tagIPTC:
+			if data, err := t.value(e); err == nil {
+				parseIPTC(data, &s.iptc)
+			}
+		case tagICCProfile:
+			if data, err := t.value(e); err == nil {
+				s.icc = data
+			}
+		}
+	}
+	// Windows Explorer writes its own UTF-16 fields when titles are edited in file properties.
+	for _, e := range entries {
+		switch e.tag {
+		case tagXPTitle:
+			f.title = first(f.title, t.utf16(e))
+		case tagXPAuthor:
+			f.artist = first(f.artist, t.utf16(e))
+		}
+	}
+
+	if exifIFD == 0 {
+		return nil
+	}
+	entries, err = t.readIFD(exifIFD)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		switch e.tag {
+		case tagDateTimeOriginal:
+			f.taken = clean(t.ascii(e))
+		case tagDateTimeDigital:
+			f.created = clean(t.ascii(e))
+		}
+	}
+	return nil
+}
+
+func (t tiffReader) readIFD(offset uint32) ([]ifdEntry, error) {
+	var n [2]byte
+	if _, err := t.r.ReadAt(n[:], int64(offset)); err != nil {
+		return nil, err
+	}
+	count := int(t.bo.Uint16(n[:]))
+	if count > maxIFDEntries {
+		return nil, errors.New("malformed TIFF directory")
+	}
+	buf := make([]byte, count*12)
+	if _, err := t.r.ReadAt(buf, int64(offset)+2); err != nil {
+		return nil, err
+	}
+	entries := make([]ifdEntry, count)
+	for i := range entries {
+		b := buf[i*12:]
+		entries[i] = ifdEntry{tag: t.bo.Uint16(b), typ: t.bo.Uint16(b[2:]), count: t.bo.Uint32(b[4:])}
+		copy(entries[i].raw[:], b[8:12])
+	}
+	return entries, nil
+}
+
+// value returns the bytes of an entry's value.
+func (t tiffReader) value(e ifdEntry) ([]byte, error) {
+	if int(e.typ) >= len(typeSizes) || e.typ == 0 {
+		return nil, errors.New("unknown TIFF field type")
+	}
+	size := int64(typeSizes[e.typ]) * int64(e.count)
+	limit := int64(maxBlock)
+	if e.tag == tagICCProfile {
+		limit = maxProfile
+	}
+	if size > limit {
+		return nil, errors.New("TIFF field too large")
+	}
+	if size <= 4 {
+		return e.raw[:size], nil
+	}
+	data := make([]byte, size)
+	if _, err := t.r.ReadAt(data, int64(t.bo.Uint32(e.raw[:]))); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (t tiffReader) ascii(e ifdEntry) string {
+	data, err := t.value(e)
+	if err != nil {
+		return ""
+	}
+	return latin1(data)
+}
+
+// number returns the first value of a SHORT or LONG entry.
+func (t tiffReader) number(e ifdEntry) uint32 {
+	switch e.typ {
+	case 3: // SHORT
+		return uint32(t.bo.Uint16(e.raw[:]))
+	case 4, 13: // LONG, IFD
+		return t.bo.Uint32(e.raw[:])
+	}
+	return 0
+}
+
+// utf16 decodes the little-endian UTF-16 text of the Windows XP* tags.
+func (t tiffReader) utf16(e ifdEntry) string {
+	data, err := t.value(e)
+	if err != nil {
+		return ""
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[i*2:])
+	}
+	return clean(string(utf16.Decode(units)))
+}
@@ -0,0 +1,211 @@
+// Package imagemeta reads the descriptive metadata embedded in image files: EXIF, XMP and IPTC,
+// plus the embedded ICC color profile. Only the headers of a file are read, never its pixel data, so it is cheap enough to call while
+// listing a folder.
+package imagemeta
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxBlock caps how much of a single metadata block is read into memory.
+const maxBlock = 1 << 20
+
+// maxProfile caps the size of an embedded ICC profile. Print (CMYK) profiles run to a few megabytes.
+const maxProfile = 4 << 20
+
+// ErrUnknownFormat is returned for files that are not JPEG, PNG, WebP or TIFF images.
+var ErrUnknownFormat = errors.New("unknown image format")
+
+// Metadata is the descriptive metadata of an image. Fields the file does not provide are empty.
+type Metadata struct {
+	Title       string
+	Artist      string // Creator or photographer
+	Caption     string
+	Copyright   string
+	Camera      string    // Make and model, e.g. "Canon EOS R5"
+	Taken       time.Time // When the photo was taken, in the camera's local time if it recorded no zone
+	Orientation int       // EXIF orientation (1-8), or 0 if unknown
+	ICCProfile  []byte    // Embedded ICC color profile, or nil if the file has none
+}
+
+// Year returns the year the photo was taken, or an empty string if unknown.
+func (m Metadata) Year() string {
+	if m.Taken.IsZero() {
+		return ""
+	}
+	return strconv.Itoa(m.Taken.Year())
+}
+
+// fields holds the raw values of one metadata block.
+type fields struct {
+	title, artist, caption, copyright string
+	make, model                       string
+	taken, created                    string // Capture date and the less reliable digitized/creation date
+	orientation                       int
+}
+
+// sources collects the blocks found in a file so they can be merged by precedence.
+type sources struct {
+	exif, xmp, iptc, text fields
+	icc                   []byte
+}
+
+// ReadFile reads the metadata of an image file.
+func ReadFile(path string) (Metadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read reads the metadata of an image. The format is detected from the content, not the file name.
+// Metadata read before a malformed block is returned together with the error.
+func Read(r io.ReadSeeker) (Metadata, error) {
+	var head [12]byte
+	n, err := io.ReadFull(r, head[:])
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return Metadata{}, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return Metadata{}, err
+	}
+
+	var s sources
+	h := head[:n]
+	switch {
+	case bytes.HasPrefix(h, []byte{0xFF, 0xD8}):
+		err = readJPEG(r, &s)
+	case bytes.HasPrefix(h, []byte("\x89PNG\r\n\x1a\n")):
+		err = readPNG(r, &s)
+	case len(h) == 12 && string(h[:4]) == "RIFF" && string(h[8:]) == "WEBP":
+		err = readWebP(r, &s)
+	case bytes.HasPrefix(h, []byte("II*\x00")) || bytes.HasPrefix(h, []byte("MM\x00*")):
+		err = readTIFF(r, &s)
+	default:
+		return Metadata{}, ErrUnknownFormat
+	}
+	return s.merge(), err
+}
+
+// merge combines the blocks. XMP is the most deliberate record of an edit, IPTC the older
+// newsroom standard and EXIF what the camera wrote, so text fields prefer them in that order.
+// Dates prefer the camera's own capture time.
+func (s *sources) merge() Metadata {
+	m := Metadata{
+		Title:     first(s.xmp.title, s.iptc.title, s.exif.title, s.text.title),
+		Artist:    first(s.xmp.artist, s.iptc.artist, s.exif.artist, s.text.artist),
+		Caption:   first(s.xmp.caption, s.iptc.caption, s.exif.caption, s.text.caption),
+		Copyright: first(s.xmp.copyright, s.iptc.copyright, s.exif.copyright, s.text.copyright),
+	}
+	if s.exif.make != "" || s.exif.model != "" {
+		m.Camera = camera(s.exif.make, s.exif.model)
+	} else {
+		m.Camera = camera(s.xmp.make, s.xmp.model)
+	}
+	for _, d := range []string{s.exif.taken, s.xmp.taken, s.iptc.taken, s.exif.created, s.xmp.created, s.text.taken} {
+		if t, ok := parseDate(d); ok {
+			m.Taken = t
+			break
+		}
+	}
+	m.Orientation = s.exif.orientation
+	if m.Orientation == 0 {
+		m.Orientation = s.xmp.orientation
+	}
+	m.ICCProfile = s.icc
+	return m
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// camera joins make and model, dropping the make when the model already starts with it
+// (e.g. "Canon" and "Canon EOS R5", or "NIKON CORPORATION" and "NIKON D850").
+func camera(mk, model string) string {
+	if model == "" {
+		return mk
+	}
+	if mk == "" {
+		return model
+	}
+	mkWord, _, _ := strings.Cut(mk, " ")
+	modelWord, _, _ := strings.Cut(model, " ")
+	if strings.EqualFold(mkWord, modelWord) {
+		return model
+	}
+	return mk + " " + model
+}
+
+// placeholders are values cameras write into the description field when the user wrote nothing.
+var placeholders = map[string]bool{
+	"olympus digital camera": true,
+	"sony dsc":               true,
+	"digital camera":         true,
+	"default":                true,
+}
+
+// clean trims a metadata string and drops invalid UTF-8 and camera placeholders.
+func clean(s string) string {
+	s = strings.TrimRight(s, "\x00")
+	s = strings.TrimSpace(strings.ToValidUTF8(s, ""))
+	if placeholders[strings.ToLower(s)] {
+		return ""
+	}
+	return s
+}
+
+// latin1 decodes ISO 8859-1 text, which PNG text chunks and some IPTC records use.
+func latin1(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
+
+// dateLayouts are the date formats of EXIF, XMP (ISO 8601), IPTC and PNG.
+var dateLayouts = []string{
+	"2006:01:02 15:04:05",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"2006-01",
+	"20060102",
+	time.RFC1123Z,
+	time.RFC1123,
+	"2006",
+}
+
+// parseDate parses a metadata date. Dates without a zone are taken as local time.
+func parseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasPrefix(s, "0000") {
+		return time.Time{}, false
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
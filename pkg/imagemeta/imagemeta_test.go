@@ -0,0 +1,286 @@
+package imagemeta
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tiffTag struct {
+	id, typ uint16
+	count   uint32
+	value   []byte
+}
+
+func asciiTag(id uint16, s string) tiffTag {
+	b := append([]byte(s), 0)
+	return tiffTag{id: id, typ: 2, count: uint32(len(b)), value: b}
+}
+
+func shortTag(id, v uint16) tiffTag {
+	return tiffTag{id: id, typ: 3, count: 1, value: binary.LittleEndian.AppendUint16(nil, v)}
+}
+
+// buildTIFF writes a little-endian TIFF structure with an IFD0 and an optional EXIF sub-IFD.
+func buildTIFF(ifd0, exif []tiffTag) []byte {
+	bo := binary.LittleEndian
+	if len(exif) > 0 {
+		ifd0 = append(ifd0, tiffTag{id: tagExifIFD, typ: 4, count: 1})
+		exifAt := 8 + ifdSize(ifd0)
+		ifd0[len(ifd0)-1].value = bo.AppendUint32(nil, uint32(exifAt))
+	}
+	out := []byte("II*\x00\x08\x00\x00\x00")
+	out = appendIFD(out, ifd0)
+	if len(exif) > 0 {
+		out = appendIFD(out, exif)
+	}
+	return out
+}
+
+func ifdSize(tags []tiffTag) int {
+	n := 2 + len(tags)*12 + 4
+	for _, t := range tags {
+		if len(t.value) > 4 {
+			n += len(t.value)
+		}
+	}
+	return n
+}
+
+func appendIFD(out []byte, tags []tiffTag) []byte {
+	bo := binary.LittleEndian
+	dataAt := len(out) + 2 + len(tags)*12 + 4
+	var data []byte
+	out = bo.AppendUint16(out, uint16(len(tags)))
+	for _, t := range tags {
+		out = bo.AppendUint16(out, t.id)
+		out = bo.AppendUint16(out, t.typ)
+		out = bo.AppendUint32(out, t.count)
+		if len(t.value) <= 4 {
+			v := make([]byte, 4)
+			copy(v, t.value)
+			out = append(out, v...)
+			continue
+		}
+		out = bo.AppendUint32(out, uint32(dataAt+len(data)))
+		data = append(data, t.value...)
+	}
+	out = bo.AppendUint32(out, 0)
+	return append(out, data...)
+}
+
+func iptcDataset(dataset byte, value string) []byte {
+	b := []byte{0x1C, 2, dataset}
+	b = binary.BigEndian.AppendUint16(b, uint16(len(value)))
+	return append(b, value...)
+}
+
+func jpegSegment(marker byte, payload []byte) []byte {
+	b := []byte{0xFF, marker}
+	b = binary.BigEndian.AppendUint16(b, uint16(len(payload)+2))
+	return append(b, payload...)
+}
+
+func pngChunk(typ string, data []byte) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	b = append(b, typ...)
+	b = append(b, data...)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(append([]byte(typ), data...)))
+}
+
+func webpChunk(typ string, data []byte) []byte {
+	b := append([]byte(typ), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+	b = append(b, data...)
+	if len(data)%2 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+const testXMP = `<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/"
+    xmlns:photoshop="http://ns.adobe.com/photoshop/1.0/" photoshop:DateCreated="2020-01-01">
+   <dc:title><rdf:Alt><rdf:li xml:lang="x-default">Sunset</rdf:li><rdf:li xml:lang="de">Sonnenuntergang</rdf:li></rdf:Alt></dc:title>
+   <dc:creator><rdf:Seq><rdf:li>Ann Smith</rdf:li><rdf:li>Bob Jones</rdf:li></rdf:Seq></dc:creator>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>`
+
+func cameraTIFF() []byte {
+	return buildTIFF(
+		[]tiffTag{
+			asciiTag(tagImageDescription, "OLYMPUS DIGITAL CAMERA"),
+			asciiTag(tagMake, "Canon"),
+			asciiTag(tagModel, "Canon EOS R5"),
+			shortTag(tagOrientation, 6),
+			asciiTag(tagArtist, "Camera Owner"),
+		},
+		[]tiffTag{asciiTag(tagDateTimeOriginal, "2019:07:14 18:30:00")},
+	)
+}
+
+func TestRead_JPEG(t *testing.T) {
+	var iptc []byte
+	iptc = append(iptc, iptcDataset(iptcObjectName, "IPTC title")...)
+	iptc = append(iptc, iptcDataset(iptcByline, "Bob")...)
+	iptc = append(iptc, iptcDataset(iptcCaption, "Lake at dusk")...)
+	resource := []byte("8BIM\x04\x04\x00\x00")
+	resource = binary.BigEndian.AppendUint32(resource, uint32(len(iptc)))
+	resource = append(resource, iptc...)
+
+	var img []byte
+	img = append(img, 0xFF, 0xD8)
+	img = append(img, jpegSegment(0xE0, []byte("JFIF\x00\x01\x01"))...)
+	img = append(img, jpegSegment(0xE1, append(append([]byte{}, exifHeader...), cameraTIFF()...))...)
+	img = append(img, jpegSegment(0xE1, append(append([]byte{}, xmpHeader...), testXMP...))...)
+	img = append(img, jpegSegment(0xED, append(append([]byte{}, photoshopHeader...), resource...))...)
+	img = append(img, 0xFF, 0xDA, 0x00, 0x02, 0x12, 0x34) // Scan data is never read
+
+	m, err := Read(bytes.NewReader(img))
+	require.NoError(t, err)
+	assert.Equal(t, "Sunset", m.Title, "XMP wins over IPTC")
+	assert.Equal(t, "Ann Smith", m.Artist, "the first creator is used")
+	assert.Equal(t, "Lake at dusk", m.Caption, "IPTC fills what XMP lacks; the camera placeholder is ignored")
+	assert.Equal(t, "Canon EOS R5", m.Camera)
+	assert.Equal(t, 6, m.Orientation)
+	assert.Equal(t, time.Date(2019, 7, 14, 18, 30, 0, 0, time.Local), m.Taken, "the camera's capture time wins")
+	assert.Equal(t, "2019", m.Year())
+}
+
+func TestRead_PNG(t *testing.T) {
+	var img []byte
+	img = append(img, "\x89PNG\r\n\x1a\n"...)
+	img = append(img, pngChunk("IHDR", make([]byte, 13))...)
+	img = append(img, pngChunk("tEXt", []byte("Title\x00Caf\xe9 terrace"))...)
+	img = append(img, pngChunk("tEXt", []byte("Author\x00Vincent"))...)
+	img = append(img, pngChunk("eXIf", buildTIFF(nil, []tiffTag{asciiTag(tagDateTimeOriginal, "1888:09:01 21:00:00")}))...)
+	img = append(img, pngChunk("IDAT", []byte{1, 2, 3})...)
+	img = append(img, pngChunk("IEND", nil)...)
+
+	m, err := Read(bytes.NewReader(img))
+	require.NoError(t, err)
+	assert.Equal(t, "Café terrace", m.Title, "tEXt is Latin-1")
+	assert.Equal(t, "Vincent", m.Artist)
+	assert.Equal(t, "1888", m.Year())
+}
+
+func TestRead_WebP(t *testing.T) {
+	var body []byte
+	body = append(body, "WEBP"...)
+	body = append(body, webpChunk("VP8X", make([]byte, 10))...)
+	body = append(body, webpChunk("EXIF", append(append([]byte{}, exifHeader...), cameraTIFF()...))...)
+	body = append(body, webpChunk("XMP ", []byte(testXMP))...)
+	img := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	img = append(img, body...)
+
+	m, err := Read(bytes.NewReader(img))
+	require.NoError(t, err)
+	assert.Equal(t, "Sunset", m.Title)
+	assert.Equal(t, "Canon EOS R5", m.Camera)
+	assert.Equal(t, "2019", m.Year())
+}
+
+func TestReadFile_TIFF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.tif")
+	require.NoError(t, os.WriteFile(path, cameraTIFF(), 0600))
+
+	m, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Camera Owner", m.Artist)
+	assert.Empty(t, m.Caption)
+	assert.Equal(t, 6, m.Orientation)
+}
+
+func TestRead_ICCProfile(t *testing.T) {
+	profile := bytes.Repeat([]byte("icc profile data "), 8)
+
+	// JPEG splits the profile across APP2 segments, numbered in case they arrive out of order
+	jpeg := []byte{0xFF, 0xD8}
+	jpeg = append(jpeg, jpegSegment(0xE2, append(append([]byte{}, iccHeader...), append([]byte{2, 2}, profile[64:]...)...))...)
+	jpeg = append(jpeg, jpegSegment(0xE2, append(append([]byte{}, iccHeader...), append([]byte{1, 2}, profile[:64]...)...))...)
+	jpeg = append(jpeg, 0xFF, 0xDA, 0x00, 0x02)
+	m, err := Read(bytes.NewReader(jpeg))
+	require.NoError(t, err)
+	assert.Equal(t, profile, m.ICCProfile)
+
+	// A missing segment drops the profile rather than returning a corrupt one
+	jpeg = append([]byte{0xFF, 0xD8}, jpegSegment(0xE2, append(append([]byte{}, iccHeader...), append([]byte{1, 2}, profile[:64]...)...))...)
+	m, err = Read(bytes.NewReader(append(jpeg, 0xFF, 0xD9)))
+	require.NoError(t, err)
+	assert.Nil(t, m.ICCProfile)
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, err = zw.Write(profile)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	png := []byte("\x89PNG\r\n\x1a\n")
+	png = append(png, pngChunk("IHDR", make([]byte, 13))...)
+	png = append(png, pngChunk("iCCP", append([]byte("Display P3\x00\x00"), compressed.Bytes()...))...)
+	png = append(png, pngChunk("IEND", nil)...)
+	m, err = Read(bytes.NewReader(png))
+	require.NoError(t, err)
+	assert.Equal(t, profile, m.ICCProfile)
+
+	var body []byte
+	body = append(body, "WEBP"...)
+	body = append(body, webpChunk("VP8X", make([]byte, 10))...)
+	body = append(body, webpChunk("ICCP", profile)...)
+	webp := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	m, err = Read(bytes.NewReader(append(webp, body...)))
+	require.NoError(t, err)
+	assert.Equal(t, profile, m.ICCProfile)
+
+	tiff := buildTIFF([]tiffTag{{id: tagICCProfile, typ: 7, count: uint32(len(profile)), value: profile}}, nil)
+	m, err = Read(bytes.NewReader(tiff))
+	require.NoError(t, err)
+	assert.Equal(t, profile, m.ICCProfile)
+}
+
+func TestRead_Malformed(t *testing.T) {
+	_, err := Read(bytes.NewReader([]byte("GIF89a")))
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+
+	// Truncated and corrupt files return an error rather than panicking
+	_, err = Read(bytes.NewReader([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0x10}))
+	assert.Error(t, err)
+	exif := jpegSegment(0xE1, append(append([]byte{}, exifHeader...), "II*\x00\xff\xff\xff\x00"...))
+	_, err = Read(bytes.NewReader(append([]byte{0xFF, 0xD8}, exif...)))
+	assert.Error(t, err)
+}
+
+func TestCamera(t *testing.T) {
+	assert.Equal(t, "Canon EOS R5", camera("Canon", "Canon EOS R5"))
+	assert.Equal(t, "NIKON D850", camera("NIKON CORPORATION", "NIKON D850"))
+	assert.Equal(t, "Apple iPhone 15 Pro", camera("Apple", "iPhone 15 Pro"))
+	assert.Equal(t, "FUJIFILM", camera("FUJIFILM", ""))
+	assert.Empty(t, camera("", ""))
+}
+
+func TestParseDate(t *testing.T) {
+	tests := map[string]time.Time{
+		"2019:07:14 18:30:00":       time.Date(2019, 7, 14, 18, 30, 0, 0, time.Local),
+		"2019-07-14T18:30:00":       time.Date(2019, 7, 14, 18, 30, 0, 0, time.Local),
+		"2019-07-14T18:30:00+02:00": time.Date(2019, 7, 14, 18, 30, 0, 0, time.FixedZone("", 2*60*60)),
+		"2019-07-14":                time.Date(2019, 7, 14, 0, 0, 0, 0, time.Local),
+		"20190714":                  time.Date(2019, 7, 14, 0, 0, 0, 0, time.Local),
+	}
+	for s, want := range tests {
+		got, ok := parseDate(s)
+		require.True(t, ok, s)
+		assert.True(t, want.Equal(got), "%s: got %v", s, got)
+	}
+	for _, s := range []string{"", "0000:00:00 00:00:00", "yesterday"} {
+		_, ok := parseDate(s)
+		assert.False(t, ok, s)
+	}
+}
@@ -0,0 +1,46 @@
+package imagemeta
+
+import "encoding/binary"
+
+// IPTC IIM datasets of the application record (record 2).
+const (
+	iptcObjectName  = 5
+	iptcDateCreated = 55
+	iptcByline      = 80
+	iptcCopyright   = 116
+	iptcCaption     = 120
+)
+
+// parseIPTC reads an IPTC-IIM block. Repeated datasets such as several bylines keep the first value.
+func parseIPTC(data []byte, f *fields) {
+	for len(data) >= 5 && data[0] == 0x1C {
+		record, dataset := data[1], data[2]
+		size := int(binary.BigEndian.Uint16(data[3:5]))
+		if size&0x8000 != 0 || 5+size > len(data) { // Extended sizes are only used for binary data
+			return
+		}
+		value := clean(latin1(data[5 : 5+size]))
+		data = data[5+size:]
+		if record != 2 || value == "" {
+			continue
+		}
+		var field *string
+		switch dataset {
+		case iptcObjectName:
+			field = &f.title
+		case iptcDateCreated:
+			field = &f.taken
+		case iptcByline:
+			field = &f.artist
+		case iptcCopyright:
+			field = &f.copyright
+		case iptcCaption:
+			field = &f.caption
+		default:
+			continue
+		}
+		if *field == "" {
+			*field = value
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// XMP namespaces of the properties read.
+const (
+	nsRDF       = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	nsDC        = "http://purl.org/dc/elements/1.1/"
+	nsEXIF      = "http://ns.adobe.com/exif/1.0/"
+	nsTIFF      = "http://ns.adobe.com/tiff/1.0/"
+	nsXMP       = "http://ns.adobe.com/xap/1.0/"
+	nsPhotoshop = "http://ns.adobe.com/photoshop/1.0/"
+)
+
+// parseXMP reads an XMP packet. Properties may be written as attributes of rdf:Description, as
+// simple elements, or as rdf:Alt/rdf:Seq/rdf:Bag lists, in which case the first item is used
+// (the x-default language of a title, or the first of several creators).
+// Parsing stops quietly at malformed XML, keeping what was read until then.
+func parseXMP(data []byte, f *fields) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	var stack []xml.Name
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Space == nsRDF && t.Name.Local == "Description" {
+				for _, a := range t.Attr {
+					setXMP(f, a.Name, a.Value)
+				}
+			}
+			stack = append(stack, t.Name)
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return
+			}
+			stack = stack[:len(stack)-1]
+			switch {
+			case t.Name.Space != nsRDF:
+				setXMP(f, t.Name, text.String())
+			case t.Name.Local == "li":
+				// The property is the element around the rdf:Alt, rdf:Seq or rdf:Bag holding this item.
+				if len(stack) >= 2 {
+					setXMP(f, stack[len(stack)-2], text.String())
+				}
+			}
+			text.Reset()
+		}
+	}
+}
+
+// setXMP stores a property value unless an earlier value was already stored.
+func setXMP(f *fields, name xml.Name, value string) {
+	value = clean(value)
+	if value == "" {
+		return
+	}
+	set := func(field *string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	switch name.Space + name.Local {
+	case nsDC + "title":
+		set(&f.title)
+	case nsDC + "creator":
+		set(&f.artist)
+	case nsDC + "description":
+		set(&f.caption)
+	case nsDC + "rights":
+		set(&f.copyright)
+	case nsEXIF + "DateTimeOriginal", nsPhotoshop + "DateCreated":
+		set(&f.taken)
+	case nsXMP + "CreateDate", nsEXIF + "DateTimeDigitized":
+		set(&f.created)
+	case nsTIFF + "Make":
+		set(&f.make)
+	case nsTIFF + "Model":
+		set(&f.model)
+	case nsTIFF + "Orientation":
+		if o, err := strconv.Atoi(value); err == nil && o >= 1 && o <= 8 && f.orientation == 0 {
+			f.orientation = o
+		}
+	}
+}
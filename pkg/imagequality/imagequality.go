@@ -0,0 +1,182 @@
+// Package imagequality scores how good an image looks without a reference to compare it with:
+// how sharp it is, how noisy it is, and how visible its JPEG compression blocks are.
+//
+// Scores are taken from the luma of the image as given, so measure an image at about the size it
+// will be displayed: a soft 8K master can look perfectly sharp on a 4K screen.
+package imagequality
+
+import (
+	"cmp"
+	"image"
+	"image/draw"
+	"math"
+	"slices"
+)
+
+const (
+	tileSize = 64
+
+	// detailStdDev is the luma standard deviation below which a tile is too flat (sky, gradients)
+	// to say whether it is in focus.
+	detailStdDev = 16
+
+	// detailShare is the share of tiles, most detailed first, that Sharpness is averaged over.
+	detailShare = 0.1
+
+	// noisePercentile picks the tile noise estimate reported as Noise. Low percentiles come from
+	// the smoothest tiles, where texture and edges inflate the estimate least.
+	noisePercentile = 0.1
+
+	// Tiles this close to black or white are clipped and hide their noise.
+	clipLow, clipHigh = 16, 239
+)
+
+// Score holds the quality measurements of an image.
+type Score struct {
+	// Sharpness is the variance of the Laplacian of luma over the most detailed tiles. In-focus
+	// photos score in the hundreds; soft, upscaled images fall below about 25. It is 0 when the
+	// image has no detailed areas to judge (flat or gradient art).
+	Sharpness float64
+
+	// Noise is the estimated standard deviation of noise in luma, in 8-bit levels.
+	Noise float64
+
+	// Blockiness is the ratio of luma steps across JPEG block edges to the steps elsewhere. Clean
+	// images score about 1; heavy compression scores above about 3. It is 0 when not measured.
+	Blockiness float64
+}
+
+// Measure scores img. blockSize is the size of a JPEG block in img's pixels: 8 for an image
+// decoded at full size, less for one decoded at reduced scale. Blocks under 2 pixels are invisible,
+// so blockiness is then not measured; pass 0 to skip it too.
+func Measure(img image.Image, blockSize int) Score {
+	pix, stride, w, h := luma(img)
+	if w < 3 || h < 3 {
+		return Score{}
+	}
+
+	var detailed []tileStats
+	var noise []float64
+	for ty := 0; ty+tileSize <= h; ty += tileSize {
+		for tx := 0; tx+tileSize <= w; tx += tileSize {
+			t := measureTile(pix, stride, w, h, image.Rect(tx, ty, tx+tileSize, ty+tileSize))
+			if t.stdDev >= detailStdDev {
+				detailed = append(detailed, t)
+			}
+			if t.mean >= clipLow && t.mean <= clipHigh {
+				noise = append(noise, t.noise)
+			}
+		}
+	}
+
+	var s Score
+	if len(detailed) > 0 {
+		slices.SortFunc(detailed, func(a, b tileStats) int { return cmp.Compare(b.stdDev, a.stdDev) })
+		top := detailed[:max(1, int(float64(len(detailed))*detailShare))]
+		for _, t := range top {
+			s.Sharpness += t.laplacianVar
+		}
+		s.Sharpness /= float64(len(top))
+	}
+	if len(noise) > 0 {
+		slices.Sort(noise)
+		s.Noise = noise[int(float64(len(noise)-1)*noisePercentile)]
+	}
+	if blockSize >= 2 {
+		s.Blockiness = blockiness(pix, stride, w, h, blockSize)
+	}
+	return s
+}
+
+// tileStats summarises one tile of luma.
+type tileStats struct {
+	mean, stdDev float64
+	laplacianVar float64
+	noise        float64
+}
+
+// measureTile computes the statistics of the pixels of r that have all eight neighbours in the image.
+func measureTile(pix []uint8, stride, w, h int, r image.Rectangle) tileStats {
+	r = r.Intersect(image.Rect(1, 1, w-1, h-1))
+	var sum, sumSq, lap, lapSq, noise float64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		above, row, below := pix[(y-1)*stride:], pix[y*stride:], pix[(y+1)*stride:]
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := float64(row[x])
+			n, s, e, west := float64(above[x]), float64(below[x]), float64(row[x+1]), float64(row[x-1])
+			sum += c
+			sumSq += c * c
+
+			l := n + s + e + west - 4*c
+			lap += l
+			lapSq += l * l
+
+			// Immerkær's operator cancels image structure up to second order, leaving noise.
+			corners := float64(above[x-1]) + float64(above[x+1]) + float64(below[x-1]) + float64(below[x+1])
+			noise += math.Abs(corners - 2*(n+s+e+west) + 4*c)
+		}
+	}
+	count := float64(r.Dx() * r.Dy())
+	mean := sum / count
+	lapMean := lap / count
+	return tileStats{
+		mean:         mean,
+		stdDev:       math.Sqrt(max(sumSq/count-mean*mean, 0)),
+		laplacianVar: lapSq/count - lapMean*lapMean,
+		noise:        math.Sqrt(math.Pi/2) * noise / (6 * count),
+	}
+}
+
+// blockiness compares the luma steps between neighbouring pixels at each offset within a block. A
+// block grid shows up as one offset with larger steps than the rest; finding it by its steps keeps
+// the measure right when cropping or rotating has moved the grid.
+func blockiness(pix []uint8, stride, w, h, blockSize int) float64 {
+	cols := make([]float64, blockSize)
+	rows := make([]float64, blockSize)
+	for y := 1; y < h; y++ {
+		row, above := pix[y*stride:], pix[(y-1)*stride:]
+		for x := 1; x < w; x++ {
+			c := int(row[x])
+			cols[x%blockSize] += float64(abs(c - int(row[x-1])))
+			rows[y%blockSize] += float64(abs(c - int(above[x])))
+		}
+	}
+	return (peakRatio(cols) + peakRatio(rows)) / 2
+}
+
+// peakRatio returns the largest of steps divided by the mean of the others.
+func peakRatio(steps []float64) float64 {
+	peak := slices.Index(steps, slices.Max(steps))
+	var rest float64
+	for i, v := range steps {
+		if i != peak {
+			rest += v
+		}
+	}
+	rest /= float64(len(steps) - 1)
+	if rest == 0 {
+		return 1
+	}
+	return steps[peak] / rest
+}
+
+// luma returns the 8-bit luma plane of img, reusing the Y plane of JPEG and grayscale images.
+func luma(img image.Image) (pix []uint8, stride, w, h int) {
+	b := img.Bounds()
+	switch m := img.(type) {
+	case *image.YCbCr:
+		return m.Y[m.YOffset(b.Min.X, b.Min.Y):], m.YStride, b.Dx(), b.Dy()
+	case *image.Gray:
+		return m.Pix[m.PixOffset(b.Min.X, b.Min.Y):], m.Stride, b.Dx(), b.Dy()
+	}
+	g := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(g, g.Rect, img, b.Min, draw.Src)
+	return g.Pix, g.Stride, b.Dx(), b.Dy()
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,105 @@
+package imagequality
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/rand"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scene draws hard-edged shapes over a grainy gradient, standing in for a sharp photo.
+func scene() *image.NRGBA {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewNRGBA(image.Rect(0, 0, 512, 384))
+	for y := range 384 {
+		for x := range 512 {
+			v := uint8(60 + x/4 + y/8 + rng.Intn(3))
+			img.SetNRGBA(x, y, color.NRGBA{v, v, v, 255})
+		}
+	}
+	for range 60 {
+		x, y := rng.Intn(480), rng.Intn(352)
+		c := color.NRGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255}
+		for dy := range 8 + rng.Intn(24) {
+			for dx := range 8 + rng.Intn(24) {
+				img.SetNRGBA(x+dx, y+dy, c)
+			}
+		}
+	}
+	return img
+}
+
+func addNoise(img *image.NRGBA, sigma float64) *image.NRGBA {
+	rng := rand.New(rand.NewSource(2))
+	out := imaging.Clone(img)
+	for y := range out.Rect.Dy() {
+		for x := range out.Rect.Dx() {
+			v := float64(out.NRGBAAt(x, y).R) + rng.NormFloat64()*sigma
+			g := uint8(min(max(v, 0), 255))
+			out.SetNRGBA(x, y, color.NRGBA{g, g, g, 255})
+		}
+	}
+	return out
+}
+
+func recompress(t *testing.T, img image.Image, quality int) image.Image {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}))
+	out, err := jpeg.Decode(&buf)
+	require.NoError(t, err)
+	return out
+}
+
+func TestMeasure_Sharpness(t *testing.T) {
+	sharp := Measure(scene(), 0)
+	soft := Measure(imaging.Blur(scene(), 2), 0)
+	assert.Greater(t, sharp.Sharpness, 25.0)
+	assert.Less(t, soft.Sharpness, 25.0)
+
+	flat := image.NewGray(image.Rect(0, 0, 256, 256))
+	for y := range 256 {
+		for x := range 256 {
+			flat.SetGray(x, y, color.Gray{uint8(x / 2)})
+		}
+	}
+	assert.Zero(t, Measure(flat, 0).Sharpness, "a gradient has no detail to judge")
+}
+
+func TestMeasure_Noise(t *testing.T) {
+	clean := imaging.Grayscale(scene())
+	assert.InDelta(t, 0.8, Measure(clean, 0).Noise, 0.3, "the scene's own grain")
+	assert.InDelta(t, 6, Measure(addNoise(clean, 6), 0).Noise, 1)
+}
+
+func TestMeasure_Blockiness(t *testing.T) {
+	src := imaging.Blur(scene(), 1)
+	assert.Zero(t, Measure(src, 0).Blockiness, "not measured")
+	assert.InDelta(t, 1, Measure(src, 8).Blockiness, 0.2)
+
+	heavy := recompress(t, src, 10)
+	assert.Greater(t, Measure(heavy, 8).Blockiness, 3.0)
+	assert.Less(t, Measure(recompress(t, src, 95), 8).Blockiness, 1.5)
+
+	// The grid is found wherever it lies, e.g. after a crop
+	cropped := imaging.Crop(heavy, image.Rect(3, 5, 480, 360))
+	assert.Greater(t, Measure(cropped, 8).Blockiness, 3.0)
+}
+
+func TestMeasure_LumaSources(t *testing.T) {
+	// A JPEG decodes to YCbCr, whose Y plane is read directly
+	img := recompress(t, scene(), 90)
+	require.IsType(t, &image.YCbCr{}, img)
+	direct := Measure(img, 8)
+	converted := Measure(imaging.Clone(img), 8)
+	assert.InDelta(t, direct.Sharpness, converted.Sharpness, direct.Sharpness*0.05)
+	assert.InDelta(t, direct.Blockiness, converted.Blockiness, 0.05)
+
+	assert.Equal(t, Score{}, Measure(image.NewGray(image.Rect(0, 0, 2, 2)), 8))
+}
@@ -116,9 +116,17 @@ type TuningOptions struct {
 	TightCrop     bool                  `json:",omitempty"` // Returns only framed art without wall background padding
 }
 
+// QualityScore records the quality measurements of an image, for debugging the quality gate.
+type QualityScore struct {
+	Sharpness  float64 // Laplacian variance of the most detailed areas; 0 when the image has none
+	Noise      float64 // Noise standard deviation, in 8-bit luma levels
+	Blockiness float64 // JPEG block-edge step ratio, about 1 when clean; 0 when not measured
+}
+
 type ContextKey string
 
 const VirtualFramedKey ContextKey = "virtual_framed_result"
+const UpscaledKey ContextKey = "upscaled_result"
 const ProviderIDKey ContextKey = "provider_id"
 
 // Image represents a generic wallpaper image.
@@ -131,10 +139,15 @@ type Image struct {
 	Title            string
 	Artist           string
 	Year             string
+	Camera           string                   // Camera make and model, from the image's embedded metadata
+	Caption          string                   // Description of the image
+	Taken            time.Time                `json:",omitzero"` // Capture date of personal photos (EXIF, else the file's modification time)
 	Provider         string                   // Source provider name
 	FileType         string                   // Content type (e.g., "image/jpeg")
 	DownloadLocation string                   // URL to trigger download event (Unsplash requirement)
 	ProcessingFlags  map[string]bool          // Flags indicating how the image was processed (e.g. "SmartFit", "FaceCrop")
+	Quality          *QualityScore            `json:",omitempty"` // Quality scores of community images, measured by the quality gate
+	PerceptualHash   uint64                   `json:",omitempty"` // dHash of the master, for finding copies from other sources (0 = not hashed)
 	DerivativePaths  map[string]string        // Local file paths for different resolutions (e.g. "3440x1440" -> "/path/to/image.jpg")
 	SourceQueryID    string                   // ID of the query that produced this image (for smart cache clearing)
 	Width            int                      // Image Width (if available from source)
@@ -142,6 +155,9 @@ type Image struct {
 	Tuning           map[string]TuningOptions `json:",omitempty"` // Per-resolution tuning overrides (key = "WxH", e.g. "3440x1440")
 	IsFavorited      bool                     // Flag to protect image from cache pruning
 	Seen             bool                     // Flag for pagination/history logic
+	Fetched          time.Time                `json:",omitzero"`  // When the image entered the cache (for cache eviction)
+	LastShown        time.Time                `json:",omitzero"`  // When the image was last set as a wallpaper
+	ShowCount        int                      `json:",omitempty"` // How many times the image was set as a wallpaper
 }
 
 // GetTuning returns the tuning options for a specific resolution key.
@@ -166,8 +182,8 @@ func (img Image) GetAnchor(resKey string) CropAnchor {
 
 // MergeExistingMetadata copies locally-computed metadata from an existing store
 // entry into this image. Used during backlog healing to preserve work already done
-// (probed dimensions, incompatibility tags, crop anchors) while allowing the
-// pipeline to regenerate DerivativePaths from scratch.
+// (probed dimensions, quality scores, perceptual hashes, incompatibility tags, crop anchors)
+// while allowing the pipeline to regenerate DerivativePaths from scratch.
 //
 // Fields NOT merged (intentionally): DerivativePaths, FilePath, Seen, IsFavorited.
 // These are either regenerated by the pipeline or managed by separate subsystems.
@@ -180,6 +196,14 @@ func (img *Image) MergeExistingMetadata(existing Image) {
 		img.Height = existing.Height
 	}
 
+	// Preserve the quality score and hash so the image is not measured again
+	if img.Quality == nil {
+		img.Quality = existing.Quality
+	}
+	if img.PerceptualHash == 0 {
+		img.PerceptualHash = existing.PerceptualHash
+	}
+
 	// Merge processing flags (incompatibility tags, etc.)
 	if img.ProcessingFlags == nil {
 		img.ProcessingFlags = make(map[string]bool)
@@ -344,6 +368,48 @@ type ResolutionAwareProvider interface {
 	WithResolution(apiURL string, width, height int) string
 }
 
+// ScalableImageProvider is an optional interface for providers whose image servers can resize on the fly (e.g. IIIF).
+// The downloader passes the largest connected display so the master is no bigger than it needs to be.
+type ScalableImageProvider interface {
+	// WithImageSize returns the image URL rewritten to request an image sized for the given display.
+	WithImageSize(imageURL string, width, height int) string
+}
+
+// CatchAllProvider is an optional interface for providers whose ParseURL accepts arbitrary input
+// rather than the URLs of one site. URLs handed over by the browser extension are offered to them
+// only when no site-specific provider recognises the URL.
+type CatchAllProvider interface {
+	AcceptsAnyURL() bool
+}
+
+// DownloadTracker is an optional interface for providers whose terms require reporting when an image
+// is actually used (e.g. Unsplash's download endpoint). It is called each time an image with a
+// DownloadLocation is set as the wallpaper.
+type DownloadTracker interface {
+	TrackDownload(ctx context.Context, img Image) error
+}
+
+// DailyProvider is an optional interface for providers that publish one canonical image per day
+// (e.g. NASA APOD, Wikimedia Picture of the Day). Page 1 always starts at today's image and later
+// pages walk back through a rolling archive. The nightly refresh re-fetches page 1 of these providers,
+// and a monitor pinned to one shows its newest image instead of rotating.
+type DailyProvider interface {
+	// ImageDay returns the local calendar day the image was published for.
+	ImageDay(img Image) (time.Time, bool)
+}
+
+// FolderProvider is an optional interface for providers whose queries are folders on this computer.
+// The plugin watches those folders so new images are queued at once and deleted ones leave the rotation.
+type FolderProvider interface {
+	// WatchFolder returns the folder behind a query and how many subfolder levels the query covers.
+	WatchFolder(queryURL string) (folder string, depth int, ok bool)
+	// ImageForFile describes a file as FetchImages would list it, without reading the file.
+	// It returns false for files the query does not list.
+	ImageForFile(queryURL, path string) (Image, bool)
+	// SourceFile returns the file an image of the query was listed from.
+	SourceFile(queryURL string, img Image) (string, bool)
+}
+
 // HeaderProvider is an optional interface for providers that need custom headers for image downloads.
 type HeaderProvider interface {
 	GetDownloadHeaders() map[string]string
@@ -30,6 +30,17 @@ func TestMergeExistingMetadata_DimensionsNotOverwritten(t *testing.T) {
 	assert.Equal(t, 3000, img.Height)
 }
 
+func TestMergeExistingMetadata_Analysis(t *testing.T) {
+	// Quality scores and perceptual hashes are measured once per master
+	img := Image{ID: "test1"}
+	existing := Image{ID: "test1", Quality: &QualityScore{Sharpness: 120}, PerceptualHash: 0xC0FFEE}
+
+	img.MergeExistingMetadata(existing)
+
+	assert.Equal(t, existing.Quality, img.Quality)
+	assert.Equal(t, uint64(0xC0FFEE), img.PerceptualHash)
+}
+
 func TestMergeExistingMetadata_ProcessingFlags(t *testing.T) {
 	img := Image{ID: "test1"}
 	existing := Image{
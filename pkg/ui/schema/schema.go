@@ -234,6 +234,7 @@ type Query struct {
 	Description string
 	Active      bool
 	Managed     bool // Whether this query is managed by sync (cannot be deleted)
+	Option      bool // State of the list's per-query option, see QueryListItem.OptionLabel
 }
 
 // QueryListItem represents the abstraction for the list of queries/collections.
@@ -248,6 +249,8 @@ type QueryListItem struct {
 	DeleteLabel          string // Text for the action button. Default is "Delete".
 	ForceActionEnabled   bool   // Enable the action button even for managed queries.
 	DeleteConfirmMessage string // Custom message for the confirmation dialog.
+	OptionLabel          string // Optional: label of a check box shown on every row for a per-query option.
+	SetOption            func(id string, on bool) error
 }
 
 func (QueryListItem) isItemSchema() {}
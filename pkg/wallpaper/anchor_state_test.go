@@ -67,6 +67,7 @@ func TestReprocessWithAnchor_StateSync(t *testing.T) {
 
 	// --- Mock expectations ---
 	mockStore.On("SetTuningOptions", imgID, "1920x1080", provider.TuningOptions{Anchor: provider.AnchorTopCenter}).Return(true)
+	mockStore.On("SetResultFlags", imgID, map[string]bool{"VirtualFramed:1920x1080": false, "Upscaled:1920x1080": false}).Return(true)
 
 	// FitImage should return a valid image (1x1 is fine for test)
 	dummyResult := image.NewRGBA(image.Rect(0, 0, 1920, 1080))
@@ -119,13 +120,14 @@ func TestReprocessWithAnchor_AnchorAuto_ClearsMap(t *testing.T) {
 	monitor := Monitor{ID: 0, Rect: image.Rect(0, 0, 1920, 1080)}
 	mc := NewMonitorController(0, monitor, mockStore, fm, mockOS, cfg, mockIP)
 
-	// Start with an existing anchor
+	// Start with an existing anchor, in a map the store's snapshots may share
+	tuning := map[string]provider.TuningOptions{
+		"1920x1080": {Anchor: provider.AnchorTopCenter},
+	}
 	mc.State.CurrentImage = provider.Image{
 		ID:       "test_img",
 		FilePath: masterPath,
-		Tuning: map[string]provider.TuningOptions{
-			"1920x1080": {Anchor: provider.AnchorTopCenter},
-		},
+		Tuning:   tuning,
 		DerivativePaths: map[string]string{
 			"1920x1080": derivPath,
 		},
@@ -133,6 +135,7 @@ func TestReprocessWithAnchor_AnchorAuto_ClearsMap(t *testing.T) {
 	mc.State.CurrentID = "test_img"
 
 	mockStore.On("SetTuningOptions", "test_img", "1920x1080", provider.TuningOptions{Anchor: provider.AnchorAuto}).Return(true)
+	mockStore.On("SetResultFlags", "test_img", mock.Anything).Return(true)
 	dummyResult := image.NewRGBA(image.Rect(0, 0, 1920, 1080))
 	mockIP.On("FitImage", mock.Anything, mock.Anything, 1920, 1080, provider.TuningOptions{Anchor: provider.AnchorAuto}).Return(dummyResult, nil)
 	mockOS.On("SetWallpaper", mock.AnythingOfType("string"), 0).Return(nil)
@@ -150,6 +153,7 @@ func TestReprocessWithAnchor_AnchorAuto_ClearsMap(t *testing.T) {
 	mc.mu.RUnlock()
 
 	assert.False(t, exists, "AnchorAuto should clear the resolution entry from Tuning")
+	assert.Contains(t, tuning, "1920x1080", "the shared map must not be edited")
 	mockStore.AssertExpectations(t)
 }
 
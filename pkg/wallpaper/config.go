@@ -74,6 +74,7 @@ type Config struct {
 	TargetedShortcutsDisabled bool                 `json:"-"`
 	WallhavenSyncEnabled      bool                 `json:"-"`
 	MonitorPauseStates        map[string]bool      `json:"monitor_pause_states"`
+	MonitorDailyPins          map[string]string    `json:"monitor_daily_pins"` // Device path -> ID of the daily provider the monitor is pinned to
 }
 
 type VirtualFramingMode int
@@ -99,6 +100,34 @@ type ImageQuery struct {
 	Active      bool   `json:"active"`
 	Provider    string `json:"provider"` // Provider name (e.g., "Wallhaven", "Unsplash", "Pexels")
 	Managed     bool   `json:"managed"`  // Whether this query is managed by sync
+
+	Mapping *APIMapping `json:"mapping,omitempty"` // Field mapping of a Custom JSON API query
+	Scan    *FolderScan `json:"scan,omitempty"`    // Scan options of a Local Folder query
+
+	Memories bool `json:"memories,omitempty"` // "On This Day": prefer photos taken on today's date in earlier years
+}
+
+// FolderScan describes how a Local Folder query scans its folder.
+// Patterns are globs such as "*_thumb.jpg" or "*/raw/*", matched against paths relative to the folder.
+type FolderScan struct {
+	Depth   int      `json:"depth,omitempty"` // Subfolder levels to include; 0 scans only the folder itself
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// APIMapping describes how a Custom JSON API query turns responses into images.
+// Field paths are dot-separated keys with optional [n] array indexes, e.g. "data.items" or "urls[0].full".
+type APIMapping struct {
+	Endpoint string            `json:"endpoint"`          // URL template with {page} and {query} placeholders
+	Query    string            `json:"query,omitempty"`   // Value substituted for {query}
+	Headers  map[string]string `json:"headers,omitempty"` // Extra request headers, e.g. an API key
+	Results  string            `json:"results,omitempty"` // Path to the array of results; empty when the response is the array
+	ID       string            `json:"id"`
+	Path     string            `json:"path"` // Path to the image URL
+	Title    string            `json:"title,omitempty"`
+	Artist   string            `json:"artist,omitempty"`
+	Width    string            `json:"width,omitempty"`
+	Height   string            `json:"height,omitempty"`
 }
 
 var (
@@ -177,6 +206,7 @@ func GetConfig(p Preferences) *Config {
 			assetMgr:           asset.NewManager(),
 			AvoidSet:           make(map[string]bool),
 			MonitorPauseStates: make(map[string]bool),
+			MonitorDailyPins:   make(map[string]string),
 			userid:             u.Uid,
 			Tuning:             DefaultTuningConfig(),
 		}
@@ -243,9 +273,16 @@ func (c *Config) loadFromPrefs() error {
 
 // AddProviderQuery is the unified method for adding a query for ANY provider.
 func (c *Config) AddProviderQuery(description, url, provider string, active, managed bool) (string, error) {
+	return c.addQuery(ImageQuery{Description: description, URL: url, Provider: provider, Active: active, Managed: managed})
+}
+
+// addQuery adds a fully described query. Its ID is derived from the provider and URL.
+func (c *Config) addQuery(newQuery ImageQuery) (string, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	url, provider, active := newQuery.URL, newQuery.Provider, newQuery.Active
+
 	var id string
 	if provider == "Favorites" {
 		// Favorites uses the URL itself as the ID (legacy behavior)
@@ -276,14 +313,7 @@ func (c *Config) AddProviderQuery(description, url, provider string, active, man
 		return "", fmt.Errorf("duplicate query: this URL already exists")
 	}
 
-	newQuery := ImageQuery{
-		ID:          id,
-		Description: description,
-		URL:         url,
-		Active:      active,
-		Provider:    provider,
-		Managed:     managed,
-	}
+	newQuery.ID = id
 	log.Debugf("[Config] AddProviderQuery: appending new query: %+v", newQuery)
 
 	c.Queries = append([]ImageQuery{newQuery}, c.Queries...)
@@ -300,6 +330,11 @@ func (c *Config) AddImageQuery(desc, url string, active bool) (string, error) {
 	return c.AddProviderQuery(desc, url, "Wallhaven", active, false)
 }
 
+// AddUnsplashQuery adds a new Unsplash query.
+func (c *Config) AddUnsplashQuery(description, url string, active bool) (string, error) {
+	return c.AddProviderQuery(description, url, "Unsplash", active, false)
+}
+
 // AddPexelsQuery adds a new Pexels query.
 func (c *Config) AddPexelsQuery(description, url string, active bool) (string, error) {
 	return c.AddProviderQuery(description, url, "Pexels", active, false)
@@ -310,6 +345,46 @@ func (c *Config) AddWikimediaQuery(description, url string, active bool) (string
 	return c.AddProviderQuery(description, url, "Wikimedia", active, false)
 }
 
+// AddScriptQuery adds a new external script query.
+func (c *Config) AddScriptQuery(description, url string, active bool) (string, error) {
+	return c.AddProviderQuery(description, url, "Script", active, false)
+}
+
+// AddFeedQuery adds a new RSS/Atom feed query.
+func (c *Config) AddFeedQuery(description, url string, active bool) (string, error) {
+	return c.AddProviderQuery(description, url, "Feed", active, false)
+}
+
+// AddIIIFQuery adds a new IIIF manifest or collection query.
+func (c *Config) AddIIIFQuery(description, url string, active bool) (string, error) {
+	return c.AddProviderQuery(description, url, "IIIF", active, false)
+}
+
+// AddEuropeanaQuery adds a new Europeana search query.
+func (c *Config) AddEuropeanaQuery(description, url string, active bool) (string, error) {
+	return c.AddProviderQuery(description, url, "Europeana", active, false)
+}
+
+// AddDailyQuery adds a new query for one of the daily image providers (APOD, Picture of the Day, daily feeds).
+func (c *Config) AddDailyQuery(description, url, provider string, active bool) (string, error) {
+	return c.AddProviderQuery(description, url, provider, active, false)
+}
+
+// AddSelfHostedQuery adds a new album, person or label query for one of the self-hosted photo server providers (Immich, PhotoPrism).
+func (c *Config) AddSelfHostedQuery(description, url, provider string, active bool) (string, error) {
+	return c.AddProviderQuery(description, url, provider, active, false)
+}
+
+// AddFediverseQuery adds a new Mastodon/Pixelfed hashtag or account query.
+func (c *Config) AddFediverseQuery(description, url string, active bool) (string, error) {
+	return c.AddProviderQuery(description, url, "Fediverse", active, false)
+}
+
+// AddCustomAPIQuery adds a new Custom JSON API query. The URL identifies the query; the mapping is saved with it.
+func (c *Config) AddCustomAPIQuery(description, url string, mapping APIMapping, active bool) (string, error) {
+	return c.addQuery(ImageQuery{Description: description, URL: url, Provider: "CustomAPI", Active: active, Mapping: &mapping})
+}
+
 // isDuplicateID checks if a query ID already exists in the unified list.
 func (c *Config) isDuplicateID(id string) bool {
 	for _, q := range c.Queries {
@@ -513,6 +588,37 @@ func (c *Config) DisablePexelsQuery(id string) error {
 	return c.DisableImageQuery(id)
 }
 
+// SetQueryMemories turns the "On This Day" preference of a personal photo query on or off.
+func (c *Config) SetQueryMemories(id string, on bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	index, err := c.findQueryIndex(id)
+	if err != nil {
+		return err
+	}
+
+	c.Queries[index].Memories = on
+	c.save()
+	return nil
+}
+
+// GetMemoryQueryIDs returns the IDs of the active queries with the "On This Day" preference.
+func (c *Config) GetMemoryQueryIDs() map[string]bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var ids map[string]bool
+	for _, q := range c.Queries {
+		if q.Active && q.Memories {
+			if ids == nil {
+				ids = make(map[string]bool)
+			}
+			ids[q.ID] = true
+		}
+	}
+	return ids
+}
+
 // GetActiveQueryIDs returns a map of all currently active query IDs.
 func (c *Config) GetActiveQueryIDs() map[string]bool {
 	c.mu.RLock()
@@ -584,6 +690,57 @@ func (c *Config) SetCacheSize(size CacheSize) {
 	c.SetInt(CacheSizePrefKey, int(size))
 }
 
+// GetDiskQuota returns the disk quota of the image cache, or the default (unlimited) if not set or invalid
+func (c *Config) GetDiskQuota() DiskQuota {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	val := DiskQuota(c.IntWithFallback(DiskQuotaPrefKey, int(DiskQuotaUnlimited))) // Opt-in: no quota until the user picks one
+	if val < DiskQuota1GB || val > DiskQuotaUnlimited {
+		return DiskQuotaUnlimited
+	}
+	return val
+}
+
+// SetDiskQuota sets the disk quota of the image cache and saves it
+func (c *Config) SetDiskQuota(quota DiskQuota) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.SetInt(DiskQuotaPrefKey, int(quota))
+}
+
+// GetEvictionMode returns which cached images are deleted first when the cache is full, or the
+// default (oldest fetched, the behaviour before eviction modes existed) if not set or invalid
+func (c *Config) GetEvictionMode() EvictionMode {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	val := EvictionMode(c.IntWithFallback(EvictionModePrefKey, int(EvictOldestFetched)))
+	if val < EvictOldestFetched || val > EvictProviderFair {
+		return EvictOldestFetched
+	}
+	return val
+}
+
+// SetEvictionMode sets the cache eviction mode and saves it
+func (c *Config) SetEvictionMode(mode EvictionMode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.SetInt(EvictionModePrefKey, int(mode))
+}
+
+// GetFediverseMinResolution returns the index of the minimum attachment resolution preset for the Fediverse provider.
+func (c *Config) GetFediverseMinResolution() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.IntWithFallback(FediverseMinResolutionPrefKey, 2) // Default to Full HD
+}
+
+// SetFediverseMinResolution sets the index of the minimum attachment resolution preset for the Fediverse provider.
+func (c *Config) SetFediverseMinResolution(preset int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.SetInt(FediverseMinResolutionPrefKey, preset)
+}
+
 func (cfg *Config) GetMuseumCollectionOTA() bool {
 	return cfg.BoolWithFallback("museum_collection_ota", true) // Default to true
 }
@@ -719,6 +876,196 @@ func (c *Config) SetPexelsAPIKey(apiKey string) {
 	}
 }
 
+// GetUnsplashAccessKey returns the Unsplash access key from the config.
+func (c *Config) GetUnsplashAccessKey() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	accessKey, err := keyring.Get(UnsplashAccessKeyPrefKey, c.userid)
+	if err != nil {
+		if !errors.Is(err, keyring.ErrNotFound) {
+			log.Printf("failed to retrieve Unsplash access key from keyring: %v", err)
+		}
+		return ""
+	}
+	return accessKey
+}
+
+// SetUnsplashAccessKey sets the Unsplash access key.
+func (c *Config) SetUnsplashAccessKey(accessKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := keyring.Set(UnsplashAccessKeyPrefKey, c.userid, accessKey)
+	if err != nil {
+		log.Printf("failed to save Unsplash access key to keyring: %v", err)
+	}
+}
+
+// GetNASAAPIKey returns the NASA API key (api.nasa.gov) from the keyring.
+func (c *Config) GetNASAAPIKey() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	apiKey, err := keyring.Get(NASAAPIKeyPrefKey, c.userid)
+	if err != nil {
+		if !errors.Is(err, keyring.ErrNotFound) {
+			log.Printf("failed to retrieve NASA API key from keyring: %v", err)
+		}
+		return ""
+	}
+	return apiKey
+}
+
+// SetNASAAPIKey sets the NASA API key.
+func (c *Config) SetNASAAPIKey(apiKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := keyring.Set(NASAAPIKeyPrefKey, c.userid, apiKey)
+	if err != nil {
+		log.Printf("failed to save NASA API key to keyring: %v", err)
+	}
+}
+
+// GetSmithsonianAPIKey returns the api.data.gov key used for the Smithsonian Open Access API from the keyring.
+func (c *Config) GetSmithsonianAPIKey() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	apiKey, err := keyring.Get(SmithsonianAPIKeyPrefKey, c.userid)
+	if err != nil {
+		if !errors.Is(err, keyring.ErrNotFound) {
+			log.Printf("failed to retrieve Smithsonian API key from keyring: %v", err)
+		}
+		return ""
+	}
+	return apiKey
+}
+
+// SetSmithsonianAPIKey sets the Smithsonian API key. An empty key removes it.
+func (c *Config) SetSmithsonianAPIKey(apiKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if apiKey == "" {
+		_ = keyring.Delete(SmithsonianAPIKeyPrefKey, c.userid)
+		return
+	}
+	err := keyring.Set(SmithsonianAPIKeyPrefKey, c.userid, apiKey)
+	if err != nil {
+		log.Printf("failed to save Smithsonian API key to keyring: %v", err)
+	}
+}
+
+// GetEuropeanaAPIKey returns the Europeana API key (wskey) from the keyring.
+func (c *Config) GetEuropeanaAPIKey() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	apiKey, err := keyring.Get(EuropeanaAPIKeyPrefKey, c.userid)
+	if err != nil {
+		if !errors.Is(err, keyring.ErrNotFound) {
+			log.Printf("failed to retrieve Europeana API key from keyring: %v", err)
+		}
+		return ""
+	}
+	return apiKey
+}
+
+// SetEuropeanaAPIKey sets the Europeana API key. An empty key removes it.
+func (c *Config) SetEuropeanaAPIKey(apiKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if apiKey == "" {
+		_ = keyring.Delete(EuropeanaAPIKeyPrefKey, c.userid)
+		return
+	}
+	err := keyring.Set(EuropeanaAPIKeyPrefKey, c.userid, apiKey)
+	if err != nil {
+		log.Printf("failed to save Europeana API key to keyring: %v", err)
+	}
+}
+
+// GetImmichServerURL returns the base URL of the user's Immich server.
+func (c *Config) GetImmichServerURL() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.StringWithFallback(ImmichServerURLPrefKey, "")
+}
+
+// SetImmichServerURL sets the base URL of the user's Immich server.
+func (c *Config) SetImmichServerURL(serverURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	log.Debugf("Config: Setting ImmichServerURL to: '%s'", serverURL)
+	c.SetString(ImmichServerURLPrefKey, serverURL)
+}
+
+// GetImmichAPIKey returns the Immich API key from the keyring.
+func (c *Config) GetImmichAPIKey() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	apiKey, err := keyring.Get(ImmichAPIKeyPrefKey, c.userid)
+	if err != nil {
+		if !errors.Is(err, keyring.ErrNotFound) {
+			log.Printf("failed to retrieve Immich API key from keyring: %v", err)
+		}
+		return ""
+	}
+	return apiKey
+}
+
+// SetImmichAPIKey sets the Immich API key. An empty key removes it.
+func (c *Config) SetImmichAPIKey(apiKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if apiKey == "" {
+		_ = keyring.Delete(ImmichAPIKeyPrefKey, c.userid)
+		return
+	}
+	err := keyring.Set(ImmichAPIKeyPrefKey, c.userid, apiKey)
+	if err != nil {
+		log.Printf("failed to save Immich API key to keyring: %v", err)
+	}
+}
+
+// GetPhotoPrismServerURL returns the base URL of the user's PhotoPrism server.
+func (c *Config) GetPhotoPrismServerURL() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.StringWithFallback(PhotoPrismServerURLPrefKey, "")
+}
+
+// SetPhotoPrismServerURL sets the base URL of the user's PhotoPrism server.
+func (c *Config) SetPhotoPrismServerURL(serverURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	log.Debugf("Config: Setting PhotoPrismServerURL to: '%s'", serverURL)
+	c.SetString(PhotoPrismServerURLPrefKey, serverURL)
+}
+
+// GetPhotoPrismAPIKey returns the PhotoPrism app password from the keyring.
+func (c *Config) GetPhotoPrismAPIKey() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	apiKey, err := keyring.Get(PhotoPrismAPIKeyPrefKey, c.userid)
+	if err != nil {
+		if !errors.Is(err, keyring.ErrNotFound) {
+			log.Printf("failed to retrieve PhotoPrism API key from keyring: %v", err)
+		}
+		return ""
+	}
+	return apiKey
+}
+
+// SetPhotoPrismAPIKey sets the PhotoPrism app password. An empty key removes it.
+func (c *Config) SetPhotoPrismAPIKey(apiKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if apiKey == "" {
+		_ = keyring.Delete(PhotoPrismAPIKeyPrefKey, c.userid)
+		return
+	}
+	err := keyring.Set(PhotoPrismAPIKeyPrefKey, c.userid, apiKey)
+	if err != nil {
+		log.Printf("failed to save PhotoPrism API key to keyring: %v", err)
+	}
+}
+
 // GetWikimediaPersonalToken returns the Wikimedia Personal API Token from the keyring.
 func (c *Config) GetWikimediaPersonalToken() string {
 	c.mu.RLock()
@@ -919,8 +1266,9 @@ func (c *Config) GetFavoritesQueries() []ImageQuery {
 
 // AddLocalFolderQuery adds a new Local Folder query.
 // The url parameter should be the absolute path to the user-selected folder.
-func (c *Config) AddLocalFolderQuery(description, url string, active bool) (string, error) {
-	log.Debugf("[Config] AddLocalFolderQuery: desc=%q, path=%q, active=%v", description, url, active)
+// A zero scan lists only the images directly inside the folder.
+func (c *Config) AddLocalFolderQuery(description, url string, scan FolderScan, active bool) (string, error) {
+	log.Debugf("[Config] AddLocalFolderQuery: desc=%q, path=%q, scan=%+v, active=%v", description, url, scan, active)
 
 	// Robust path normalization to catch duplicates even with trailing slashes or relative paths.
 	cleanPath := filepath.Clean(url)
@@ -928,7 +1276,11 @@ func (c *Config) AddLocalFolderQuery(description, url string, active bool) (stri
 		cleanPath = abs
 	}
 
-	return c.AddProviderQuery(description, cleanPath, LocalFolderProviderID, active, false)
+	q := ImageQuery{Description: description, URL: cleanPath, Provider: LocalFolderProviderID, Active: active}
+	if scan.Depth > 0 || len(scan.Include) > 0 || len(scan.Exclude) > 0 {
+		q.Scan = &scan
+	}
+	return c.addQuery(q)
 }
 
 // RemoveLocalFolderQuery removes a Local Folder query from the unified list.
@@ -1052,6 +1404,38 @@ func (c *Config) GetFaceCropEnabled() bool {
 	return c.BoolWithFallback(FaceCropPrefKey, true) // Default: true
 }
 
+// SetUpscaleEnabled sets the preference for sharpening images slightly smaller than a monitor after enlarging them.
+func (c *Config) SetUpscaleEnabled(enable bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.SetBool(UpscalePrefKey, enable)
+}
+
+// GetUpscaleEnabled returns whether images slightly smaller than a monitor are sharpened after enlarging them.
+func (c *Config) GetUpscaleEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.BoolWithFallback(UpscalePrefKey, false) // Default: false (opt-in)
+}
+
+// SetUpscaleMaxFactor sets the largest enlargement that is sharpened when upscaling is enabled.
+func (c *Config) SetUpscaleMaxFactor(factor float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.SetFloat(UpscaleMaxFactorPrefKey, factor)
+}
+
+// GetUpscaleMaxFactor returns the largest enlargement that is sharpened when upscaling is enabled (e.g. 1.25 for 125%).
+func (c *Config) GetUpscaleMaxFactor() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	factor := c.FloatWithFallback(UpscaleMaxFactorPrefKey, DefaultUpscaleMaxFactor)
+	if factor < 1 {
+		return 1
+	}
+	return factor
+}
+
 // GetShortcutsDisabled returns the hotkey disabled preference.
 func (c *Config) GetShortcutsDisabled() bool {
 	c.mu.RLock()
@@ -1180,6 +1564,14 @@ func (c *Config) save() {
 		}
 	}
 
+	// Deep copy MonitorDailyPins map
+	if c.MonitorDailyPins != nil {
+		clone.MonitorDailyPins = make(map[string]string)
+		for k, v := range c.MonitorDailyPins {
+			clone.MonitorDailyPins[k] = v
+		}
+	}
+
 	// Fast-path: spin off the actual marshaling/saving to a goroutine so the
 	// caller's defer c.mu.Unlock() executes instantly and Fyne isn't blocked!
 	// UPDATE: Removing goroutine to prevent "Stale Overwrite" race conditions where
@@ -1221,6 +1613,34 @@ func (c *Config) SetMonitorPaused(devicePath string, paused bool) {
 	c.save()
 }
 
+// GetMonitorDailyPin returns the ID of the daily provider the specified monitor is pinned to, or "" if it is not pinned.
+func (c *Config) GetMonitorDailyPin(devicePath string) string {
+	if devicePath == "" {
+		return ""
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.MonitorDailyPins[devicePath]
+}
+
+// SetMonitorDailyPin pins a specific monitor to a daily provider. An empty providerID unpins it.
+func (c *Config) SetMonitorDailyPin(devicePath, providerID string) {
+	if devicePath == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if providerID == "" {
+		delete(c.MonitorDailyPins, devicePath)
+	} else {
+		if c.MonitorDailyPins == nil {
+			c.MonitorDailyPins = make(map[string]string)
+		}
+		c.MonitorDailyPins[devicePath] = providerID
+	}
+	c.save()
+}
+
 // GetImageQueries returns a copy of the Wallhaven queries in a thread-safe manner.
 func (c *Config) GetImageQueries() []ImageQuery {
 	c.mu.RLock()
@@ -1247,6 +1667,19 @@ func (c *Config) GetPexelsQueries() []ImageQuery {
 	return queries
 }
 
+// GetUnsplashQueries returns a copy of the Unsplash queries in a thread-safe manner.
+func (c *Config) GetUnsplashQueries() []ImageQuery {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var queries []ImageQuery
+	for _, q := range c.Queries {
+		if q.Provider == "Unsplash" {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
 // GetWikimediaQueries returns a copy of the Wikimedia queries in a thread-safe manner.
 func (c *Config) GetWikimediaQueries() []ImageQuery {
 	c.mu.RLock()
@@ -1260,6 +1693,110 @@ func (c *Config) GetWikimediaQueries() []ImageQuery {
 	return queries
 }
 
+// GetScriptQueries returns a copy of the external script queries in a thread-safe manner.
+func (c *Config) GetScriptQueries() []ImageQuery {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var queries []ImageQuery
+	for _, q := range c.Queries {
+		if q.Provider == "Script" {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+// GetFeedQueries returns a copy of the RSS/Atom feed queries in a thread-safe manner.
+func (c *Config) GetFeedQueries() []ImageQuery {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var queries []ImageQuery
+	for _, q := range c.Queries {
+		if q.Provider == "Feed" {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+// GetIIIFQueries returns a copy of the IIIF manifest and collection queries in a thread-safe manner.
+func (c *Config) GetIIIFQueries() []ImageQuery {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var queries []ImageQuery
+	for _, q := range c.Queries {
+		if q.Provider == "IIIF" {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+// GetEuropeanaQueries returns a copy of the Europeana queries (searches and curated galleries) in a thread-safe manner.
+func (c *Config) GetEuropeanaQueries() []ImageQuery {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var queries []ImageQuery
+	for _, q := range c.Queries {
+		if q.Provider == "Europeana" {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+// GetDailyQueries returns a copy of the queries of the given daily image provider in a thread-safe manner.
+func (c *Config) GetDailyQueries(provider string) []ImageQuery {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var queries []ImageQuery
+	for _, q := range c.Queries {
+		if q.Provider == provider {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+// GetSelfHostedQueries returns a copy of the queries of the given self-hosted photo server provider in a thread-safe manner.
+func (c *Config) GetSelfHostedQueries(provider string) []ImageQuery {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var queries []ImageQuery
+	for _, q := range c.Queries {
+		if q.Provider == provider {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+// GetFediverseQueries returns a copy of the Mastodon/Pixelfed hashtag and account queries in a thread-safe manner.
+func (c *Config) GetFediverseQueries() []ImageQuery {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var queries []ImageQuery
+	for _, q := range c.Queries {
+		if q.Provider == "Fediverse" {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+// GetCustomAPIQueries returns a copy of the Custom JSON API queries in a thread-safe manner.
+func (c *Config) GetCustomAPIQueries() []ImageQuery {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var queries []ImageQuery
+	for _, q := range c.Queries {
+		if q.Provider == "CustomAPI" {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
 // GetQueries returns a copy of all queries in a thread-safe manner.
 func (c *Config) GetQueries() []ImageQuery {
 	c.mu.RLock()
@@ -1457,6 +1994,36 @@ func (c *Config) GetWallhavenUsername() string {
 	return c.StringWithFallback(WallhavenUsernamePrefKey, "")
 }
 
+// SetScriptExecutable sets the path of the executable used by the Script provider.
+func (c *Config) SetScriptExecutable(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	log.Debugf("Config: Setting ScriptExecutable to: '%s'", path)
+	c.SetString(ScriptExecutablePrefKey, path)
+}
+
+// GetScriptExecutable returns the path of the executable used by the Script provider.
+func (c *Config) GetScriptExecutable() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.StringWithFallback(ScriptExecutablePrefKey, "")
+}
+
+// SetScriptTimeout sets the maximum run time of a single script invocation, in seconds.
+func (c *Config) SetScriptTimeout(seconds int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.SetInt(ScriptTimeoutPrefKey, seconds)
+}
+
+// GetScriptTimeout returns the maximum run time of a single script invocation, in seconds.
+// Returns 0 if not set, in which case the provider default applies.
+func (c *Config) GetScriptTimeout() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.IntWithFallback(ScriptTimeoutPrefKey, 0)
+}
+
 // SetLogLevel sets the log level for the application.
 func (c *Config) SetLogLevel(level string) {
 	c.mu.Lock()
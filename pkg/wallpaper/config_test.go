@@ -84,6 +84,63 @@ func TestQueryManagement(t *testing.T) {
 	assert.Equal(t, 1, len(cfg.GetQueries()))
 }
 
+func TestCustomAPIQueryMapping(t *testing.T) {
+	ResetConfig()
+	p := NewMockPreferences()
+	cfg := GetConfig(p)
+
+	mapping := APIMapping{
+		Endpoint: "https://api.example.com/search?q={query}&page={page}",
+		Query:    "nebula",
+		Headers:  map[string]string{"X-Api-Key": "abc"},
+		Results:  "data.items",
+		ID:       "id",
+		Path:     "urls.full",
+	}
+	id, err := cfg.AddCustomAPIQuery("Nebulae", "https://api.example.com/search?q=nebula&page={page}", mapping, true)
+	assert.NoError(t, err)
+
+	queries := cfg.GetCustomAPIQueries()
+	if assert.Len(t, queries, 1) {
+		assert.Equal(t, id, queries[0].ID)
+		assert.Equal(t, &mapping, queries[0].Mapping)
+	}
+
+	// The mapping is saved alongside the query and survives a reload
+	ResetConfig()
+	cfg = GetConfig(p)
+	q, found := cfg.GetQuery(id)
+	assert.True(t, found)
+	assert.Equal(t, &mapping, q.Mapping)
+}
+
+func TestLocalFolderQueryScan(t *testing.T) {
+	ResetConfig()
+	p := NewMockPreferences()
+	cfg := GetConfig(p)
+
+	dir := t.TempDir()
+	scan := FolderScan{Depth: 3, Exclude: []string{"*/raw/*", "*_thumb.jpg"}}
+	id, err := cfg.AddLocalFolderQuery("Library", dir, scan, false)
+	assert.NoError(t, err)
+
+	flatDir := t.TempDir()
+	flatID, err := cfg.AddLocalFolderQuery("Flat", flatDir, FolderScan{}, false)
+	assert.NoError(t, err)
+
+	// The scan options are saved alongside the query and survive a reload
+	ResetConfig()
+	cfg = GetConfig(p)
+	q, found := cfg.GetQuery(id)
+	assert.True(t, found)
+	assert.Equal(t, &scan, q.Scan)
+	assert.Equal(t, GenerateQueryID(LocalFolderProviderID+":"+dir), q.ID, "query IDs do not depend on scan options")
+
+	q, found = cfg.GetQuery(flatID)
+	assert.True(t, found)
+	assert.Nil(t, q.Scan, "a zero scan is not stored")
+}
+
 func TestConfigPreferences(t *testing.T) {
 	ResetConfig()
 	p := NewMockPreferences()
@@ -97,6 +154,14 @@ func TestConfigPreferences(t *testing.T) {
 	cfg.SetCacheSize(Cache500Images)
 	assert.Equal(t, Cache500Images, cfg.GetCacheSize())
 
+	// Disk Quota & Eviction
+	assert.Equal(t, DiskQuotaUnlimited, cfg.GetDiskQuota())
+	cfg.SetDiskQuota(DiskQuota5GB)
+	assert.Equal(t, DiskQuota5GB, cfg.GetDiskQuota())
+	assert.Equal(t, EvictOldestFetched, cfg.GetEvictionMode())
+	cfg.SetEvictionMode(EvictProviderFair)
+	assert.Equal(t, EvictProviderFair, cfg.GetEvictionMode())
+
 	// Wallpaper Change Frequency
 	cfg.SetWallpaperChangeFrequency(FrequencyDaily)
 	assert.Equal(t, FrequencyDaily, cfg.GetWallpaperChangeFrequency())
@@ -7,6 +7,8 @@ import (
 	"math"
 	"path/filepath"
 	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
 )
 
 // pluginName is the name of the wallpaper plugin
@@ -18,6 +20,8 @@ const (
 	SmartFitPrefKey                  = pluginPrefix + "smart_fit_key"                   // SmartFitPrefKey is used to set and retrieve the boolean flag for wallpaper smart fit
 	SmartFitModePrefKey              = pluginPrefix + "smart_fit_mode_key"              // SmartFitModePrefKey is used to set and retrieve the int smart fit mode
 	CacheSizePrefKey                 = pluginPrefix + "cache_size_key"                  // WallpaperCacheSizePrefKey is used to set and retrieve the int wallpaper cache size
+	DiskQuotaPrefKey                 = pluginPrefix + "disk_quota_key"                  // DiskQuotaPrefKey is used to set and retrieve the int disk quota of the image cache
+	EvictionModePrefKey              = pluginPrefix + "eviction_mode_key"               // EvictionModePrefKey is used to set and retrieve the int cache eviction mode
 	WallpaperChgFreqPrefKey          = pluginPrefix + "wallpaper_chg_freq_key"          // Legacy enum key
 	WallpaperChgFreqMinsPrefKey      = pluginPrefix + "wallpaper_chg_freq_mins_key"     // New key for raw minutes
 	ImgShufflePrefKey                = pluginPrefix + "img_shuffle_key"                 // ImgShufflePrefKey is used to set and retrieve the boolean flag for wallpaper image shuffle
@@ -25,6 +29,8 @@ const (
 	NightlyRefreshPrefKey            = pluginPrefix + "nightly_refresh_key"             // NightlyRefreshPrefKey is used to set and retrieve the boolean flag for nightly refresh
 	FaceBoostPrefKey                 = pluginPrefix + "face_boost_key"                  // FaceBoostPrefKey is used to set and retrieve the boolean flag for face boost
 	FaceCropPrefKey                  = pluginPrefix + "face_crop_key"                   // FaceCropPrefKey is used to set and retrieve the boolean flag for face crop
+	UpscalePrefKey                   = pluginPrefix + "upscale_key"                     // UpscalePrefKey is used to set and retrieve the boolean flag for upscaling near-miss images
+	UpscaleMaxFactorPrefKey          = pluginPrefix + "upscale_max_factor_key"          // UpscaleMaxFactorPrefKey is used to set and retrieve the float maximum upscale factor
 	StaggerMonitorChangesPrefKey     = pluginPrefix + "stagger_changes_key"             // StaggerMonitorChangesPrefKey is used to set and retrieve the boolean flag for staggering wallpaper changes
 	ShortcutsDisabledPrefKey         = pluginPrefix + "shortcuts_disabled_key"          // ShortcutsDisabledPrefKey is used to set and retrieve the boolean flag for disabling hotkeys
 	TargetedShortcutsDisabledPrefKey = pluginPrefix + "targeted_shortcuts_disabled_key" // TargetedShortcutsDisabledPrefKey is used to set and retrieve the boolean flag for disabling targeted hotkeys
@@ -48,8 +54,21 @@ const (
 	PexelsDescRegexp                = `^[a-zA-Z0-9\s]{5,50}$`
 	PexelsAPISearchURL              = "https://api.pexels.com/v1/search"
 	PexelsAPICollectionURL          = "https://api.pexels.com/v1/collections/%s"
+	UnsplashAccessKeyPrefKey        = "unsplash_access_key" //nolint:gosec // Preference key, not a secret
+	NASAAPIKeyPrefKey               = "nasa_api_key"        //nolint:gosec // Preference key, not a secret
+	SmithsonianAPIKeyPrefKey        = "smithsonian_api_key" //nolint:gosec // Preference key, not a secret
+	EuropeanaAPIKeyPrefKey          = "europeana_api_key"   //nolint:gosec // Preference key, not a secret
+	ImmichServerURLPrefKey          = pluginPrefix + "immich_server_url_key"
+	ImmichAPIKeyPrefKey             = "immich_api_key" //nolint:gosec // Preference key, not a secret
+	PhotoPrismServerURLPrefKey      = pluginPrefix + "photoprism_server_url_key"
+	PhotoPrismAPIKeyPrefKey         = "photoprism_api_key" //nolint:gosec // Preference key, not a secret
 
 	WikimediaTokenPrefKey = "wikimedia_personal_token" //nolint:gosec // Preference key, not a secret
+
+	ScriptExecutablePrefKey = pluginPrefix + "script_executable_key" // ScriptExecutablePrefKey is used to set and retrieve the path of the external script provider executable
+	ScriptTimeoutPrefKey    = pluginPrefix + "script_timeout_key"    // ScriptTimeoutPrefKey is used to set and retrieve the script execution timeout in seconds
+
+	FediverseMinResolutionPrefKey = pluginPrefix + "fediverse_min_resolution_key" // FediverseMinResolutionPrefKey is used to set and retrieve the int minimum attachment resolution preset of the Fediverse provider
 )
 
 // URLType indicates the type of image source (Search or Collection).
@@ -89,6 +108,7 @@ const (
 	StandardDir               = "standard"
 	FaceBoostDir              = "faceboost"
 	FaceCropDir               = "facecrop"
+	ConvertedDir              = "converted" // Full-size desktop copies of masters in other formats while Smart Fit is off
 	PrcntSeenTillDownload     = 0.8
 	MinSeenImagesForDownload  = 5
 	MinLocalImageBeforePulse  = 1
@@ -103,6 +123,14 @@ const (
 	FavoritesQueryID          = "favorites://" + FavoritesCollection
 	LocalFolderNamespace      = "local_folders" // API Namespace for user-selected local folders
 	LocalFolderProviderID     = "LocalFolder"   // Stable provider ID
+	FolderWatchSettleDelay    = 2 * time.Second // Quiet period before a new or changed local file is queued
+	MaxWatchedFolders         = 4096            // Cap on directories watched for folder queries
+	MemoryShuffleWeight       = 25              // "On This Day" photos are this many times as likely as others to come up next
+	DecodeMemoryBudget        = 1 << 30         // Bytes that concurrent large decodes may claim together
+	LargeDecodeBytes          = 128 << 20       // Decodes estimated at this size or more wait for the decode memory budget
+	DefaultUpscaleMaxFactor   = 1.25            // Largest enlargement that is sharpened when upscaling is on
+	UpscaleSharpenSigma       = 0.6             // Sharpening applied after upscaling, to restore edge contrast lost to resampling
+	PerceptualHashDecodeSize  = 64              // Smallest width and height an image is decoded at to compute its perceptual hash
 )
 
 // HashFolderPath generates a short, URL-safe hash for a folder path to use as a collectionID.
@@ -203,6 +231,52 @@ func GetCacheSizes() []fmt.Stringer {
 	return stringers
 }
 
+// DiskQuota represents the predefined disk budgets of the image cache (masters and derivatives).
+type DiskQuota int
+
+// DiskQuota constants
+const (
+	DiskQuota1GB DiskQuota = iota
+	DiskQuota2GB
+	DiskQuota5GB
+	DiskQuota10GB
+	DiskQuota20GB
+	DiskQuota50GB
+	DiskQuotaUnlimited
+)
+
+// diskQuotaGB maps each limited DiskQuota to its size in gigabytes.
+var diskQuotaGB = map[DiskQuota]int64{
+	DiskQuota1GB:  1,
+	DiskQuota2GB:  2,
+	DiskQuota5GB:  5,
+	DiskQuota10GB: 10,
+	DiskQuota20GB: 20,
+	DiskQuota50GB: 50,
+}
+
+// String returns the string representation of a DiskQuota.
+func (q DiskQuota) String() string {
+	if gb, ok := diskQuotaGB[q]; ok {
+		return fmt.Sprintf("%d GB", gb)
+	}
+	return i18n.T("Unlimited")
+}
+
+// Bytes returns the budget in bytes, or 0 for DiskQuotaUnlimited.
+func (q DiskQuota) Bytes() int64 {
+	return diskQuotaGB[q] << 30
+}
+
+// GetDiskQuotas returns a list of all available disk quotas AS fmt.Stringer, in DiskQuota order.
+func GetDiskQuotas() []fmt.Stringer {
+	stringers := make([]fmt.Stringer, 0, DiskQuotaUnlimited+1)
+	for q := DiskQuota1GB; q <= DiskQuotaUnlimited; q++ {
+		stringers = append(stringers, q)
+	}
+	return stringers
+}
+
 // NetworkTimeouts defines the standard durations for various network operations.
 const (
 	// HTTPClientRequestTimeout is the total time limit for a single HTTP request,
@@ -165,6 +165,7 @@ func TestCropAnchor_SurviveDoubleSyncModeChange(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, cacheFile)
+	t.Cleanup(store.Close)
 
 	// === SETUP: Image processed with SmartFit Aggressive mode ===
 	originalFlags := makeDownloaderFlags(true, SmartFitAggressive, false, false)
@@ -219,6 +220,7 @@ func TestCropAnchor_SurviveTripleSyncStartup(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 
 	flags := makeDownloaderFlags(true, SmartFitAggressive, false, false)
 	target := makeGroomingTarget(true, SmartFitAggressive, false, false)
@@ -251,6 +253,7 @@ func TestCropAnchor_SurviveSaveLoadCycle(t *testing.T) {
 	store1 := NewImageStore()
 	store1.SetAsyncSave(false)
 	store1.SetFileManager(fm, cacheFile)
+	t.Cleanup(store1.Close)
 
 	store1.Add(provider.Image{
 		ID:              "persist_test",
@@ -260,14 +263,16 @@ func TestCropAnchor_SurviveSaveLoadCycle(t *testing.T) {
 	store1.SetTuningOptions("persist_test", "1920x1080", provider.TuningOptions{Anchor: provider.AnchorBottomLeft})
 	store1.SaveCache()
 
-	// Verify cache file was written
-	_, err := os.Stat(cacheFile)
-	assert.NoError(t, err, "Cache file should exist")
+	// Verify cache database was written
+	_, err := os.Stat(dbPathFor(cacheFile))
+	assert.NoError(t, err, "Cache database should exist")
 
 	// Session 2: New store, load from disk
+	store1.Close()
 	store2 := NewImageStore()
 	store2.SetAsyncSave(false)
 	store2.SetFileManager(fm, cacheFile)
+	t.Cleanup(store2.Close)
 	err = store2.LoadCache()
 	assert.NoError(t, err)
 
@@ -290,6 +295,7 @@ func TestCropAnchor_NotExemptFromQueryPruning(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 
 	flags := makeDownloaderFlags(true, SmartFitAggressive, false, false)
 
@@ -323,6 +329,7 @@ func TestCropAnchor_NotExemptFromLRUPruning(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 
 	flags := makeDownloaderFlags(true, SmartFitAggressive, false, false)
 
@@ -55,6 +55,7 @@ func TestDeactivate_SuppressesNotificationDuringShutdown(t *testing.T) {
 	// Mock Store
 	mockStore := wp.store.(*MockImageStore)
 	mockStore.On("GetByID", mock.Anything).Return(provider.Image{}, false)
+	mockStore.On("Close").Return()
 
 	// Mock OS
 	wp.os.(*MockOS).On("GetMonitors").Return([]Monitor{}, nil).Maybe()
@@ -1,17 +1,23 @@
 package wallpaper
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
 	"image"
 	"io"
+	"maps"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/disintegration/imaging"
+	"github.com/dixieflatline76/Spice/v2/pkg/imagecodec"
+	"github.com/dixieflatline76/Spice/v2/pkg/imagehash"
+	"github.com/dixieflatline76/Spice/v2/pkg/imagequality"
 	"github.com/dixieflatline76/Spice/v2/pkg/provider"
 	"github.com/dixieflatline76/Spice/v2/util/log"
 )
@@ -23,6 +29,8 @@ import (
 func (wp *Plugin) ProcessImageJob(ctx context.Context, job DownloadJob) (resultImg provider.Image, finalErr error) {
 	img := job.Image
 	downloadProvider := job.Provider
+	// Copy-on-write: a re-processed image comes from the store, whose snapshots share its maps
+	img.ProcessingFlags = maps.Clone(img.ProcessingFlags)
 
 	// Prevent orphaned files from leaking by ensuring partial processing artifacts are scrubbed.
 	// If context is cancelled midway through downloading/processing, we aggressively clean up
@@ -64,6 +72,11 @@ func (wp *Plugin) ProcessImageJob(ctx context.Context, job DownloadJob) (resultI
 		return provider.Image{}, fmt.Errorf("failed to ensure master (%s): %w", providerName, err)
 	}
 
+	// 2.4 Fetch Time (for cache eviction). Re-processed images keep theirs when they land in the store.
+	if img.Fetched.IsZero() {
+		img.Fetched = time.Now()
+	}
+
 	// 2.5 Resolution Probing & Persistence (Fixes "Ghost Dimensions")
 	if img.Width == 0 || img.Height == 0 {
 		w, h, err := wp.probeDimensions(masterPath)
@@ -108,6 +121,25 @@ func (wp *Plugin) ProcessImageJob(ctx context.Context, job DownloadJob) (resultI
 		return img, fmt.Errorf("incompatible image skipped (fits zero monitors)")
 	}
 
+	// 2.8 Quality Gate (community sources only)
+	if err := wp.checkImageQuality(ctx, &img, downloadProvider, masterPath, resolutions); err != nil {
+		return provider.Image{}, err
+	}
+	if reason := qualityRejection(img); reason != "" {
+		return img, fmt.Errorf("low quality image skipped (%s)", reason)
+	}
+
+	// 2.9 Duplicate Check (copies of the same picture from other sources)
+	if err := wp.ensurePerceptualHash(ctx, &img, downloadProvider, masterPath); err != nil {
+		return provider.Image{}, err
+	}
+	// Images already stored are settled by the store when they land, after healing
+	if !wp.store.Exists(img.ID) {
+		if keeper, ok := wp.store.FindDuplicate(img); ok && !preferredCopy(img, keeper) {
+			return markDuplicate(img, keeper.ID), fmt.Errorf("duplicate image skipped (copy of %s)", keeper.ID)
+		}
+	}
+
 	// 3. Ensure Derivative (Processed Image)
 	mode := wp.cfg.GetSmartFitMode()
 	isFlex := mode == SmartFitAggressive
@@ -123,6 +155,10 @@ func (wp *Plugin) ProcessImageJob(ctx context.Context, job DownloadJob) (resultI
 	img.ProcessingFlags["FitQuality"] = isQuality
 	img.ProcessingFlags["FaceCrop"] = wp.cfg.GetFaceCropEnabled()
 	img.ProcessingFlags["FaceBoost"] = wp.cfg.GetFaceBoostEnabled()
+	img.ProcessingFlags["Upscale"] = wp.cfg.GetUpscaleEnabled()
+	if wp.cfg.GetUpscaleEnabled() {
+		img.ProcessingFlags[upscaleMaxFlag(wp.cfg.GetUpscaleMaxFactor())] = true
+	}
 
 	derivativePaths, err := wp.ensureDerivative(ctx, img, masterPath)
 	if err != nil {
@@ -193,6 +229,108 @@ func (wp *Plugin) checkImageCompatibility(img provider.Image) error {
 	return nil
 }
 
+// checkImageQuality scores images from community sources at about the size they are displayed,
+// and tags those that fail the TuningConfig quality thresholds with "lowquality:<reason>". The
+// score is kept on the image, so backlog healing does not decode it again.
+func (wp *Plugin) checkImageQuality(ctx context.Context, img *provider.Image, p provider.ImageProvider, masterPath string, resolutions []Resolution) error {
+	if p == nil || p.Type() != provider.TypeCommunity {
+		return nil
+	}
+
+	if img.Quality == nil {
+		// Decode as the derivatives will be, no larger than the biggest monitor needs
+		var minW, minH int
+		for _, res := range resolutions {
+			minW, minH = max(minW, res.Width), max(minH, res.Height)
+		}
+		release, err := wp.reserveDecode(ctx, masterPath, minW, minH)
+		if err != nil {
+			return err
+		}
+		src, size, err := imagecodec.DecodeFileScaled(masterPath, minW, minH)
+		release()
+		if err != nil {
+			log.Debugf("Quality gate: skipping %s: %v", img.ID, err)
+			return nil // Derivative generation reports unreadable masters
+		}
+
+		blockSize := 0
+		if imagecodec.ContentType(masterPath) == imagecodec.JPEG && size.X > 0 {
+			blockSize = 8 * src.Bounds().Dx() / size.X // JPEG blocks shrink with a reduced-scale decode
+		}
+		score := imagequality.Measure(src, blockSize)
+		img.Quality = &provider.QualityScore{Sharpness: score.Sharpness, Noise: score.Noise, Blockiness: score.Blockiness}
+		log.Debugf("Quality gate: %s scored %+v", img.ID, *img.Quality)
+	}
+
+	for k := range img.ProcessingFlags {
+		if strings.HasPrefix(k, "lowquality:") {
+			delete(img.ProcessingFlags, k) // Judged afresh against the current thresholds
+		}
+	}
+	if reason := lowQualityReason(*img.Quality, wp.cfg.Tuning); reason != "" {
+		if img.ProcessingFlags == nil {
+			img.ProcessingFlags = make(map[string]bool)
+		}
+		img.ProcessingFlags["lowquality:"+reason] = true
+	}
+	return nil
+}
+
+// ensurePerceptualHash hashes the master of an image from an online source, once per image.
+// Personal photos are never hashed, so they are never merged as duplicates.
+func (wp *Plugin) ensurePerceptualHash(ctx context.Context, img *provider.Image, p provider.ImageProvider, masterPath string) error {
+	if img.PerceptualHash != 0 || p == nil || p.Type() == provider.TypePersonal {
+		return nil
+	}
+	// The hash only needs a 9x8 grid, so the smallest JPEG scale will do
+	release, err := wp.reserveDecode(ctx, masterPath, PerceptualHashDecodeSize, PerceptualHashDecodeSize)
+	if err != nil {
+		return err
+	}
+	src, _, err := imagecodec.DecodeFileScaled(masterPath, PerceptualHashDecodeSize, PerceptualHashDecodeSize)
+	release()
+	if err != nil {
+		log.Debugf("Duplicate check: skipping %s: %v", img.ID, err)
+		return nil // Derivative generation reports unreadable masters
+	}
+	img.PerceptualHash = imagehash.DHash(src)
+	return nil
+}
+
+// lowQualityReason returns which quality threshold q fails ("blur", "noise" or "blockiness"), or "".
+func lowQualityReason(q provider.QualityScore, t TuningConfig) string {
+	switch {
+	case t.QualityMinSharpness > 0 && q.Sharpness > 0 && q.Sharpness < t.QualityMinSharpness:
+		return "blur"
+	case t.QualityMaxNoise > 0 && q.Noise > t.QualityMaxNoise:
+		return "noise"
+	case t.QualityMaxBlockiness > 0 && q.Blockiness > t.QualityMaxBlockiness:
+		return "blockiness"
+	}
+	return ""
+}
+
+// qualityRejection returns the reason the quality gate tagged img with, or "" if it passed.
+func qualityRejection(img provider.Image) string {
+	for k, v := range img.ProcessingFlags {
+		if reason, ok := strings.CutPrefix(k, "lowquality:"); ok && v {
+			return reason
+		}
+	}
+	return ""
+}
+
+// currentQualityRejection returns the reason img fails the quality gate under the current
+// TuningConfig thresholds, or "" if it passes. A stored score is judged again rather than the
+// lowquality tag trusted, so relaxing a threshold brings back the images it rejected.
+func (wp *Plugin) currentQualityRejection(img provider.Image) string {
+	if img.Quality == nil {
+		return qualityRejection(img)
+	}
+	return lowQualityReason(*img.Quality, wp.cfg.Tuning)
+}
+
 // probeDimensions uses DecodeConfig to get image dimensions without loading the whole file.
 func (wp *Plugin) probeDimensions(path string) (int, int, error) {
 	file, err := os.Open(path)
@@ -249,11 +387,11 @@ func (wp *Plugin) enrichImage(ctx context.Context, img provider.Image, p provide
 // Returns absolute path.
 func (wp *Plugin) ensureMaster(ctx context.Context, img provider.Image, imgProvider provider.ImageProvider) (string, error) {
 	// Determine extension. We prefer what's in URL or Content-Type.
-	ext := filepath.Ext(extractFilenameFromURL(img.Path))
-	if ext == "" {
-		if img.FileType == "image/png" {
-			ext = ".png"
-		} else {
+	// The download corrects it if the content turns out to be in another format.
+	ext := strings.ToLower(filepath.Ext(extractFilenameFromURL(img.Path)))
+	if !imagecodec.IsSourceExt(ext) {
+		ext = imagecodec.ExtensionFor(img.FileType)
+		if ext == "" {
 			ext = ".jpg" // Default
 		}
 	}
@@ -263,10 +401,9 @@ func (wp *Plugin) ensureMaster(ctx context.Context, img provider.Image, imgProvi
 		return "", fmt.Errorf("security check failed for master path: %w", err)
 	}
 
-	// Check existence
-	if _, err := os.Stat(masterPath); !os.IsNotExist(err) {
-		// Exists.
-		return masterPath, nil
+	// Check existence, in whichever format the master was saved
+	if existing, ok := wp.fm.FindMaster(img.ID); ok {
+		return existing, nil
 	}
 
 	// Download Remote URL
@@ -284,6 +421,12 @@ func (wp *Plugin) ensureMaster(ctx context.Context, img provider.Image, imgProvi
 			reqUrl = rap.WithResolution(reqUrl, w, h)
 		}
 	}
+	if sp, ok := imgProvider.(provider.ScalableImageProvider); ok {
+		// Request a master large enough for the biggest display; smaller displays get downscaled derivatives.
+		if res, ok := LargestResolution(wp.getResolutionsForDerivatives()); ok {
+			reqUrl = sp.WithImageSize(reqUrl, res.Width, res.Height)
+		}
+	}
 
 	return wp.downloadMasterFile(ctx, client, reqUrl, masterPath, imgProvider)
 }
@@ -318,13 +461,29 @@ func (wp *Plugin) downloadMasterFile(ctx context.Context, client *http.Client, r
 		return "", fmt.Errorf("failed to ensure master (%s): status %d", providerName, resp.StatusCode)
 	}
 
+	// Save the master with the extension of its actual format; URLs and headers are often wrong
+	// (e.g. AVIF served from a ".jpg" URL by image CDNs).
+	body := bufio.NewReader(resp.Body)
+	head, _ := body.Peek(512)
+	contentType := imagecodec.Sniff(head)
+	if contentType == "" {
+		contentType = resp.Header.Get("Content-Type")
+	}
+	ext := imagecodec.ExtensionFor(contentType)
+	if ext == "" {
+		return "", fmt.Errorf("response is not a supported image (Content-Type %q)", resp.Header.Get("Content-Type"))
+	}
+	if imagecodec.ExtensionFor(imagecodec.ContentType(masterPath)) != ext {
+		masterPath = strings.TrimSuffix(masterPath, filepath.Ext(masterPath)) + ext
+	}
+
 	file, err := os.Create(masterPath)
 	if err != nil {
 		return "", err
 	}
 	defer file.Close()
 
-	if _, err := io.Copy(file, resp.Body); err != nil {
+	if _, err := io.Copy(file, body); err != nil {
 		file.Close() // Close before attempting removal
 		if err := os.Remove(masterPath); err != nil && !os.IsNotExist(err) {
 			log.Printf("Failed to clean up aborted download %s: %v", masterPath, err)
@@ -369,10 +528,18 @@ func (wp *Plugin) getBaseDerivativeDir() string {
 func (wp *Plugin) ensureDerivative(ctx context.Context, img provider.Image, masterPath string) (map[string]string, error) {
 	derivativeDir := wp.getBaseDerivativeDir()
 	if derivativeDir == "" {
-		return map[string]string{"primary": masterPath}, nil
+		if imagecodec.IsDesktopExt(filepath.Ext(masterPath)) {
+			return map[string]string{"primary": masterPath}, nil
+		}
+		convertedPath, err := wp.ensureConverted(ctx, img.ID, masterPath)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"primary": convertedPath}, nil
 	}
 
-	ext := filepath.Ext(masterPath)
+	// Derivatives are always saved in a format every desktop backend accepts.
+	ext := imagecodec.DesktopExt(filepath.Ext(masterPath))
 	resolutions := wp.getResolutionsForDerivatives()
 
 	// 1. Check if all exist
@@ -393,6 +560,38 @@ func (wp *Plugin) ensureDerivative(ctx context.Context, img provider.Image, mast
 	return wp.ensurePrimaryPath(paths), nil
 }
 
+// ensureConverted saves a full-size JPEG copy of a master whose format desktops cannot display,
+// e.g. HEIC or WebP, for use while Smart Fit is off.
+func (wp *Plugin) ensureConverted(ctx context.Context, imgID, masterPath string) (string, error) {
+	targetPath, err := wp.fm.GetDerivativePath(imgID, ".jpg", filepath.Join(FittedRootDir, ConvertedDir))
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(targetPath); err == nil {
+		return targetPath, nil
+	}
+
+	release, err := wp.reserveDecode(ctx, masterPath, 0, 0)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	srcImg, err := imagecodec.DecodeFile(masterPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open master %s: %w", masterPath, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return "", err
+	}
+	if err := imaging.Save(srcImg, targetPath); err != nil {
+		return "", fmt.Errorf("failed to save converted copy %s: %w", targetPath, err)
+	}
+	return targetPath, nil
+}
+
 func (wp *Plugin) getResolutionsForDerivatives() []Resolution {
 	monitors, err := wp.os.GetMonitors()
 	if err != nil || len(monitors) == 0 {
@@ -432,7 +631,19 @@ func (wp *Plugin) checkExistingDerivatives(imgID, ext, derivativeDir string, res
 }
 
 func (wp *Plugin) generateMissingDerivatives(ctx context.Context, img provider.Image, masterPath, derivativeDir, ext string, resolutions []Resolution, paths map[string]string) error {
-	srcImg, err := imaging.Open(masterPath)
+	// Decode no larger than the biggest missing derivative needs
+	var minW, minH int
+	for _, res := range resolutions {
+		if _, ok := paths[fmt.Sprintf("%dx%d", res.Width, res.Height)]; !ok {
+			minW, minH = max(minW, res.Width), max(minH, res.Height)
+		}
+	}
+	release, err := wp.reserveDecode(ctx, masterPath, minW, minH)
+	if err != nil {
+		return err
+	}
+	defer release()
+	srcImg, srcSize, err := imagecodec.DecodeFileScaled(masterPath, minW, minH)
 	if err != nil {
 		return fmt.Errorf("failed to open master %s: %w", masterPath, err)
 	}
@@ -462,27 +673,25 @@ func (wp *Plugin) generateMissingDerivatives(ctx context.Context, img provider.I
 			continue
 		}
 
-		// Compatibility check
-		if err := wp.imgProcessor.CheckCompatibility(srcImg.Bounds().Dx(), srcImg.Bounds().Dy(), res.Width, res.Height); err != nil && !errors.Is(err, ErrRequiresVirtualFraming) {
+		// Compatibility check, against the master's full size rather than the scaled decode
+		if err := wp.imgProcessor.CheckCompatibility(srcSize.X, srcSize.Y, res.Width, res.Height); err != nil && !errors.Is(err, ErrRequiresVirtualFraming) {
 			log.Debugf("Skipping derivative for %s: incompatible: %v", resDir, err)
 			continue
 		}
 
 		// Generate
-		var framed bool
+		var framed, upscaled bool
 		fitCtx := context.WithValue(ctx, provider.VirtualFramedKey, &framed)
+		fitCtx = context.WithValue(fitCtx, provider.UpscaledKey, &upscaled)
 		fitCtx = context.WithValue(fitCtx, provider.ProviderIDKey, img.Provider)
 		processedImg, err := wp.imgProcessor.FitImage(fitCtx, srcImg, res.Width, res.Height, img.GetTuning(resDir))
 
 		if err == nil {
-			if framed {
-				if img.ProcessingFlags == nil {
-					img.ProcessingFlags = make(map[string]bool)
-				}
-				img.ProcessingFlags["VirtualFramed:"+resDir] = true
-			} else if img.ProcessingFlags != nil {
-				delete(img.ProcessingFlags, "VirtualFramed:"+resDir)
+			if img.ProcessingFlags == nil {
+				img.ProcessingFlags = make(map[string]bool)
 			}
+			setResultFlag(img.ProcessingFlags, "VirtualFramed:"+resDir, framed)
+			setResultFlag(img.ProcessingFlags, "Upscaled:"+resDir, upscaled)
 		}
 		if err != nil {
 			log.Printf("Error fitting image for %s: %v", resDir, err)
@@ -511,6 +720,19 @@ func (wp *Plugin) generateMissingDerivatives(ctx context.Context, img provider.I
 	return nil
 }
 
+// reserveDecode waits for the pipeline's decode memory budget to cover decoding masterPath at a
+// size that covers minW×minH (zero for full size).
+func (wp *Plugin) reserveDecode(ctx context.Context, masterPath string, minW, minH int) (func(), error) {
+	need, err := imagecodec.DecodeMemory(masterPath, minW, minH)
+	if err != nil {
+		return func() {}, nil // The decode itself reports unreadable masters
+	}
+	wp.downloadMutex.RLock()
+	pipeline := wp.pipeline
+	wp.downloadMutex.RUnlock()
+	return pipeline.ReserveDecode(ctx, need)
+}
+
 func (wp *Plugin) updatePrimaryPath(paths map[string]string, res Resolution, targetPath string) {
 	for _, mid := range res.Monitors {
 		if mid == 0 {
@@ -528,3 +750,9 @@ func (wp *Plugin) ensurePrimaryPath(paths map[string]string) map[string]string {
 	}
 	return paths
 }
+
+// upscaleMaxFlag is the processing flag recording the maximum upscale factor, so that changing it
+// re-evaluates images previously tagged incompatible.
+func upscaleMaxFlag(factor float64) string {
+	return fmt.Sprintf("UpscaleMax_%.2f", factor)
+}
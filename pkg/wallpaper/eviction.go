@@ -0,0 +1,221 @@
+package wallpaper
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/imagecodec"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+)
+
+// CacheEntry is a cached image and the disk space its master and derivatives use.
+type CacheEntry struct {
+	Image provider.Image
+	Bytes int64
+}
+
+// EvictionPolicy decides which cached images are deleted first when the cache is over its size
+// limit or disk quota. Favorites and the wallpapers on screen are never handed to a policy.
+type EvictionPolicy interface {
+	// Order returns entries sorted from first to evict to last. Entries arrive in store order,
+	// which is the order they were fetched in.
+	Order(entries []CacheEntry) []CacheEntry
+}
+
+// EvictionMode selects one of the built-in eviction policies.
+type EvictionMode int
+
+const (
+	EvictOldestFetched     EvictionMode = iota // Images fetched longest ago go first
+	EvictLeastRecentlyUsed                     // Images shown (or, if not shown yet, fetched) longest ago go first
+	EvictLeastDisplayed                        // Images shown the fewest times go first
+	EvictProviderFair                          // Images from whichever provider uses the most space go first
+)
+
+func (m EvictionMode) String() string {
+	switch m {
+	case EvictOldestFetched:
+		return i18n.T("Oldest First")
+	case EvictLeastRecentlyUsed:
+		return i18n.T("Least Recently Shown")
+	case EvictLeastDisplayed:
+		return i18n.T("Least Shown")
+	case EvictProviderFair:
+		return i18n.T("Fair Across Sources")
+	default:
+		return i18n.T("Unknown")
+	}
+}
+
+// GetEvictionModes returns a list of available eviction modes as strings
+func GetEvictionModes() []string {
+	return []string{
+		EvictOldestFetched.String(),
+		EvictLeastRecentlyUsed.String(),
+		EvictLeastDisplayed.String(),
+		EvictProviderFair.String(),
+	}
+}
+
+// Policy returns the eviction policy for the mode. Unknown modes evict the oldest images first.
+func (m EvictionMode) Policy() EvictionPolicy {
+	switch m {
+	case EvictLeastRecentlyUsed:
+		return LRUPolicy{}
+	case EvictLeastDisplayed:
+		return LeastDisplayedPolicy{}
+	case EvictProviderFair:
+		return ProviderFairPolicy{}
+	default:
+		return OldestFetchedPolicy{}
+	}
+}
+
+// OldestFetchedPolicy evicts images in the order they were fetched.
+type OldestFetchedPolicy struct{}
+
+// Order implements EvictionPolicy.
+func (OldestFetchedPolicy) Order(entries []CacheEntry) []CacheEntry {
+	return sortedEntries(entries, func(a, b CacheEntry) int {
+		return a.Image.Fetched.Compare(b.Image.Fetched)
+	})
+}
+
+// LRUPolicy evicts the images shown least recently. An image not shown yet counts as used when it
+// was fetched, so new downloads get their turn on screen before they can be evicted.
+type LRUPolicy struct{}
+
+// Order implements EvictionPolicy.
+func (LRUPolicy) Order(entries []CacheEntry) []CacheEntry {
+	lastUsed := func(img provider.Image) time.Time {
+		if img.LastShown.IsZero() {
+			return img.Fetched
+		}
+		return img.LastShown
+	}
+	return sortedEntries(entries, func(a, b CacheEntry) int {
+		return lastUsed(a.Image).Compare(lastUsed(b.Image))
+	})
+}
+
+// LeastDisplayedPolicy evicts the images shown the fewest times, the oldest first among equals.
+type LeastDisplayedPolicy struct{}
+
+// Order implements EvictionPolicy.
+func (LeastDisplayedPolicy) Order(entries []CacheEntry) []CacheEntry {
+	return sortedEntries(entries, func(a, b CacheEntry) int {
+		if a.Image.ShowCount != b.Image.ShowCount {
+			return a.Image.ShowCount - b.Image.ShowCount
+		}
+		return a.Image.Fetched.Compare(b.Image.Fetched)
+	})
+}
+
+// ProviderFairPolicy evicts from whichever provider uses the most disk space at that point, its
+// oldest image first, so one provider with very large masters cannot crowd out the others.
+type ProviderFairPolicy struct{}
+
+// Order implements EvictionPolicy.
+func (ProviderFairPolicy) Order(entries []CacheEntry) []CacheEntry {
+	queues := make(map[string][]CacheEntry)
+	usage := make(map[string]int64)
+	for _, e := range (OldestFetchedPolicy{}).Order(entries) {
+		queues[e.Image.Provider] = append(queues[e.Image.Provider], e)
+		usage[e.Image.Provider] += e.Bytes
+	}
+
+	ordered := make([]CacheEntry, 0, len(entries))
+	for len(ordered) < len(entries) {
+		// Ties go to the provider with more images left, then by name, so the order is stable
+		pick := ""
+		for name, queue := range queues {
+			if len(queue) == 0 {
+				continue
+			}
+			if pick == "" || usage[name] > usage[pick] ||
+				(usage[name] == usage[pick] && (len(queue) > len(queues[pick]) || (len(queue) == len(queues[pick]) && name < pick))) {
+				pick = name
+			}
+		}
+		e := queues[pick][0]
+		queues[pick] = queues[pick][1:]
+		usage[pick] -= e.Bytes
+		ordered = append(ordered, e)
+	}
+	return ordered
+}
+
+// sortedEntries returns a sorted copy of entries. The sort is stable, so entries that compare
+// equal (e.g. images cached before fetch times were recorded) keep their store order.
+func sortedEntries(entries []CacheEntry, cmp func(a, b CacheEntry) int) []CacheEntry {
+	sorted := slices.Clone(entries)
+	slices.SortStableFunc(sorted, cmp)
+	return sorted
+}
+
+// usageEntry is the disk space an image's master and derivatives used when last measured, and the
+// derivative paths it was measured with.
+type usageEntry struct {
+	paths string
+	bytes int64
+}
+
+// measureUsage returns the bytes used on disk by the master and derivatives of each image. Sizes
+// are remembered between calls and measured again only for images whose derivatives changed, so
+// a Sync does not stat every file of a large cache.
+func (s *ImageStore) measureUsage(images []provider.Image) map[string]int64 {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	statFunc := s.getStatFunc()
+	usage := make(map[string]int64, len(images))
+	measured := make(map[string]usageEntry, len(images)) // Rebuilt so removed images drop out
+	for _, img := range images {
+		files := derivativeFiles(img.DerivativePaths)
+		key := strings.Join(files, "\n")
+		entry, ok := s.usage[img.ID]
+		if !ok || entry.paths != key {
+			entry = usageEntry{paths: key, bytes: s.fileBytes(img.ID, files, statFunc)}
+		}
+		measured[img.ID] = entry
+		usage[img.ID] = entry.bytes
+	}
+	s.usage = measured
+	return usage
+}
+
+// fileBytes returns the size of the master of the image with the given ID plus that of each file
+// in derivatives. Files that are gone count as empty.
+func (s *ImageStore) fileBytes(id string, derivatives []string, statFunc func(string) (os.FileInfo, error)) int64 {
+	var bytes int64
+	for _, ext := range imagecodec.SourceExts() {
+		path, err := s.fm.GetMasterPath(id, ext)
+		if err != nil {
+			break
+		}
+		if info, err := statFunc(path); err == nil {
+			bytes += info.Size()
+			break
+		}
+	}
+	for _, path := range derivatives {
+		if info, err := statFunc(path); err == nil {
+			bytes += info.Size()
+		}
+	}
+	return bytes
+}
+
+// derivativeFiles returns the distinct files of an image's derivative paths, sorted. "primary"
+// repeats the path of one of the resolutions.
+func derivativeFiles(paths map[string]string) []string {
+	files := make([]string, 0, len(paths))
+	for _, path := range paths {
+		files = append(files, path)
+	}
+	slices.Sort(files)
+	return slices.Compact(files)
+}
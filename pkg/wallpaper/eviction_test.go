@@ -0,0 +1,180 @@
+package wallpaper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var evictionEpoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func entryIDs(entries []CacheEntry) []string {
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.Image.ID
+	}
+	return ids
+}
+
+func evictionEntries() []CacheEntry {
+	day := func(n int) time.Time { return evictionEpoch.AddDate(0, 0, n) }
+	return []CacheEntry{
+		{Image: provider.Image{ID: "a", Provider: "Wikimedia", Fetched: day(3), LastShown: day(9), ShowCount: 4}, Bytes: 100},
+		{Image: provider.Image{ID: "b", Provider: "Wikimedia", Fetched: day(1), LastShown: day(8), ShowCount: 1}, Bytes: 100},
+		{Image: provider.Image{ID: "c", Provider: "Unsplash", Fetched: day(2)}, Bytes: 50},
+		{Image: provider.Image{ID: "d", Provider: "Unsplash", Fetched: day(4), LastShown: day(5), ShowCount: 1}, Bytes: 50},
+	}
+}
+
+func TestEvictionPolicies(t *testing.T) {
+	entries := evictionEntries()
+
+	assert.Equal(t, []string{"b", "c", "a", "d"}, entryIDs(OldestFetchedPolicy{}.Order(entries)))
+	// c has never been shown, so it counts as used when it was fetched
+	assert.Equal(t, []string{"c", "d", "b", "a"}, entryIDs(LRUPolicy{}.Order(entries)))
+	assert.Equal(t, []string{"c", "b", "d", "a"}, entryIDs(LeastDisplayedPolicy{}.Order(entries)))
+	// Wikimedia uses 200 bytes to Unsplash's 100, so it gives up one image before they alternate
+	assert.Equal(t, []string{"b", "c", "a", "d"}, entryIDs(ProviderFairPolicy{}.Order(entries)))
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, entryIDs(entries), "policies do not reorder their input")
+}
+
+func TestProviderFairPolicy_LargeProviderFirst(t *testing.T) {
+	entries := []CacheEntry{
+		{Image: provider.Image{ID: "small1", Provider: "Unsplash"}, Bytes: 10},
+		{Image: provider.Image{ID: "small2", Provider: "Unsplash"}, Bytes: 10},
+		{Image: provider.Image{ID: "big1", Provider: "NASA"}, Bytes: 500},
+		{Image: provider.Image{ID: "big2", Provider: "NASA"}, Bytes: 500},
+	}
+	assert.Equal(t, []string{"big1", "big2", "small1", "small2"}, entryIDs(ProviderFairPolicy{}.Order(entries)))
+	assert.Empty(t, ProviderFairPolicy{}.Order(nil))
+}
+
+func TestEvictionMode_Policy(t *testing.T) {
+	assert.IsType(t, OldestFetchedPolicy{}, EvictOldestFetched.Policy())
+	assert.IsType(t, LRUPolicy{}, EvictLeastRecentlyUsed.Policy())
+	assert.IsType(t, LeastDisplayedPolicy{}, EvictLeastDisplayed.Policy())
+	assert.IsType(t, ProviderFairPolicy{}, EvictProviderFair.Policy())
+	assert.IsType(t, OldestFetchedPolicy{}, EvictionMode(42).Policy())
+	assert.Len(t, GetEvictionModes(), int(EvictProviderFair)+1)
+}
+
+func TestDiskQuota_Bytes(t *testing.T) {
+	assert.Equal(t, int64(1)<<30, DiskQuota1GB.Bytes())
+	assert.Equal(t, int64(50)<<30, DiskQuota50GB.Bytes())
+	assert.Zero(t, DiskQuotaUnlimited.Bytes())
+	assert.Equal(t, "5 GB", DiskQuota5GB.String())
+	assert.Len(t, GetDiskQuotas(), int(DiskQuotaUnlimited)+1)
+}
+
+func TestStoreSync_DiskQuota(t *testing.T) {
+	tmpDir := t.TempDir()
+	fm := NewFileManager(tmpDir)
+	require.NoError(t, fm.EnsureDirs())
+	store := NewImageStore()
+	store.SetAsyncSave(false)
+	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
+
+	// Five 1 KB masters fetched a day apart, plus a 1 KB derivative for img3 (also its primary)
+	derivPath, _ := fm.GetDerivativePath("img3", ".jpg", filepath.Join(FittedRootDir, QualityDir, StandardDir))
+	require.NoError(t, os.WriteFile(derivPath, make([]byte, 1024), 0644))
+	for i, id := range []string{"img0", "img1", "img2", "img3", "img4"} {
+		masterPath, _ := fm.GetMasterPath(id, ".jpg")
+		require.NoError(t, os.WriteFile(masterPath, make([]byte, 1024), 0644))
+		img := provider.Image{ID: id, FilePath: masterPath, Fetched: evictionEpoch.AddDate(0, 0, i)}
+		if id == "img0" {
+			img.IsFavorited = true
+		}
+		if id == "img3" {
+			img.DerivativePaths = map[string]string{"1920x1080": derivPath, "primary": derivPath}
+		}
+		require.True(t, store.Add(img))
+	}
+
+	store.SetInUseFunc(func() map[string]bool { return map[string]bool{"img1": true} })
+	store.SetDiskQuota(3 * 1024)
+
+	// 6 KB used: the favorite and the image on screen are skipped, img2 and img3 (2 KB) go first
+	store.Sync(100, nil, nil)
+	known := store.GetKnownIDs()
+	assert.Equal(t, map[string]bool{"img0": true, "img1": true, "img4": true}, known)
+
+	// The count limit is still honoured, and the policy decides what goes
+	store.SetEvictionPolicy(LeastDisplayedPolicy{})
+	store.SetDiskQuota(0)
+	store.Sync(2, nil, nil)
+	assert.Equal(t, map[string]bool{"img0": true, "img1": true}, store.GetKnownIDs(), "protected images are kept even over the limit")
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(derivPath)
+		return os.IsNotExist(err)
+	}, 2*time.Second, 50*time.Millisecond, "evicted files are deleted")
+}
+
+func TestStoreSync_DiskUsage(t *testing.T) {
+	tmpDir := t.TempDir()
+	fm := NewFileManager(tmpDir)
+	require.NoError(t, fm.EnsureDirs())
+	store := NewImageStore()
+	store.SetAsyncSave(false)
+	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
+
+	derivPath, _ := fm.GetDerivativePath("a", ".jpg", filepath.Join(FittedRootDir, QualityDir, StandardDir))
+	require.NoError(t, os.WriteFile(derivPath, make([]byte, 512), 0644))
+	for _, id := range []string{"a", "b"} {
+		masterPath, _ := fm.GetMasterPath(id, ".jpg")
+		require.NoError(t, os.WriteFile(masterPath, make([]byte, 1024), 0644))
+		require.True(t, store.Add(provider.Image{ID: id, FilePath: masterPath}))
+	}
+	require.True(t, store.SetDerivativePath("a", "1920x1080", derivPath))
+	// A zombie: kept for its flags after its master was deleted
+	require.True(t, store.Add(provider.Image{ID: "z", ProcessingFlags: map[string]bool{"incompatible:1920x1080": true}}))
+
+	// Without a quota nothing is measured, and the zombie does not count toward the size limit
+	store.Sync(2, nil, nil)
+	assert.Nil(t, store.usage)
+	assert.Equal(t, map[string]bool{"a": true, "b": true, "z": true}, store.GetKnownIDs())
+
+	usage := store.measureUsage(store.List())
+	assert.Equal(t, map[string]int64{"a": 1536, "b": 1024, "z": 0}, usage)
+
+	// Sizes are remembered until an image's derivatives change
+	require.NoError(t, os.WriteFile(derivPath, make([]byte, 2048), 0644))
+	assert.Equal(t, int64(1536), store.measureUsage(store.List())["a"])
+	widePath, _ := fm.GetDerivativePath("a", ".jpg", filepath.Join(FittedRootDir, QualityDir, StandardDir, "3440x1440"))
+	require.NoError(t, os.MkdirAll(filepath.Dir(widePath), 0755))
+	require.NoError(t, os.WriteFile(widePath, make([]byte, 256), 0644))
+	require.True(t, store.SetDerivativePath("a", "3440x1440", widePath))
+	assert.Equal(t, int64(1024+2048+256), store.measureUsage(store.List())["a"])
+}
+
+func TestStore_MarkSeenRecordsUsage(t *testing.T) {
+	store := NewImageStore()
+	store.SetAsyncSave(false)
+	require.True(t, store.Add(provider.Image{
+		ID: "a", FilePath: "/cache/a.jpg",
+		DerivativePaths: map[string]string{"3440x1440": "/cache/fitted/a.jpg"},
+	}))
+
+	store.MarkSeen("/cache/a.jpg")
+	store.MarkSeen("/cache/fitted/a.jpg")
+	store.MarkSeen("/cache/unknown.jpg")
+
+	img, _ := store.GetByID("a")
+	assert.True(t, img.Seen)
+	assert.Equal(t, 2, img.ShowCount, "a derivative shown on another monitor counts too")
+	assert.False(t, img.LastShown.IsZero())
+	assert.Equal(t, 1, store.SeenCount())
+
+	// Pipeline updates keep the usage
+	require.True(t, store.replace(provider.Image{ID: "a", FilePath: "/cache/a.jpg"}))
+	img, _ = store.GetByID("a")
+	assert.Equal(t, 2, img.ShowCount)
+}
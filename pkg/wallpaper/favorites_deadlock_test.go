@@ -23,6 +23,7 @@ func TestToggleFavorite_NoDeadlock_FavoritesProvider(t *testing.T) {
 	store := NewImageStore()
 	fm := NewFileManager(tmpDir)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 
 	img := provider.Image{
 		ID:          "test_img_1",
@@ -73,6 +74,7 @@ func TestReconcileFavorites(t *testing.T) {
 	store := NewImageStore()
 	fm := NewFileManager(tmpDir)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 
 	// Image marked as favorited in cache but NOT in the favorites provider (ghost)
 	ghostFav := provider.Image{
@@ -161,6 +163,7 @@ func TestReconcileFavorites_SkipsFavoritesProviderImages(t *testing.T) {
 	store := NewImageStore()
 	fm := NewFileManager(tmpDir)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 
 	// Provider=Favorites image with realistic LocalFolder-prefixed ID
 	// favMap will never have this key, but reconcile should skip it entirely
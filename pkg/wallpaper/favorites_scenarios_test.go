@@ -198,6 +198,7 @@ func TestFavScenario5_ReconcileSkipsFavoritesProvider(t *testing.T) {
 	store := NewImageStore()
 	fm := NewFileManager(tmpDir)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 	store.SetAsyncSave(false)
 
 	// Provider=Favorites image — reconcile should skip this entirely
@@ -289,6 +290,7 @@ func TestFavCrossCutting_IsFavoritedDoesNotAffectPruning(t *testing.T) {
 	store := NewImageStore()
 	fm := NewFileManager(tmpDir)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 	store.SetAsyncSave(false)
 
 	fittedDir := filepath.Join(tmpDir, "fitted")
@@ -139,6 +139,59 @@ func (wp *Plugin) FetchNewImages(force bool, providerID ...string) {
 	}
 }
 
+// FetchDailyImages fetches the newest page (today and the days just before it) of every active query
+// of a DailyProvider, then moves pinned monitors onto the new day's image.
+// It runs synchronously and outside the global fetch context, so a rotation fetch started at the same
+// time cannot abort it.
+func (wp *Plugin) FetchDailyImages() {
+	var wg sync.WaitGroup
+	var sourcesMutex sync.Mutex
+	activeSources := make(map[string]bool)
+	totalQueued := util.NewSafeInt()
+
+	parent := wp.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	for _, q := range wp.cfg.GetActiveQueries() {
+		p, ok := wp.providers[q.Provider]
+		if !ok {
+			continue
+		}
+		if _, ok := p.(provider.DailyProvider); !ok {
+			continue
+		}
+
+		// Daily providers page backwards from today, so the new day's image is always on page 1.
+		wp.downloadMutex.Lock()
+		if pg, ok := wp.queryPages[q.ID]; ok {
+			pg.Set(1)
+		} else {
+			wp.queryPages[q.ID] = util.NewSafeIntWithValue(1)
+		}
+		wp.downloadMutex.Unlock()
+
+		wg.Add(1)
+		go func(q ImageQuery, p provider.ImageProvider) {
+			defer wg.Done()
+			if limiter := wp.getAPILimiter(p); limiter != nil {
+				if err := limiter.Wait(parent); err != nil {
+					return
+				}
+			}
+			wp.fetchFromProvider(parent, q, p, false, &sourcesMutex, activeSources, totalQueued)
+		}(q, p)
+	}
+	wg.Wait()
+
+	if totalQueued.Value() > 0 {
+		log.Debugf("[Fetch] Queued %d daily images. Broadcasting shuffle update to monitors...", totalQueued.Value())
+		wp.dispatch(-1, CmdUpdateShuffle)
+	}
+	wp.dispatch(-1, CmdShowDaily)
+}
+
 // RefreshImagesAndPulse triggers a fetch and then updates the wallpaper.
 func (wp *Plugin) RefreshImagesAndPulse() {
 	go func() {
@@ -236,36 +289,10 @@ func (wp *Plugin) fetchFromProvider(fetchCtx context.Context, q ImageQuery, p pr
 	queryCtx := wp.GetOrCreateQueryContext(q.ID)
 
 	for _, img := range images {
-		// Critical Fix: Tag image with its source query ID so Sync knows it's active.
-		img.SourceQueryID = q.ID
-
-		// *** NAMESPACING Middleware ***
-		// Ensure ID is unique across providers by prefixing it.
-		// Local providers use filesystem paths (already unique), all others need namespacing.
-		if p.Type() != provider.TypePersonal {
-			prefix := p.ID() + "_"
-			if !strings.HasPrefix(img.ID, prefix) {
-				img.ID = prefix + img.ID
-			}
-		}
-
-		if !isFavRequest && wp.cfg.InAvoidSet(img.ID) {
-			log.Debugf("Skipping blocked image: %s", img.ID)
+		img, ok := wp.admitImage(q, p, img, isFavRequest)
+		if !ok {
 			continue
 		}
-
-		// Pattern: Deduplication (At-the-Gate)
-		// Deadlock Break: We only skip an image if it exists AND already has derivatives for your current monitors.
-		// This allow "Backlog Healing": if you have 1000 images but 0 derivatives, this allows them back into the pipeline.
-		if existing, exists := wp.store.GetByID(img.ID); exists {
-			if wp.allMonitorDerivativesExist(existing) {
-				log.Debugf("Skipping image already in store with all derivatives: %s", img.ID)
-				continue
-			}
-			log.Debugf("Image %s exists but is missing derivatives. Allowing re-processing for backlog healing.", img.ID)
-			// Merge existing metadata (like already probed dimensions) into the fetch-result image
-			img.MergeExistingMetadata(existing)
-		}
 		job := DownloadJob{
 			Ctx:      queryCtx,
 			Image:    img,
@@ -290,6 +317,69 @@ func (wp *Plugin) fetchFromProvider(fetchCtx context.Context, q ImageQuery, p pr
 	}
 }
 
+// admitImage prepares a fetched image for the pipeline. It returns false if the image is blocked
+// or already in the store with all of its derivatives.
+func (wp *Plugin) admitImage(q ImageQuery, p provider.ImageProvider, img provider.Image, isFavRequest bool) (provider.Image, bool) {
+	// Critical Fix: Tag image with its source query ID so Sync knows it's active.
+	img.SourceQueryID = q.ID
+
+	// *** NAMESPACING Middleware ***
+	// Ensure ID is unique across providers by prefixing it.
+	// Local providers use filesystem paths (already unique), all others need namespacing.
+	if p.Type() != provider.TypePersonal {
+		prefix := p.ID() + "_"
+		if !strings.HasPrefix(img.ID, prefix) {
+			img.ID = prefix + img.ID
+		}
+	}
+
+	if !isFavRequest && wp.cfg.InAvoidSet(img.ID) {
+		log.Debugf("Skipping blocked image: %s", img.ID)
+		return img, false
+	}
+
+	// Pattern: Deduplication (At-the-Gate)
+	// Deadlock Break: We only skip an image if it exists AND already has derivatives for your current monitors.
+	// This allow "Backlog Healing": if you have 1000 images but 0 derivatives, this allows them back into the pipeline.
+	if existing, exists := wp.store.GetByID(img.ID); exists {
+		if reason := wp.currentQualityRejection(existing); reason != "" {
+			log.Debugf("Skipping image rejected by the quality gate (%s): %s", reason, img.ID)
+			return img, false
+		}
+		if keeperID := duplicateOf(existing); keeperID != "" {
+			log.Debugf("Skipping image already stored as %s: %s", keeperID, img.ID)
+			return img, false
+		}
+		if wp.allMonitorDerivativesExist(existing) {
+			log.Debugf("Skipping image already in store with all derivatives: %s", img.ID)
+			return img, false
+		}
+		log.Debugf("Image %s exists but is missing derivatives. Allowing re-processing for backlog healing.", img.ID)
+		// Merge existing metadata (like already probed dimensions) into the fetch-result image
+		img.MergeExistingMetadata(existing)
+	}
+	return img, true
+}
+
+// queueImage submits a single image outside a fetch cycle, e.g. one found by the folder watcher.
+func (wp *Plugin) queueImage(ctx context.Context, q ImageQuery, p provider.ImageProvider, img provider.Image) bool {
+	img, ok := wp.admitImage(q, p, img, false)
+	if !ok {
+		return false
+	}
+	wp.downloadMutex.RLock()
+	submitter := wp.jobSubmitter
+	wp.downloadMutex.RUnlock()
+	if submitter == nil {
+		return false
+	}
+	return submitter.Submit(ctx, DownloadJob{
+		Ctx:      wp.GetOrCreateQueryContext(q.ID),
+		Image:    img,
+		Provider: p,
+	})
+}
+
 // allMonitorDerivativesExist checks if the image has a processed file for every unique monitor resolution.
 func (wp *Plugin) allMonitorDerivativesExist(img provider.Image) bool {
 	if len(img.DerivativePaths) == 0 {
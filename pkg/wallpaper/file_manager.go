@@ -13,6 +13,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/dixieflatline76/Spice/v2/pkg/imagecodec"
 	"github.com/dixieflatline76/Spice/v2/util/log"
 )
 
@@ -91,6 +92,20 @@ func (fm *FileManager) GetMasterPath(id string, ext string) (string, error) {
 	return filepath.Join(fm.rootDir, id+ext), nil
 }
 
+// FindMaster returns the path of the Master image for an ID, whichever supported format it was saved in.
+func (fm *FileManager) FindMaster(id string) (string, bool) {
+	if err := fm.validateID(id); err != nil {
+		return "", false
+	}
+	for _, ext := range imagecodec.SourceExts() {
+		path := filepath.Join(fm.rootDir, id+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
 // GetDerivativePath returns the path for a processed image based on the type.
 // derivativeType is now a relative path segment (e.g., "fitted/quality/standard")
 func (fm *FileManager) GetDerivativePath(id string, ext string, derivativeType string) (string, error) {
@@ -218,9 +233,8 @@ func (fm *FileManager) CleanupOrphans(knownIDs map[string]bool) {
 			}
 			name := entry.Name()
 			ext := filepath.Ext(name)
-			lowerExt := strings.ToLower(ext)
 
-			if lowerExt != ".jpg" && lowerExt != ".jpeg" && lowerExt != ".png" {
+			if !imagecodec.IsSourceExt(ext) {
 				continue
 			}
 
@@ -249,9 +263,8 @@ func (fm *FileManager) CleanupOrphans(knownIDs map[string]bool) {
 			name := info.Name()
 			trimmed := strings.TrimSuffix(name, ".spice_tmp")
 			ext := filepath.Ext(trimmed)
-			lowerExt := strings.ToLower(ext)
 
-			if lowerExt != ".jpg" && lowerExt != ".jpeg" && lowerExt != ".png" {
+			if !imagecodec.IsSourceExt(ext) {
 				return nil
 			}
 
@@ -275,6 +288,26 @@ func (fm *FileManager) CleanupOrphans(knownIDs map[string]bool) {
 	log.Printf("FileManager: Orphan cleanup finished. Removed %d files.", deletedCount)
 }
 
+// DerivativeFiles returns the path and modification time of every file under the derivative
+// directories, including temporary files left behind by interrupted writes.
+func (fm *FileManager) DerivativeFiles() map[string]time.Time {
+	files := make(map[string]time.Time)
+	fittedRoot := filepath.Join(fm.rootDir, FittedRootDir)
+	err := filepath.Walk(fittedRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Skip access errors
+		}
+		if !info.IsDir() {
+			files[path] = info.ModTime()
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("DerivativeFiles: Error walking fitted dir: %v", err)
+	}
+	return files
+}
+
 // DeleteDerivatives removes ONLY the processed versions of an image, keeping the Master.
 // This is used when invalidating cache due to settings changes (Smart Fit, etc.).
 func (fm *FileManager) DeleteDerivatives(id string) error {
@@ -82,6 +82,27 @@ func TestResolvePaths(t *testing.T) {
 	}
 }
 
+func TestFindMaster(t *testing.T) {
+	tmpDir := t.TempDir()
+	fm := NewFileManager(tmpDir)
+
+	if _, ok := fm.FindMaster("phone"); ok {
+		t.Error("Expected no master before download")
+	}
+
+	heicMaster, _ := fm.GetMasterPath("phone", ".heic")
+	if err := os.WriteFile(heicMaster, []byte("heic"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if path, ok := fm.FindMaster("phone"); !ok || path != heicMaster {
+		t.Errorf("Expected master %s, got %s (found %v)", heicMaster, path, ok)
+	}
+
+	if _, ok := fm.FindMaster("../phone"); ok {
+		t.Error("Expected path traversal ID to be rejected")
+	}
+}
+
 func TestSecurityValidation(t *testing.T) {
 	tmpDir := t.TempDir()
 	fm := NewFileManager(tmpDir)
@@ -129,6 +150,10 @@ func TestCleanupOrphans(t *testing.T) {
 	if err := os.WriteFile(orphanMaster, []byte("trash"), 0644); err != nil {
 		t.Fatal(err)
 	}
+	orphanHEIC := filepath.Join(tmpDir, "orphan_phone.heic")
+	if err := os.WriteFile(orphanHEIC, []byte("trash"), 0644); err != nil {
+		t.Fatal(err)
+	}
 
 	orphanDeriv, _ := fm.GetDerivativePath("orphan", ".png", filepath.Join(FittedRootDir, FlexibilityDir, FaceBoostDir))
 	if err := os.WriteFile(orphanDeriv, []byte("trash"), 0644); err != nil {
@@ -153,6 +178,9 @@ func TestCleanupOrphans(t *testing.T) {
 	if _, err := os.Stat(orphanMaster); !os.IsNotExist(err) {
 		t.Error("Orphan master NOT deleted")
 	}
+	if _, err := os.Stat(orphanHEIC); !os.IsNotExist(err) {
+		t.Error("Orphan HEIC master NOT deleted")
+	}
 	if _, err := os.Stat(orphanDeriv); !os.IsNotExist(err) {
 		t.Error("Orphan derivative NOT deleted")
 	}
@@ -0,0 +1,348 @@
+package wallpaper
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/dixieflatline76/Spice/v2/util/log"
+	"github.com/fsnotify/fsnotify"
+)
+
+// folderWatcher keeps file system watches on the folders of active queries whose provider implements
+// provider.FolderProvider. New images are queued into the pipeline as soon as their file stops changing,
+// and images whose file is deleted or renamed are dropped from the store together with their derivatives.
+type folderWatcher struct {
+	wp      *Plugin
+	ctx     context.Context
+	cancel  context.CancelFunc
+	watcher *fsnotify.Watcher
+	settle  time.Duration
+
+	mu      sync.Mutex
+	queries map[string]*watchedFolder // Query ID -> watched folder
+	dirRefs map[string]int            // Watched directory -> number of queries using it
+	pending map[string]*pendingFile   // File path -> settle timer
+	done    chan struct{}
+}
+
+// watchedFolder is the folder of one query.
+type watchedFolder struct {
+	query    ImageQuery
+	provider provider.ImageProvider
+	folders  provider.FolderProvider
+	root     string
+	depth    int
+	dirs     map[string]bool
+}
+
+// pendingFile is a new or changed file waiting for its size to settle.
+type pendingFile struct {
+	timer *time.Timer
+	size  int64
+}
+
+func newFolderWatcher(parent context.Context, wp *Plugin) (*folderWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(parent)
+	fw := &folderWatcher{
+		wp:      wp,
+		ctx:     ctx,
+		cancel:  cancel,
+		watcher: w,
+		settle:  FolderWatchSettleDelay,
+		queries: make(map[string]*watchedFolder),
+		dirRefs: make(map[string]int),
+		pending: make(map[string]*pendingFile),
+		done:    make(chan struct{}),
+	}
+	go fw.run()
+	return fw, nil
+}
+
+// Close removes all watches and stops the event loop.
+func (fw *folderWatcher) Close() {
+	fw.cancel()
+	fw.mu.Lock()
+	for path, pf := range fw.pending {
+		pf.timer.Stop()
+		delete(fw.pending, path)
+	}
+	fw.queries = make(map[string]*watchedFolder)
+	fw.dirRefs = make(map[string]int)
+	fw.mu.Unlock()
+	_ = fw.watcher.Close()
+	<-fw.done
+}
+
+// Watch starts watching the folder of a query. Queries of other providers are ignored.
+func (fw *folderWatcher) Watch(q ImageQuery) {
+	p, ok := fw.wp.providers[q.Provider]
+	if !ok {
+		return
+	}
+	folders, ok := p.(provider.FolderProvider)
+	if !ok {
+		return
+	}
+	root, depth, ok := folders.WatchFolder(q.URL)
+	if !ok {
+		return
+	}
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if _, exists := fw.queries[q.ID]; exists || fw.ctx.Err() != nil {
+		return
+	}
+	wf := &watchedFolder{
+		query:    q,
+		provider: p,
+		folders:  folders,
+		root:     filepath.Clean(root),
+		depth:    depth,
+		dirs:     make(map[string]bool),
+	}
+	fw.queries[q.ID] = wf
+	fw.addTreeLocked(wf, wf.root)
+	log.Debugf("[FolderWatch] Watching %d folder(s) of query %s", len(wf.dirs), q.ID)
+}
+
+// Unwatch stops watching the folder of a query.
+func (fw *folderWatcher) Unwatch(queryID string) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	wf, ok := fw.queries[queryID]
+	if !ok {
+		return
+	}
+	delete(fw.queries, queryID)
+	for dir := range wf.dirs {
+		fw.releaseDirLocked(dir)
+	}
+	log.Debugf("[FolderWatch] Stopped watching query %s", queryID)
+}
+
+// addTreeLocked watches dir and its subfolders down to the query's depth.
+// It returns the files found in the watched folders.
+func (fw *folderWatcher) addTreeLocked(wf *watchedFolder, dir string) []string {
+	var files []string
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+			return nil
+		}
+		if path != wf.root && (strings.HasPrefix(d.Name(), ".") || folderDepth(wf.root, path) > wf.depth) {
+			return filepath.SkipDir
+		}
+		if wf.dirs[path] {
+			return nil
+		}
+		if fw.dirRefs[path] == 0 {
+			if len(fw.dirRefs) >= MaxWatchedFolders {
+				log.Printf("[FolderWatch] Watching the maximum of %d folders; changes in %s are picked up by the next fetch.", MaxWatchedFolders, path)
+				return filepath.SkipDir
+			}
+			if err := fw.watcher.Add(path); err != nil {
+				log.Printf("[FolderWatch] Cannot watch %s: %v", path, err)
+				return filepath.SkipDir
+			}
+		}
+		fw.dirRefs[path]++
+		wf.dirs[path] = true
+		return nil
+	})
+	return files
+}
+
+func (fw *folderWatcher) releaseDirLocked(dir string) {
+	fw.dirRefs[dir]--
+	if fw.dirRefs[dir] <= 0 {
+		delete(fw.dirRefs, dir)
+		_ = fw.watcher.Remove(dir) // Fails harmlessly if the directory is already gone
+	}
+}
+
+func (fw *folderWatcher) run() {
+	defer close(fw.done)
+	for {
+		select {
+		case ev, ok := <-fw.watcher.Events:
+			if !ok {
+				return
+			}
+			fw.handle(ev)
+		case err, ok := <-fw.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[FolderWatch] Watcher error: %v", err)
+		}
+	}
+}
+
+func (fw *folderWatcher) handle(ev fsnotify.Event) {
+	path := filepath.Clean(ev.Name)
+	switch {
+	case ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename):
+		// A rename reports the old name here; the new name arrives as a separate Create event.
+		fw.removed(path)
+	case ev.Has(fsnotify.Create) || ev.Has(fsnotify.Write):
+		info, err := os.Stat(path)
+		if err != nil {
+			return
+		}
+		if info.IsDir() {
+			if ev.Has(fsnotify.Create) {
+				fw.dirCreated(path)
+			}
+			return
+		}
+		fw.schedule(path, info.Size())
+	}
+}
+
+// dirCreated watches a new subfolder and queues the images it was created with, e.g. when a folder is moved in.
+func (fw *folderWatcher) dirCreated(dir string) {
+	fw.mu.Lock()
+	var files []string
+	for _, wf := range fw.queries {
+		if within(wf.root, dir) && folderDepth(wf.root, dir) <= wf.depth {
+			files = append(files, fw.addTreeLocked(wf, dir)...)
+		}
+	}
+	fw.mu.Unlock()
+
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			fw.schedule(f, info.Size())
+		}
+	}
+}
+
+// schedule queues a file once it has not changed for the settle delay.
+func (fw *folderWatcher) schedule(path string, size int64) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if fw.ctx.Err() != nil {
+		return
+	}
+	if pf, ok := fw.pending[path]; ok {
+		pf.size = size
+		pf.timer.Reset(fw.settle)
+		return
+	}
+	fw.pending[path] = &pendingFile{
+		size:  size,
+		timer: time.AfterFunc(fw.settle, func() { fw.settled(path) }),
+	}
+}
+
+// settled queues a file whose size stopped changing into the pipeline of every query that lists it.
+func (fw *folderWatcher) settled(path string) {
+	info, err := os.Stat(path)
+
+	fw.mu.Lock()
+	pf, ok := fw.pending[path]
+	if !ok {
+		fw.mu.Unlock()
+		return
+	}
+	if err == nil && info.Size() != pf.size {
+		// Still being written (some platforms report no Write events), so wait another round.
+		pf.size = info.Size()
+		pf.timer.Reset(fw.settle)
+		fw.mu.Unlock()
+		return
+	}
+	delete(fw.pending, path)
+	var targets []*watchedFolder
+	for _, wf := range fw.queries {
+		if within(wf.root, path) {
+			targets = append(targets, wf)
+		}
+	}
+	fw.mu.Unlock()
+
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+
+	queued := 0
+	for _, wf := range targets {
+		img, ok := wf.folders.ImageForFile(wf.query.URL, path)
+		if !ok {
+			continue
+		}
+		if fw.wp.queueImage(fw.ctx, wf.query, wf.provider, img) {
+			queued++
+		}
+	}
+	if queued > 0 {
+		log.Debugf("[FolderWatch] Queued new image %s", path)
+		fw.wp.dispatch(-1, CmdUpdateShuffle)
+	}
+}
+
+// removed drops the images listed from a deleted or renamed file, or from anything inside a deleted
+// or renamed folder, and stops watching such folders.
+func (fw *folderWatcher) removed(path string) {
+	fw.mu.Lock()
+	for p, pf := range fw.pending {
+		if within(path, p) {
+			pf.timer.Stop()
+			delete(fw.pending, p)
+		}
+	}
+	var targets []*watchedFolder
+	for _, wf := range fw.queries {
+		if !within(wf.root, path) && !within(path, wf.root) {
+			continue
+		}
+		targets = append(targets, wf)
+		for dir := range wf.dirs {
+			if within(path, dir) {
+				delete(wf.dirs, dir)
+				fw.releaseDirLocked(dir)
+			}
+		}
+	}
+	fw.mu.Unlock()
+
+	if len(targets) == 0 {
+		return
+	}
+	for _, wf := range targets {
+		for _, img := range fw.wp.store.Query(StoreQuery{SourceQueryID: wf.query.ID}).Images {
+			if src, ok := wf.folders.SourceFile(wf.query.URL, img); ok && within(path, filepath.Clean(src)) {
+				log.Debugf("[FolderWatch] Source of %s was removed: %s", img.ID, src)
+				fw.wp.store.Forget(img.ID)
+			}
+		}
+	}
+}
+
+// within reports whether path is dir itself or inside it.
+func within(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// folderDepth returns how many levels below root a folder is.
+func folderDepth(root, dir string) int {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
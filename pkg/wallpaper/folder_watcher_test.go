@@ -0,0 +1,140 @@
+//go:build !linux
+
+package wallpaper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFolderProvider lists every .jpg in a folder and its subfolders, with the relative path as ID.
+type fakeFolderProvider struct {
+	*MockImageProvider
+	depth int
+}
+
+func (f *fakeFolderProvider) WatchFolder(queryURL string) (string, int, bool) {
+	return queryURL, f.depth, true
+}
+
+func (f *fakeFolderProvider) ImageForFile(queryURL, path string) (provider.Image, bool) {
+	rel, err := filepath.Rel(queryURL, path)
+	if err != nil || !strings.HasSuffix(path, ".jpg") {
+		return provider.Image{}, false
+	}
+	return provider.Image{ID: filepath.ToSlash(rel), Path: "file://" + filepath.ToSlash(path)}, true
+}
+
+func (f *fakeFolderProvider) SourceFile(_ string, img provider.Image) (string, bool) {
+	return filepath.FromSlash(strings.TrimPrefix(img.Path, "file://")), true
+}
+
+func TestFolderWatcher(t *testing.T) {
+	root := t.TempDir()
+	fake := &fakeFolderProvider{MockImageProvider: &MockImageProvider{}, depth: 1}
+	fake.On("ID").Return("Fake").Maybe()
+
+	store := &MockImageStore{}
+	store.On("GetByID", mock.Anything).Return(provider.Image{}, false)
+
+	submitted := make(chan string, 10)
+	pipeline := &MockPipeline{}
+	pipeline.On("Submit", mock.Anything, mock.Anything).Return(true).Run(func(args mock.Arguments) {
+		job := args.Get(1).(DownloadJob)
+		assert.Equal(t, "q1", job.Image.SourceQueryID)
+		submitted <- job.Image.ID
+	})
+
+	wp := &Plugin{
+		providers:        map[string]provider.ImageProvider{"Fake": fake},
+		store:            store,
+		cfg:              &Config{},
+		ctx:              context.Background(),
+		jobSubmitter:     pipeline,
+		queryContexts:    make(map[string]context.Context),
+		queryCancelFuncs: make(map[string]context.CancelFunc),
+		Monitors:         make(map[int]*MonitorController),
+	}
+
+	fw, err := newFolderWatcher(context.Background(), wp)
+	require.NoError(t, err)
+	defer fw.Close()
+	fw.settle = 50 * time.Millisecond
+
+	q := ImageQuery{ID: "q1", Provider: "Fake", URL: root, Active: true}
+	fw.Watch(q)
+
+	expect := func(id string) {
+		t.Helper()
+		select {
+		case got := <-submitted:
+			assert.Equal(t, id, got)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s was not queued", id)
+		}
+	}
+	expectNothing := func() {
+		t.Helper()
+		select {
+		case got := <-submitted:
+			t.Fatalf("unexpected image queued: %s", got)
+		case <-time.After(300 * time.Millisecond):
+		}
+	}
+
+	// New files are queued once they settle
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.jpg"), []byte("image"), 0600))
+	expect("Fake_a.jpg")
+
+	// New subfolders are watched down to the query's depth, including the files they arrive with
+	sub := filepath.Join(root, "2024")
+	require.NoError(t, os.Mkdir(sub, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "b.jpg"), []byte("image"), 0600))
+	expect("Fake_2024/b.jpg")
+
+	deep := filepath.Join(sub, "07")
+	require.NoError(t, os.Mkdir(deep, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(deep, "c.jpg"), []byte("image"), 0600))
+	expectNothing()
+
+	// Deleting a file forgets its image without blocking it
+	forgotten := make(chan string, 10)
+	store.On("Query", StoreQuery{SourceQueryID: "q1"}).Return(StoreQueryResult{Images: []provider.Image{
+		{ID: "Fake_a.jpg", SourceQueryID: "q1", Path: "file://" + filepath.ToSlash(filepath.Join(root, "a.jpg"))},
+		{ID: "Fake_2024/b.jpg", SourceQueryID: "q1", Path: "file://" + filepath.ToSlash(filepath.Join(sub, "b.jpg"))},
+	}, Total: 2})
+	store.On("Forget", mock.Anything).Return(provider.Image{}, true).Run(func(args mock.Arguments) {
+		forgotten <- args.String(0)
+	})
+	require.NoError(t, os.Remove(filepath.Join(root, "a.jpg")))
+	select {
+	case id := <-forgotten:
+		assert.Equal(t, "Fake_a.jpg", id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the deleted file's image was not removed")
+	}
+
+	// Unwatched queries no longer react to changes
+	fw.Unwatch("q1")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "d.jpg"), []byte("image"), 0600))
+	expectNothing()
+	store.AssertNotCalled(t, "Forget", "Other")
+}
+
+func TestFolderDepth(t *testing.T) {
+	root := filepath.Join("photos", "library")
+	assert.Equal(t, 0, folderDepth(root, root))
+	assert.Equal(t, 1, folderDepth(root, filepath.Join(root, "2024")))
+	assert.Equal(t, 2, folderDepth(root, filepath.Join(root, "2024", "07")))
+	assert.True(t, within(root, filepath.Join(root, "a.jpg")))
+	assert.False(t, within(root, root+"-old"))
+}
@@ -0,0 +1,337 @@
+package wallpaper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/util/log"
+)
+
+// orphanGracePeriod keeps the integrity check away from derivatives the pipeline is still writing,
+// which exist on disk a moment before the store records them.
+const orphanGracePeriod = 10 * time.Minute
+
+// IntegrityIssue is a kind of inconsistency between the store and the files on disk.
+type IntegrityIssue int
+
+const (
+	IssueMissingMaster     IntegrityIssue = iota // Store entry whose master file is gone
+	IssueMissingDerivative                       // Store entry pointing at a derivative file that is gone
+	IssueOrphanDerivative                        // Derivative file no store entry points at
+	IssueMissingFavorite                         // Favorited image absent from the favorites folder
+)
+
+// integrityIssues lists every IntegrityIssue in report order.
+var integrityIssues = []IntegrityIssue{IssueMissingMaster, IssueMissingDerivative, IssueOrphanDerivative, IssueMissingFavorite}
+
+func (i IntegrityIssue) String() string {
+	switch i {
+	case IssueMissingMaster:
+		return i18n.T("Missing originals")
+	case IssueMissingDerivative:
+		return i18n.T("Missing processed images")
+	case IssueOrphanDerivative:
+		return i18n.T("Orphaned processed images")
+	case IssueMissingFavorite:
+		return i18n.T("Favorites missing from the favorites folder")
+	default:
+		return fmt.Sprintf("IntegrityIssue(%d)", int(i))
+	}
+}
+
+// IntegrityReport is the result of an integrity check. Findings are image IDs, except for
+// IssueOrphanDerivative, where they are file paths.
+type IntegrityReport struct {
+	Checked  int // Store entries checked
+	Issues   map[IntegrityIssue][]string
+	Repaired map[IntegrityIssue][]string // Findings fixed by RepairIntegrity or the Plugin
+}
+
+// Count returns the number of findings across all categories.
+func (r IntegrityReport) Count() int {
+	n := 0
+	for _, found := range r.Issues {
+		n += len(found)
+	}
+	return n
+}
+
+// RepairedCount returns the number of findings that were fixed.
+func (r IntegrityReport) RepairedCount() int {
+	n := 0
+	for _, fixed := range r.Repaired {
+		n += len(fixed)
+	}
+	return n
+}
+
+// String summarizes the report for the log, one category per line.
+func (r IntegrityReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "checked %d images, found %d problems, repaired %d", r.Checked, r.Count(), r.RepairedCount())
+	for _, issue := range integrityIssues {
+		if found := r.Issues[issue]; len(found) > 0 {
+			fmt.Fprintf(&sb, "\n  %s: %d found, %d repaired", issue, len(found), len(r.Repaired[issue]))
+		}
+	}
+	return sb.String()
+}
+
+// markRepaired records that finding was fixed.
+func (r *IntegrityReport) markRepaired(issue IntegrityIssue, finding string) {
+	if r.Repaired == nil {
+		r.Repaired = make(map[IntegrityIssue][]string)
+	}
+	r.Repaired[issue] = append(r.Repaired[issue], finding)
+}
+
+// CheckIntegrity cross-checks the store against the files managed by the FileManager and reports
+// every inconsistency without changing anything. favoritesDir is the favorites folder; pass ""
+// to skip the favorites check.
+//
+// Entries kept without a master on purpose (incompatible, low-quality or duplicate images, see
+// determineSyncAction) are not reported.
+func (s *ImageStore) CheckIntegrity(favoritesDir string) IntegrityReport {
+	report := IntegrityReport{Issues: make(map[IntegrityIssue][]string)}
+	if s.fm == nil {
+		return report
+	}
+	statFunc := s.getStatFunc()
+	images := s.List()
+	report.Checked = len(images)
+
+	favorites := favoriteFileNames(favoritesDir)
+	referenced := make(map[string]bool)
+	for _, img := range images {
+		for _, path := range img.DerivativePaths {
+			referenced[filepath.Clean(path)] = true
+		}
+
+		if !s.masterFileExists(img.ID) {
+			if !s.hasIncompatibleFlags(img) {
+				report.Issues[IssueMissingMaster] = append(report.Issues[IssueMissingMaster], img.ID)
+			}
+			continue
+		}
+		if hasMissingFile(img.DerivativePaths, statFunc) {
+			report.Issues[IssueMissingDerivative] = append(report.Issues[IssueMissingDerivative], img.ID)
+		}
+		if favorites != nil && img.IsFavorited && img.Provider != "Favorites" &&
+			!favorites[filepath.Base(img.FilePath)] && !favorites[img.ID] {
+			report.Issues[IssueMissingFavorite] = append(report.Issues[IssueMissingFavorite], img.ID)
+		}
+	}
+
+	cutoff := time.Now().Add(-orphanGracePeriod)
+	for path, modTime := range s.fm.DerivativeFiles() {
+		if !referenced[filepath.Clean(path)] && modTime.Before(cutoff) {
+			report.Issues[IssueOrphanDerivative] = append(report.Issues[IssueOrphanDerivative], path)
+		}
+	}
+
+	for issue, found := range report.Issues {
+		if len(found) == 0 {
+			delete(report.Issues, issue)
+			continue
+		}
+		slices.Sort(found)
+	}
+	return report
+}
+
+// RepairIntegrity fixes the store-side findings of report: entries with a missing master are
+// dropped, entries with missing derivatives have them cleared so the pipeline can make them again,
+// and orphaned derivatives are deleted. Each finding is checked again first, since the store may
+// have moved on since the report was made. Missing favorites are left to the caller, which owns
+// the favorites folder.
+func (s *ImageStore) RepairIntegrity(report *IntegrityReport) {
+	if s.fm == nil {
+		return
+	}
+	statFunc := s.getStatFunc()
+
+	for _, id := range report.Issues[IssueMissingMaster] {
+		img, ok := s.GetByID(id)
+		if !ok || s.masterFileExists(id) || s.hasIncompatibleFlags(img) {
+			continue
+		}
+		if _, ok := s.Forget(id); ok {
+			report.markRepaired(IssueMissingMaster, id)
+		}
+	}
+
+	for _, id := range report.Issues[IssueMissingDerivative] {
+		img, ok := s.GetByID(id)
+		if !ok || !hasMissingFile(img.DerivativePaths, statFunc) {
+			continue
+		}
+		// The derivatives that are left are made again along with the missing ones
+		if err := s.fm.DeleteDerivatives(id); err != nil {
+			log.Printf("Integrity: Failed to delete derivatives of %s: %v", id, err)
+		}
+		if s.ClearDerivatives(id) {
+			report.markRepaired(IssueMissingDerivative, id)
+		}
+	}
+
+	if orphans := report.Issues[IssueOrphanDerivative]; len(orphans) > 0 {
+		referenced := make(map[string]bool)
+		for _, img := range s.List() {
+			for _, path := range img.DerivativePaths {
+				referenced[filepath.Clean(path)] = true
+			}
+		}
+		for _, path := range orphans {
+			if referenced[filepath.Clean(path)] {
+				continue
+			}
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				log.Printf("Integrity: Failed to delete orphaned derivative %s: %v", path, err)
+				continue
+			}
+			report.markRepaired(IssueOrphanDerivative, path)
+		}
+	}
+}
+
+// hasMissingFile reports whether any of paths cannot be found.
+func hasMissingFile(paths map[string]string, statFunc func(string) (os.FileInfo, error)) bool {
+	for _, path := range paths {
+		if _, err := statFunc(path); err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// favoriteFileNames returns the names of the files in the favorites folder, with and without their
+// extension, or nil if dir is "". A folder that does not exist yet holds no favorites.
+func favoriteFileNames(dir string) map[string]bool {
+	if dir == "" {
+		return nil
+	}
+	names := make(map[string]bool)
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("Integrity: Failed to read favorites folder: %v", err)
+		return nil
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			names[e.Name()] = true
+			names[strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))] = true
+		}
+	}
+	return names
+}
+
+// CacheRepair selects which findings CheckCache fixes.
+type CacheRepair int
+
+const (
+	RepairNothing   CacheRepair = iota // Only report
+	RepairStore                        // Drop dead entries, delete orphans and re-queue missing derivatives
+	RepairFavorites                    // As RepairStore, and restore favorites missing from the favorites folder
+)
+
+// CheckCache runs the integrity check on the store and cache files and fixes what repair allows:
+// broken entries are dropped or sent back through the pipeline, orphaned files are deleted, and
+// favorites missing from the favorites folder are copied there again (or unmarked when the image
+// itself is gone).
+func (wp *Plugin) CheckCache(repair CacheRepair) IntegrityReport {
+	favoritesDir := ""
+	if wp.favoriter != nil {
+		favoritesDir = filepath.Join(GetPath(), FavoritesCollection)
+	}
+	report := wp.store.CheckIntegrity(favoritesDir)
+
+	if repair >= RepairStore && report.Count() > 0 {
+		wp.store.RepairIntegrity(&report)
+		for _, id := range report.Repaired[IssueMissingDerivative] {
+			wp.requeueImage(id)
+		}
+	}
+	if repair >= RepairFavorites {
+		for _, id := range report.Issues[IssueMissingFavorite] {
+			if wp.repairFavorite(id) {
+				report.markRepaired(IssueMissingFavorite, id)
+			}
+		}
+	}
+
+	log.Printf("Integrity: %s", report)
+	return report
+}
+
+// nightlyCacheCheck runs the integrity check of nightly maintenance with the store-side repairs.
+// Favorites are only restored on request, since that writes to the user's favorites folder. It is
+// skipped while another check is still running.
+func (wp *Plugin) nightlyCacheCheck() {
+	if !wp.cacheCheckMu.TryLock() {
+		log.Print("Nightly Maintenance: Skipping integrity check, another one is still running.")
+		return
+	}
+	defer wp.cacheCheckMu.Unlock()
+	wp.CheckCache(RepairStore)
+}
+
+// RepairCache checks and repairs the cache on request from the user and tells them the outcome.
+func (wp *Plugin) RepairCache() {
+	wp.cacheCheckMu.Lock()
+	report := wp.CheckCache(RepairFavorites)
+	wp.cacheCheckMu.Unlock()
+	if wp.manager == nil {
+		return
+	}
+	msg := i18n.T("No problems found.")
+	if report.Count() > 0 {
+		msg = i18n.Tf("Repaired {{.Repaired}} of {{.Count}} problems found.", map[string]any{"Repaired": report.RepairedCount(), "Count": report.Count()})
+	}
+	wp.manager.NotifyUser(i18n.T("Cache Check"), msg)
+}
+
+// requeueImage sends a stored image back through the download pipeline, which re-creates its
+// derivatives from the master. Images of removed or inactive queries are left for Sync.
+func (wp *Plugin) requeueImage(id string) bool {
+	img, ok := wp.store.GetByID(id)
+	if !ok {
+		return false
+	}
+	q, ok := wp.cfg.GetQuery(img.SourceQueryID)
+	if !ok || !q.Active {
+		return false
+	}
+	p, ok := wp.providers[q.Provider]
+	if !ok {
+		return false
+	}
+	return wp.queueImage(context.Background(), q, p, img)
+}
+
+// repairFavorite copies a favorited image into the favorites folder again, or clears its favorite
+// flag when the image to copy is gone too.
+func (wp *Plugin) repairFavorite(id string) bool {
+	img, ok := wp.store.GetByID(id)
+	if !ok || !img.IsFavorited || wp.favoriter == nil {
+		return false
+	}
+	if img.FilePath != "" {
+		if _, err := os.Stat(img.FilePath); err == nil {
+			if err := wp.favoriter.AddFavorite(img); err != nil {
+				log.Printf("Integrity: Failed to restore favorite %s: %v", id, err)
+				return false
+			}
+			return true
+		}
+	}
+	if err := wp.favoriter.RemoveFavorite(img); err != nil {
+		log.Printf("Integrity: Failed to remove favorite %s: %v", id, err)
+	}
+	return wp.store.SetFavorited(id, false)
+}
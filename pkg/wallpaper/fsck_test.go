@@ -0,0 +1,135 @@
+package wallpaper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newIntegrityFixture returns a store backed by files in a temp dir, with one image for every kind
+// of finding and a few that must not be reported, plus the favorites folder.
+func newIntegrityFixture(t *testing.T) (*ImageStore, *FileManager, string) {
+	tmpDir := t.TempDir()
+	fm := NewFileManager(tmpDir)
+	require.NoError(t, fm.EnsureDirs())
+	store := NewImageStore()
+	store.SetAsyncSave(false)
+	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
+	t.Cleanup(func() { store.WaitTimeout(2 * time.Second) })
+
+	favDir := filepath.Join(tmpDir, FavoritesCollection)
+	require.NoError(t, os.MkdirAll(favDir, 0755))
+
+	write := func(path string) string {
+		require.NoError(t, os.WriteFile(path, []byte("x"), 0644))
+		return path
+	}
+	derivative := func(id, res string) string {
+		path, err := fm.GetDerivativePath(id, ".jpg", filepath.Join(FittedRootDir, QualityDir, StandardDir, res))
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		return path
+	}
+	add := func(img provider.Image, withMaster bool) {
+		img.FilePath, _ = fm.GetMasterPath(img.ID, ".jpg")
+		if withMaster {
+			write(img.FilePath)
+		}
+		require.True(t, store.Add(img))
+	}
+
+	// Healthy, and favorited with its copy in the favorites folder
+	add(provider.Image{ID: "ok", IsFavorited: true, DerivativePaths: map[string]string{"1920x1080": write(derivative("ok", "1920x1080"))}}, true)
+	write(filepath.Join(favDir, "ok.jpg"))
+	// Kept without a master on purpose
+	add(provider.Image{ID: "zombie", ProcessingFlags: map[string]bool{"incompatible:1920x1080": true}}, false)
+	// From the Favorites provider, which lives in its own folder
+	add(provider.Image{ID: "LocalFolder_favorite_images_x", Provider: "Favorites", IsFavorited: true}, true)
+
+	add(provider.Image{ID: "gone1"}, false)
+	add(provider.Image{ID: "gone2"}, false)
+	add(provider.Image{ID: "stale", DerivativePaths: map[string]string{
+		"1920x1080": derivative("stale", "1920x1080"),
+		"2560x1440": write(derivative("stale", "2560x1440")),
+	}}, true)
+	add(provider.Image{ID: "fav", IsFavorited: true}, true)
+
+	// Orphans: an old one, and one the pipeline may still be writing
+	old := write(derivative("orphan", "1920x1080"))
+	require.NoError(t, os.Chtimes(old, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+	write(derivative("fresh", "1920x1080"))
+
+	return store, fm, favDir
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	store, fm, favDir := newIntegrityFixture(t)
+
+	report := store.CheckIntegrity(favDir)
+	assert.Equal(t, 7, report.Checked)
+	assert.Equal(t, map[IntegrityIssue][]string{
+		IssueMissingMaster:     {"gone1", "gone2"},
+		IssueMissingDerivative: {"stale"},
+		IssueOrphanDerivative:  {filepath.Join(fm.rootDir, FittedRootDir, QualityDir, StandardDir, "1920x1080", "orphan.jpg")},
+		IssueMissingFavorite:   {"fav"},
+	}, report.Issues)
+	assert.Equal(t, 5, report.Count())
+	assert.Empty(t, report.Repaired)
+
+	// Without a favorites folder, favorites are not checked
+	assert.NotContains(t, store.CheckIntegrity("").Issues, IssueMissingFavorite)
+	// Checking changes nothing
+	assert.Equal(t, 7, store.Count())
+}
+
+func TestRepairIntegrity(t *testing.T) {
+	store, fm, favDir := newIntegrityFixture(t)
+	report := store.CheckIntegrity(favDir)
+
+	// gone2 comes back before the repair runs, so it must be left alone
+	master, _ := fm.GetMasterPath("gone2", ".jpg")
+	require.NoError(t, os.WriteFile(master, []byte("x"), 0644))
+
+	store.RepairIntegrity(&report)
+	assert.Equal(t, map[IntegrityIssue][]string{
+		IssueMissingMaster:     {"gone1"},
+		IssueMissingDerivative: {"stale"},
+		IssueOrphanDerivative:  report.Issues[IssueOrphanDerivative],
+	}, report.Repaired, "missing favorites are left to the caller")
+	assert.Equal(t, 3, report.RepairedCount())
+
+	assert.False(t, store.Contains("gone1"))
+	assert.True(t, store.Contains("gone2"))
+	stale, ok := store.GetByID("stale")
+	require.True(t, ok)
+	assert.Empty(t, stale.DerivativePaths)
+	assert.NotContains(t, store.GetIDsForResolution("2560x1440"), "stale")
+	assert.NoFileExists(t, filepath.Join(fm.rootDir, FittedRootDir, QualityDir, StandardDir, "2560x1440", "stale.jpg"), "the derivative left is made again too")
+	for _, path := range report.Issues[IssueOrphanDerivative] {
+		assert.NoFileExists(t, path)
+	}
+
+	// What is left: the missing favorite, and the fresh file until it is old enough
+	store.WaitTimeout(2 * time.Second)
+	after := store.CheckIntegrity(favDir)
+	assert.Equal(t, map[IntegrityIssue][]string{IssueMissingFavorite: {"fav"}}, after.Issues)
+	assert.Len(t, fm.DerivativeFiles(), 2, "ok.jpg and fresh.jpg")
+}
+
+func TestIntegrityReport_String(t *testing.T) {
+	report := IntegrityReport{
+		Checked:  10,
+		Issues:   map[IntegrityIssue][]string{IssueMissingMaster: {"a", "b"}, IssueOrphanDerivative: {"/c.jpg"}},
+		Repaired: map[IntegrityIssue][]string{IssueMissingMaster: {"a"}},
+	}
+	assert.Equal(t, "checked 10 images, found 3 problems, repaired 1\n"+
+		"  Missing originals: 2 found, 1 repaired\n"+
+		"  Orphaned processed images: 1 found, 0 repaired", report.String())
+	assert.Equal(t, "IntegrityIssue(9)", IntegrityIssue(9).String())
+}
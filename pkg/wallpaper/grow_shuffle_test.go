@@ -5,6 +5,7 @@ package wallpaper
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/dixieflatline76/Spice/v2/pkg/provider"
 	"github.com/stretchr/testify/assert"
@@ -188,3 +189,63 @@ func TestGrowShuffle_ProviderInterleaving(t *testing.T) {
 	assert.True(t, hasCMA, "Unplayed portion should contain CMA images")
 	assert.True(t, hasAIC, "Unplayed portion should contain remaining AIC images")
 }
+
+func TestIsAnniversary(t *testing.T) {
+	now := time.Date(2026, time.July, 14, 9, 0, 0, 0, time.Local)
+
+	assert.True(t, isAnniversary(time.Date(2019, time.July, 14, 18, 3, 0, 0, time.Local), now))
+	assert.False(t, isAnniversary(time.Date(2026, time.July, 14, 8, 0, 0, 0, time.Local), now), "photos from today are not memories")
+	assert.False(t, isAnniversary(time.Date(2019, time.July, 15, 0, 0, 0, 0, time.Local), now))
+	assert.False(t, isAnniversary(time.Time{}, now), "undated photos never match")
+
+	feb28 := time.Date(2026, time.February, 28, 12, 0, 0, 0, time.Local)
+	assert.True(t, isAnniversary(time.Date(2024, time.February, 29, 12, 0, 0, 0, time.Local), feb28), "leap day photos come up on February 28")
+	leapFeb28 := time.Date(2028, time.February, 28, 12, 0, 0, 0, time.Local)
+	assert.False(t, isAnniversary(time.Date(2024, time.February, 29, 12, 0, 0, 0, time.Local), leapFeb28))
+}
+
+func TestShuffle_MemoriesComeFirst(t *testing.T) {
+	mockStore := new(MockImageStore)
+	cfg := &Config{Queries: []ImageQuery{
+		{ID: "photos", Active: true, Memories: true},
+		{ID: "other", Active: true},
+	}}
+	mc := NewMonitorController(0, Monitor{ID: 0}, mockStore, nil, nil, cfg, nil)
+
+	today := time.Now()
+	memory := time.Date(today.Year()-4, today.Month(), today.Day(), 12, 0, 0, 0, time.Local)
+	ids := make([]string, 50)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("img%d", i)
+		img := provider.Image{ID: ids[i], SourceQueryID: "photos", Taken: memory.AddDate(0, 0, 3)}
+		switch i {
+		case 7:
+			img.Taken = memory
+		case 8:
+			img.Taken = memory
+			img.SourceQueryID = "other" // Query without the option
+		}
+		mockStore.On("GetByID", ids[i]).Return(img, true)
+	}
+
+	positions := map[string]int{}
+	const runs = 50
+	for range runs {
+		mc.rebuildShuffle(ids)
+		assert.Len(t, mc.State.ShuffleIDs, len(ids))
+		for pos, id := range mc.State.ShuffleIDs {
+			if id == "img7" || id == "img8" {
+				positions[id] += pos
+			}
+		}
+	}
+	assert.Less(t, positions["img7"]/runs, 10, "the memory comes up early on average")
+	assert.Greater(t, positions["img8"]/runs, 10, "photos of queries without the option are not preferred")
+	assert.Equal(t, mc.memoriesKey(), mc.State.MemoriesKey)
+
+	// Without the option the shuffle is uniform and never looks images up.
+	plain := NewMonitorController(0, Monitor{ID: 0}, new(MockImageStore), nil, nil, &Config{}, nil)
+	plain.rebuildShuffle(ids)
+	assert.Len(t, plain.State.ShuffleIDs, len(ids))
+	assert.Empty(t, plain.State.MemoriesKey)
+}
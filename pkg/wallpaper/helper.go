@@ -55,3 +55,13 @@ func SanitizeMenuString(s string) string {
 	fields := strings.Fields(s)
 	return strings.Join(fields, " ")
 }
+
+// truncateMenuString sanitizes a string and shortens it to fit a tray menu label.
+func truncateMenuString(s string) string {
+	s = SanitizeMenuString(s)
+	runes := []rune(s)
+	if len(runes) > 20 {
+		return string(runes[:17]) + "..."
+	}
+	return s
+}
@@ -37,3 +37,9 @@ func TestGenerateQueryID(t *testing.T) {
 	assert.NotEqual(t, id1, id3)
 	assert.NotEmpty(t, id1)
 }
+
+func TestTruncateMenuString(t *testing.T) {
+	assert.Equal(t, "Ann Smith", truncateMenuString("  Ann\n Smith "))
+	assert.Equal(t, "Henri Cartier-Bre...", truncateMenuString("Henri Cartier-Bresson"))
+	assert.Equal(t, "Ansel Adams Gallery!", truncateMenuString("Ansel Adams Gallery!"), "20 runes still fit")
+}
@@ -0,0 +1,52 @@
+package wallpaper
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBudget caps the memory that concurrent decodes may claim together. Museum masters of
+// 50-200 megapixels take gigabytes each at full size, so workers reserve their estimated share
+// before decoding and wait while the budget is spent.
+type MemoryBudget struct {
+	mu      sync.Mutex
+	limit   int64
+	used    int64
+	changed chan struct{} // Closed and replaced whenever memory is released
+}
+
+// NewMemoryBudget creates a budget of limit bytes.
+func NewMemoryBudget(limit int64) *MemoryBudget {
+	return &MemoryBudget{limit: limit, changed: make(chan struct{})}
+}
+
+// Acquire blocks until n bytes fit in the budget or ctx is done. A request larger than the whole
+// budget is admitted once nothing else holds memory, so no image is refused outright. The
+// returned release gives the memory back and may be called more than once.
+func (b *MemoryBudget) Acquire(ctx context.Context, n int64) (release func(), err error) {
+	for {
+		b.mu.Lock()
+		if b.used == 0 || b.used+n <= b.limit {
+			b.used += n
+			b.mu.Unlock()
+			var once sync.Once
+			return func() { once.Do(func() { b.release(n) }) }, nil
+		}
+		changed := b.changed
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (b *MemoryBudget) release(n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used -= n
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
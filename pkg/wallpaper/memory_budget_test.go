@@ -0,0 +1,70 @@
+package wallpaper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBudget_WaitsForRoom(t *testing.T) {
+	b := NewMemoryBudget(100)
+	releaseA, err := b.Acquire(context.Background(), 60)
+	require.NoError(t, err)
+
+	acquired := make(chan func())
+	go func() {
+		release, err := b.Acquire(context.Background(), 50)
+		assert.NoError(t, err)
+		acquired <- release
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired beyond the budget")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	releaseA()
+	releaseA() // A second call is a no-op
+	select {
+	case releaseB := <-acquired:
+		assert.Equal(t, int64(50), b.used)
+		releaseB()
+	case <-time.After(time.Second):
+		t.Fatal("not admitted after release")
+	}
+	assert.Zero(t, b.used)
+}
+
+func TestMemoryBudget_OversizedRunsAlone(t *testing.T) {
+	b := NewMemoryBudget(100)
+	release, err := b.Acquire(context.Background(), 500)
+	require.NoError(t, err, "a request larger than the budget is admitted when nothing else runs")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = b.Acquire(ctx, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "nothing fits next to it")
+	release()
+}
+
+func TestPipeline_ReserveDecode(t *testing.T) {
+	var nilPipeline *Pipeline
+	release, err := nilPipeline.ReserveDecode(context.Background(), LargeDecodeBytes)
+	require.NoError(t, err)
+	release()
+
+	p := &Pipeline{memory: NewMemoryBudget(DecodeMemoryBudget)}
+	release, err = p.ReserveDecode(context.Background(), LargeDecodeBytes-1)
+	require.NoError(t, err)
+	assert.Zero(t, p.memory.used, "small decodes do not use the budget")
+	release()
+
+	release, err = p.ReserveDecode(context.Background(), LargeDecodeBytes)
+	require.NoError(t, err)
+	assert.Equal(t, int64(LargeDecodeBytes), p.memory.used)
+	release()
+	assert.Zero(t, p.memory.used)
+}
@@ -155,6 +155,11 @@ func (m *MockImageStore) Remove(id string) (provider.Image, bool) {
 	return args.Get(0).(provider.Image), args.Bool(1)
 }
 
+func (m *MockImageStore) Forget(id string) (provider.Image, bool) {
+	args := m.Called(id)
+	return args.Get(0).(provider.Image), args.Bool(1)
+}
+
 func (m *MockImageStore) SetDerivativePath(id string, resKey string, path string) bool {
 	// We don't use m.Called() here to prevent panicking in tests that don't explicitly
 	// expect this method to be called, since it's an OS-specific internal detail.
@@ -193,6 +198,11 @@ func (m *MockImageStore) GetByID(id string) (provider.Image, bool) {
 	return args.Get(0).(provider.Image), args.Bool(1)
 }
 
+func (m *MockImageStore) SetResultFlags(id string, flags map[string]bool) bool {
+	args := m.Called(id, flags)
+	return args.Bool(0)
+}
+
 func (m *MockImageStore) SetFavorited(id string, favorited bool) bool {
 	args := m.Called(id, favorited)
 	return args.Bool(0)
@@ -250,6 +260,27 @@ func (m *MockImageStore) SetQueryActiveFunc(fn func(string) bool) {
 	m.Called(fn)
 }
 
+func (m *MockImageStore) SetDuplicateMaxDistance(d int) {
+	m.Called(d)
+}
+
+func (m *MockImageStore) SetDiskQuota(bytes int64) {
+	m.Called(bytes)
+}
+
+func (m *MockImageStore) SetEvictionPolicy(policy EvictionPolicy) {
+	m.Called(policy)
+}
+
+func (m *MockImageStore) SetInUseFunc(fn func() map[string]bool) {
+	m.Called(fn)
+}
+
+func (m *MockImageStore) FindDuplicate(img provider.Image) (provider.Image, bool) {
+	args := m.Called(img)
+	return args.Get(0).(provider.Image), args.Bool(1)
+}
+
 func (m *MockImageStore) LoadCache() error {
 	args := m.Called()
 	return args.Error(0)
@@ -259,6 +290,10 @@ func (m *MockImageStore) LoadAvoidSet(avoidSet map[string]bool) {
 	m.Called(avoidSet)
 }
 
+func (m *MockImageStore) Close() {
+	m.Called()
+}
+
 func (m *MockImageStore) Wipe() {
 	m.Called()
 }
@@ -279,6 +314,20 @@ func (m *MockImageStore) List() []provider.Image {
 	return args.Get(0).([]provider.Image)
 }
 
+func (m *MockImageStore) Query(q StoreQuery) StoreQueryResult {
+	args := m.Called(q)
+	return args.Get(0).(StoreQueryResult)
+}
+
+func (m *MockImageStore) CheckIntegrity(favoritesDir string) IntegrityReport {
+	args := m.Called(favoritesDir)
+	return args.Get(0).(IntegrityReport)
+}
+
+func (m *MockImageStore) RepairIntegrity(report *IntegrityReport) {
+	m.Called(report)
+}
+
 func (m *MockImageStore) WaitForImages(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
@@ -124,6 +124,82 @@ func TestMonitorController_HistoryPrev(t *testing.T) {
 	assert.Equal(t, "img0.jpg", mc.State.CurrentImage.FilePath)
 }
 
+func TestMonitorController_OnImageDisplayed(t *testing.T) {
+	mockStore := new(MockImageStore)
+	mockStore.On("MarkSeen", "ok.jpg").Return()
+	mockStore.On("MarkSeen", "fail.jpg").Return()
+
+	mockOS := new(MockOS)
+	mockOS.On("Stat", "ok.jpg").Return(nil, nil)
+	mockOS.On("Stat", "fail.jpg").Return(nil, nil)
+	mockOS.On("SetWallpaper", "ok.jpg", 1).Return(nil)
+	mockOS.On("SetWallpaper", "fail.jpg", 1).Return(assert.AnError)
+
+	mc := NewMonitorController(1, Monitor{ID: 1}, mockStore, nil, mockOS, nil, nil)
+	var displayed []string
+	mc.OnImageDisplayed = func(img provider.Image) {
+		displayed = append(displayed, img.ID)
+	}
+
+	mc.applyImage(provider.Image{ID: "ok", FilePath: "ok.jpg"})
+	mc.applyImage(provider.Image{ID: "fail", FilePath: "fail.jpg"})
+
+	assert.Equal(t, []string{"ok"}, displayed, "only successfully applied images count as displayed")
+}
+
+func TestMonitorController_PinnedDaily(t *testing.T) {
+	now := time.Now()
+	todayImg := provider.Image{ID: "APOD_" + now.Format("2006-01-02"), Provider: "APOD", FilePath: "today.jpg"}
+	oldImg := provider.Image{ID: "APOD_" + now.AddDate(0, 0, -1).Format("2006-01-02"), Provider: "APOD", FilePath: "old.jpg"}
+	otherImg := provider.Image{ID: "Pexels_1", Provider: "Pexels", FilePath: "other.jpg"}
+
+	mockStore := new(MockImageStore)
+	mockOS := new(MockOS)
+	for _, img := range []provider.Image{todayImg, oldImg, otherImg} {
+		mockStore.On("GetByID", img.ID).Return(img, true)
+		mockStore.On("MarkSeen", img.FilePath).Return()
+		mockOS.On("Stat", img.FilePath).Return(nil, nil)
+		mockOS.On("SetWallpaper", img.FilePath, 1).Return(nil)
+	}
+	// Today's image is only processed after the monitor has been pinned.
+	pinnedQuery := StoreQuery{Provider: "APOD", Resolution: "0x0"}
+	mockStore.On("Query", pinnedQuery).Return(StoreQueryResult{Images: []provider.Image{oldImg}, Total: 1}).Once()
+	mockStore.On("Query", pinnedQuery).Return(StoreQueryResult{Images: []provider.Image{oldImg, todayImg}, Total: 2})
+
+	mc := NewMonitorController(1, Monitor{ID: 1}, mockStore, nil, mockOS, nil, nil)
+	mc.ImageDay = func(img provider.Image) (time.Time, bool) {
+		if img.Provider != "APOD" {
+			return time.Time{}, false
+		}
+		day, err := time.ParseInLocation("2006-01-02", img.ID[len("APOD_"):], time.Local)
+		return day, err == nil
+	}
+
+	// Only images from daily providers can be pinned.
+	mc.State.CurrentImage = otherImg
+	mc.togglePinDaily()
+	assert.Empty(t, mc.State.PinnedDaily)
+
+	// Pinning jumps to the newest image of the provider; today's is not processed yet.
+	mc.State.CurrentImage = oldImg
+	mc.togglePinDaily()
+	assert.Equal(t, "APOD", mc.State.PinnedDaily)
+	assert.Equal(t, oldImg.ID, mc.State.CurrentID)
+	assert.True(t, mc.State.WaitingForDaily)
+
+	// Today's image arrives: the monitor switches to it and stops waiting.
+	mc.showDaily()
+	assert.Equal(t, todayImg.ID, mc.State.CurrentID)
+	assert.False(t, mc.State.WaitingForDaily)
+
+	// The rotation timer does not move a pinned monitor.
+	mc.next(false)
+	assert.Equal(t, todayImg.ID, mc.State.CurrentID)
+
+	mc.togglePinDaily()
+	assert.Empty(t, mc.State.PinnedDaily)
+}
+
 func TestMonitorController_Delete(t *testing.T) {
 	// Plan: Send CmdDelete, verify Delete callback is invoked (mocked)
 	// TODO: Needs DeleteDelegate interface
@@ -3,13 +3,16 @@ package wallpaper
 import (
 	"context"
 	"fmt"
+	"maps"
 	"math/rand"
 	"os"
-	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/disintegration/imaging"
+	"github.com/dixieflatline76/Spice/v2/pkg/imagecodec"
 	"github.com/dixieflatline76/Spice/v2/pkg/provider"
 	"github.com/dixieflatline76/Spice/v2/util/log"
 )
@@ -32,6 +35,10 @@ const (
 	CmdTuningStart
 	CmdTuningEnd
 
+	// Daily image commands
+	CmdPinDaily  // Toggle pinning the monitor to the daily provider of the current image
+	CmdShowDaily // Show the newest image of the pinned daily provider
+
 	// Legacy Anchor commands
 	CmdAnchorAuto Command = 200
 	CmdAnchorTL   Command = 201
@@ -52,8 +59,10 @@ type StoreInterface interface {
 	GetByID(id string) (provider.Image, bool)
 	Exists(id string) bool
 	Remove(id string) (provider.Image, bool)
+	Forget(id string) (provider.Image, bool)
 	SetFavorited(id string, favorited bool) bool
 	SetTuningOptions(id string, resKey string, opts provider.TuningOptions) bool
+	SetResultFlags(id string, flags map[string]bool) bool
 	SetDerivativePath(id string, resKey string, path string) bool
 	ClearDerivatives(id string) bool
 	Add(img provider.Image) bool
@@ -62,6 +71,7 @@ type StoreInterface interface {
 	SeenCount() int
 	GetIDsForResolution(resolution string) []string
 	GetBucketSize(resolution string) int
+	FindDuplicate(img provider.Image) (provider.Image, bool)
 	GetUpdateChannel() <-chan struct{}
 
 	// Administrative and Batch Operations
@@ -71,12 +81,20 @@ type StoreInterface interface {
 	SetAsyncSave(enabled bool)
 	SetDebounceDuration(d time.Duration)
 	SetQueryActiveFunc(fn func(string) bool)
+	SetDuplicateMaxDistance(d int)
+	SetDiskQuota(bytes int64)
+	SetEvictionPolicy(policy EvictionPolicy)
+	SetInUseFunc(fn func() map[string]bool)
 	LoadCache() error
 	LoadAvoidSet(avoidSet map[string]bool)
+	Close()
 	Wipe()
 	RemoveByQueryID(queryID string)
 	ResetFavorites()
 	List() []provider.Image
+	Query(q StoreQuery) StoreQueryResult
+	CheckIntegrity(favoritesDir string) IntegrityReport
+	RepairIntegrity(report *IntegrityReport)
 	WaitForImages(ctx context.Context) error
 }
 
@@ -90,7 +108,10 @@ type MonitorState struct {
 	WaitingForImages bool
 	Paused           bool
 	TuningInProgress bool
-	ManualRecovery   bool // True if WaitingForImages was triggered by a manual request
+	ManualRecovery   bool   // True if WaitingForImages was triggered by a manual request
+	PinnedDaily      string // ID of the daily provider this monitor is pinned to ("" = normal rotation)
+	WaitingForDaily  bool   // True while today's image of the pinned provider has not been processed yet
+	MemoriesKey      string // Day and "On This Day" queries the unplayed deck was weighted for
 }
 
 // MonitorController is an Actor that manages one specific monitor.
@@ -111,16 +132,20 @@ type MonitorController struct {
 	cancel             context.CancelFunc
 	isRunning          bool
 	OnWallpaperChanged func(img provider.Image, monitorID int)
+	OnImageDisplayed   func(img provider.Image) // Fired only when a new image was successfully set as the wallpaper
 	OnFavoriteRequest  func(img provider.Image)
 	OnFetchRequest     func()
+	ImageDay           func(img provider.Image) (time.Time, bool)
 	pendingUpdate      bool // Flag to indicate Store content has changed
 }
 
 // NewMonitorController creates a new actor for managing a specific monitor's state.
 func NewMonitorController(id int, m Monitor, store StoreInterface, fm *FileManager, os OS, cfg *Config, processor ImageProcessor) *MonitorController {
 	paused := false
+	pinned := ""
 	if cfg != nil {
 		paused = cfg.IsMonitorPaused(m.DevicePath)
+		pinned = cfg.GetMonitorDailyPin(m.DevicePath)
 	}
 
 	return &MonitorController{
@@ -134,11 +159,12 @@ func NewMonitorController(id int, m Monitor, store StoreInterface, fm *FileManag
 		cfg:        cfg,
 		processor:  processor,
 		State: &MonitorState{
-			CurrentID:  "",
-			History:    make([]string, 0),
-			RandomPos:  0,
-			ShuffleIDs: make([]string, 0),
-			Paused:     paused,
+			CurrentID:   "",
+			History:     make([]string, 0),
+			RandomPos:   0,
+			ShuffleIDs:  make([]string, 0),
+			Paused:      paused,
+			PinnedDaily: pinned,
 		},
 	}
 }
@@ -182,6 +208,8 @@ func (mc *MonitorController) Run(ctx context.Context) {
 			if mc.State.WaitingForImages {
 				log.Debugf("[Monitor %d] Store updated while starving. Retrying next(manual=%v)...", mc.ID, mc.State.ManualRecovery)
 				mc.next(mc.State.ManualRecovery)
+			} else if mc.State.WaitingForDaily {
+				mc.showDaily()
 			}
 		case cmd := <-mc.Commands:
 			mc.mu.Lock()
@@ -232,6 +260,10 @@ func (mc *MonitorController) handleCommand(cmd Command) {
 		mc.State.TuningInProgress = true
 	case CmdTuningEnd:
 		mc.State.TuningInProgress = false
+	case CmdPinDaily:
+		mc.togglePinDaily()
+	case CmdShowDaily:
+		mc.showDaily()
 	}
 }
 
@@ -246,11 +278,90 @@ func (mc *MonitorController) togglePause() {
 	}
 }
 
+// togglePinDaily pins the monitor to the daily provider of the current image, or unpins it.
+func (mc *MonitorController) togglePinDaily() {
+	if mc.State.PinnedDaily != "" {
+		mc.State.PinnedDaily = ""
+		mc.State.WaitingForDaily = false
+	} else {
+		img := mc.State.CurrentImage
+		if img.ID == "" || mc.ImageDay == nil {
+			return
+		}
+		if _, ok := mc.ImageDay(img); !ok {
+			log.Debugf("[Monitor %d] Cannot pin: %s is not a daily image provider", mc.ID, img.Provider)
+			return
+		}
+		mc.State.PinnedDaily = img.Provider
+	}
+	if mc.cfg != nil {
+		mc.cfg.SetMonitorDailyPin(mc.Monitor.DevicePath, mc.State.PinnedDaily)
+	}
+	log.Printf("[Monitor %d] Daily pin set to %q", mc.ID, mc.State.PinnedDaily)
+
+	if mc.State.PinnedDaily != "" {
+		mc.showDaily()
+	}
+	if mc.OnWallpaperChanged != nil {
+		mc.OnWallpaperChanged(mc.State.CurrentImage, mc.ID)
+	}
+}
+
+// showDaily displays the newest image of the pinned daily provider. If today's image has not
+// been processed yet, the newest available one is shown and the monitor keeps waiting for store
+// updates until today's image arrives.
+func (mc *MonitorController) showDaily() {
+	if mc.State.PinnedDaily == "" || mc.ImageDay == nil {
+		mc.State.WaitingForDaily = false
+		return
+	}
+	resKey := fmt.Sprintf("%dx%d", mc.Monitor.Rect.Dx(), mc.Monitor.Rect.Dy())
+
+	var newest provider.Image
+	var newestDay time.Time
+	for _, img := range mc.Store.Query(StoreQuery{Provider: mc.State.PinnedDaily, Resolution: resKey}).Images {
+		if mc.cfg != nil && mc.cfg.InAvoidSet(img.ID) {
+			continue
+		}
+		if day, ok := mc.ImageDay(img); ok && day.After(newestDay) {
+			newest, newestDay = img, day
+		}
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	mc.State.WaitingForDaily = newestDay.Before(today)
+
+	if newest.ID == "" {
+		log.Printf("[Monitor %d] No images from pinned daily provider %s yet. Waiting for fetch...", mc.ID, mc.State.PinnedDaily)
+		if mc.OnFetchRequest != nil {
+			mc.OnFetchRequest()
+		}
+		return
+	}
+	if newest.ID == mc.State.CurrentID {
+		return
+	}
+
+	mc.State.CurrentID = newest.ID
+	mc.State.History = append(mc.State.History, newest.ID)
+	if len(mc.State.History) > 100 {
+		mc.State.History = mc.State.History[1:]
+	}
+	mc.applyImage(newest)
+}
+
 func (mc *MonitorController) next(manual bool) {
 	if !manual && (mc.State.Paused || mc.State.TuningInProgress) {
 		log.Debugf("[Monitor %d] Skipping automatic Next (Monitor is paused or tuning)", mc.ID)
 		return
 	}
+	if !manual && mc.State.PinnedDaily != "" {
+		// Pinned monitors change at midnight, not on the rotation timer. Re-checking here
+		// also brings the monitor back to today's image after a manual Next/Prev.
+		mc.showDaily()
+		return
+	}
 	width, height := mc.Monitor.Rect.Dx(), mc.Monitor.Rect.Dy()
 	resKey := fmt.Sprintf("%dx%d", width, height)
 
@@ -298,6 +409,9 @@ func (mc *MonitorController) next(manual bool) {
 			log.Debugf("[Monitor %d] Shuffle full rebuild (Bucket: %d, Current: %d).", mc.ID, len(bucketIDs), len(mc.State.ShuffleIDs))
 			mc.rebuildShuffle(bucketIDs)
 		}
+	} else if key := mc.memoriesKey(); key != mc.State.MemoriesKey {
+		// A new day (or a changed "On This Day" option) brings other photos to the front
+		mc.reweightShuffle()
 	}
 	mc.pendingUpdate = false // Always consume the pending update
 
@@ -344,14 +458,110 @@ func (mc *MonitorController) rebuildShuffle(ids []string) {
 	shuffled := make([]string, len(ids))
 	copy(shuffled, ids)
 
-	rand.Shuffle(len(shuffled), func(i, j int) {
-		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
-	})
+	mc.shuffle(shuffled)
 
 	mc.State.ShuffleIDs = shuffled
 	mc.State.RandomPos = 0
 }
 
+// shuffle randomizes the order of ids in place. Images with a weight come up earlier in proportion
+// to it (weighted random sampling: each image draws the key -ln(u)/weight and the deck is sorted by
+// key); without weights every order is equally likely.
+func (mc *MonitorController) shuffle(ids []string) {
+	weights := mc.shuffleWeights(ids)
+	if len(weights) == 0 {
+		rand.Shuffle(len(ids), func(i, j int) {
+			ids[i], ids[j] = ids[j], ids[i]
+		})
+		return
+	}
+
+	keys := make(map[string]float64, len(ids))
+	for _, id := range ids {
+		w := weights[id]
+		if w == 0 {
+			w = 1
+		}
+		keys[id] = rand.ExpFloat64() / w
+	}
+	sort.Slice(ids, func(i, j int) bool { return keys[ids[i]] < keys[ids[j]] })
+}
+
+// shuffleWeights returns the weights of the "On This Day" photos among ids: images of queries with
+// the memories option that were taken on today's month and day in an earlier year. It records the
+// day and queries in MemoriesKey and returns nil if no image is preferred.
+func (mc *MonitorController) shuffleWeights(ids []string) map[string]float64 {
+	mc.State.MemoriesKey = mc.memoriesKey()
+	if mc.cfg == nil || mc.Store == nil {
+		return nil
+	}
+	queries := mc.cfg.GetMemoryQueryIDs()
+	if len(queries) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var weights map[string]float64
+	for _, id := range ids {
+		img, ok := mc.Store.GetByID(id)
+		if !ok || !queries[img.SourceQueryID] || !isAnniversary(img.Taken, now) {
+			continue
+		}
+		if weights == nil {
+			weights = make(map[string]float64)
+		}
+		weights[id] = MemoryShuffleWeight
+	}
+	return weights
+}
+
+// memoriesKey identifies what the "On This Day" weighting depends on: today's date and the
+// queries with the memories option. It is empty while no query uses the option.
+func (mc *MonitorController) memoriesKey() string {
+	if mc.cfg == nil {
+		return ""
+	}
+	queries := mc.cfg.GetMemoryQueryIDs()
+	if len(queries) == 0 {
+		return ""
+	}
+	ids := make([]string, 0, len(queries))
+	for id := range queries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return time.Now().Format(time.DateOnly) + ":" + strings.Join(ids, ",")
+}
+
+// isAnniversary reports whether a photo was taken on now's month and day in an earlier year.
+// Photos taken on February 29 come up on February 28 in other years.
+func isAnniversary(taken, now time.Time) bool {
+	if taken.IsZero() {
+		return false
+	}
+	taken = taken.In(now.Location())
+	if taken.Year() >= now.Year() {
+		return false
+	}
+	month, day := taken.Month(), taken.Day()
+	if month == time.February && day == 29 && !isLeapYear(now.Year()) {
+		day = 28
+	}
+	return month == now.Month() && day == now.Day()
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// reweightShuffle reshuffles the unplayed portion of the deck, e.g. when a new day brings other
+// "On This Day" photos. The played portion and position are kept.
+func (mc *MonitorController) reweightShuffle() {
+	unplayed := mc.State.ShuffleIDs[mc.State.RandomPos:]
+	mc.shuffle(unplayed)
+	log.Debugf("[Monitor %d] Reweighted %d unplayed images for %q", mc.ID, len(unplayed), mc.State.MemoriesKey)
+}
+
 // growShuffle incrementally inserts newly arrived images into the unplayed
 // portion of the deck. This preserves the current playback position and
 // prevents provider clustering when images arrive in same-provider bursts.
@@ -391,9 +601,7 @@ func (mc *MonitorController) growShuffle(bucketIDs []string) {
 	merged = append(merged, newIDs...)
 
 	// Shuffle only the merged unplayed portion to scatter new arrivals
-	rand.Shuffle(len(merged), func(i, j int) {
-		merged[i], merged[j] = merged[j], merged[i]
-	})
+	mc.shuffle(merged)
 
 	// Reassemble: played portion stays intact, unplayed is now mixed
 	mc.State.ShuffleIDs = append(played, merged...)
@@ -525,6 +733,8 @@ func (mc *MonitorController) applyImage(img provider.Image) {
 	log.Printf("[Monitor %d] Setting wallpaper: %s", mc.ID, path)
 	if err := mc.os.SetWallpaper(path, mc.ID); err != nil {
 		log.Printf("[ERROR] [Monitor %d] Failed to set wallpaper: %v", mc.ID, err)
+	} else if mc.OnImageDisplayed != nil {
+		mc.OnImageDisplayed(img)
 	}
 	mc.Store.MarkSeen(path)
 
@@ -552,7 +762,9 @@ func (mc *MonitorController) reprocessWithTuning(opts provider.TuningOptions) {
 	// Mirror the tuning change into the local image copy so that
 	// mc.State.CurrentImage stays in sync with the store. Without this,
 	// the tuning popup would show the stale (pre-change) tuning until
-	// the user navigates away and back.
+	// the user navigates away and back. The map is copied first, since the
+	// store's snapshots may share it.
+	img.Tuning = maps.Clone(img.Tuning)
 	if img.Tuning == nil {
 		img.Tuning = make(map[string]provider.TuningOptions)
 	}
@@ -565,32 +777,25 @@ func (mc *MonitorController) reprocessWithTuning(opts provider.TuningOptions) {
 	mc.State.CurrentImage = img
 
 	// 2. Find master path
-	ext := filepath.Ext(img.FilePath)
-	if ext == "" {
-		ext = ".jpg"
-	}
-	masterPath, err := mc.fm.GetMasterPath(img.ID, ext)
-	if err != nil {
-		log.Printf("[ERROR] [Monitor %d] Failed to get master path: %v", mc.ID, err)
-		return
-	}
-	if _, err := os.Stat(masterPath); os.IsNotExist(err) {
-		log.Printf("[ERROR] [Monitor %d] Master file missing: %s", mc.ID, masterPath)
+	masterPath, ok := mc.fm.FindMaster(img.ID)
+	if !ok {
+		log.Printf("[ERROR] [Monitor %d] Master file missing for %s", mc.ID, img.ID)
 		return
 	}
 
-	// 3. Open and decode master
-	srcImg, err := imaging.Open(masterPath)
+	// 3. Open and decode master, no larger than this monitor needs
+	width, height := mc.Monitor.Rect.Dx(), mc.Monitor.Rect.Dy()
+	srcImg, _, err := imagecodec.DecodeFileScaled(masterPath, width, height)
 	if err != nil {
 		log.Printf("[ERROR] [Monitor %d] Failed to open master: %v", mc.ID, err)
 		return
 	}
 
 	// 4. Re-run FitImage with new tuning
-	width, height := mc.Monitor.Rect.Dx(), mc.Monitor.Rect.Dy()
 	ctx := context.Background()
-	virtualFramed := false
+	virtualFramed, upscaled := false, false
 	ctx = context.WithValue(ctx, provider.VirtualFramedKey, &virtualFramed)
+	ctx = context.WithValue(ctx, provider.UpscaledKey, &upscaled)
 
 	processedImg, err := mc.processor.FitImage(ctx, srcImg, width, height, opts)
 	if err != nil {
@@ -600,14 +805,16 @@ func (mc *MonitorController) reprocessWithTuning(opts provider.TuningOptions) {
 
 	resKey = fmt.Sprintf("%dx%d", width, height)
 
-	// Keep the UI sync state fresh by tracking if VirtualFramer actually framed it
+	// Keep the UI sync state fresh by tracking if VirtualFramer actually framed it,
+	// in the store and, copied like the tuning above, in the local image
+	results := map[string]bool{"VirtualFramed:" + resKey: virtualFramed, "Upscaled:" + resKey: upscaled}
+	mc.Store.SetResultFlags(img.ID, results)
+	img.ProcessingFlags = maps.Clone(img.ProcessingFlags)
 	if img.ProcessingFlags == nil {
 		img.ProcessingFlags = make(map[string]bool)
 	}
-	if virtualFramed {
-		img.ProcessingFlags["VirtualFramed:"+resKey] = true
-	} else {
-		delete(img.ProcessingFlags, "VirtualFramed:"+resKey)
+	for key, on := range results {
+		setResultFlag(img.ProcessingFlags, key, on)
 	}
 
 	// 5. Determine derivative path and overwrite
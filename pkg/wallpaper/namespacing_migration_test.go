@@ -50,6 +50,7 @@ func TestStore_NamespacingMigration(t *testing.T) {
 	// 4. Initialize store and load cache
 	store := NewImageStore()
 	store.SetFileManager(fm, cacheFile)
+	t.Cleanup(store.Close)
 	err = store.LoadCache()
 	require.NoError(t, err)
 
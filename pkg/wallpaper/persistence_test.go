@@ -96,3 +96,26 @@ func TestMonitorPausePersistence(t *testing.T) {
 	finalCfg := GetConfig(prefs)
 	assert.False(t, finalCfg.IsMonitorPaused(devicePath), "Monitor unpause state should persist")
 }
+
+func TestMonitorDailyPinPersistence(t *testing.T) {
+	ResetConfig()
+	prefs := NewMockPreferences()
+	cfg := GetConfig(prefs)
+
+	devicePath := "MONITOR_1"
+	assert.Empty(t, cfg.GetMonitorDailyPin(devicePath))
+
+	cfg.SetMonitorDailyPin(devicePath, "APOD")
+	assert.Equal(t, "APOD", cfg.GetMonitorDailyPin(devicePath))
+
+	// Verify Persistence
+	ResetConfig()
+	newCfg := GetConfig(prefs)
+	assert.Equal(t, "APOD", newCfg.GetMonitorDailyPin(devicePath), "Daily pin should persist")
+
+	// Unpin
+	newCfg.SetMonitorDailyPin(devicePath, "")
+	ResetConfig()
+	finalCfg := GetConfig(prefs)
+	assert.Empty(t, finalCfg.GetMonitorDailyPin(devicePath), "Unpinning should persist")
+}
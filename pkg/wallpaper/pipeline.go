@@ -3,7 +3,6 @@ package wallpaper
 import (
 	"context"
 	"golang.org/x/time/rate"
-	"runtime"
 	"strings"
 	"sync"
 
@@ -23,6 +22,7 @@ type Pipeline struct {
 	config     *Config
 	store      *ImageStore
 	processor  ProcessFunc
+	memory     *MemoryBudget
 }
 
 // DownloadJob represents a task to download and process an image.
@@ -66,6 +66,7 @@ func NewPipeline(ctx context.Context, cfg *Config, store *ImageStore, processor
 		config:     cfg,
 		store:      store,
 		processor:  processor,
+		memory:     NewMemoryBudget(DecodeMemoryBudget),
 	}
 	p.dispatcher = NewDispatcher(ctx, p.jobChan, apiLim, procLim, &p.workerWg)
 	return p
@@ -101,6 +102,17 @@ func (p *Pipeline) Submit(ctx context.Context, job DownloadJob) bool {
 	return p.dispatcher.Submit(job)
 }
 
+// ReserveDecode waits until the decode memory budget has room for a decode estimated at the given
+// number of bytes, so workers decoding very large masters at the same time cannot exhaust RAM.
+// Decodes below LargeDecodeBytes never wait. Call release once the decoded image is discarded.
+// A nil pipeline (no workers running) reserves nothing.
+func (p *Pipeline) ReserveDecode(ctx context.Context, bytes int64) (release func(), err error) {
+	if p == nil || bytes < LargeDecodeBytes {
+		return func() {}, nil
+	}
+	return p.memory.Acquire(ctx, bytes)
+}
+
 // workerLoop is the main loop for a worker goroutine.
 func (p *Pipeline) workerLoop(id int) {
 	defer p.workerWg.Done()
@@ -168,8 +180,6 @@ func (p *Pipeline) stateManagerLoop() {
 		case <-p.ctx.Done():
 			return
 		}
-		// Yield to allow Readers (UI) to acquire RLock
-		runtime.Gosched()
 	}
 }
 
@@ -190,7 +200,7 @@ func (p *Pipeline) logPipelineError(err error) {
 		log.Debugf("Pipeline: %v", err)
 	} else if strings.Contains(errMsg, "status 429") || strings.Contains(errMsg, "enrichment") {
 		log.Debugf("Pipeline: %v", err)
-	} else if strings.Contains(errMsg, "incompatible") {
+	} else if strings.Contains(errMsg, "incompatible") || strings.Contains(errMsg, "low quality") || strings.Contains(errMsg, "duplicate image") {
 		log.Debugf("Pipeline: %v", err)
 	} else {
 		log.Printf("Pipeline Error: %v", err)
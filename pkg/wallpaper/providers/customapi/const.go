@@ -0,0 +1,28 @@
+package customapi
+
+import "time"
+
+const (
+	// ProviderName is the unique identifier of the Custom JSON API provider.
+	ProviderName = "CustomAPI"
+
+	// CustomAPIUserAgent identifies Spice to the API host.
+	CustomAPIUserAgent = "Spice-Wallpaper-App/1.0 (https://github.com/dixieflatline76/Spice)"
+
+	// PagePlaceholder is replaced with the 1-based page number.
+	PagePlaceholder = "{page}"
+	// QueryPlaceholder is replaced with the query's search term, escaped for its position in the URL.
+	QueryPlaceholder = "{query}"
+
+	// CustomAPIMaxBodyBytes caps the size of a single API response.
+	CustomAPIMaxBodyBytes = 16 << 20 // 16 MiB
+
+	// CustomAPITestTimeout bounds the test request made before a query is added.
+	CustomAPITestTimeout = 15 * time.Second
+
+	// CustomAPIAPIPacing spaces out API requests. The API may be a small hobby service.
+	CustomAPIAPIPacing = 1 * time.Second
+
+	// CustomAPIMediaPacing spaces out image downloads.
+	CustomAPIMediaPacing = 250 * time.Millisecond
+)
@@ -0,0 +1,423 @@
+package customapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/schema"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/setting"
+	"github.com/dixieflatline76/Spice/v2/pkg/wallpaper"
+	"github.com/dixieflatline76/Spice/v2/util/log"
+)
+
+//go:embed CustomAPI.png
+var iconData []byte
+
+// Provider implements ImageProvider for JSON APIs described by a user-defined field mapping.
+// Each query carries its own endpoint template, headers and path expressions (wallpaper.APIMapping).
+type Provider struct {
+	cfg        *wallpaper.Config
+	httpClient *http.Client
+}
+
+func init() {
+	wallpaper.RegisterProvider(ProviderName, func(cfg *wallpaper.Config, client *http.Client) provider.ImageProvider {
+		return NewProvider(cfg, client)
+	})
+}
+
+// NewProvider creates a new Custom JSON API Provider.
+func NewProvider(cfg *wallpaper.Config, client *http.Client) *Provider {
+	return &Provider{
+		cfg:        cfg,
+		httpClient: client,
+	}
+}
+
+func (p *Provider) ID() string {
+	return ProviderName
+}
+
+func (p *Provider) Name() string {
+	return i18n.T("Custom JSON API")
+}
+
+func (p *Provider) Title() string {
+	return "Custom API"
+}
+
+func (p *Provider) GetProviderIcon() interface{} {
+	return iconData
+}
+
+func (p *Provider) Type() provider.ProviderType {
+	return provider.TypeCommunity
+}
+
+func (p *Provider) HomeURL() string {
+	return ""
+}
+
+func (p *Provider) GetAttributionType() provider.AttributionType {
+	return provider.AttributionBy
+}
+
+func (p *Provider) SupportsUserQueries() bool {
+	return true
+}
+
+// ParseURL always fails: a URL alone does not say how to read the response, so Custom JSON API
+// queries are only added from the query panel together with their field mapping.
+func (p *Provider) ParseURL(webURL string) (string, error) {
+	return "", errors.New("custom JSON API queries need a field mapping; add them from the Custom JSON API panel")
+}
+
+// GetAPIPacing implements the PacedProvider interface to space out API requests.
+func (p *Provider) GetAPIPacing() time.Duration {
+	return CustomAPIAPIPacing
+}
+
+// GetProcessPacing implements the PacedProvider interface to space out image downloads.
+func (p *Provider) GetProcessPacing() time.Duration {
+	return CustomAPIMediaPacing
+}
+
+// FetchImages looks up the mapping saved with the query and fetches one page.
+// Endpoints without {page} have a single page.
+func (p *Provider) FetchImages(ctx context.Context, apiURL string, page int) ([]provider.Image, error) {
+	m, ok := p.mappingFor(apiURL)
+	if !ok {
+		return nil, fmt.Errorf("no field mapping saved for %s", apiURL)
+	}
+	if page > 1 && !strings.Contains(m.Endpoint, PagePlaceholder) {
+		return nil, nil
+	}
+	return p.fetchPage(ctx, m, page)
+}
+
+func (p *Provider) mappingFor(apiURL string) (wallpaper.APIMapping, bool) {
+	for _, q := range p.cfg.GetCustomAPIQueries() {
+		if q.URL == apiURL && q.Mapping != nil {
+			return *q.Mapping, true
+		}
+	}
+	return wallpaper.APIMapping{}, false
+}
+
+// fetchPage requests one page of the endpoint and maps each result to an image.
+// Results without an ID or an http(s) image URL are skipped.
+func (p *Provider) fetchPage(ctx context.Context, m wallpaper.APIMapping, page int) ([]provider.Image, error) {
+	cm, err := compile(m)
+	if err != nil {
+		return nil, err
+	}
+
+	pageURL := expandEndpoint(m.Endpoint, m.Query, page)
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint URL: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", CustomAPIUserAgent)
+	for name, value := range m.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", base.Host, resp.Status)
+	}
+
+	var doc any
+	dec := json.NewDecoder(io.LimitReader(resp.Body, CustomAPIMaxBodyBytes))
+	dec.UseNumber() // Keep large numeric IDs exact
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	results, ok := lookup(doc, cm.results)
+	items, isArray := results.([]any)
+	if !ok || !isArray {
+		if m.Results == "" {
+			return nil, errors.New("the response is not an array; set the results path")
+		}
+		return nil, fmt.Errorf("results path %q does not point to an array", m.Results)
+	}
+
+	var images []provider.Image
+	for _, item := range items {
+		if img, ok := mapItem(cm, base, item); ok {
+			images = append(images, img)
+		}
+	}
+	log.Debugf("Found %d images in %d results from %s (page %d)", len(images), len(items), base.Host, page)
+	return images, nil
+}
+
+func mapItem(cm *compiledMapping, base *url.URL, item any) (provider.Image, bool) {
+	idVal, _ := lookup(item, cm.id)
+	id, ok := asString(idVal)
+	if !ok {
+		return provider.Image{}, false
+	}
+	pathVal, _ := lookup(item, cm.path)
+	rawPath, ok := asString(pathVal)
+	if !ok {
+		return provider.Image{}, false
+	}
+	ref, err := url.Parse(rawPath)
+	if err != nil {
+		return provider.Image{}, false
+	}
+	imgURL := base.ResolveReference(ref)
+	if imgURL.Scheme != "http" && imgURL.Scheme != "https" {
+		return provider.Image{}, false
+	}
+
+	img := provider.Image{
+		ID:       ProviderName + "_" + idSafe(base.Hostname()) + "_" + idSafe(id),
+		Path:     imgURL.String(),
+		Provider: ProviderName,
+	}
+	if len(cm.title) > 0 {
+		v, _ := lookup(item, cm.title)
+		img.Title, _ = asString(v)
+	}
+	if len(cm.artist) > 0 {
+		v, _ := lookup(item, cm.artist)
+		img.Artist, _ = asString(v)
+		img.Attribution = img.Artist
+	}
+	if len(cm.width) > 0 {
+		v, _ := lookup(item, cm.width)
+		img.Width, _ = asInt(v)
+	}
+	if len(cm.height) > 0 {
+		v, _ := lookup(item, cm.height)
+		img.Height, _ = asInt(v)
+	}
+	return img, true
+}
+
+var idUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// idSafe makes a host name or result ID safe for use in a cache file name.
+func idSafe(s string) string {
+	return idUnsafe.ReplaceAllString(s, "-")
+}
+
+// EnrichImage is a no-op as all metadata comes from the mapped response.
+func (p *Provider) EnrichImage(ctx context.Context, img provider.Image) (provider.Image, error) {
+	return img, nil
+}
+
+// testMapping validates a mapping and fetches its first page, so a query is only saved once it yields images.
+func (p *Provider) testMapping(ctx context.Context, m wallpaper.APIMapping) error {
+	if err := validateMapping(m); err != nil {
+		return err
+	}
+	images, err := p.fetchPage(ctx, m, 1)
+	if err != nil {
+		return err
+	}
+	if len(images) == 0 {
+		return errors.New("the first page has no results with both an ID and an image URL; check the paths")
+	}
+	return nil
+}
+
+// --- UI Implementation (Pure Go) ---
+
+// Names of the mapping form fields in the query panel.
+const (
+	fieldDescription = "customapi_description"
+	fieldEndpoint    = "customapi_endpoint"
+	fieldQuery       = "customapi_query"
+	fieldHeaders     = "customapi_headers"
+	fieldResults     = "customapi_results"
+	fieldID          = "customapi_id"
+	fieldPath        = "customapi_path"
+	fieldTitle       = "customapi_title"
+	fieldArtist      = "customapi_artist"
+	fieldWidth       = "customapi_width"
+	fieldHeight      = "customapi_height"
+)
+
+// CreateSettingsPanel returns the declarative UI for Custom JSON API settings.
+func (p *Provider) CreateSettingsPanel(sm setting.SettingsManager) *schema.PanelSchema {
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:   i18n.T("Custom JSON API"),
+				Compact: true,
+				Items: []schema.ItemSchema{
+					schema.LabelItem{
+						Text:       i18n.T("Use images from any JSON API by telling Spice where to find each field in the response."),
+						Importance: schema.ImportanceLow,
+					},
+				},
+			},
+		},
+	}
+}
+
+// CreateQueryPanel creates the mapping form and the image query management panel.
+func (p *Provider) CreateQueryPanel(sm setting.SettingsManager, pendingUrl string) *schema.PanelSchema {
+	value := func(name string) string {
+		s, _ := sm.GetValue(name).(string)
+		return strings.TrimSpace(s)
+	}
+	pathValidator := func(s string) error {
+		_, err := parsePath(s)
+		return err
+	}
+	pathItem := func(name, label, help, placeholder string) schema.TextItem {
+		return schema.TextItem{
+			Name:        name,
+			Label:       label,
+			Help:        help,
+			PlaceHolder: placeholder,
+			Validator:   pathValidator,
+			SkipApply:   true,
+		}
+	}
+
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:       i18n.T("New API Query"),
+				Description: i18n.T("Describe the endpoint, then map response fields with paths such as data.items or urls[0].full."),
+				Items: []schema.ItemSchema{
+					schema.TextItem{
+						Name:          fieldDescription,
+						Label:         i18n.T("Description:"),
+						PlaceHolder:   i18n.T("Query Description (e.g. Space Photos)"),
+						DisplayStatus: true,
+						SkipApply:     true,
+					},
+					schema.TextItem{
+						Name:        fieldEndpoint,
+						Label:       i18n.T("Endpoint URL:"),
+						Help:        i18n.T("The address of one page of results. {page} is replaced with the page number (starting at 1) and {query} with the search term."),
+						PlaceHolder: "https://api.example.com/search?q={query}&page={page}",
+						Validator:   validateEndpoint,
+						SkipApply:   true,
+					},
+					schema.TextItem{
+						Name:        fieldQuery,
+						Label:       i18n.T("Search Term:"),
+						PlaceHolder: "nebula",
+						SkipApply:   true,
+					},
+					schema.TextItem{
+						Name:        fieldHeaders,
+						Label:       i18n.T("Headers:"),
+						Help:        i18n.T("Optional request headers, separated by semicolons. They are stored with the query in Spice's settings."),
+						PlaceHolder: "Authorization: Bearer …; Accept-Language: en",
+						Validator: func(s string) error {
+							_, err := parseHeaders(s)
+							return err
+						},
+						SkipApply: true,
+					},
+					pathItem(fieldResults, i18n.T("Results Path:"), i18n.T("Path to the array of results. Leave empty if the response itself is the array."), "data.items"),
+					pathItem(fieldID, i18n.T("ID Path:"), i18n.T("Path to a value that uniquely identifies each result."), "id"),
+					pathItem(fieldPath, i18n.T("Image URL Path:"), i18n.T("Path to the full-size image URL. Relative URLs are resolved against the endpoint."), "urls.full"),
+					pathItem(fieldTitle, i18n.T("Title Path:"), "", "title"),
+					pathItem(fieldArtist, i18n.T("Artist Path:"), "", "user.name"),
+					pathItem(fieldWidth, i18n.T("Width Path:"), "", "width"),
+					pathItem(fieldHeight, i18n.T("Height Path:"), "", "height"),
+					schema.AsyncButtonItem{
+						Name:            "customapi_add",
+						ButtonText:      i18n.T("Test & Add"),
+						LoadingText:     i18n.T("Testing..."),
+						Style:           schema.ButtonStylePrimary,
+						TargetStatusKey: fieldDescription,
+						IconName:        "add",
+						NeedsRefresh:    true,
+						OnPressed: func() error {
+							desc := value(fieldDescription)
+							if desc == "" {
+								return errors.New(i18n.T("Enter a description for the query"))
+							}
+							headers, err := parseHeaders(value(fieldHeaders))
+							if err != nil {
+								return err
+							}
+							m := wallpaper.APIMapping{
+								Endpoint: value(fieldEndpoint),
+								Query:    value(fieldQuery),
+								Headers:  headers,
+								Results:  value(fieldResults),
+								ID:       value(fieldID),
+								Path:     value(fieldPath),
+								Title:    value(fieldTitle),
+								Artist:   value(fieldArtist),
+								Width:    value(fieldWidth),
+								Height:   value(fieldHeight),
+							}
+
+							ctx, cancel := context.WithTimeout(context.Background(), CustomAPITestTimeout)
+							defer cancel()
+							if err := p.testMapping(ctx, m); err != nil {
+								return err
+							}
+							_, err = p.cfg.AddCustomAPIQuery(desc, queryURL(m.Endpoint, m.Query), m, true)
+							return err
+						},
+						OnCompleted: func(error) {},
+					},
+				},
+			},
+			{
+				Title:       i18n.T("My API Queries"),
+				Description: i18n.T("Manage your API queries here."),
+				Items: []schema.ItemSchema{
+					schema.QueryListItem{
+						GetQueries: func() []schema.Query {
+							queries := p.cfg.GetCustomAPIQueries()
+							abstracts := make([]schema.Query, len(queries))
+							for i, q := range queries {
+								abstracts[i] = schema.Query{
+									ID:          q.ID,
+									URL:         q.URL,
+									Description: q.Description,
+									Active:      q.Active,
+									Managed:     q.Managed,
+								}
+							}
+							return abstracts
+						},
+						EnableQuery:  p.cfg.EnableImageQuery,
+						DisableQuery: p.cfg.DisableImageQuery,
+						RemoveQuery:  p.cfg.RemoveImageQuery,
+						GetDisplayURL: func(q schema.Query) *url.URL {
+							u, _ := url.Parse(strings.ReplaceAll(q.URL, PagePlaceholder, "1"))
+							return u
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,149 @@
+package customapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/wallpaper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    []step
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"id", []step{{key: "id"}}, false},
+		{"$.data.items", []step{{key: "data"}, {key: "items"}}, false},
+		{"urls[0].full", []step{{key: "urls"}, {index: 0, isIndex: true}, {key: "full"}}, false},
+		{"[2][1]", []step{{index: 2, isIndex: true}, {index: 1, isIndex: true}}, false},
+		{"tags.0", []step{{key: "tags"}, {key: "0"}}, false},
+		{"data..items", nil, true},
+		{"data.", nil, true},
+		{"urls[x]", nil, true},
+		{"urls[0", nil, true},
+		{"urls]0", nil, true},
+		{"urls[0]full", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := parsePath(tt.expr)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExpandEndpoint(t *testing.T) {
+	assert.Equal(t, "https://api.example.com/search?q=red+fox&page=2",
+		expandEndpoint("https://api.example.com/search?q={query}&page={page}", "red fox", 2))
+	assert.Equal(t, "https://api.example.com/tags/red%20fox/{page}",
+		queryURL("https://api.example.com/tags/{query}/{page}", "red fox"), "the query URL keeps {page}")
+	assert.Equal(t, "https://api.example.com/random", expandEndpoint("https://api.example.com/random", "", 3))
+}
+
+func TestValidateMapping(t *testing.T) {
+	valid := wallpaper.APIMapping{Endpoint: "https://api.example.com/search?q={query}", Query: "cats", ID: "id", Path: "url"}
+	assert.NoError(t, validateMapping(valid))
+
+	tests := map[string]func(m *wallpaper.APIMapping){
+		"missing endpoint":    func(m *wallpaper.APIMapping) { m.Endpoint = "" },
+		"unknown placeholder": func(m *wallpaper.APIMapping) { m.Endpoint = "https://api.example.com/{offset}" },
+		"not http":            func(m *wallpaper.APIMapping) { m.Endpoint = "ftp://api.example.com/list" },
+		"missing search term": func(m *wallpaper.APIMapping) { m.Query = "" },
+		"missing ID path":     func(m *wallpaper.APIMapping) { m.ID = "" },
+		"missing image path":  func(m *wallpaper.APIMapping) { m.Path = " " },
+		"bad title path":      func(m *wallpaper.APIMapping) { m.Title = "a..b" },
+		"bad header":          func(m *wallpaper.APIMapping) { m.Headers = map[string]string{"Bad Name": "x"} },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := valid
+			mutate(&m)
+			assert.Error(t, validateMapping(m))
+		})
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := parseHeaders("Authorization: Bearer abc:def; Accept-Language: en")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer abc:def", "Accept-Language": "en"}, headers)
+
+	headers, err = parseHeaders("  ")
+	require.NoError(t, err)
+	assert.Nil(t, headers)
+
+	_, err = parseHeaders("no colon here")
+	assert.Error(t, err)
+}
+
+func TestFetchPage(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+		assert.Equal(t, "aurora borealis", r.URL.Query().Get("q"))
+		if r.URL.Query().Get("page") != "1" {
+			_, _ = w.Write([]byte(`{"data":{"items":[]}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"items":[
+			{"id":9007199254740993,"title":"Aurora","user":{"name":"Ann"},"files":[{"href":"/img/1.jpg","w":"6000","h":4000.0}]},
+			{"id":"abc/2","files":[{"href":"https://cdn.example.com/2.png"}]},
+			{"title":"No ID","files":[{"href":"/img/3.jpg"}]},
+			{"id":"4","files":[{"href":"javascript:alert(1)"}]},
+			{"id":"5","files":[]}
+		]}}`))
+	}))
+	defer ts.Close()
+
+	m := wallpaper.APIMapping{
+		Endpoint: ts.URL + "/search?q={query}&page={page}",
+		Query:    "aurora borealis",
+		Headers:  map[string]string{"X-Api-Key": "secret"},
+		Results:  "data.items",
+		ID:       "id",
+		Path:     "files[0].href",
+		Title:    "title",
+		Artist:   "user.name",
+		Width:    "files.0.w",
+		Height:   "files[0].h",
+	}
+	p := NewProvider(nil, ts.Client())
+	images, err := p.fetchPage(context.Background(), m, 1)
+	require.NoError(t, err)
+	require.Len(t, images, 2, "results without an ID or a usable image URL are skipped")
+
+	img := images[0]
+	assert.Equal(t, "CustomAPI_127-0-0-1_9007199254740993", img.ID, "large numeric IDs stay exact")
+	assert.Equal(t, ts.URL+"/img/1.jpg", img.Path)
+	assert.Equal(t, "Aurora", img.Title)
+	assert.Equal(t, "Ann", img.Artist)
+	assert.Equal(t, "Ann", img.Attribution)
+	assert.Equal(t, 6000, img.Width)
+	assert.Equal(t, 4000, img.Height)
+	assert.Equal(t, "CustomAPI_127-0-0-1_abc-2", images[1].ID)
+	assert.Equal(t, "https://cdn.example.com/2.png", images[1].Path)
+
+	images, err = p.fetchPage(context.Background(), m, 2)
+	require.NoError(t, err)
+	assert.Empty(t, images)
+
+	assert.NoError(t, p.testMapping(context.Background(), m))
+
+	m.Results = "data"
+	_, err = p.fetchPage(context.Background(), m, 1)
+	assert.ErrorContains(t, err, "does not point to an array")
+
+	m.Results = "data.items"
+	m.Path = "missing"
+	assert.ErrorContains(t, p.testMapping(context.Background(), m), "check the paths")
+}
@@ -0,0 +1,249 @@
+package customapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/wallpaper"
+)
+
+// step is one element of a path expression: an object key or an array index.
+type step struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parsePath parses a path expression such as "data.items", "$.results" or "urls[0].full".
+// A leading "$" is optional. Numeric keys also index arrays, so "tags.0" and "tags[0]" are equivalent.
+// An empty expression selects the value itself.
+func parsePath(expr string) ([]step, error) {
+	orig := expr
+	expr = strings.TrimSpace(expr)
+	expr = strings.TrimPrefix(expr, "$")
+	expr = strings.TrimPrefix(expr, ".")
+
+	var steps []step
+	for i := 0; i < len(expr); {
+		switch expr[i] {
+		case '[':
+			end := strings.IndexByte(expr[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("missing ] in %q", orig)
+			}
+			n, err := strconv.Atoi(expr[i+1 : i+end])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid array index in %q", orig)
+			}
+			steps = append(steps, step{index: n, isIndex: true})
+			i += end + 1
+		case '.', ']':
+			return nil, fmt.Errorf("empty field name in %q", orig)
+		default:
+			j := i
+			for j < len(expr) && expr[j] != '.' && expr[j] != '[' && expr[j] != ']' {
+				j++
+			}
+			steps = append(steps, step{key: expr[i:j]})
+			i = j
+		}
+
+		if i < len(expr) {
+			switch expr[i] {
+			case '.':
+				i++
+				if i == len(expr) {
+					return nil, fmt.Errorf("empty field name in %q", orig)
+				}
+			case '[':
+			default:
+				return nil, fmt.Errorf("unexpected %q in %q", expr[i], orig)
+			}
+		}
+	}
+	return steps, nil
+}
+
+// lookup follows a parsed path through a decoded JSON document.
+func lookup(v any, steps []step) (any, bool) {
+	for _, s := range steps {
+		switch cur := v.(type) {
+		case map[string]any:
+			if s.isIndex {
+				return nil, false
+			}
+			next, ok := cur[s.key]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []any:
+			idx := s.index
+			if !s.isIndex {
+				n, err := strconv.Atoi(s.key)
+				if err != nil {
+					return nil, false
+				}
+				idx = n
+			}
+			if idx >= len(cur) {
+				return nil, false
+			}
+			v = cur[idx]
+		default:
+			return nil, false
+		}
+	}
+	return v, v != nil
+}
+
+// asString converts a string or number to a string. Numbers keep their exact digits.
+func asString(v any) (string, bool) {
+	switch val := v.(type) {
+	case string:
+		val = strings.TrimSpace(val)
+		return val, val != ""
+	case json.Number:
+		return val.String(), true
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// asInt converts a number or numeric string to an int.
+func asInt(v any) (int, bool) {
+	s, ok := asString(v)
+	if !ok {
+		return 0, false
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, true
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return int(f), true
+	}
+	return 0, false
+}
+
+// compiledMapping is an APIMapping with its path expressions parsed.
+type compiledMapping struct {
+	results, id, path, title, artist, width, height []step
+}
+
+func compile(m wallpaper.APIMapping) (*compiledMapping, error) {
+	if strings.TrimSpace(m.ID) == "" {
+		return nil, errors.New("the ID path is required")
+	}
+	if strings.TrimSpace(m.Path) == "" {
+		return nil, errors.New("the image URL path is required")
+	}
+
+	cm := &compiledMapping{}
+	fields := []struct {
+		expr string
+		dst  *[]step
+	}{
+		{m.Results, &cm.results},
+		{m.ID, &cm.id},
+		{m.Path, &cm.path},
+		{m.Title, &cm.title},
+		{m.Artist, &cm.artist},
+		{m.Width, &cm.width},
+		{m.Height, &cm.height},
+	}
+	for _, f := range fields {
+		steps, err := parsePath(f.expr)
+		if err != nil {
+			return nil, err
+		}
+		*f.dst = steps
+	}
+	return cm, nil
+}
+
+var placeholderRegex = regexp.MustCompile(`\{[^{}]*\}`)
+
+// validateMapping checks the endpoint template, headers and path expressions of a mapping.
+func validateMapping(m wallpaper.APIMapping) error {
+	if err := validateEndpoint(m.Endpoint); err != nil {
+		return err
+	}
+	if strings.Contains(m.Endpoint, QueryPlaceholder) && strings.TrimSpace(m.Query) == "" {
+		return errors.New("the endpoint uses {query}, so a search term is required")
+	}
+	for name := range m.Headers {
+		if !headerNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+	}
+	_, err := compile(m)
+	return err
+}
+
+// validateEndpoint checks that an endpoint template is an http(s) URL with only known placeholders.
+func validateEndpoint(template string) error {
+	template = strings.TrimSpace(template)
+	if template == "" {
+		return errors.New("the endpoint URL is required")
+	}
+	for _, ph := range placeholderRegex.FindAllString(template, -1) {
+		if ph != PagePlaceholder && ph != QueryPlaceholder {
+			return fmt.Errorf("unknown placeholder %s; use {page} or {query}", ph)
+		}
+	}
+	u, err := url.Parse(expandEndpoint(template, "query", 1))
+	if err != nil {
+		return fmt.Errorf("invalid endpoint URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("the endpoint must be an http:// or https:// URL")
+	}
+	return nil
+}
+
+// expandEndpoint fills in the {page} and {query} placeholders of an endpoint template.
+func expandEndpoint(template, query string, page int) string {
+	return strings.ReplaceAll(queryURL(template, query), PagePlaceholder, strconv.Itoa(page))
+}
+
+// queryURL fills in {query} only. The result identifies the query, so the same endpoint
+// can be added once per search term.
+func queryURL(template, query string) string {
+	if !strings.Contains(template, QueryPlaceholder) {
+		return template
+	}
+	escaped := url.PathEscape(query)
+	if q := strings.IndexByte(template, '?'); q >= 0 && q < strings.Index(template, QueryPlaceholder) {
+		escaped = url.QueryEscape(query)
+	}
+	return strings.ReplaceAll(template, QueryPlaceholder, escaped)
+}
+
+var headerNameRegex = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// parseHeaders parses "Name: value" pairs separated by semicolons or new lines.
+func parseHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, line := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == '\n' || r == '\r' }) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || !headerNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("invalid header %q; use Name: value", line)
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	return headers, nil
+}
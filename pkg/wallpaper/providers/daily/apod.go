@@ -0,0 +1,183 @@
+package daily
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/schema"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/setting"
+)
+
+// apodSource fetches NASA's Astronomy Picture of the Day.
+type apodSource struct {
+	apiURL string
+	apiKey string // Overrides the configured key (tests)
+}
+
+// apodEntry is one day of the APOD API (https://github.com/nasa/apod-api).
+type apodEntry struct {
+	Date      string `json:"date"`
+	Title     string `json:"title"`
+	URL       string `json:"url"`
+	HDURL     string `json:"hdurl"`
+	MediaType string `json:"media_type"`
+	Copyright string `json:"copyright"`
+}
+
+var apodURLRegex = regexp.MustCompile(`^https?://apod\.nasa\.gov/apod/`)
+
+func (s *apodSource) id() string         { return "APOD" }
+func (s *apodSource) name() string       { return i18n.T("NASA Astronomy Picture of the Day") }
+func (s *apodSource) title() string      { return "APOD" }
+func (s *apodSource) homeURL() string    { return APODHomeURL }
+func (s *apodSource) builtinURL() string { return APODHomeURL }
+
+func (s *apodSource) parseURL(webURL string) (string, error) {
+	if _, err := matchURL(apodURLRegex, webURL); err != nil {
+		return "", err
+	}
+	return APODHomeURL, nil
+}
+
+// fetch requests the whole page in a single date-range call. APOD publishes on US Eastern time, so
+// early in the day (or east of the US) today's entry may not exist yet; the API then rejects the
+// range and it is retried ending one day earlier.
+func (s *apodSource) fetch(ctx context.Context, p *Provider, queryURL string, days []time.Time) ([]provider.Image, error) {
+	apiKey := s.apiKey
+	if apiKey == "" && p.cfg != nil {
+		apiKey = p.cfg.GetNASAAPIKey()
+	}
+	if apiKey == "" {
+		apiKey = APODDemoAPIKey
+	}
+
+	start, end := days[len(days)-1], days[0]
+	var entries []apodEntry
+	for {
+		params := url.Values{}
+		params.Set("api_key", apiKey)
+		params.Set("start_date", start.Format(dayLayout))
+		params.Set("end_date", end.Format(dayLayout))
+
+		entries = nil
+		err := p.getJSON(ctx, s.apiURL+"?"+params.Encode(), &entries)
+		if err == nil {
+			break
+		}
+
+		var se *statusError
+		if !errors.As(err, &se) {
+			return nil, err
+		}
+		switch {
+		case se.status == http.StatusBadRequest && end.Equal(days[0]) && end.After(start):
+			end = end.AddDate(0, 0, -1)
+			continue
+		case se.status == http.StatusTooManyRequests:
+			return nil, errors.New("NASA API rate limit exceeded (add your own API key to raise the limit)")
+		case se.status == http.StatusForbidden:
+			return nil, errors.New("NASA API rejected the API key")
+		}
+		return nil, err
+	}
+
+	var images []provider.Image
+	for _, e := range entries {
+		if e.MediaType != "image" {
+			continue // Videos and interactive pages cannot be used as wallpapers
+		}
+		day, err := time.ParseInLocation(dayLayout, e.Date, time.Local)
+		if err != nil {
+			continue
+		}
+		path := e.HDURL
+		if path == "" {
+			path = e.URL
+		}
+		if path == "" {
+			continue
+		}
+
+		credit := collapseSpace(e.Copyright)
+		if credit == "" {
+			credit = "NASA"
+		}
+		images = append(images, provider.Image{
+			ID:          day.Format(dayLayout),
+			Path:        path,
+			ViewURL:     fmt.Sprintf(APODPageURL, day.Format("060102")),
+			Title:       e.Title,
+			Attribution: credit,
+			Artist:      credit,
+		})
+	}
+	return images, nil
+}
+
+// CheckNASAAPIKeyWithContext verifies if the given api.nasa.gov key is valid using the provided context.
+func CheckNASAAPIKeyWithContext(ctx context.Context, apiKey string) error {
+	if apiKey == "" {
+		return errors.New("NASA API key is empty")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, APODAPIURL+"?api_key="+url.QueryEscape(apiKey), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", DailyUserAgent)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.New("network error while contacting api.nasa.gov")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return errors.New("invalid NASA API key")
+	}
+	return fmt.Errorf("NASA API verification failed (status %d)", resp.StatusCode)
+}
+
+// --- UI Implementation (Pure Go) ---
+
+const nasaAPIKeyIdent = "nasaAPIKey"
+
+// settingsItems returns the API key setting. A personal key raises the DEMO_KEY limits.
+func (s *apodSource) settingsItems(p *Provider, sm setting.SettingsManager) []schema.ItemSchema {
+	return []schema.ItemSchema{
+		schema.SecretItem{
+			Name:         nasaAPIKeyIdent,
+			Label:        i18n.T("NASA API Key (optional):"),
+			InitialValue: p.cfg.GetNASAAPIKey(),
+			Placeholder:  i18n.T("Enter your NASA API Key"),
+			OnVerify: func(key string) error {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				return CheckNASAAPIKeyWithContext(ctx, key)
+			},
+			ApplyFunc: func(key string) {
+				p.cfg.SetNASAAPIKey(key)
+			},
+			OnClear: func() {
+				p.cfg.SetNASAAPIKey("")
+				sm.ResetSettings(
+					setting.SettingReset{Name: nasaAPIKeyIdent, Value: ""},
+				)
+			},
+		},
+		schema.HyperlinkItem{
+			Text: i18n.T("Without a key, NASA's shared demo key is used. Get a free key for higher limits."),
+			URL:  "https://api.nasa.gov/",
+		},
+	}
+}
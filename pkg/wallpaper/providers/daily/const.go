@@ -0,0 +1,42 @@
+package daily
+
+import "time"
+
+const (
+	// APODAPIURL is NASA's Astronomy Picture of the Day API.
+	APODAPIURL = "https://api.nasa.gov/planetary/apod"
+	// APODHomeURL is the APOD website; it also serves as the built-in APOD query URL.
+	APODHomeURL = "https://apod.nasa.gov/apod/astropix.html"
+	// APODPageURL is the archive page of a single APOD day (date formatted as yymmdd).
+	APODPageURL = "https://apod.nasa.gov/apod/ap%s.html"
+	// APODDemoAPIKey is used when no personal key is configured. It allows 30 requests per hour and 50 per day.
+	APODDemoAPIKey = "DEMO_KEY" //nolint:gosec // Public demo key documented by api.nasa.gov
+
+	// POTDAPIURL is the Wikimedia feed API that returns the featured content of a day, including the Commons Picture of the Day.
+	POTDAPIURL = "https://api.wikimedia.org/feed/v1/wikipedia/en/featured"
+	// POTDHomeURL is the Commons Picture of the Day page; it also serves as the built-in POTD query URL.
+	POTDHomeURL = "https://commons.wikimedia.org/wiki/Commons:Picture_of_the_day"
+
+	// DailyFeedURLRegexp validates daily JSON endpoints. Placeholders such as {date} are allowed anywhere.
+	DailyFeedURLRegexp = `^(?i)https?://[^\s/$.?#][^\s]*$`
+
+	// DailyUserAgent identifies Spice to the APIs. Wikimedia rejects requests without a descriptive User-Agent.
+	DailyUserAgent = "Spice-Wallpaper-App/1.0 (https://github.com/dixieflatline76/Spice)"
+
+	// DailyDaysPerPage is the number of days covered by one page of a daily provider.
+	DailyDaysPerPage = 7
+	// DailyArchiveDays is how far back the rolling archive reaches. Pages beyond it are empty, which
+	// makes the fetch logic wrap back to page 1 (today).
+	DailyArchiveDays = 28
+
+	// DailyMaxBodyBytes caps the size of a single API response.
+	DailyMaxBodyBytes = 4 << 20 // 4 MiB
+
+	// DailyAPIPacing spaces out API calls. One call covers a whole page, except for POTD (one call per day).
+	DailyAPIPacing = 2 * time.Second
+	// DailyMediaPacing spaces out image downloads.
+	DailyMediaPacing = 500 * time.Millisecond
+
+	// dayLayout formats a calendar day. Every image ID ends with its day in this format.
+	dayLayout = "2006-01-02"
+)
@@ -0,0 +1,430 @@
+package daily
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/schema"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/setting"
+	"github.com/dixieflatline76/Spice/v2/pkg/wallpaper"
+	"github.com/dixieflatline76/Spice/v2/util/log"
+)
+
+//go:embed Daily.png
+var iconData []byte
+
+// source is a publisher of one canonical image per day.
+type source interface {
+	id() string
+	name() string
+	title() string
+	homeURL() string
+
+	// builtinURL is the only query URL of sources that publish a single image stream (APOD, POTD).
+	// It is empty for sources where users add their own endpoints.
+	builtinURL() string
+
+	// parseURL validates a query URL for this source.
+	parseURL(webURL string) (string, error)
+
+	// fetch returns the images published on the given days, which are ordered newest first.
+	// Days without an image (e.g. an APOD video) are simply missing from the result.
+	fetch(ctx context.Context, p *Provider, queryURL string, days []time.Time) ([]provider.Image, error)
+}
+
+// Provider implements ImageProvider and DailyProvider for a daily image source.
+// Page 1 holds the most recent DailyDaysPerPage days and later pages walk back through the archive.
+type Provider struct {
+	cfg        *wallpaper.Config
+	httpClient *http.Client
+	src        source
+	now        func() time.Time
+}
+
+func init() {
+	wallpaper.RegisterProvider("APOD", func(cfg *wallpaper.Config, client *http.Client) provider.ImageProvider {
+		return NewAPODProvider(cfg, client)
+	})
+	wallpaper.RegisterProvider("CommonsPOTD", func(cfg *wallpaper.Config, client *http.Client) provider.ImageProvider {
+		return NewPOTDProvider(cfg, client)
+	})
+	wallpaper.RegisterProvider("DailyFeed", func(cfg *wallpaper.Config, client *http.Client) provider.ImageProvider {
+		return NewFeedProvider(cfg, client)
+	})
+}
+
+// NewAPODProvider creates a provider for NASA's Astronomy Picture of the Day.
+func NewAPODProvider(cfg *wallpaper.Config, client *http.Client) *Provider {
+	return newProvider(cfg, client, &apodSource{apiURL: APODAPIURL})
+}
+
+// NewPOTDProvider creates a provider for the Wikimedia Commons Picture of the Day.
+func NewPOTDProvider(cfg *wallpaper.Config, client *http.Client) *Provider {
+	return newProvider(cfg, client, &potdSource{apiURL: POTDAPIURL})
+}
+
+// NewFeedProvider creates a provider for user-configured daily JSON endpoints (e.g. Bing-style image archives).
+func NewFeedProvider(cfg *wallpaper.Config, client *http.Client) *Provider {
+	return newProvider(cfg, client, &feedSource{})
+}
+
+func newProvider(cfg *wallpaper.Config, client *http.Client, src source) *Provider {
+	return &Provider{
+		cfg:        cfg,
+		httpClient: client,
+		src:        src,
+		now:        time.Now,
+	}
+}
+
+func (p *Provider) ID() string {
+	return p.src.id()
+}
+
+func (p *Provider) Name() string {
+	return p.src.name()
+}
+
+func (p *Provider) Title() string {
+	return p.src.title()
+}
+
+func (p *Provider) GetProviderIcon() interface{} {
+	return iconData
+}
+
+func (p *Provider) Type() provider.ProviderType {
+	return provider.TypeCommunity
+}
+
+func (p *Provider) HomeURL() string {
+	return p.src.homeURL()
+}
+
+func (p *Provider) GetAttributionType() provider.AttributionType {
+	return provider.AttributionBy
+}
+
+// SupportsUserQueries returns false for single-stream sources (APOD, POTD), which are simply switched on or off.
+func (p *Provider) SupportsUserQueries() bool {
+	return p.src.builtinURL() == ""
+}
+
+// AcceptsAnyURL implements the CatchAllProvider interface: any http(s) URL may be a daily JSON endpoint.
+func (p *Provider) AcceptsAnyURL() bool {
+	return p.src.builtinURL() == ""
+}
+
+func (p *Provider) ParseURL(webURL string) (string, error) {
+	return p.src.parseURL(strings.TrimSpace(webURL))
+}
+
+// GetAPIPacing implements the PacedProvider interface to space out API requests.
+func (p *Provider) GetAPIPacing() time.Duration {
+	return DailyAPIPacing
+}
+
+// GetProcessPacing implements the PacedProvider interface to space out image downloads.
+func (p *Provider) GetProcessPacing() time.Duration {
+	return DailyMediaPacing
+}
+
+// GetDownloadHeaders implements HeaderProvider. Wikimedia's media servers reject anonymous clients.
+func (p *Provider) GetDownloadHeaders() map[string]string {
+	return map[string]string{
+		"User-Agent": DailyUserAgent,
+	}
+}
+
+// FetchImages returns the images of the days covered by the given page.
+// Pages beyond the rolling archive are empty, which wraps the fetch logic back to today.
+func (p *Provider) FetchImages(ctx context.Context, apiURL string, page int) ([]provider.Image, error) {
+	days := pageDays(page, p.now())
+	if len(days) == 0 {
+		return nil, nil
+	}
+	images, err := p.src.fetch(ctx, p, apiURL, days)
+	if err != nil {
+		return nil, err
+	}
+	for i := range images {
+		images[i].Provider = p.ID()
+	}
+	log.Debugf("[%s] Page %d (%s to %s): %d images", p.ID(), page,
+		days[len(days)-1].Format(dayLayout), days[0].Format(dayLayout), len(images))
+	return images, nil
+}
+
+func (p *Provider) EnrichImage(ctx context.Context, img provider.Image) (provider.Image, error) {
+	return img, nil
+}
+
+// ImageDay implements the DailyProvider interface. Every image ID ends with its day (YYYY-MM-DD).
+func (p *Provider) ImageDay(img provider.Image) (time.Time, bool) {
+	if len(img.ID) < len(dayLayout) {
+		return time.Time{}, false
+	}
+	day, err := time.ParseInLocation(dayLayout, img.ID[len(img.ID)-len(dayLayout):], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return day, true
+}
+
+// pageDays returns the local calendar days covered by a page, newest first.
+func pageDays(page int, now time.Time) []time.Time {
+	if page < 1 {
+		page = 1
+	}
+	first := (page - 1) * DailyDaysPerPage
+	if first >= DailyArchiveDays {
+		return nil
+	}
+	last := min(first+DailyDaysPerPage, DailyArchiveDays)
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	days := make([]time.Time, 0, last-first)
+	for i := first; i < last; i++ {
+		days = append(days, today.AddDate(0, 0, -i))
+	}
+	return days
+}
+
+// statusError is returned for non-200 API responses.
+type statusError struct {
+	status int
+	body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.status, e.body)
+}
+
+// getJSON performs a GET request and decodes the JSON response into v.
+func (p *Provider) getJSON(ctx context.Context, rawURL string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", DailyUserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		// url.Error repeats the full URL, API key included.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("fetching %s: %w", redact(rawURL), err)
+	}
+	defer resp.Body.Close()
+
+	body := io.LimitReader(resp.Body, DailyMaxBodyBytes)
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(body, 500))
+		return &statusError{status: resp.StatusCode, body: strings.TrimSpace(string(snippet))}
+	}
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// redact hides API keys in URLs before they are logged.
+func redact(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	if q.Has("api_key") {
+		q.Set("api_key", "REDACTED")
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
+
+// collapseSpace turns multi-line credits (APOD copyrights contain newlines) into a single line.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+var errInvalidURL = errors.New("invalid daily image URL")
+
+// matchURL validates a URL against a pattern and returns it unchanged.
+func matchURL(pattern *regexp.Regexp, webURL string) (string, error) {
+	if !pattern.MatchString(webURL) {
+		return "", errInvalidURL
+	}
+	return webURL, nil
+}
+
+// --- UI Implementation (Pure Go) ---
+
+// builtinQueryID is the ID the built-in query of a single-stream source gets in the config.
+func (p *Provider) builtinQueryID() string {
+	return wallpaper.GenerateQueryID(p.ID() + ":" + p.src.builtinURL())
+}
+
+// isBuiltinActive reports whether the built-in query exists and is active.
+func (p *Provider) isBuiltinActive() bool {
+	q, ok := p.cfg.GetQuery(p.builtinQueryID())
+	return ok && q.Active
+}
+
+// setBuiltinActive switches the built-in query on or off, creating it the first time it is enabled.
+func (p *Provider) setBuiltinActive(active bool) {
+	id := p.builtinQueryID()
+	var err error
+	if _, ok := p.cfg.GetQuery(id); !ok {
+		if active {
+			_, err = p.cfg.AddDailyQuery(p.Name(), p.src.builtinURL(), p.ID(), true)
+		}
+	} else if active {
+		err = p.cfg.EnableImageQuery(id)
+	} else {
+		err = p.cfg.DisableImageQuery(id)
+	}
+	if err != nil {
+		log.Printf("[%s] Failed to update daily image query: %v", p.ID(), err)
+	}
+}
+
+// CreateSettingsPanel returns the declarative UI for the daily provider settings.
+func (p *Provider) CreateSettingsPanel(sm setting.SettingsManager) *schema.PanelSchema {
+	items := []schema.ItemSchema{
+		schema.LabelItem{
+			Text:       p.description(),
+			Importance: schema.ImportanceLow,
+		},
+	}
+	if src, ok := p.src.(*apodSource); ok {
+		items = append(items, src.settingsItems(p, sm)...)
+	}
+
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:   p.Name(),
+				Compact: true,
+				Items:   items,
+			},
+		},
+	}
+}
+
+func (p *Provider) description() string {
+	switch p.src.(type) {
+	case *apodSource:
+		return i18n.T("Each day NASA features a different image of our universe, along with a brief explanation written by a professional astronomer.")
+	case *potdSource:
+		return i18n.T("Each day the Wikimedia Commons community features one of its finest freely licensed images.")
+	default:
+		return i18n.T("Use any JSON endpoint that publishes one image per day, such as the Bing image archive.")
+	}
+}
+
+// CreateQueryPanel creates the image query management panel.
+func (p *Provider) CreateQueryPanel(sm setting.SettingsManager, pendingUrl string) *schema.PanelSchema {
+	if p.src.builtinURL() != "" {
+		return p.createBuiltinQueryPanel()
+	}
+
+	addCfg := schema.AddQueryConfig{
+		Title:           i18n.T("Add Daily Feed"),
+		Description:     i18n.T("Paste a JSON endpoint. Use {date} for endpoints that return a single day, or {offset} and {count} for archives."),
+		URLPlaceholder:  "https://www.bing.com/HPImageArchive.aspx?format=js&idx={offset}&n={count}",
+		URLValidator:    DailyFeedURLRegexp,
+		URLErrorMsg:     i18n.T("Invalid daily feed URL"),
+		DescPlaceholder: i18n.T("Query Description (e.g. Bing)"),
+		AddHandler: func(desc, url string, active bool) (string, error) {
+			feedURL, err := p.ParseURL(url)
+			if err != nil {
+				return "", err
+			}
+			return p.cfg.AddDailyQuery(desc, feedURL, p.ID(), active)
+		},
+	}
+
+	if pendingUrl != "" {
+		sm.ShowAddQueryDialog(addCfg, pendingUrl, "", sm.RefreshUI)
+	}
+
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:       i18n.T("My Daily Feeds"),
+				Description: i18n.T("Manage your daily feeds here."),
+				Items: []schema.ItemSchema{
+					schema.ButtonItem{
+						Name:       "dailyfeed_add",
+						ButtonText: i18n.T("Add Daily Feed"),
+						IconName:   "add",
+						OnPressed: func() {
+							sm.ShowAddQueryDialog(addCfg, "", "", sm.RefreshUI)
+						},
+					},
+					schema.QueryListItem{
+						GetQueries: func() []schema.Query {
+							queries := p.cfg.GetDailyQueries(p.ID())
+							abstracts := make([]schema.Query, len(queries))
+							for i, q := range queries {
+								abstracts[i] = schema.Query{
+									ID:          q.ID,
+									URL:         q.URL,
+									Description: q.Description,
+									Active:      q.Active,
+									Managed:     q.Managed,
+								}
+							}
+							return abstracts
+						},
+						EnableQuery:  p.cfg.EnableImageQuery,
+						DisableQuery: p.cfg.DisableImageQuery,
+						RemoveQuery:  p.cfg.RemoveImageQuery,
+						GetDisplayURL: func(q schema.Query) *url.URL {
+							u, _ := url.Parse(q.URL)
+							return u
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (p *Provider) createBuiltinQueryPanel() *schema.PanelSchema {
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title: i18n.T("Daily Image"),
+				Items: []schema.ItemSchema{
+					schema.BoolItem{
+						Name:         strings.ToLower(p.ID()) + "_enabled",
+						Label:        i18n.T("Download the image of the day:"),
+						Help:         i18n.T("Adds today's image and those of the previous four weeks to the rotation. To show only today's image on a display, choose \"Pin Today's Image\" from its tray menu."),
+						InitialValue: p.isBuiltinActive(),
+						ApplyFunc:    p.setBuiltinActive,
+						NeedsRefresh: true,
+					},
+					schema.HyperlinkItem{
+						Text: i18n.Tf("Visit {{.Name}}", map[string]any{"Name": p.Name()}),
+						URL:  p.src.homeURL(),
+					},
+				},
+			},
+		},
+	}
+}
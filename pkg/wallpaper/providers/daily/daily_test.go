@@ -0,0 +1,191 @@
+package daily
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedNow is mid-afternoon on 2026-10-16, local time.
+func fixedNow() time.Time {
+	return time.Date(2026, 10, 16, 15, 0, 0, 0, time.Local)
+}
+
+func newTestProvider(client *http.Client, src source) *Provider {
+	p := newProvider(nil, client, src)
+	p.now = fixedNow
+	return p
+}
+
+func TestPageDays(t *testing.T) {
+	page1 := pageDays(1, fixedNow())
+	require.Len(t, page1, DailyDaysPerPage)
+	assert.Equal(t, "2026-10-16", page1[0].Format(dayLayout), "page 1 starts today")
+	assert.Equal(t, "2026-10-10", page1[6].Format(dayLayout))
+
+	page2 := pageDays(2, fixedNow())
+	assert.Equal(t, "2026-10-09", page2[0].Format(dayLayout))
+
+	last := pageDays(DailyArchiveDays/DailyDaysPerPage, fixedNow())
+	assert.Equal(t, "2026-09-19", last[len(last)-1].Format(dayLayout))
+
+	assert.Empty(t, pageDays(DailyArchiveDays/DailyDaysPerPage+1, fixedNow()), "pages beyond the archive are empty")
+}
+
+func TestImageDay(t *testing.T) {
+	p := NewFeedProvider(nil, http.DefaultClient)
+
+	day, ok := p.ImageDay(provider.Image{ID: "DailyFeed_ab12cd34_2026-10-16"})
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local), day)
+
+	_, ok = p.ImageDay(provider.Image{ID: "Pexels_12345"})
+	assert.False(t, ok)
+}
+
+func TestAPOD_FetchImages(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		requests = append(requests, q.Get("start_date")+".."+q.Get("end_date"))
+		assert.Equal(t, "test-key", q.Get("api_key"))
+
+		// Today's APOD is not out yet in the US.
+		if q.Get("end_date") == "2026-10-16" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":400,"msg":"Date must be between Jun 16, 1995 and Oct 15, 2026."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"date":"2026-10-14","title":"A Comet","url":"https://apod.nasa.gov/apod/image/comet_small.jpg","hdurl":"https://apod.nasa.gov/apod/image/comet.jpg","media_type":"image","copyright":"\nJane\nDoe\n"},
+			{"date":"2026-10-15","title":"A Video","url":"https://www.youtube.com/embed/xyz","media_type":"video"}
+		]`))
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.Client(), &apodSource{apiURL: ts.URL, apiKey: "test-key"})
+	images, err := p.FetchImages(context.Background(), APODHomeURL, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"2026-10-10..2026-10-16", "2026-10-10..2026-10-15"}, requests, "the range is retried ending yesterday")
+	require.Len(t, images, 1, "videos are skipped")
+	img := images[0]
+	assert.Equal(t, "2026-10-14", img.ID)
+	assert.Equal(t, "APOD", img.Provider)
+	assert.Equal(t, "https://apod.nasa.gov/apod/image/comet.jpg", img.Path)
+	assert.Equal(t, "https://apod.nasa.gov/apod/ap261014.html", img.ViewURL)
+	assert.Equal(t, "Jane Doe", img.Attribution)
+	assert.Equal(t, "A Comet", img.Title)
+}
+
+func TestAPOD_RateLimited(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.Client(), &apodSource{apiURL: ts.URL, apiKey: "test-key"})
+	_, err := p.FetchImages(context.Background(), APODHomeURL, 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rate limit")
+}
+
+func TestPOTD_FetchImages(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, DailyUserAgent, r.Header.Get("User-Agent"))
+		switch r.URL.Path {
+		case "/2026/10/16":
+			http.NotFound(w, r)
+		case "/2026/10/15":
+			_, _ = w.Write([]byte(`{"image":{
+				"title":"File:Lighthouse at dusk.jpg",
+				"image":{"source":"https://upload.wikimedia.org/lighthouse.jpg","width":6000,"height":4000},
+				"file_page":"https://commons.wikimedia.org/wiki/File:Lighthouse_at_dusk.jpg",
+				"artist":{"text":"John Smith"},
+				"description":{"text":""}
+			}}`))
+		default:
+			_, _ = w.Write([]byte(`{"tfa":{}}`))
+		}
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.Client(), &potdSource{apiURL: ts.URL})
+	images, err := p.FetchImages(context.Background(), POTDHomeURL, 1)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	assert.Equal(t, "2026-10-15", images[0].ID)
+	assert.Equal(t, "Lighthouse at dusk", images[0].Title)
+	assert.Equal(t, "John Smith", images[0].Attribution)
+	assert.Equal(t, 6000, images[0].Width)
+}
+
+func TestFeed_BingArchive(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "7", r.URL.Query().Get("idx"), "page 2 starts a week back")
+		assert.Equal(t, "7", r.URL.Query().Get("n"))
+		_, _ = w.Write([]byte(`{"images":[
+			{"startdate":"20261009","url":"/th?id=OHR.Fox_1920x1080.jpg","urlbase":"/th?id=OHR.Fox","copyright":"Red fox (© Someone/Getty Images)","copyrightlink":"https://www.bing.com/search?q=fox","title":"Curious fox"},
+			{"startdate":"20261008","url":"/th?id=OHR.Owl_1920x1080.jpg","title":"Owl"},
+			{"startdate":"20261016","url":"/th?id=OHR.Today_1920x1080.jpg","title":"Not on this page"}
+		]}`))
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.Client(), &feedSource{})
+	feedURL := ts.URL + "/HPImageArchive.aspx?format=js&idx={offset}&n={count}"
+	images, err := p.FetchImages(context.Background(), feedURL, 2)
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+
+	assert.True(t, strings.HasSuffix(images[0].ID, "_2026-10-09"))
+	assert.Equal(t, ts.URL+"/th?id=OHR.Fox_UHD.jpg", images[0].Path)
+	assert.Equal(t, "https://www.bing.com/search?q=fox", images[0].ViewURL)
+	assert.Equal(t, "Red fox (© Someone/Getty Images)", images[0].Attribution)
+	assert.Equal(t, "Curious fox", images[0].Title)
+	assert.Equal(t, ts.URL+"/th?id=OHR.Owl_1920x1080.jpg", images[1].Path)
+	assert.NotEqual(t, images[0].ID, "2026-10-09", "feed IDs are prefixed with the endpoint hash")
+}
+
+func TestFeed_DateTemplate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("day") != "2026-10-16" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"image":"https://cdn.example.com/today.jpg","credit":"Example"}`))
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.Client(), &feedSource{})
+	images, err := p.FetchImages(context.Background(), ts.URL+"/potd?day={date}", 1)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	assert.True(t, strings.HasSuffix(images[0].ID, "_2026-10-16"), "entries without a date take the requested day")
+	assert.Equal(t, "https://cdn.example.com/today.jpg", images[0].Path)
+	assert.Equal(t, "Example", images[0].Attribution)
+}
+
+func TestParseURL(t *testing.T) {
+	apod := NewAPODProvider(nil, http.DefaultClient)
+	u, err := apod.ParseURL("https://apod.nasa.gov/apod/ap261014.html")
+	require.NoError(t, err)
+	assert.Equal(t, APODHomeURL, u)
+	_, err = apod.ParseURL("https://www.nasa.gov/")
+	assert.Error(t, err)
+	assert.False(t, apod.SupportsUserQueries())
+
+	feed := NewFeedProvider(nil, http.DefaultClient)
+	u, err = feed.ParseURL(" https://example.com/daily?date={date} ")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/daily?date={date}", u)
+	assert.True(t, feed.SupportsUserQueries())
+	assert.True(t, feed.AcceptsAnyURL())
+}
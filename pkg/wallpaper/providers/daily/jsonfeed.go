@@ -0,0 +1,224 @@
+package daily
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+)
+
+// feedSource fetches user-configured JSON endpoints that publish one image per day.
+//
+// The endpoint URL may contain placeholders:
+//   - {date}: the endpoint returns a single day (YYYY-MM-DD) and is called once per day.
+//   - {offset}, {count}: the endpoint returns an archive window, newest first (Bing's idx and n).
+//
+// Without placeholders the endpoint is fetched as is and its entries are matched to the page's days.
+type feedSource struct{}
+
+// feedEntry covers the common field names of daily image APIs. The response may be an array of
+// entries, an object with an "images" array (Bing) or a single entry.
+type feedEntry struct {
+	URL           string `json:"url"`
+	HDURL         string `json:"hdurl"`
+	Image         string `json:"image"`
+	URLBase       string `json:"urlbase"`
+	Date          string `json:"date"`
+	StartDate     string `json:"startdate"`
+	Title         string `json:"title"`
+	Copyright     string `json:"copyright"`
+	Credit        string `json:"credit"`
+	Author        string `json:"author"`
+	Link          string `json:"link"`
+	CopyrightLink string `json:"copyrightlink"`
+}
+
+var feedURLRegex = regexp.MustCompile(DailyFeedURLRegexp)
+
+func (s *feedSource) id() string         { return "DailyFeed" }
+func (s *feedSource) name() string       { return i18n.T("Daily Image Feeds") }
+func (s *feedSource) title() string      { return "Daily Feeds" }
+func (s *feedSource) homeURL() string    { return "" }
+func (s *feedSource) builtinURL() string { return "" }
+
+func (s *feedSource) parseURL(webURL string) (string, error) {
+	return matchURL(feedURLRegex, webURL)
+}
+
+func (s *feedSource) fetch(ctx context.Context, p *Provider, queryURL string, days []time.Time) ([]provider.Image, error) {
+	// Several feeds can be active at once, so IDs carry a short hash of the endpoint before the day.
+	sum := sha256.Sum256([]byte(queryURL))
+	prefix := hex.EncodeToString(sum[:4]) + "_"
+
+	wanted := make(map[string]bool, len(days))
+	for _, day := range days {
+		wanted[day.Format(dayLayout)] = true
+	}
+
+	var images []provider.Image
+	seen := make(map[string]bool)
+	add := func(base *url.URL, entries []feedEntry, fallbackDay time.Time) {
+		for _, e := range entries {
+			img, ok := e.toImage(base, fallbackDay)
+			if !ok || !wanted[img.ID] || seen[img.ID] {
+				continue
+			}
+			seen[img.ID] = true
+			img.ID = prefix + img.ID
+			images = append(images, img)
+		}
+	}
+
+	if strings.Contains(queryURL, "{date}") {
+		for _, day := range days {
+			dayURL := strings.ReplaceAll(queryURL, "{date}", day.Format(dayLayout))
+			entries, base, err := s.get(ctx, p, dayURL)
+			if err != nil {
+				if ctx.Err() != nil {
+					return images, ctx.Err()
+				}
+				continue // Days without an entry are skipped, like APOD videos
+			}
+			add(base, entries, day)
+		}
+		return images, nil
+	}
+
+	now := p.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	windowURL := strings.NewReplacer(
+		"{offset}", strconv.Itoa(daysBetween(days[0], today)),
+		"{count}", strconv.Itoa(len(days)),
+	).Replace(queryURL)
+
+	entries, base, err := s.get(ctx, p, windowURL)
+	if err != nil {
+		return nil, err
+	}
+	add(base, entries, time.Time{})
+	return images, nil
+}
+
+// get fetches an endpoint and returns its entries and the URL relative image links resolve against.
+func (s *feedSource) get(ctx context.Context, p *Provider, rawURL string) ([]feedEntry, *url.URL, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid daily feed URL: %w", err)
+	}
+
+	var raw json.RawMessage
+	if err := p.getJSON(ctx, rawURL, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	raw = bytes.TrimSpace(raw)
+	var entries []feedEntry
+	switch {
+	case bytes.HasPrefix(raw, []byte("[")):
+		err = json.Unmarshal(raw, &entries)
+	default:
+		var wrapper struct {
+			Images []feedEntry `json:"images"`
+		}
+		if err = json.Unmarshal(raw, &wrapper); err == nil && len(wrapper.Images) > 0 {
+			entries = wrapper.Images
+		} else {
+			var single feedEntry
+			if err = json.Unmarshal(raw, &single); err == nil {
+				entries = []feedEntry{single}
+			}
+		}
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("unsupported daily feed format: %w", err)
+	}
+	return entries, base, nil
+}
+
+// toImage maps an entry to an image whose ID is its day. Entries without a date take fallbackDay.
+func (e feedEntry) toImage(base *url.URL, fallbackDay time.Time) (provider.Image, bool) {
+	day, ok := parseDay(firstNonEmpty(e.Date, e.StartDate))
+	if !ok {
+		if fallbackDay.IsZero() {
+			return provider.Image{}, false
+		}
+		day = fallbackDay
+	}
+
+	var imageURL string
+	if e.URLBase != "" {
+		imageURL = resolve(base, e.URLBase+"_UHD.jpg") // Bing serves every resolution from the same base
+	} else {
+		imageURL = resolve(base, firstNonEmpty(e.HDURL, e.URL, e.Image))
+	}
+	if imageURL == "" {
+		return provider.Image{}, false
+	}
+
+	credit := collapseSpace(firstNonEmpty(e.Copyright, e.Credit, e.Author))
+	viewURL := resolve(base, firstNonEmpty(e.CopyrightLink, e.Link))
+	if viewURL == "" {
+		viewURL = imageURL
+	}
+	return provider.Image{
+		ID:          day.Format(dayLayout),
+		Path:        imageURL,
+		ViewURL:     viewURL,
+		Title:       collapseSpace(firstNonEmpty(e.Title, e.Copyright)),
+		Attribution: credit,
+		Artist:      credit,
+	}, true
+}
+
+// parseDay accepts the date formats used by daily image APIs.
+func parseDay(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{dayLayout, "20060102"} {
+		if day, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return day, true
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		t = t.Local()
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local), true
+	}
+	return time.Time{}, false
+}
+
+// daysBetween counts calendar days from earlier to later, independent of DST changes.
+func daysBetween(earlier, later time.Time) int {
+	a := time.Date(earlier.Year(), earlier.Month(), earlier.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(later.Year(), later.Month(), later.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func resolve(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return u.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
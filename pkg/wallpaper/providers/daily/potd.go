@@ -0,0 +1,98 @@
+package daily
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+)
+
+// potdSource fetches the Wikimedia Commons Picture of the Day through the Wikimedia feed API.
+type potdSource struct {
+	apiURL string
+}
+
+// potdFeed is the part of the featured content feed we use
+// (https://api.wikimedia.org/wiki/Feed_API/Reference/Featured_content).
+type potdFeed struct {
+	Image *struct {
+		Title string `json:"title"`
+		Image struct {
+			Source string `json:"source"`
+			Width  int    `json:"width"`
+			Height int    `json:"height"`
+		} `json:"image"`
+		FilePage string `json:"file_page"`
+		Artist   struct {
+			Text string `json:"text"`
+		} `json:"artist"`
+		Description struct {
+			Text string `json:"text"`
+		} `json:"description"`
+	} `json:"image"`
+}
+
+var potdURLRegex = regexp.MustCompile(`^https?://commons\.wikimedia\.org/wiki/Commons:Picture_of_the_day`)
+
+func (s *potdSource) id() string         { return "CommonsPOTD" }
+func (s *potdSource) name() string       { return i18n.T("Wikimedia Commons Picture of the Day") }
+func (s *potdSource) title() string      { return "POTD" }
+func (s *potdSource) homeURL() string    { return POTDHomeURL }
+func (s *potdSource) builtinURL() string { return POTDHomeURL }
+
+func (s *potdSource) parseURL(webURL string) (string, error) {
+	if _, err := matchURL(potdURLRegex, webURL); err != nil {
+		return "", err
+	}
+	return POTDHomeURL, nil
+}
+
+// fetch requests each day separately; the feed API has no date ranges. A day that fails is skipped
+// (today's feed may not be published yet), but if every day fails the last error is returned.
+func (s *potdSource) fetch(ctx context.Context, p *Provider, queryURL string, days []time.Time) ([]provider.Image, error) {
+	var images []provider.Image
+	var lastErr error
+	for _, day := range days {
+		var feed potdFeed
+		if err := p.getJSON(ctx, s.apiURL+"/"+day.Format("2006/01/02"), &feed); err != nil {
+			var se *statusError
+			if errors.As(err, &se) && se.status == http.StatusTooManyRequests {
+				return images, errors.New("wikimedia API rate limit exceeded")
+			}
+			if ctx.Err() != nil {
+				return images, ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		if feed.Image == nil || feed.Image.Image.Source == "" {
+			continue
+		}
+
+		img := feed.Image
+		title := collapseSpace(img.Description.Text)
+		if title == "" {
+			title = strings.TrimSuffix(strings.TrimPrefix(img.Title, "File:"), path.Ext(img.Title))
+		}
+		images = append(images, provider.Image{
+			ID:          day.Format(dayLayout),
+			Path:        img.Image.Source,
+			ViewURL:     img.FilePage,
+			Title:       title,
+			Attribution: collapseSpace(img.Artist.Text),
+			Artist:      collapseSpace(img.Artist.Text),
+			Width:       img.Image.Width,
+			Height:      img.Image.Height,
+		})
+	}
+	if len(images) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return images, nil
+}
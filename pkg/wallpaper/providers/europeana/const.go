@@ -0,0 +1,41 @@
+package europeana
+
+import "time"
+
+const (
+	// ProviderName is the unique identifier for config
+	ProviderName = "Europeana"
+
+	// ProviderTitle is the user-facing name
+	ProviderTitle = "Europeana"
+
+	// EuropeanaSearchURL is the Search API endpoint. Stored queries use it as their base URL.
+	EuropeanaSearchURL = "https://api.europeana.eu/record/v2/search.json"
+
+	// EuropeanaItemURL is the public item page, followed by the record ID (e.g. /2048128/618580)
+	EuropeanaItemURL = "https://www.europeana.eu/item"
+
+	// EuropeanaHomeURL is the public website
+	EuropeanaHomeURL = "https://www.europeana.eu"
+
+	// EuropeanaDemoAPIKey is Europeana's public demo key, used until the user adds their own.
+	EuropeanaDemoAPIKey = "api2demo" //nolint:gosec // Public demo key
+
+	// EuropeanaURLRegexp validates Europeana website and API search URLs.
+	EuropeanaURLRegexp = `^https://(?:www\.europeana\.eu/(?:[a-z]{2}/)?search\?|api\.europeana\.eu/record/v2/search\.json\?).*$`
+
+	// EuropeanaPageSize is the number of records requested per page.
+	EuropeanaPageSize = 50
+
+	// EuropeanaMaxResults is the deepest result the Search API serves with start/rows pagination.
+	EuropeanaMaxResults = 1000
+
+	// EuropeanaAPIPacing spaces out Search API requests.
+	EuropeanaAPIPacing = 500 * time.Millisecond
+
+	// EuropeanaMediaPacing spaces out image downloads. Images are served by each data provider's own servers.
+	EuropeanaMediaPacing = 1 * time.Second
+
+	// CollectionHighlights is the fallback curated collection key.
+	CollectionHighlights = "europeana_highlights"
+)
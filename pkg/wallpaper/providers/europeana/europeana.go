@@ -0,0 +1,591 @@
+package europeana
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/curation"
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/schema"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/setting"
+	"github.com/dixieflatline76/Spice/v2/pkg/wallpaper"
+	"github.com/dixieflatline76/Spice/v2/util/log"
+)
+
+//go:embed Europeana.png
+var iconData []byte
+
+// Provider implements the Europeana aggregator provider. It serves user search URLs as well as
+// curated galleries from the curation Manager, all restricted to openly reusable images.
+type Provider struct {
+	cfg        *wallpaper.Config
+	httpClient *http.Client
+	searchURL  string
+}
+
+// API response types
+
+type searchResponse struct {
+	Success      bool      `json:"success"`
+	Error        string    `json:"error"`
+	TotalResults int       `json:"totalResults"`
+	Items        []apiItem `json:"items"`
+}
+
+type apiItem struct {
+	ID           string   `json:"id"` // e.g. /2048128/618580
+	Title        []string `json:"title"`
+	DcCreator    []string `json:"dcCreator"`
+	Year         []string `json:"year"`
+	Rights       []string `json:"rights"`
+	DataProvider []string `json:"dataProvider"`
+	EdmIsShownBy []string `json:"edmIsShownBy"`
+	EdmPreview   []string `json:"edmPreview"`
+}
+
+var europeanaURLRegex = regexp.MustCompile(EuropeanaURLRegexp)
+
+func init() {
+	wallpaper.RegisterProvider(ProviderName, func(cfg *wallpaper.Config, client *http.Client) provider.ImageProvider {
+		return NewProvider(cfg, client)
+	})
+}
+
+// NewProvider creates a new Europeana provider.
+func NewProvider(cfg *wallpaper.Config, client *http.Client) *Provider {
+	return &Provider{
+		cfg:        cfg,
+		httpClient: client,
+		searchURL:  EuropeanaSearchURL,
+	}
+}
+
+func (p *Provider) ID() string      { return ProviderName }
+func (p *Provider) HomeURL() string { return EuropeanaHomeURL }
+
+func (p *Provider) Name() string {
+	return i18n.T("Europeana")
+}
+
+func (p *Provider) Title() string { return ProviderTitle }
+
+func (p *Provider) GetProviderIcon() interface{} { return iconData }
+
+func (p *Provider) Type() provider.ProviderType {
+	return provider.TypeMuseum
+}
+
+func (p *Provider) GetAttributionType() provider.AttributionType {
+	return provider.AttributionBy
+}
+
+func (p *Provider) SupportsUserQueries() bool { return true }
+
+// GetAPIPacing implements PacedProvider.
+func (p *Provider) GetAPIPacing() time.Duration { return EuropeanaAPIPacing }
+
+// GetProcessPacing implements PacedProvider.
+func (p *Provider) GetProcessPacing() time.Duration { return EuropeanaMediaPacing }
+
+// ParseURL converts a Europeana website or API search URL into a canonical Search API URL.
+// The openly reusable, has-media and image-only filters are always added; the API key and
+// pagination parameters are dropped.
+func (p *Provider) ParseURL(webURL string) (string, error) {
+	webURL = strings.TrimSpace(webURL)
+	if !europeanaURLRegex.MatchString(webURL) {
+		return "", errors.New("invalid Europeana search URL")
+	}
+	u, err := url.Parse(webURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid Europeana search URL: %w", err)
+	}
+
+	in := u.Query()
+	out := url.Values{}
+	query := strings.TrimSpace(in.Get("query"))
+	if query == "" {
+		query = "*"
+	}
+	out.Set("query", query)
+	for _, qf := range in["qf"] {
+		if qf = strings.TrimSpace(qf); qf != "" {
+			out.Add("qf", qf)
+		}
+	}
+	if u.Host == "api.europeana.eu" {
+		// API URLs may carry extra filters; keep them, except for credentials and paging.
+		for k, v := range in {
+			switch k {
+			case "query", "qf", "wskey", "start", "rows", "cursor", "profile", "callback":
+				continue
+			}
+			out[k] = v
+		}
+	}
+	return canonicalSearchURL(out), nil
+}
+
+// canonicalSearchURL adds the mandatory filters and returns the stored form of a search.
+func canonicalSearchURL(params url.Values) string {
+	params.Set("reusability", "open")
+	params.Set("media", "true")
+	hasType := false
+	for _, qf := range params["qf"] {
+		if strings.HasPrefix(strings.ToUpper(qf), "TYPE:") {
+			hasType = true
+		}
+	}
+	if !hasType {
+		params.Add("qf", "TYPE:IMAGE")
+	}
+	return EuropeanaSearchURL + "?" + params.Encode()
+}
+
+// FetchImages fetches wallpaper candidates. Queries are either stored search URLs or curated collection keys.
+func (p *Provider) FetchImages(ctx context.Context, query string, page int) ([]provider.Image, error) {
+	if strings.HasPrefix(query, EuropeanaSearchURL) {
+		u, err := url.Parse(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Europeana search URL: %w", err)
+		}
+		return p.fetchSearch(ctx, u.Query(), page)
+	}
+
+	entry := curation.GetManager().GetEntry(p.ID(), query)
+	if entry == nil {
+		log.Printf("Europeana: Unknown collection key %q, falling back to highlights", query)
+		entry = curation.GetManager().GetEntry(p.ID(), CollectionHighlights)
+		if entry == nil {
+			return nil, fmt.Errorf("no collection entries available")
+		}
+	}
+
+	switch entry.Type {
+	case "curated":
+		return p.fetchCurated(ctx, entry, page)
+	case "search":
+		params, err := url.ParseQuery(entry.SearchParams)
+		if err != nil {
+			return nil, fmt.Errorf("invalid search params for %s: %w", entry.Key, err)
+		}
+		return p.fetchSearch(ctx, params, page)
+	default:
+		return nil, fmt.Errorf("unknown collection type: %s", entry.Type)
+	}
+}
+
+// fetchSearch fetches one page of a search using the API's native start/rows pagination.
+func (p *Provider) fetchSearch(ctx context.Context, params url.Values, page int) ([]provider.Image, error) {
+	start := (page-1)*EuropeanaPageSize + 1 // start is 1-based
+	if start+EuropeanaPageSize-1 > EuropeanaMaxResults {
+		return nil, nil // Deeper pages need cursor pagination; wrap around instead
+	}
+
+	items, err := p.search(ctx, params, start, EuropeanaPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []provider.Image
+	for i := range items {
+		if img := itemToImage(&items[i]); img != nil {
+			images = append(images, *img)
+		}
+	}
+	log.Debugf("Europeana: Search page %d: %d images from %d items", page, len(images), len(items))
+	return images, nil
+}
+
+// fetchCurated resolves one page of curated record IDs with a single search request.
+func (p *Provider) fetchCurated(ctx context.Context, entry *curation.CollectionEntry, page int) ([]provider.Image, error) {
+	const pageSize = 20
+	start := (page - 1) * pageSize
+	if start >= len(entry.IDs) {
+		return nil, nil
+	}
+	end := start + pageSize
+	if end > len(entry.IDs) {
+		end = len(entry.IDs)
+	}
+
+	items, err := p.lookup(ctx, entry.IDs[start:end])
+	if err != nil {
+		return nil, err
+	}
+
+	var images []provider.Image
+	for _, id := range entry.IDs[start:end] {
+		item, ok := items[id]
+		if !ok {
+			log.Debugf("Europeana: Record %s is missing or no longer openly reusable", id)
+			continue
+		}
+		if img := itemToImage(item); img != nil {
+			images = append(images, *img)
+		}
+	}
+	log.Debugf("Europeana: Curated page %d: %d images from %d IDs", page, len(images), end-start)
+	return images, nil
+}
+
+// lookup fetches records by ID, keyed by ID. The curation order is kept by the caller.
+func (p *Provider) lookup(ctx context.Context, ids []string) (map[string]*apiItem, error) {
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = strconv.Quote(id)
+	}
+	params := url.Values{}
+	params.Set("query", "europeana_id:("+strings.Join(quoted, " OR ")+")")
+
+	items, err := p.search(ctx, params, 1, len(ids))
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*apiItem, len(items))
+	for i := range items {
+		byID[items[i].ID] = &items[i]
+	}
+	return byID, nil
+}
+
+// search performs a Search API request with the mandatory filters applied.
+func (p *Provider) search(ctx context.Context, params url.Values, start, rows int) ([]apiItem, error) {
+	q := url.Values{}
+	for k, v := range params {
+		q[k] = append([]string(nil), v...)
+	}
+	reqURL := strings.Replace(canonicalSearchURL(q), EuropeanaSearchURL, p.searchURL, 1)
+	reqURL += "&" + url.Values{
+		"start": {strconv.Itoa(start)},
+		"rows":  {strconv.Itoa(rows)},
+		"wskey": {p.apiKey()},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		// Avoid leaking the API key through the request URL in url.Error.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return nil, urlErr.Err
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, errors.New("europeana API rejected the API key")
+	case http.StatusTooManyRequests:
+		return nil, errors.New("europeana API rate limit exceeded")
+	default:
+		return nil, fmt.Errorf("europeana API returned %s", resp.Status)
+	}
+
+	var result searchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if !result.Success && result.Error != "" {
+		return nil, fmt.Errorf("europeana API error: %s", result.Error)
+	}
+	return result.Items, nil
+}
+
+// apiKey returns the user's Europeana key, or the public demo key.
+func (p *Provider) apiKey() string {
+	if p.cfg != nil {
+		if key := p.cfg.GetEuropeanaAPIKey(); key != "" {
+			return key
+		}
+	}
+	return EuropeanaDemoAPIKey
+}
+
+// itemToImage maps a search item to a provider.Image. edm:isShownBy is the full-size media file;
+// items that only have a landing page (edm:isShownAt) cannot be downloaded and are skipped.
+func itemToImage(item *apiItem) *provider.Image {
+	path := first(item.EdmIsShownBy)
+	if item.ID == "" || path == "" {
+		return nil
+	}
+
+	title := first(item.Title)
+	artist := first(item.DcCreator)
+	attribution := title
+	if artist != "" {
+		attribution = fmt.Sprintf("%s - %s", artist, title)
+	}
+
+	// Europeana aggregates other institutions' records; credit the holder and the license.
+	var credits []string
+	if dp := first(item.DataProvider); dp != "" {
+		credits = append(credits, dp)
+	}
+	if rights := rightsLabel(first(item.Rights)); rights != "" {
+		credits = append(credits, rights)
+	}
+	if len(credits) > 0 {
+		attribution = fmt.Sprintf("%s (%s)", attribution, strings.Join(credits, ", "))
+	}
+
+	return &provider.Image{
+		ID:          strings.ReplaceAll(strings.TrimPrefix(item.ID, "/"), "/", "_"),
+		Path:        path,
+		ViewURL:     EuropeanaItemURL + item.ID,
+		Attribution: attribution,
+		Title:       title,
+		Artist:      artist,
+		Year:        first(item.Year),
+		Provider:    ProviderName,
+	}
+}
+
+// rightsLabel shortens a rights statement URL to its common name (e.g. "CC BY-SA 4.0").
+func rightsLabel(rights string) string {
+	u, err := url.Parse(rights)
+	if err != nil || u.Host == "" {
+		return rights
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case strings.HasSuffix(u.Host, "creativecommons.org") && len(parts) >= 2 && parts[0] == "publicdomain":
+		if parts[1] == "zero" {
+			return "CC0"
+		}
+		return "Public Domain"
+	case strings.HasSuffix(u.Host, "creativecommons.org") && len(parts) >= 3 && parts[0] == "licenses":
+		return "CC " + strings.ToUpper(parts[1]) + " " + parts[2]
+	case strings.HasSuffix(u.Host, "rightsstatements.org") && len(parts) >= 2 && parts[0] == "vocab":
+		return parts[1]
+	}
+	return rights
+}
+
+func first(values []string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// EnrichImage is a no-op — all metadata comes from the search results.
+func (p *Provider) EnrichImage(_ context.Context, img provider.Image) (provider.Image, error) {
+	img.Provider = ProviderName
+	return img, nil
+}
+
+// FetchThumbnails implements provider.ThumbnailProvider using Europeana's preview images.
+func (p *Provider) FetchThumbnails(ctx context.Context, ids []string) ([]provider.Thumbnail, error) {
+	const batchSize = 50
+	var thumbnails []provider.Thumbnail
+	for start := 0; start < len(ids); start += batchSize {
+		end := min(start+batchSize, len(ids))
+		items, err := p.lookup(ctx, ids[start:end])
+		if err != nil {
+			log.Printf("Europeana: Failed to fetch thumbnails: %v", err)
+			continue
+		}
+		for _, id := range ids[start:end] {
+			item, ok := items[id]
+			if !ok {
+				continue
+			}
+			img := itemToImage(item)
+			if img == nil {
+				continue
+			}
+			thumbURL := first(item.EdmPreview)
+			if thumbURL == "" {
+				thumbURL = img.Path
+			}
+			thumbnails = append(thumbnails, provider.Thumbnail{
+				ID:          id,
+				URL:         thumbURL,
+				FallbackURL: img.Path,
+				ViewURL:     img.ViewURL,
+				Title:       img.Title,
+				Artist:      img.Artist,
+				Year:        img.Year,
+			})
+		}
+	}
+	return thumbnails, nil
+}
+
+// CheckEuropeanaAPIKeyWithContext verifies if the given Europeana API key is valid using the provided context.
+func CheckEuropeanaAPIKeyWithContext(ctx context.Context, apiKey string) error {
+	if apiKey == "" {
+		return errors.New("europeana API key is empty")
+	}
+
+	reqURL := EuropeanaSearchURL + "?" + url.Values{"query": {"*"}, "rows": {"0"}, "wskey": {apiKey}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.New("network error while contacting api.europeana.eu")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errors.New("invalid Europeana API key")
+	}
+	return fmt.Errorf("europeana API verification failed (status %d)", resp.StatusCode)
+}
+
+// --- UI Implementation (Pure Go) ---
+
+const europeanaAPIKeyIdent = "europeanaAPIKey"
+
+// CreateSettingsPanel returns the declarative UI for Europeana settings.
+func (p *Provider) CreateSettingsPanel(sm setting.SettingsManager) *schema.PanelSchema {
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				ID:      "Europeana",
+				Title:   i18n.T("Europeana"),
+				Compact: true,
+				Items: []schema.ItemSchema{
+					schema.LabelItem{
+						Text:       i18n.T("Europe's digital cultural heritage platform brings together millions of artworks from thousands of museums, galleries, libraries and archives. Spice only uses openly licensed images."),
+						Importance: schema.ImportanceLow,
+					},
+					schema.ButtonItem{
+						Name:       "europeana_web",
+						ButtonText: i18n.T("Visit Website"),
+						IconName:   "Home",
+						OnPressed:  func() { sm.OpenURL(EuropeanaHomeURL) },
+					},
+					schema.BoolItem{
+						Name:         "europeana_museum_framing",
+						Label:        i18n.T("Display as Framed Gallery"),
+						Help:         i18n.T("Present all artwork from this collection inside a virtual museum frame with a dynamic background, regardless of its original dimensions."),
+						InitialValue: p.cfg.GetMuseumFraming(p.ID()),
+						ApplyFunc:    func(val bool) { p.cfg.SetMuseumFraming(p.ID(), val) },
+					},
+				},
+			},
+			{
+				Title:   i18n.T("Authentication"),
+				Compact: true,
+				Items: []schema.ItemSchema{
+					schema.SecretItem{
+						Name:         europeanaAPIKeyIdent,
+						Label:        i18n.T("Europeana API Key (optional):"),
+						InitialValue: p.cfg.GetEuropeanaAPIKey(),
+						Placeholder:  i18n.T("Enter your Europeana API Key"),
+						OnVerify: func(key string) error {
+							ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+							defer cancel()
+							return CheckEuropeanaAPIKeyWithContext(ctx, key)
+						},
+						ApplyFunc: func(key string) {
+							p.cfg.SetEuropeanaAPIKey(key)
+						},
+						OnClear: func() {
+							p.cfg.SetEuropeanaAPIKey("")
+							sm.ResetSettings(
+								setting.SettingReset{Name: europeanaAPIKeyIdent, Value: ""},
+							)
+						},
+					},
+					schema.HyperlinkItem{
+						Text: i18n.T("Get a free API key"),
+						URL:  "https://pro.europeana.eu/page/get-api",
+					},
+				},
+			},
+		},
+	}
+}
+
+// CreateQueryPanel combines the curated galleries with the user's own Europeana searches.
+func (p *Provider) CreateQueryPanel(sm setting.SettingsManager, pendingUrl string) *schema.PanelSchema {
+	addCfg := schema.AddQueryConfig{
+		Title:           i18n.T("Add Europeana Search"),
+		URLPlaceholder:  "https://www.europeana.eu/en/search?query=vermeer",
+		URLValidator:    EuropeanaURLRegexp,
+		URLErrorMsg:     i18n.T("Invalid Europeana search URL"),
+		DescPlaceholder: i18n.T("Query Description (e.g. Vermeer)"),
+		AddHandler: func(desc, url string, active bool) (string, error) {
+			searchURL, err := p.ParseURL(url)
+			if err != nil {
+				return "", err
+			}
+			return p.cfg.AddEuropeanaQuery(desc, searchURL, active)
+		},
+	}
+
+	if pendingUrl != "" {
+		sm.ShowAddQueryDialog(addCfg, pendingUrl, "", sm.RefreshUI)
+	}
+
+	panel := wallpaper.CreateCuratedQueryPanel(p, sm, p.cfg)
+	panel.Sections = append(panel.Sections, schema.SectionSchema{
+		Title:       i18n.T("My Europeana Searches"),
+		Description: i18n.T("Paste a search from europeana.eu. Only openly licensed images are used."),
+		Items: []schema.ItemSchema{
+			schema.ButtonItem{
+				Name:       "europeana_add",
+				ButtonText: i18n.T("Add Europeana Search"),
+				IconName:   "add",
+				OnPressed: func() {
+					sm.ShowAddQueryDialog(addCfg, "", "", sm.RefreshUI)
+				},
+			},
+			schema.QueryListItem{
+				GetQueries: func() []schema.Query {
+					var abstracts []schema.Query
+					for _, q := range p.cfg.GetEuropeanaQueries() {
+						if !strings.HasPrefix(q.URL, EuropeanaSearchURL) {
+							continue // Curated galleries are toggled above
+						}
+						abstracts = append(abstracts, schema.Query{
+							ID:          q.ID,
+							URL:         q.URL,
+							Description: q.Description,
+							Active:      q.Active,
+							Managed:     q.Managed,
+						})
+					}
+					return abstracts
+				},
+				EnableQuery:  p.cfg.EnableImageQuery,
+				DisableQuery: p.cfg.DisableImageQuery,
+				RemoveQuery:  p.cfg.RemoveImageQuery,
+				GetDisplayURL: func(q schema.Query) *url.URL {
+					u, _ := url.Parse(q.URL)
+					if u == nil {
+						return nil
+					}
+					// Link to the website search rather than the raw API.
+					return &url.URL{Scheme: "https", Host: "www.europeana.eu", Path: "/en/search", RawQuery: url.Values{"query": {u.Query().Get("query")}}.Encode()}
+				},
+			},
+		},
+	})
+	return panel
+}
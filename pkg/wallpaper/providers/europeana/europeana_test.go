@@ -0,0 +1,168 @@
+package europeana
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/curation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const milkmaid = `{
+	"id": "/90402/SK_A_2344",
+	"title": ["The Milkmaid"],
+	"dcCreator": ["Johannes Vermeer"],
+	"year": ["1660"],
+	"rights": ["http://creativecommons.org/publicdomain/zero/1.0/"],
+	"dataProvider": ["Rijksmuseum"],
+	"edmIsShownBy": ["https://lh3.googleusercontent.com/milkmaid=s0"],
+	"edmPreview": ["https://api.europeana.eu/thumbnail/v2/url.json?uri=milkmaid&type=IMAGE"]
+}`
+
+const landingPageOnly = `{
+	"id": "/123/abc",
+	"title": ["Only a landing page"],
+	"rights": ["http://creativecommons.org/licenses/by/4.0/"]
+}`
+
+func newTestProvider(serverURL string) *Provider {
+	p := NewProvider(nil, http.DefaultClient)
+	p.searchURL = serverURL
+	return p
+}
+
+func TestParseURL(t *testing.T) {
+	p := &Provider{}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    url.Values
+		wantErr bool
+	}{
+		{
+			name:  "Website search",
+			input: "https://www.europeana.eu/en/search?page=2&view=grid&query=vermeer&qf=TYPE%3A%22IMAGE%22",
+			want:  url.Values{"query": {"vermeer"}, "qf": {`TYPE:"IMAGE"`}, "reusability": {"open"}, "media": {"true"}},
+		},
+		{
+			name:  "Website search without language or query",
+			input: "https://www.europeana.eu/search?qf=DATA_PROVIDER%3A%22Nationalmuseum%22",
+			want:  url.Values{"query": {"*"}, "qf": {`DATA_PROVIDER:"Nationalmuseum"`, "TYPE:IMAGE"}, "reusability": {"open"}, "media": {"true"}},
+		},
+		{
+			name:  "API search drops key and paging, forces filters",
+			input: "https://api.europeana.eu/record/v2/search.json?wskey=secret&query=monet&rows=100&start=5&theme=art&reusability=restricted",
+			want:  url.Values{"query": {"monet"}, "theme": {"art"}, "qf": {"TYPE:IMAGE"}, "reusability": {"open"}, "media": {"true"}},
+		},
+		{
+			name:    "Item page",
+			input:   "https://www.europeana.eu/en/item/90402/SK_A_2344",
+			wantErr: true,
+		},
+		{
+			name:    "Other site",
+			input:   "https://example.com/search?query=vermeer",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.ParseURL(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			u, err := url.Parse(got)
+			require.NoError(t, err)
+			assert.Equal(t, EuropeanaSearchURL, u.Scheme+"://"+u.Host+u.Path)
+			assert.Equal(t, tt.want, u.Query())
+		})
+	}
+}
+
+func TestFetchImages_Search(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assert.Equal(t, "vermeer", q.Get("query"))
+		assert.Equal(t, "open", q.Get("reusability"))
+		assert.Equal(t, "true", q.Get("media"))
+		assert.Equal(t, "51", q.Get("start"), "start is 1-based")
+		assert.Equal(t, "50", q.Get("rows"))
+		assert.Equal(t, EuropeanaDemoAPIKey, q.Get("wskey"))
+		_, _ = w.Write([]byte(`{"success":true,"totalResults":2,"items":[` + milkmaid + `,` + landingPageOnly + `]}`))
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.URL)
+	query, err := p.ParseURL("https://www.europeana.eu/en/search?query=vermeer")
+	require.NoError(t, err)
+
+	images, err := p.FetchImages(context.Background(), query, 2)
+	require.NoError(t, err)
+	require.Len(t, images, 1, "items without edm:isShownBy are skipped")
+
+	img := images[0]
+	assert.Equal(t, "90402_SK_A_2344", img.ID)
+	assert.Equal(t, "https://lh3.googleusercontent.com/milkmaid=s0", img.Path)
+	assert.Equal(t, "https://www.europeana.eu/item/90402/SK_A_2344", img.ViewURL)
+	assert.Equal(t, "Johannes Vermeer - The Milkmaid (Rijksmuseum, CC0)", img.Attribution)
+	assert.Equal(t, "1660", img.Year)
+}
+
+func TestFetchImages_BeyondSearchLimit(t *testing.T) {
+	p := newTestProvider("http://127.0.0.1:0")
+	images, err := p.fetchSearch(context.Background(), url.Values{"query": {"*"}}, EuropeanaMaxResults/EuropeanaPageSize+1)
+	require.NoError(t, err)
+	assert.Empty(t, images, "pages past the API limit are empty so pagination wraps")
+}
+
+func TestFetchCurated_KeepsOrder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, `europeana_id:("/1/missing" OR "/90402/SK_A_2344")`, r.URL.Query().Get("query"))
+		_, _ = w.Write([]byte(`{"success":true,"items":[` + milkmaid + `]}`))
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.URL)
+	entry := &curation.CollectionEntry{Key: "test", Type: "curated", IDs: []string{"/1/missing", "/90402/SK_A_2344"}}
+	images, err := p.fetchCurated(context.Background(), entry, 1)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	assert.Equal(t, "90402_SK_A_2344", images[0].ID)
+
+	thumbs, err := p.FetchThumbnails(context.Background(), entry.IDs)
+	require.NoError(t, err)
+	require.Len(t, thumbs, 1)
+	assert.Equal(t, "/90402/SK_A_2344", thumbs[0].ID)
+	assert.Contains(t, thumbs[0].URL, "thumbnail/v2")
+	assert.Equal(t, "https://lh3.googleusercontent.com/milkmaid=s0", thumbs[0].FallbackURL)
+}
+
+func TestSearch_InvalidKey(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"success":false,"error":"Invalid API key"}`))
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.URL)
+	_, err := p.fetchSearch(context.Background(), url.Values{"query": {"*"}}, 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "API key")
+	assert.NotContains(t, err.Error(), EuropeanaDemoAPIKey)
+}
+
+func TestRightsLabel(t *testing.T) {
+	assert.Equal(t, "CC0", rightsLabel("http://creativecommons.org/publicdomain/zero/1.0/"))
+	assert.Equal(t, "Public Domain", rightsLabel("http://creativecommons.org/publicdomain/mark/1.0/"))
+	assert.Equal(t, "CC BY-SA 4.0", rightsLabel("http://creativecommons.org/licenses/by-sa/4.0/"))
+	assert.Equal(t, "NoC-NC", rightsLabel("http://rightsstatements.org/vocab/NoC-NC/1.0/"))
+	assert.Equal(t, "custom", rightsLabel("custom"))
+}
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/dixieflatline76/Spice/v2/config"
+	"github.com/dixieflatline76/Spice/v2/pkg/api"
 	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
 	"github.com/dixieflatline76/Spice/v2/pkg/provider"
 	"github.com/dixieflatline76/Spice/v2/pkg/ui/schema"
@@ -551,9 +552,7 @@ func (p *Provider) hasLocalImages() bool {
 		if name == "metadata.json" || name == ".ds_store" {
 			continue
 		}
-		// Check for common image extensions
-		if strings.HasSuffix(name, ".jpg") || strings.HasSuffix(name, ".jpeg") ||
-			strings.HasSuffix(name, ".png") || strings.HasSuffix(name, ".webp") {
+		if api.IsLocalImage(name) {
 			return true
 		}
 	}
@@ -590,12 +589,7 @@ func (p *Provider) FetchImages(ctx context.Context, apiURL string, page int) ([]
 		return nil, fmt.Errorf("local API returned status: %d", resp.StatusCode)
 	}
 
-	var respData []struct {
-		ID          string `json:"id"`
-		URL         string `json:"url"`
-		Attribution string `json:"attribution"`
-		ProductURL  string `json:"product_url"`
-	}
+	var respData []api.LocalImage
 	if err := json.NewDecoder(resp.Body).Decode(&respData); err != nil {
 		return nil, err
 	}
@@ -607,6 +601,11 @@ func (p *Provider) FetchImages(ctx context.Context, apiURL string, page int) ([]
 			Path:          d.URL, // Map local API 'url' to 'Path' (download)
 			Attribution:   d.Attribution,
 			ViewURL:       d.ProductURL, // Map local API 'product_url' to 'ViewURL'
+			Title:         d.Title,
+			Artist:        d.Artist,
+			Year:          d.Year,
+			Camera:        d.Camera,
+			Caption:       d.Caption,
 			Provider:      ProviderName,
 			SourceQueryID: wallpaper.FavoritesQueryID,
 			IsFavorited:   true, // Images from the Favorites provider are, by definition, favorites
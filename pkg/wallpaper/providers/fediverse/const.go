@@ -0,0 +1,58 @@
+package fediverse
+
+import "time"
+
+const (
+	// ProviderName is the unique identifier of the Fediverse provider.
+	ProviderName = "Fediverse"
+
+	// FediverseURLRegexp validates hashtag and account links on a Mastodon or Pixelfed instance:
+	// https://<instance>/tags/<tag>, https://<instance>/discover/tags/<tag> or https://<instance>/@<user>[@<domain>].
+	FediverseURLRegexp = `^(?i)https://[a-z0-9.-]+(?::\d+)?/(?:(?:discover/)?tags/[\p{L}\p{N}_]+|@[a-z0-9_.-]+(?:@[a-z0-9.-]+)?)/?(?:\?[^\s#]*)?$`
+
+	// FediversePageSize is the number of statuses requested per page (the Mastodon API maximum).
+	FediversePageSize = 40
+
+	// FediverseMaxPages caps how far back a timeline is followed before the query wraps around.
+	FediverseMaxPages = 25
+
+	// FediverseMaxSkippedPages is the number of timeline pages read in one fetch when the pages
+	// hold no qualifying images.
+	FediverseMaxSkippedPages = 3
+
+	// FediverseMaxBodyBytes caps the size of a single API response.
+	FediverseMaxBodyBytes = 8 << 20 // 8 MiB
+
+	// FediverseRateLimitReserve is the number of requests left in a rate-limit window at which
+	// the provider stops calling the instance until the window resets.
+	FediverseRateLimitReserve = 5
+
+	// FediverseDefaultBackoff is used after a 429 response that carries no reset time.
+	FediverseDefaultBackoff = 5 * time.Minute
+
+	// FediverseAPIPacing spaces out API requests. Mastodon allows 300 requests per 5 minutes
+	// per IP by default; one request per second stays well inside that.
+	FediverseAPIPacing = 1 * time.Second
+
+	// FediverseMediaPacing spaces out image downloads from the instance's media storage.
+	FediverseMediaPacing = 500 * time.Millisecond
+)
+
+// Resolution is a minimum attachment size preset.
+type Resolution struct {
+	Label  string
+	Width  int
+	Height int
+}
+
+// DefaultResolution is the index of the default minimum resolution preset (Full HD).
+const DefaultResolution = 2
+
+// Resolutions are the minimum resolution presets offered in the settings. The index is stored in the config.
+var Resolutions = []Resolution{
+	{Label: "Any", Width: 0, Height: 0},
+	{Label: "HD", Width: 1280, Height: 720},
+	{Label: "Full HD", Width: 1920, Height: 1080},
+	{Label: "QHD", Width: 2560, Height: 1440},
+	{Label: "4K", Width: 3840, Height: 2160},
+}
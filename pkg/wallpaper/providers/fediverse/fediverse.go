@@ -0,0 +1,674 @@
+package fediverse
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/schema"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/setting"
+	"github.com/dixieflatline76/Spice/v2/pkg/wallpaper"
+	"github.com/dixieflatline76/Spice/v2/util/log"
+)
+
+//go:embed Fediverse.png
+var iconData []byte
+
+var (
+	errRateLimited = errors.New("rate limited")
+	errNotFound    = errors.New("hashtag or account not found")
+)
+
+// nonFediverseHosts use /@user profile paths too, but are not Mastodon API servers.
+var nonFediverseHosts = []string{"unsplash.com", "medium.com", "youtube.com", "tiktok.com", "threads.net", "threads.com"}
+
+// query is a parsed hashtag or account query.
+type query struct {
+	instance string // https://<host>[:<port>]
+	tag      string
+	acct     string // user or user@domain
+	discover bool   // Pixelfed-style /discover/tags/ link
+}
+
+// String returns the canonical web URL of the query.
+func (q query) String() string {
+	switch {
+	case q.acct != "":
+		return q.instance + "/@" + q.acct
+	case q.discover:
+		return q.instance + "/discover/tags/" + q.tag
+	default:
+		return q.instance + "/tags/" + q.tag
+	}
+}
+
+type status struct {
+	ID               string       `json:"id"`
+	URL              string       `json:"url"`
+	URI              string       `json:"uri"`
+	CreatedAt        string       `json:"created_at"`
+	Sensitive        bool         `json:"sensitive"`
+	Reblog           *status      `json:"reblog"`
+	Account          account      `json:"account"`
+	MediaAttachments []attachment `json:"media_attachments"`
+}
+
+type account struct {
+	ID          string `json:"id"`
+	Acct        string `json:"acct"`
+	DisplayName string `json:"display_name"`
+}
+
+type attachment struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	URL         string `json:"url"`
+	RemoteURL   string `json:"remote_url"`
+	Description string `json:"description"`
+	Meta        *struct {
+		Original *struct {
+			Width  int `json:"width"`
+			Height int `json:"height"`
+		} `json:"original"`
+	} `json:"meta"`
+}
+
+// Provider implements ImageProvider for hashtag and account timelines on Mastodon, Pixelfed and other
+// servers that speak the Mastodon client API. Only public endpoints are used, so no login is needed.
+type Provider struct {
+	cfg        *wallpaper.Config
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu sync.Mutex
+	// cursors maps a query URL to the max_id that starts each page beyond the first.
+	cursors map[string]map[int]string
+	// accounts caches the account ID of each account query.
+	accounts map[string]string
+	// throttledUntil maps an instance to the end of its rate-limit window, once the window is used up.
+	throttledUntil map[string]time.Time
+}
+
+func init() {
+	wallpaper.RegisterProvider(ProviderName, func(cfg *wallpaper.Config, client *http.Client) provider.ImageProvider {
+		return NewProvider(cfg, client)
+	})
+}
+
+// NewProvider creates a new Fediverse Provider.
+func NewProvider(cfg *wallpaper.Config, client *http.Client) *Provider {
+	return &Provider{
+		cfg:            cfg,
+		httpClient:     client,
+		now:            time.Now,
+		cursors:        make(map[string]map[int]string),
+		accounts:       make(map[string]string),
+		throttledUntil: make(map[string]time.Time),
+	}
+}
+
+func (p *Provider) ID() string {
+	return ProviderName
+}
+
+func (p *Provider) Name() string {
+	return i18n.T("Mastodon & Pixelfed")
+}
+
+func (p *Provider) Title() string {
+	return ProviderName
+}
+
+func (p *Provider) GetProviderIcon() interface{} {
+	return iconData
+}
+
+func (p *Provider) Type() provider.ProviderType {
+	return provider.TypeCommunity
+}
+
+func (p *Provider) HomeURL() string {
+	return "https://joinmastodon.org"
+}
+
+func (p *Provider) GetAttributionType() provider.AttributionType {
+	return provider.AttributionBy
+}
+
+func (p *Provider) SupportsUserQueries() bool {
+	return true
+}
+
+var fediverseURLRegex = regexp.MustCompile(FediverseURLRegexp)
+
+// ParseURL accepts hashtag and account links on any instance and returns their canonical form.
+func (p *Provider) ParseURL(webURL string) (string, error) {
+	q, err := parseQuery(webURL)
+	if err != nil {
+		return "", err
+	}
+	return q.String(), nil
+}
+
+func parseQuery(raw string) (query, error) {
+	raw = strings.TrimSpace(raw)
+	if !fediverseURLRegex.MatchString(raw) {
+		return query{}, errors.New("not a Mastodon or Pixelfed hashtag or account URL")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return query{}, fmt.Errorf("invalid URL: %w", err)
+	}
+	host := strings.ToLower(u.Host)
+	hostname := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if slices.Contains(nonFediverseHosts, hostname) {
+		return query{}, fmt.Errorf("%s is not a Fediverse server", hostname)
+	}
+
+	q := query{instance: "https://" + host}
+	urlPath := strings.Trim(u.Path, "/")
+	switch {
+	case strings.HasPrefix(urlPath, "@"):
+		q.acct = strings.TrimPrefix(urlPath, "@")
+		// A link to a local account written with the instance's own domain is the same account.
+		if user, domain, ok := strings.Cut(q.acct, "@"); ok && strings.EqualFold(domain, u.Hostname()) {
+			q.acct = user
+		}
+	case strings.HasPrefix(strings.ToLower(urlPath), "discover/"):
+		q.discover = true
+		q.tag = strings.ToLower(path.Base(urlPath))
+	default:
+		q.tag = strings.ToLower(path.Base(urlPath))
+	}
+	return q, nil
+}
+
+// GetAPIPacing implements the PacedProvider interface to stay inside the default Mastodon rate limit.
+func (p *Provider) GetAPIPacing() time.Duration {
+	return FediverseAPIPacing
+}
+
+// GetProcessPacing implements the PacedProvider interface to space out image downloads.
+func (p *Provider) GetProcessPacing() time.Duration {
+	return FediverseMediaPacing
+}
+
+// IsThrottled implements the ThrottledProvider interface. Rate limits are per instance, so the provider
+// only reports itself throttled when every instance with an active query has used up its window;
+// otherwise FetchImages skips the throttled instances on its own.
+func (p *Provider) IsThrottled() bool {
+	instances := make(map[string]bool)
+	if p.cfg != nil {
+		for _, q := range p.cfg.GetFediverseQueries() {
+			if fq, err := parseQuery(q.URL); err == nil && q.Active {
+				instances[fq.instance] = true
+			}
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.expireThrottlesLocked()
+	if len(p.throttledUntil) == 0 {
+		return false
+	}
+	for instance := range instances {
+		if _, ok := p.throttledUntil[instance]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Provider) expireThrottlesLocked() {
+	now := p.now()
+	for instance, until := range p.throttledUntil {
+		if !now.Before(until) {
+			delete(p.throttledUntil, instance)
+		}
+	}
+}
+
+// FetchImages returns the image attachments of one page of a hashtag or account timeline, newest first.
+// Page 1 is the top of the timeline; later pages continue from the max_id cursor recorded by the page before.
+func (p *Provider) FetchImages(ctx context.Context, apiURL string, page int) ([]provider.Image, error) {
+	if page < 1 {
+		page = 1
+	}
+	if page > FediverseMaxPages {
+		return nil, nil
+	}
+	q, err := parseQuery(apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	maxID := ""
+	if page > 1 {
+		p.mu.Lock()
+		cursor, ok := p.cursors[apiURL][page]
+		p.mu.Unlock()
+		if !ok {
+			return nil, nil
+		}
+		maxID = cursor
+	}
+
+	minRes := p.minResolution()
+	var images []provider.Image
+	// Busy hashtags can fill a whole page with small or video attachments. Skip ahead a few
+	// pages rather than returning an empty page, which would wrap the query back to page 1.
+	for hop := 0; hop < FediverseMaxSkippedPages && len(images) == 0; hop++ {
+		statuses, err := p.fetchStatuses(ctx, q, maxID)
+		if err != nil {
+			return nil, err
+		}
+		if len(statuses) == 0 {
+			return nil, nil
+		}
+		for _, st := range statuses {
+			images = append(images, p.mapStatus(q, st, minRes)...)
+		}
+		maxID = statuses[len(statuses)-1].ID
+	}
+
+	p.mu.Lock()
+	if p.cursors[apiURL] == nil {
+		p.cursors[apiURL] = make(map[int]string)
+	}
+	p.cursors[apiURL][page+1] = maxID
+	p.mu.Unlock()
+
+	log.Debugf("Found %d images in %s (page %d)", len(images), q, page)
+	return images, nil
+}
+
+// fetchStatuses returns one page of statuses with media, starting below maxID.
+func (p *Provider) fetchStatuses(ctx context.Context, q query, maxID string) ([]status, error) {
+	params := url.Values{
+		"limit":      {strconv.Itoa(FediversePageSize)},
+		"only_media": {"true"},
+	}
+	if maxID != "" {
+		params.Set("max_id", maxID)
+	}
+
+	apiPath := "/api/v1/timelines/tag/" + url.PathEscape(q.tag)
+	if q.acct != "" {
+		id, err := p.accountID(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		apiPath = "/api/v1/accounts/" + url.PathEscape(id) + "/statuses"
+		params.Set("exclude_replies", "true")
+		params.Set("exclude_reblogs", "true")
+	}
+
+	var statuses []status
+	if err := p.getJSON(ctx, q.instance, apiPath, params, &statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// accountID resolves an account handle to the instance's account ID.
+func (p *Provider) accountID(ctx context.Context, q query) (string, error) {
+	key := q.String()
+	p.mu.Lock()
+	id, ok := p.accounts[key]
+	p.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	var acc account
+	if err := p.getJSON(ctx, q.instance, "/api/v1/accounts/lookup", url.Values{"acct": {q.acct}}, &acc); err != nil {
+		return "", err
+	}
+	if acc.ID == "" {
+		return "", errNotFound
+	}
+	p.mu.Lock()
+	p.accounts[key] = acc.ID
+	p.mu.Unlock()
+	return acc.ID, nil
+}
+
+// getJSON calls a public API endpoint of an instance and records its rate-limit headers.
+func (p *Provider) getJSON(ctx context.Context, instance, apiPath string, params url.Values, v any) error {
+	p.mu.Lock()
+	p.expireThrottlesLocked()
+	until, throttled := p.throttledUntil[instance]
+	p.mu.Unlock()
+	if throttled {
+		return fmt.Errorf("%s %w until %s", instance, errRateLimited, until.Format(time.Kitchen))
+	}
+
+	reqURL := instance + apiPath
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	p.recordRateLimit(instance, resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%s %w", instance, errRateLimited)
+	case http.StatusNotFound:
+		return errNotFound
+	default:
+		return fmt.Errorf("%s returned %s", apiPath, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, FediverseMaxBodyBytes)).Decode(v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// recordRateLimit throttles an instance until its rate-limit window resets once the window is (nearly)
+// used up, or after a 429 response. Mastodon and Pixelfed send X-RateLimit-Remaining and an ISO 8601
+// X-RateLimit-Reset on every API response.
+func (p *Provider) recordRateLimit(instance string, resp *http.Response) {
+	now := p.now()
+	reset := parseReset(resp.Header, now)
+
+	var until time.Time
+	if resp.StatusCode == http.StatusTooManyRequests {
+		until = reset
+		if until.IsZero() {
+			until = now.Add(FediverseDefaultBackoff)
+		}
+	} else if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil && remaining <= FediverseRateLimitReserve {
+		until = reset
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if until.After(now) {
+		if _, ok := p.throttledUntil[instance]; !ok {
+			log.Printf("Fediverse: %s rate limit reached, pausing requests until %s", instance, until.Format(time.Kitchen))
+		}
+		p.throttledUntil[instance] = until
+	} else {
+		delete(p.throttledUntil, instance)
+	}
+}
+
+// parseReset returns the end of the rate-limit window from X-RateLimit-Reset, falling back to Retry-After.
+func parseReset(h http.Header, now time.Time) time.Time {
+	if t, err := time.Parse(time.RFC3339, h.Get("X-RateLimit-Reset")); err == nil {
+		return t
+	}
+	if ra := h.Get("Retry-After"); ra != "" {
+		if secs, err := strconv.Atoi(ra); err == nil {
+			return now.Add(time.Duration(secs) * time.Second)
+		}
+		if t, err := http.ParseTime(ra); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (p *Provider) minResolution() Resolution {
+	idx := DefaultResolution
+	if p.cfg != nil {
+		idx = p.cfg.GetFediverseMinResolution()
+	}
+	if idx < 0 || idx >= len(Resolutions) {
+		idx = DefaultResolution
+	}
+	return Resolutions[idx]
+}
+
+// mapStatus converts the qualifying image attachments of a status. Reblogs, sensitive posts, videos,
+// formats the wallpaper pipeline cannot decode and images below the minimum resolution are skipped.
+func (p *Provider) mapStatus(q query, st status, minRes Resolution) []provider.Image {
+	if st.Reblog != nil || st.Sensitive {
+		return nil
+	}
+
+	handle := st.Account.Acct
+	if handle != "" && !strings.Contains(handle, "@") {
+		handle += "@" + hostOnly(q.instance) // Local accounts are reported without their domain
+	}
+	viewURL := st.URL
+	if viewURL == "" {
+		viewURL = st.URI
+	}
+
+	var images []provider.Image
+	for _, att := range st.MediaAttachments {
+		if att.Type != "image" {
+			continue
+		}
+		src := att.URL
+		if src == "" {
+			src = att.RemoteURL
+		}
+		fileType, ok := imageType(src)
+		if !ok {
+			continue
+		}
+
+		var width, height int
+		if att.Meta != nil && att.Meta.Original != nil {
+			width, height = att.Meta.Original.Width, att.Meta.Original.Height
+		}
+		if !meetsResolution(width, height, minRes) {
+			continue
+		}
+
+		img := provider.Image{
+			ID:       ProviderName + "_" + idSafe(hostOnly(q.instance)) + "_" + idSafe(att.ID),
+			Path:     src,
+			ViewURL:  viewURL,
+			Title:    firstLine(att.Description),
+			FileType: fileType,
+			Width:    width,
+			Height:   height,
+			Provider: ProviderName,
+		}
+		if handle != "" {
+			img.Attribution = "@" + handle
+		}
+		if len(st.CreatedAt) >= 4 {
+			img.Year = st.CreatedAt[:4]
+		}
+		images = append(images, img)
+	}
+	return images
+}
+
+// EnrichImage is a no-op as all metadata comes from the status.
+func (p *Provider) EnrichImage(ctx context.Context, img provider.Image) (provider.Image, error) {
+	return img, nil
+}
+
+// meetsResolution compares the long and short sides so portrait photos qualify on the same terms as landscape ones.
+// Attachments without reported dimensions only pass when no minimum is set.
+func meetsResolution(width, height int, minRes Resolution) bool {
+	if minRes.Width == 0 && minRes.Height == 0 {
+		return true
+	}
+	long, short := max(width, height), min(width, height)
+	return long >= minRes.Width && short >= minRes.Height
+}
+
+// imageType returns the MIME type of a JPEG or PNG attachment URL.
+func imageType(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg", true
+	case ".png":
+		return "image/png", true
+	}
+	return "", false
+}
+
+func hostOnly(instance string) string {
+	if u, err := url.Parse(instance); err == nil {
+		return u.Hostname()
+	}
+	return instance
+}
+
+var idUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// idSafe makes a host name or attachment ID safe for use in a cache file name.
+func idSafe(s string) string {
+	return idUnsafe.ReplaceAllString(s, "-")
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, "\r\n"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s
+}
+
+// --- UI Implementation (Pure Go) ---
+
+// resolutionOptions returns the labels of the minimum resolution presets.
+func resolutionOptions() []string {
+	options := make([]string, len(Resolutions))
+	for i, r := range Resolutions {
+		if r.Width == 0 {
+			options[i] = i18n.T("Any")
+			continue
+		}
+		options[i] = fmt.Sprintf("%s (%d×%d)", r.Label, r.Width, r.Height)
+	}
+	return options
+}
+
+// CreateSettingsPanel returns the declarative UI for Fediverse settings.
+func (p *Provider) CreateSettingsPanel(sm setting.SettingsManager) *schema.PanelSchema {
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:   i18n.T("Mastodon & Pixelfed"),
+				Compact: true,
+				Items: []schema.ItemSchema{
+					schema.LabelItem{
+						Text:       i18n.T("Use photos posted to hashtags or by accounts on Mastodon, Pixelfed and other Fediverse servers. Only public posts are used; sensitive posts are skipped."),
+						Importance: schema.ImportanceLow,
+					},
+					schema.SelectItem{
+						Name:         "fediverseMinResolution",
+						Label:        i18n.T("Minimum Resolution:"),
+						Help:         i18n.T("Skip attachments smaller than this. Photos whose server does not report their size are only used with \"Any\"."),
+						Options:      resolutionOptions(),
+						InitialValue: p.minResolutionIndex(),
+						ApplyFunc: func(val interface{}) {
+							p.cfg.SetFediverseMinResolution(val.(int))
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (p *Provider) minResolutionIndex() int {
+	idx := p.cfg.GetFediverseMinResolution()
+	if idx < 0 || idx >= len(Resolutions) {
+		return DefaultResolution
+	}
+	return idx
+}
+
+// CreateQueryPanel creates the image query management panel.
+func (p *Provider) CreateQueryPanel(sm setting.SettingsManager, pendingUrl string) *schema.PanelSchema {
+	addCfg := schema.AddQueryConfig{
+		Title:           i18n.T("Add Hashtag or Account"),
+		URLPlaceholder:  "https://mastodon.social/tags/wallpaper",
+		URLValidator:    FediverseURLRegexp,
+		URLErrorMsg:     i18n.T("Enter a hashtag or account link, e.g. https://pixelfed.social/discover/tags/landscape or https://mastodon.social/@user"),
+		DescPlaceholder: i18n.T("Query Description (e.g. #landscapephotography)"),
+		AddHandler: func(desc, url string, active bool) (string, error) {
+			queryURL, err := p.ParseURL(url)
+			if err != nil {
+				return "", err
+			}
+			return p.cfg.AddFediverseQuery(desc, queryURL, active)
+		},
+	}
+
+	if pendingUrl != "" {
+		sm.ShowAddQueryDialog(addCfg, pendingUrl, "", sm.RefreshUI)
+	}
+
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:       i18n.T("My Hashtags & Accounts"),
+				Description: i18n.T("Manage your hashtags and accounts here."),
+				Items: []schema.ItemSchema{
+					schema.ButtonItem{
+						Name:       "fediverse_add",
+						ButtonText: i18n.T("Add Hashtag or Account"),
+						IconName:   "add",
+						OnPressed: func() {
+							sm.ShowAddQueryDialog(addCfg, "", "", sm.RefreshUI)
+						},
+					},
+					schema.QueryListItem{
+						GetQueries: func() []schema.Query {
+							queries := p.cfg.GetFediverseQueries()
+							abstracts := make([]schema.Query, len(queries))
+							for i, q := range queries {
+								abstracts[i] = schema.Query{
+									ID:          q.ID,
+									URL:         q.URL,
+									Description: q.Description,
+									Active:      q.Active,
+									Managed:     q.Managed,
+								}
+							}
+							return abstracts
+						},
+						EnableQuery:  p.cfg.EnableImageQuery,
+						DisableQuery: p.cfg.DisableImageQuery,
+						RemoveQuery:  p.cfg.RemoveImageQuery,
+						GetDisplayURL: func(q schema.Query) *url.URL {
+							u, _ := url.Parse(q.URL)
+							return u
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,234 @@
+package fediverse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProvider returns a provider that talks to the given test server over HTTPS.
+func newTestProvider(ts *httptest.Server) (*Provider, string) {
+	p := NewProvider(nil, ts.Client())
+	return p, ts.URL
+}
+
+func statusJSON(id, acct, mediaURL string, width, height int, extra string) string {
+	return fmt.Sprintf(`{"id":%q,"url":"https://example.social/@%s/%s","created_at":"2025-03-01T10:00:00.000Z","sensitive":false,%s
+		"account":{"id":"77","acct":%q},
+		"media_attachments":[{"id":"m%s","type":"image","url":%q,"description":"Fjord at dawn\nShot on film",
+			"meta":{"original":{"width":%d,"height":%d}}}]}`, id, acct, id, extra, acct, id, mediaURL, width, height)
+}
+
+func TestFetchImages_HashtagPaging(t *testing.T) {
+	var maxIDs []string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/timelines/tag/wallpaper", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("only_media"))
+		assert.Equal(t, "40", r.URL.Query().Get("limit"))
+		maxID := r.URL.Query().Get("max_id")
+		maxIDs = append(maxIDs, maxID)
+		switch maxID {
+		case "":
+			_, _ = w.Write([]byte("[" + strings.Join([]string{
+				statusJSON("300", "alice", "https://files.example.social/a.jpg", 4000, 3000, ""),
+				statusJSON("299", "bob@pixelfed.example", "https://files.example.social/b.png", 1200, 800, ""), // Too small
+				statusJSON("298", "carol", "https://files.example.social/c.webp", 4000, 3000, ""),              // Not decodable
+				statusJSON("297", "dave", "https://files.example.social/d.jpg", 4000, 3000, `"reblog":{"id":"1"},`),
+			}, ",") + "]"))
+		case "297":
+			_, _ = w.Write([]byte("[" + statusJSON("200", "erin@pixelfed.example", "https://files.example.social/e.jpeg", 2160, 3840, "") + "]"))
+		default:
+			_, _ = w.Write([]byte("[]"))
+		}
+	}))
+	defer ts.Close()
+
+	p, base := newTestProvider(ts)
+	queryURL, err := p.ParseURL(base + "/tags/Wallpaper")
+	require.NoError(t, err)
+
+	images, err := p.FetchImages(context.Background(), queryURL, 1)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+
+	img := images[0]
+	assert.Equal(t, "https://files.example.social/a.jpg", img.Path)
+	assert.Equal(t, "https://example.social/@alice/300", img.ViewURL)
+	assert.Equal(t, "@alice@127.0.0.1", img.Attribution, "local accounts get the instance domain")
+	assert.Equal(t, "Fjord at dawn", img.Title)
+	assert.Equal(t, "2025", img.Year)
+	assert.Equal(t, "image/jpeg", img.FileType)
+	assert.Equal(t, 4000, img.Width)
+	assert.Equal(t, "Fediverse_127-0-0-1_m300", img.ID)
+
+	images, err = p.FetchImages(context.Background(), queryURL, 2)
+	require.NoError(t, err)
+	require.Len(t, images, 1, "page 2 continues below the last status of page 1")
+	assert.Equal(t, "@erin@pixelfed.example", images[0].Attribution)
+	assert.Equal(t, 3840, images[0].Height, "portrait photos qualify by their long side")
+
+	images, err = p.FetchImages(context.Background(), queryURL, 3)
+	require.NoError(t, err)
+	assert.Empty(t, images)
+
+	images, err = p.FetchImages(context.Background(), queryURL, 7)
+	require.NoError(t, err)
+	assert.Empty(t, images, "pages without a cursor wrap around")
+	assert.Equal(t, []string{"", "297", "200"}, maxIDs)
+}
+
+func TestFetchImages_SkipsPagesWithoutImages(t *testing.T) {
+	calls := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Query().Get("max_id") == "" {
+			_, _ = w.Write([]byte("[" + statusJSON("50", "alice", "https://files.example.social/small.jpg", 640, 480, "") + "]"))
+			return
+		}
+		_, _ = w.Write([]byte("[" + statusJSON("40", "alice", "https://files.example.social/big.jpg", 6000, 4000, "") + "]"))
+	}))
+	defer ts.Close()
+
+	p, base := newTestProvider(ts)
+	images, err := p.FetchImages(context.Background(), base+"/tags/landscape", 1)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	assert.Equal(t, "https://files.example.social/big.jpg", images[0].Path)
+	assert.Equal(t, 2, calls)
+
+	p.mu.Lock()
+	assert.Equal(t, "40", p.cursors[base+"/tags/landscape"][2])
+	p.mu.Unlock()
+}
+
+func TestFetchImages_Account(t *testing.T) {
+	lookups := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/accounts/lookup":
+			lookups++
+			assert.Equal(t, "photog@pixelfed.example", r.URL.Query().Get("acct"))
+			_, _ = w.Write([]byte(`{"id":"109","acct":"photog@pixelfed.example"}`))
+		case "/api/v1/accounts/109/statuses":
+			assert.Equal(t, "true", r.URL.Query().Get("exclude_reblogs"))
+			assert.Equal(t, "true", r.URL.Query().Get("exclude_replies"))
+			_, _ = w.Write([]byte("[" + statusJSON("9", "photog@pixelfed.example", "https://cdn.example/p.jpg", 5000, 3000, "") + "]"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	p, base := newTestProvider(ts)
+	for page := 1; page <= 2; page++ {
+		images, err := p.FetchImages(context.Background(), base+"/@photog@pixelfed.example", page)
+		require.NoError(t, err)
+		require.Len(t, images, 1)
+		assert.Equal(t, "@photog@pixelfed.example", images[0].Attribution)
+	}
+	assert.Equal(t, 1, lookups, "the account ID is cached")
+}
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	reset := now.Add(3 * time.Minute)
+	remaining := "100"
+	calls := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-RateLimit-Limit", "300")
+		w.Header().Set("X-RateLimit-Remaining", remaining)
+		w.Header().Set("X-RateLimit-Reset", reset.Format("2006-01-02T15:04:05.000Z"))
+		if remaining == "0" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer ts.Close()
+
+	p, base := newTestProvider(ts)
+	p.now = func() time.Time { return now }
+	queryURL := base + "/tags/wallpaper"
+
+	_, err := p.FetchImages(context.Background(), queryURL, 1)
+	require.NoError(t, err)
+	assert.False(t, p.IsThrottled())
+
+	remaining = "3"
+	_, err = p.FetchImages(context.Background(), queryURL, 1)
+	require.NoError(t, err)
+	assert.True(t, p.IsThrottled(), "the instance is paused once its window is nearly used up")
+
+	_, err = p.FetchImages(context.Background(), queryURL, 1)
+	assert.ErrorIs(t, err, errRateLimited)
+	assert.Equal(t, 2, calls, "no request is sent while throttled")
+
+	now = reset.Add(time.Second)
+	assert.False(t, p.IsThrottled(), "the throttle lifts when the window resets")
+
+	remaining = "0"
+	_, err = p.FetchImages(context.Background(), queryURL, 1)
+	assert.ErrorIs(t, err, errRateLimited)
+	assert.False(t, p.IsThrottled(), "a reset time in the past does not throttle")
+
+	reset = now.Add(time.Minute)
+	_, err = p.FetchImages(context.Background(), queryURL, 1)
+	assert.ErrorIs(t, err, errRateLimited)
+	assert.True(t, p.IsThrottled())
+}
+
+func TestParseReset_RetryAfter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	h := http.Header{}
+	h.Set("Retry-After", "120")
+	assert.Equal(t, now.Add(2*time.Minute), parseReset(h, now))
+	assert.True(t, parseReset(http.Header{}, now).IsZero())
+}
+
+func TestParseURL(t *testing.T) {
+	p := NewProvider(nil, http.DefaultClient)
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"https://mastodon.social/tags/Wallpaper", "https://mastodon.social/tags/wallpaper", false},
+		{"https://Mastodon.Social/tags/landscapephotography/", "https://mastodon.social/tags/landscapephotography", false},
+		{"https://pixelfed.social/discover/tags/landscape?src=hash", "https://pixelfed.social/discover/tags/landscape", false},
+		{"https://mastodon.social/@alice", "https://mastodon.social/@alice", false},
+		{"https://mastodon.social/@alice@mastodon.social", "https://mastodon.social/@alice", false},
+		{"https://mastodon.social/@bob@pixelfed.social", "https://mastodon.social/@bob@pixelfed.social", false},
+		{"https://mastodon.social/@alice/1234567", "", true},
+		{"https://unsplash.com/@alice", "", true},
+		{"http://mastodon.social/tags/wallpaper", "", true},
+		{"https://example.com/feed.xml", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := p.ParseURL(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMeetsResolution(t *testing.T) {
+	fullHD := Resolutions[DefaultResolution]
+	assert.True(t, meetsResolution(1920, 1080, fullHD))
+	assert.True(t, meetsResolution(1080, 1920, fullHD))
+	assert.False(t, meetsResolution(1920, 1000, fullHD))
+	assert.False(t, meetsResolution(0, 0, fullHD), "unknown sizes need the Any preset")
+	assert.True(t, meetsResolution(0, 0, Resolutions[0]))
+}
@@ -0,0 +1,20 @@
+package feed
+
+import "time"
+
+const (
+	// FeedUserAgent identifies Spice to feed hosts. Some CDNs reject Go's default User-Agent.
+	FeedUserAgent = "Spice-Wallpaper-App/1.0 (https://github.com/dixieflatline76/Spice)"
+
+	// FeedURLRegexp validates feed URLs. feed:// is accepted and rewritten to https://.
+	FeedURLRegexp = `^(?i)(?:https?|feed)://[^\s/$.?#][^\s]*$`
+
+	// FeedMaxBodyBytes caps the size of a single feed document.
+	FeedMaxBodyBytes = 16 << 20 // 16 MiB
+
+	// FeedAPIPacing spaces out feed requests. Feeds are often served by small self-hosted sites.
+	FeedAPIPacing = 1 * time.Second
+
+	// FeedMediaPacing spaces out image downloads.
+	FeedMediaPacing = 250 * time.Millisecond
+)
@@ -0,0 +1,642 @@
+package feed
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/schema"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/setting"
+	"github.com/dixieflatline76/Spice/v2/pkg/wallpaper"
+	"github.com/dixieflatline76/Spice/v2/util/log"
+)
+
+//go:embed Feed.png
+var iconData []byte
+
+// Provider implements ImageProvider for RSS 2.0, RSS 1.0 (RDF) and Atom feeds,
+// including the Media RSS extension used by most photo sites.
+type Provider struct {
+	cfg        *wallpaper.Config
+	httpClient *http.Client
+
+	// nextPages maps a feed URL to the document URL of each page beyond the first,
+	// as advertised by the feed's rel="next" links (RFC 5005).
+	nextPages map[string]map[int]string
+	mu        sync.Mutex
+}
+
+func init() {
+	wallpaper.RegisterProvider("Feed", func(cfg *wallpaper.Config, client *http.Client) provider.ImageProvider {
+		return NewProvider(cfg, client)
+	})
+}
+
+// NewProvider creates a new Feed Provider.
+func NewProvider(cfg *wallpaper.Config, client *http.Client) *Provider {
+	return &Provider{
+		cfg:        cfg,
+		httpClient: client,
+		nextPages:  make(map[string]map[int]string),
+	}
+}
+
+func (p *Provider) ID() string {
+	return "Feed"
+}
+
+func (p *Provider) Name() string {
+	return i18n.T("RSS / Atom Feeds")
+}
+
+func (p *Provider) Title() string {
+	return "Feeds"
+}
+
+func (p *Provider) GetProviderIcon() interface{} {
+	return iconData
+}
+
+func (p *Provider) Type() provider.ProviderType {
+	return provider.TypeCommunity
+}
+
+func (p *Provider) HomeURL() string {
+	return ""
+}
+
+func (p *Provider) GetAttributionType() provider.AttributionType {
+	return provider.AttributionBy
+}
+
+func (p *Provider) SupportsUserQueries() bool {
+	return true
+}
+
+// AcceptsAnyURL implements the CatchAllProvider interface: any http(s) URL may be a feed.
+func (p *Provider) AcceptsAnyURL() bool {
+	return true
+}
+
+var feedURLRegex = regexp.MustCompile(FeedURLRegexp)
+
+// ParseURL validates a feed URL. The feed:// pseudo-scheme some browsers hand out is rewritten to https://.
+func (p *Provider) ParseURL(webURL string) (string, error) {
+	webURL = strings.TrimSpace(webURL)
+	if !feedURLRegex.MatchString(webURL) {
+		return "", errors.New("invalid feed URL")
+	}
+
+	u, err := url.Parse(webURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid feed URL: %w", err)
+	}
+	if strings.EqualFold(u.Scheme, "feed") {
+		u.Scheme = "https"
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Fragment = ""
+	return u.String(), nil
+}
+
+// GetAPIPacing implements the PacedProvider interface to space out feed requests.
+func (p *Provider) GetAPIPacing() time.Duration {
+	return FeedAPIPacing
+}
+
+// GetProcessPacing implements the PacedProvider interface to space out image downloads.
+func (p *Provider) GetProcessPacing() time.Duration {
+	return FeedMediaPacing
+}
+
+// GetDownloadHeaders implements HeaderProvider so image hosts see the same User-Agent as the feed host.
+func (p *Provider) GetDownloadHeaders() map[string]string {
+	return map[string]string{
+		"User-Agent": FeedUserAgent,
+	}
+}
+
+// FetchImages downloads one page of the feed and extracts an image from each entry.
+// Page 1 is the feed URL itself; later pages are only known once the previous page
+// has been fetched and advertised a "next" link.
+func (p *Provider) FetchImages(ctx context.Context, apiURL string, page int) ([]provider.Image, error) {
+	pageURL := apiURL
+	if page > 1 {
+		p.mu.Lock()
+		next, ok := p.nextPages[apiURL][page]
+		p.mu.Unlock()
+		if !ok {
+			return nil, nil
+		}
+		pageURL = next
+	}
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed URL: %w", err)
+	}
+
+	doc, err := p.fetchDocument(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	feedTitle := doc.Title
+	var entries []feedEntry
+	entries = append(entries, doc.Entries...)
+	entries = append(entries, doc.Items...)
+	links := doc.Links
+	if doc.Channel != nil {
+		feedTitle = doc.Channel.Title
+		entries = append(entries, doc.Channel.Items...)
+		links = append(links, doc.Channel.Links...)
+	}
+
+	var images []provider.Image
+	for _, entry := range entries {
+		img, ok := p.mapEntry(entry, base, strings.TrimSpace(feedTitle))
+		if !ok {
+			continue
+		}
+		images = append(images, img)
+	}
+
+	if next := resolveURL(base, findLink(links, "next")); next != "" && next != pageURL {
+		p.mu.Lock()
+		if p.nextPages[apiURL] == nil {
+			p.nextPages[apiURL] = make(map[int]string)
+		}
+		p.nextPages[apiURL][page+1] = next
+		p.mu.Unlock()
+	}
+
+	if len(images) == 0 {
+		log.Printf("Feed returned 0 images for URL: %s (page %d)", pageURL, page)
+	} else {
+		log.Debugf("Found %d images in feed %s", len(images), pageURL)
+	}
+
+	return images, nil
+}
+
+func (p *Provider) fetchDocument(ctx context.Context, pageURL string) (*feedDocument, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", FeedUserAgent)
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/rdf+xml;q=0.9, application/xml;q=0.9, text/xml;q=0.8, */*;q=0.5")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+		log.Printf("Feed Error (%d): %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("feed returned status %d", resp.StatusCode)
+	}
+
+	// Real-world feeds are frequently not well-formed XML (HTML entities, stray ampersands),
+	// so the decoder runs in non-strict mode with the HTML entity table.
+	decoder := xml.NewDecoder(io.LimitReader(resp.Body, FeedMaxBodyBytes))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = charsetReader
+
+	var doc feedDocument
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode feed: %w", err)
+	}
+	return &doc, nil
+}
+
+// charsetReader handles the single-byte Western encodings that are still common in older feeds.
+// Windows-1252 is decoded as ISO-8859-1, which only differs in rarely used punctuation.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(label) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1", "windows-1252", "cp1252":
+		return &latin1Reader{r: bufio.NewReader(input)}, nil
+	}
+	return nil, fmt.Errorf("unsupported feed charset %q", label)
+}
+
+// latin1Reader transcodes ISO-8859-1 to UTF-8. Every byte maps directly to the rune of the same value.
+type latin1Reader struct {
+	r       *bufio.Reader
+	pending []byte
+}
+
+func (l *latin1Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(l.pending) > 0 {
+			c := copy(p[n:], l.pending)
+			l.pending = l.pending[c:]
+			n += c
+			continue
+		}
+		b, err := l.r.ReadByte()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		if b < 0x80 {
+			p[n] = b
+			n++
+			continue
+		}
+		l.pending = []byte(string(rune(b)))
+	}
+	return n, nil
+}
+
+func (p *Provider) mapEntry(entry feedEntry, base *url.URL, feedTitle string) (provider.Image, bool) {
+	media, ok := pickImage(entry, base)
+	if !ok {
+		return provider.Image{}, false
+	}
+
+	viewURL := resolveURL(base, entryLink(entry.Links))
+
+	// IDs become file names, so derive one from the most stable identifier the entry offers.
+	key := firstNonEmpty(entry.GUID, entry.ID, viewURL, media.URL)
+	sum := sha256.Sum256([]byte(key))
+
+	artist := firstNonEmpty(entry.Creator, firstNonEmpty(entry.MediaCredits...), entryAuthor(entry.Authors))
+	attribution := artist
+	if attribution == "" {
+		attribution = feedTitle
+	}
+
+	return provider.Image{
+		ID:          hex.EncodeToString(sum[:8]),
+		Path:        media.URL,
+		ViewURL:     viewURL,
+		Attribution: attribution,
+		Title:       firstNonEmpty(entry.MediaTitle, entry.Title),
+		Artist:      artist,
+		Provider:    p.ID(),
+		FileType:    media.Type,
+		Width:       media.Width,
+		Height:      media.Height,
+	}, true
+}
+
+// mediaCandidate is an image reference found in an entry, after URL resolution.
+type mediaCandidate struct {
+	URL    string
+	Type   string
+	Width  int
+	Height int
+}
+
+var imgSrcRegex = regexp.MustCompile(`(?i)<img\s[^>]*?src\s*=\s*["']([^"']+)["']`)
+
+// pickImage chooses the best full-size image for an entry, in order of preference:
+// the largest Media RSS image, an image enclosure, and finally the first <img> in the entry's HTML.
+func pickImage(entry feedEntry, base *url.URL) (mediaCandidate, bool) {
+	var best mediaCandidate
+	bestArea := -1
+	contents := entry.MediaContents
+	for _, g := range entry.MediaGroups {
+		contents = append(contents, g.Contents...)
+	}
+	for _, mc := range contents {
+		if !isImage(mc.URL, mc.Type, mc.Medium) {
+			continue
+		}
+		u := resolveURL(base, mc.URL)
+		if u == "" {
+			continue
+		}
+		w, _ := strconv.Atoi(strings.TrimSpace(mc.Width))
+		h, _ := strconv.Atoi(strings.TrimSpace(mc.Height))
+		if area := w * h; area > bestArea {
+			best = mediaCandidate{URL: u, Type: mc.Type, Width: w, Height: h}
+			bestArea = area
+		}
+	}
+	if best.URL != "" {
+		return best, true
+	}
+
+	for _, enc := range entry.Enclosures {
+		if !isImage(enc.URL, enc.Type, "") {
+			continue
+		}
+		if u := resolveURL(base, enc.URL); u != "" {
+			return mediaCandidate{URL: u, Type: enc.Type}, true
+		}
+	}
+
+	for _, l := range entry.Links {
+		if l.Rel != "enclosure" || !isImage(l.Href, l.Type, "") {
+			continue
+		}
+		if u := resolveURL(base, l.Href); u != "" {
+			return mediaCandidate{URL: u, Type: l.Type}, true
+		}
+	}
+
+	for _, body := range []string{entry.Encoded, entry.Content.Text, entry.Content.Inner, entry.Description, entry.Summary.Text, entry.Summary.Inner} {
+		for _, m := range imgSrcRegex.FindAllStringSubmatch(body, -1) {
+			src := html.UnescapeString(m[1])
+			if !isImage(src, "", "") {
+				continue
+			}
+			if u := resolveURL(base, src); u != "" {
+				return mediaCandidate{URL: u}, true
+			}
+		}
+	}
+
+	return mediaCandidate{}, false
+}
+
+// isImage reports whether a media reference is a still image we can decode.
+// The MIME type and Media RSS medium are trusted when present; otherwise the file extension decides,
+// and references without any extension (common for CDN URLs) are given the benefit of the doubt.
+func isImage(rawURL, mimeType, medium string) bool {
+	if rawURL == "" {
+		return false
+	}
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	if mimeType == "image/gif" || mimeType == "image/svg+xml" {
+		return false
+	}
+	if mimeType != "" {
+		return strings.HasPrefix(mimeType, "image/")
+	}
+	if medium != "" {
+		return strings.EqualFold(medium, "image")
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".jpg", ".jpeg", ".png", ".webp", "":
+		return true
+	}
+	return false
+}
+
+// resolveURL resolves ref against the page it came from and returns it only if it is an http(s) URL.
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	u = base.ResolveReference(u)
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.String()
+}
+
+// findLink returns the href of the first link with the given relation.
+func findLink(links []feedLink, rel string) string {
+	for _, l := range links {
+		if strings.EqualFold(l.Rel, rel) && l.Href != "" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// entryLink returns the entry's web page: the Atom alternate link or the RSS <link> text.
+func entryLink(links []feedLink) string {
+	for _, l := range links {
+		if l.Href != "" && (l.Rel == "" || l.Rel == "alternate") {
+			return l.Href
+		}
+		if l.Href == "" && strings.TrimSpace(l.Text) != "" {
+			return l.Text
+		}
+	}
+	return ""
+}
+
+// rssAuthorRegex matches the RSS 2.0 author format "email@example.com (Full Name)".
+var rssAuthorRegex = regexp.MustCompile(`^\S+@\S+\s+\((.+)\)$`)
+
+func entryAuthor(authors []feedAuthor) string {
+	for _, a := range authors {
+		if name := strings.TrimSpace(a.Name); name != "" {
+			return name
+		}
+		text := strings.TrimSpace(a.Text)
+		if m := rssAuthorRegex.FindStringSubmatch(text); m != nil {
+			return strings.TrimSpace(m[1])
+		}
+		if text != "" && !strings.Contains(text, "@") {
+			return text
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// EnrichImage is a no-op for feeds as all metadata comes from the feed document.
+func (p *Provider) EnrichImage(ctx context.Context, img provider.Image) (provider.Image, error) {
+	return img, nil
+}
+
+// --- Feed document model ---
+//
+// A single model covers RSS 2.0 (<rss><channel><item>), RSS 1.0 (<rdf:RDF><channel/><item>)
+// and Atom (<feed><entry>). Namespaced fields must be declared before the un-namespaced
+// fields with the same local name, because encoding/xml assigns an element to the first match.
+
+type feedDocument struct {
+	XMLName xml.Name
+	Title   string       `xml:"title"`
+	Channel *feedChannel `xml:"channel"`
+	Links   []feedLink   `xml:"link"`
+	Entries []feedEntry  `xml:"entry"`
+	Items   []feedEntry  `xml:"item"`
+}
+
+type feedChannel struct {
+	Title string      `xml:"title"`
+	Links []feedLink  `xml:"link"`
+	Items []feedEntry `xml:"item"`
+}
+
+type feedEntry struct {
+	MediaContents []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaGroups   []mediaGroup   `xml:"http://search.yahoo.com/mrss/ group"`
+	MediaCredits  []string       `xml:"http://search.yahoo.com/mrss/ credit"`
+	MediaTitle    string         `xml:"http://search.yahoo.com/mrss/ title"`
+	Creator       string         `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Encoded       string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+
+	ID          string          `xml:"id"`
+	GUID        string          `xml:"guid"`
+	Title       string          `xml:"title"`
+	Links       []feedLink      `xml:"link"`
+	Authors     []feedAuthor    `xml:"author"`
+	Enclosures  []feedEnclosure `xml:"enclosure"`
+	Description string          `xml:"description"`
+	Summary     feedText        `xml:"summary"`
+	Content     feedText        `xml:"content"`
+}
+
+// feedLink covers both Atom links (attributes) and RSS links (element text).
+type feedLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+// feedAuthor covers Atom (<author><name>) and RSS (<author>text</author>).
+type feedAuthor struct {
+	Name string `xml:"name"`
+	Text string `xml:",chardata"`
+}
+
+// feedText captures both escaped HTML (type="html") and inline XHTML (type="xhtml") content.
+type feedText struct {
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+type feedEnclosure struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type mediaGroup struct {
+	Contents []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+}
+
+// mediaContent keeps dimensions as strings since feeds in the wild put junk in them.
+type mediaContent struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Medium string `xml:"medium,attr"`
+	Width  string `xml:"width,attr"`
+	Height string `xml:"height,attr"`
+}
+
+// --- UI Implementation (Pure Go) ---
+
+// CreateSettingsPanel returns the declarative UI for Feed settings.
+func (p *Provider) CreateSettingsPanel(sm setting.SettingsManager) *schema.PanelSchema {
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:   i18n.T("RSS / Atom Feeds"),
+				Compact: true,
+				Items: []schema.ItemSchema{
+					schema.LabelItem{
+						Text:       i18n.T("Use images from any RSS or Atom feed, such as a photo blog, a Flickr feed or a news site."),
+						Importance: schema.ImportanceLow,
+					},
+				},
+			},
+		},
+	}
+}
+
+// CreateQueryPanel creates the image query management panel.
+func (p *Provider) CreateQueryPanel(sm setting.SettingsManager, pendingUrl string) *schema.PanelSchema {
+	addCfg := schema.AddQueryConfig{
+		Title:           i18n.T("Add Feed"),
+		URLPlaceholder:  "https://example.com/feed.xml",
+		URLValidator:    FeedURLRegexp,
+		URLErrorMsg:     i18n.T("Invalid feed URL"),
+		DescPlaceholder: i18n.T("Query Description (e.g. Photo Blog)"),
+		AddHandler: func(desc, url string, active bool) (string, error) {
+			feedURL, err := p.ParseURL(url)
+			if err != nil {
+				return "", err
+			}
+			return p.cfg.AddFeedQuery(desc, feedURL, active)
+		},
+	}
+
+	if pendingUrl != "" {
+		sm.ShowAddQueryDialog(addCfg, pendingUrl, "", sm.RefreshUI)
+	}
+
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:       i18n.T("My Feeds"),
+				Description: i18n.T("Manage your feeds here."),
+				Items: []schema.ItemSchema{
+					schema.ButtonItem{
+						Name:       "feed_add",
+						ButtonText: i18n.T("Add Feed"),
+						IconName:   "add",
+						OnPressed: func() {
+							sm.ShowAddQueryDialog(addCfg, "", "", sm.RefreshUI)
+						},
+					},
+					schema.QueryListItem{
+						GetQueries: func() []schema.Query {
+							queries := p.cfg.GetFeedQueries()
+							abstracts := make([]schema.Query, len(queries))
+							for i, q := range queries {
+								abstracts[i] = schema.Query{
+									ID:          q.ID,
+									URL:         q.URL,
+									Description: q.Description,
+									Active:      q.Active,
+									Managed:     q.Managed,
+								}
+							}
+							return abstracts
+						},
+						EnableQuery:  p.cfg.EnableImageQuery,
+						DisableQuery: p.cfg.DisableImageQuery,
+						RemoveQuery:  p.cfg.RemoveImageQuery,
+						GetDisplayURL: func(q schema.Query) *url.URL {
+							u, _ := url.Parse(q.URL)
+							return u
+						},
+					},
+				},
+			},
+		},
+	}
+}
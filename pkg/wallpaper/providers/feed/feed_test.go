@@ -0,0 +1,246 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rssPage1 = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/" xmlns:atom="http://www.w3.org/2005/Atom"
+     xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+  <channel>
+    <title>Photo Blog</title>
+    <link>https://blog.example.com/</link>
+    <atom:link rel="self" href="/rss"/>
+    <atom:link rel="next" href="/rss?page=2"/>
+    <item>
+      <title>Sunset &amp; Sea</title>
+      <link>https://blog.example.com/sunset</link>
+      <guid>sunset-1</guid>
+      <dc:creator>Jane Doe</dc:creator>
+      <media:title>Sunset over the sea</media:title>
+      <media:content url="https://img.example.com/sunset_small.jpg" medium="image" width="640" height="360"/>
+      <media:content url="https://img.example.com/sunset_large.jpg" medium="image" width="3840" height="2160"/>
+      <media:content url="https://img.example.com/sunset.mp4" medium="video" width="7680" height="4320"/>
+    </item>
+    <item>
+      <title>Harbour</title>
+      <link>https://blog.example.com/harbour</link>
+      <author>jdoe@example.com (John Smith)</author>
+      <enclosure url="/images/harbour.png" type="image/png" length="1000"/>
+    </item>
+    <item>
+      <title>Inline</title>
+      <link>https://blog.example.com/inline</link>
+      <description>&lt;p&gt;Look: &lt;img alt="x" src="https://img.example.com/inline.jpg?w=2000&amp;amp;h=1000"&gt;&lt;/p&gt;</description>
+    </item>
+    <item>
+      <title>Podcast</title>
+      <enclosure url="https://cdn.example.com/episode.mp3" type="audio/mpeg"/>
+    </item>
+  </channel>
+</rss>`
+
+const rssPage2 = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+  <channel>
+    <title>Photo Blog</title>
+    <item>
+      <title>Forest</title>
+      <guid>forest-1</guid>
+      <media:group>
+        <media:content url="https://img.example.com/forest.jpg" type="image/jpeg" width="2560" height="1440"/>
+      </media:group>
+    </item>
+  </channel>
+</rss>`
+
+const atomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Atom Photos</title>
+  <link rel="self" href="https://atom.example.com/feed"/>
+  <entry>
+    <id>tag:atom.example.com,2024:1</id>
+    <title>Mountains</title>
+    <link rel="alternate" type="text/html" href="https://atom.example.com/mountains"/>
+    <link rel="enclosure" type="image/jpeg" href="https://atom.example.com/mountains.jpg"/>
+    <author><name>Alex Roe</name></author>
+  </entry>
+  <entry>
+    <id>tag:atom.example.com,2024:2</id>
+    <title>Lake</title>
+    <link href="https://atom.example.com/lake"/>
+    <content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><img src="/lake.webp"/></div></content>
+  </entry>
+</feed>`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rss", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, FeedUserAgent, r.Header.Get("User-Agent"))
+		w.Header().Set("Content-Type", "application/rss+xml")
+		if r.URL.Query().Get("page") == "2" {
+			_, _ = w.Write([]byte(rssPage2))
+			return
+		}
+		_, _ = w.Write([]byte(rssPage1))
+	})
+	mux.HandleFunc("/atom", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/atom+xml")
+		_, _ = w.Write([]byte(atomFeed))
+	})
+	mux.HandleFunc("/latin1", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<rss><channel><item><title>Caf\xe9</title>" +
+			"<enclosure url=\"https://img.example.com/cafe.jpg\" type=\"image/jpeg\"/></item></channel></rss>"))
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFeedParseURL(t *testing.T) {
+	p := &Provider{}
+
+	tests := []struct {
+		input    string
+		expected string
+		hasError bool
+	}{
+		{"https://example.com/feed.xml", "https://example.com/feed.xml", false},
+		{"  http://example.com/rss  ", "http://example.com/rss", false},
+		{"feed://example.com/atom", "https://example.com/atom", false},
+		{"FEED://example.com/atom#top", "https://example.com/atom", false},
+		{"ftp://example.com/feed", "", true},
+		{"example.com/feed", "", true},
+		{"", "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			res, err := p.ParseURL(tc.input)
+			if tc.hasError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, res)
+			}
+		})
+	}
+}
+
+func TestFeedFetchImages_RSS(t *testing.T) {
+	server := newTestServer(t)
+	p := NewProvider(nil, server.Client())
+	feedURL := server.URL + "/rss"
+
+	images, err := p.FetchImages(context.Background(), feedURL, 1)
+	require.NoError(t, err)
+	require.Len(t, images, 3, "entries without a usable image should be skipped")
+
+	// Media RSS: largest image wins, videos are ignored.
+	assert.Equal(t, "https://img.example.com/sunset_large.jpg", images[0].Path)
+	assert.Equal(t, 3840, images[0].Width)
+	assert.Equal(t, 2160, images[0].Height)
+	assert.Equal(t, "Sunset over the sea", images[0].Title)
+	assert.Equal(t, "Jane Doe", images[0].Artist)
+	assert.Equal(t, "Jane Doe", images[0].Attribution)
+	assert.Equal(t, "https://blog.example.com/sunset", images[0].ViewURL)
+	assert.Equal(t, "Feed", images[0].Provider)
+	assert.Len(t, images[0].ID, 16)
+
+	// Enclosure: relative URL resolved against the feed, RSS author format parsed.
+	assert.Equal(t, server.URL+"/images/harbour.png", images[1].Path)
+	assert.Equal(t, "image/png", images[1].FileType)
+	assert.Equal(t, "John Smith", images[1].Artist)
+
+	// HTML fallback: entities unescaped, feed title used when there is no author.
+	assert.Equal(t, "https://img.example.com/inline.jpg?w=2000&h=1000", images[2].Path)
+	assert.Equal(t, "Photo Blog", images[2].Attribution)
+
+	again, err := p.FetchImages(context.Background(), feedURL, 1)
+	require.NoError(t, err)
+	assert.Equal(t, images[0].ID, again[0].ID, "IDs must be stable across fetches")
+
+	// Page 2 comes from the rel="next" link of page 1.
+	page2, err := p.FetchImages(context.Background(), feedURL, 2)
+	require.NoError(t, err)
+	require.Len(t, page2, 1)
+	assert.Equal(t, "https://img.example.com/forest.jpg", page2[0].Path)
+
+	// Page 2 has no next link, so page 3 is the end of the feed.
+	page3, err := p.FetchImages(context.Background(), feedURL, 3)
+	assert.NoError(t, err)
+	assert.Empty(t, page3)
+}
+
+func TestFeedFetchImages_Atom(t *testing.T) {
+	server := newTestServer(t)
+	p := NewProvider(nil, server.Client())
+
+	images, err := p.FetchImages(context.Background(), server.URL+"/atom", 1)
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+
+	assert.Equal(t, "https://atom.example.com/mountains.jpg", images[0].Path)
+	assert.Equal(t, "Mountains", images[0].Title)
+	assert.Equal(t, "Alex Roe", images[0].Attribution)
+	assert.Equal(t, "https://atom.example.com/mountains", images[0].ViewURL)
+
+	assert.Equal(t, server.URL+"/lake.webp", images[1].Path, "inline XHTML images should be found")
+	assert.Equal(t, "Atom Photos", images[1].Attribution)
+	assert.Equal(t, "https://atom.example.com/lake", images[1].ViewURL)
+	assert.NotEqual(t, images[0].ID, images[1].ID)
+
+	page2, err := p.FetchImages(context.Background(), server.URL+"/atom", 2)
+	assert.NoError(t, err)
+	assert.Empty(t, page2, "feeds without a next link have a single page")
+}
+
+func TestFeedFetchImages_Latin1(t *testing.T) {
+	server := newTestServer(t)
+	p := NewProvider(nil, server.Client())
+
+	images, err := p.FetchImages(context.Background(), server.URL+"/latin1", 1)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	assert.Equal(t, "Café", images[0].Title)
+}
+
+func TestFeedFetchImages_HTTPError(t *testing.T) {
+	server := newTestServer(t)
+	p := NewProvider(nil, server.Client())
+
+	_, err := p.FetchImages(context.Background(), server.URL+"/broken", 1)
+	assert.ErrorContains(t, err, "410")
+}
+
+func TestIsImage(t *testing.T) {
+	tests := []struct {
+		url, mimeType, medium string
+		expected              bool
+	}{
+		{"https://a/b.jpg", "", "", true},
+		{"https://a/b", "", "", true},
+		{"https://a/b.mp4", "", "", false},
+		{"https://a/b.gif", "", "", false},
+		{"https://a/b.jpg", "image/gif", "", false},
+		{"https://a/b", "image/webp", "", true},
+		{"https://a/b.jpg", "", "video", false},
+		{"https://a/b", "", "image", true},
+		{"", "image/jpeg", "", false},
+	}
+	for _, tc := range tests {
+		t.Run(strings.Join([]string{tc.url, tc.mimeType, tc.medium}, "|"), func(t *testing.T) {
+			assert.Equal(t, tc.expected, isImage(tc.url, tc.mimeType, tc.medium))
+		})
+	}
+}
@@ -218,6 +218,10 @@ func (p *Provider) DownloadItems(ctx context.Context, items []PickerMediaItem, t
 			log.Printf("Failed to download %s: %v", item.ID, err)
 			continue // Partial success allowed?
 		}
+		// Date the file by when the photo was taken, for photos whose download lacks EXIF dates
+		if !item.CreateTime.IsZero() {
+			_ = os.Chtimes(path, item.CreateTime, item.CreateTime)
+		}
 	}
 
 	return urlMap, nil
@@ -14,6 +14,7 @@ import (
 	_ "embed"
 
 	"github.com/dixieflatline76/Spice/v2/config"
+	"github.com/dixieflatline76/Spice/v2/pkg/api"
 	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
 	"github.com/dixieflatline76/Spice/v2/pkg/provider"
 	"github.com/dixieflatline76/Spice/v2/pkg/ui/schema"
@@ -145,12 +146,7 @@ func (p *Provider) FetchImages(ctx context.Context, apiURL string, page int) ([]
 		return nil, fmt.Errorf("local api error: %d", resp.StatusCode)
 	}
 
-	var items []struct {
-		ID          string `json:"id"`
-		URL         string `json:"url"`
-		Attribution string `json:"attribution"`
-		ProductURL  string `json:"product_url"`
-	}
+	var items []api.LocalImage
 	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
 		return nil, err
 	}
@@ -166,6 +162,12 @@ func (p *Provider) FetchImages(ctx context.Context, apiURL string, page int) ([]
 			Path:        item.URL,
 			ViewURL:     viewURL,
 			Attribution: item.Attribution,
+			Title:       item.Title,
+			Artist:      item.Artist,
+			Year:        item.Year,
+			Camera:      item.Camera,
+			Caption:     item.Caption,
+			Taken:       item.Taken,
 			Provider:    p.ID(),
 		})
 	}
@@ -289,12 +291,15 @@ func (p *Provider) CreateQueryPanel(sm setting.SettingsManager, pendingUrl strin
 									Description: q.Description,
 									Active:      q.Active,
 									Managed:     q.Managed,
+									Option:      q.Memories,
 								}
 							}
 							return abstracts
 						},
 						EnableQuery:  p.cfg.EnableGooglePhotosQuery,
 						DisableQuery: p.cfg.DisableGooglePhotosQuery,
+						OptionLabel:  i18n.T("On This Day"),
+						SetOption:    p.cfg.SetQueryMemories,
 						RemoveQuery: func(id string) error {
 							// Cleanup files before removing query
 							queries := p.cfg.GetGooglePhotosQueries()
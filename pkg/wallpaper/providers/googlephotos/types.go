@@ -128,8 +128,9 @@ type PickerMediaItemResponse struct {
 }
 
 type PickerMediaItem struct {
-	ID         string `json:"id"`
-	ProductUrl string `json:"productUrl"`
+	ID         string    `json:"id"`
+	ProductUrl string    `json:"productUrl"`
+	CreateTime time.Time `json:"createTime"` // When the photo was taken
 	MediaFile  struct {
 		BaseURL  string `json:"baseUrl"`
 		MimeType string `json:"mimeType"`
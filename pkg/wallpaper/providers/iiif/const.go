@@ -0,0 +1,30 @@
+package iiif
+
+import "time"
+
+const (
+	// IIIFURLRegexp validates manifest and collection URLs (and viewer links that embed one).
+	IIIFURLRegexp = `^(?i)https?://[^\s/$.?#][^\s]*$`
+
+	// IIIFMaxBodyBytes caps the size of a single manifest. Digitised books can list thousands of canvases.
+	IIIFMaxBodyBytes = 32 << 20 // 32 MiB
+
+	// IIIFCanvasesPerPage is the number of canvases returned per page for a single manifest.
+	IIIFCanvasesPerPage = 50
+
+	// IIIFManifestsPerPage is the number of manifests resolved per page for a collection.
+	// Each one costs a request, so this is kept small.
+	IIIFManifestsPerPage = 10
+
+	// IIIFMaxCollectionThumbnails caps how many manifests of a collection are expanded for gallery thumbnails.
+	IIIFMaxCollectionThumbnails = 48
+
+	// IIIFThumbnailSize is the bounding box requested from image services for gallery thumbnails.
+	IIIFThumbnailSize = 800
+
+	// IIIFAPIPacing spaces out manifest requests.
+	IIIFAPIPacing = 1 * time.Second
+
+	// IIIFMediaPacing spaces out image requests. Image servers render each size on demand.
+	IIIFMediaPacing = 500 * time.Millisecond
+)
@@ -0,0 +1,474 @@
+package iiif
+
+import (
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/schema"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/setting"
+	"github.com/dixieflatline76/Spice/v2/pkg/wallpaper"
+	"github.com/dixieflatline76/Spice/v2/util/log"
+)
+
+//go:embed IIIF.png
+var iconData []byte
+
+// Provider implements ImageProvider for any IIIF Presentation API (v2 or v3) manifest or collection.
+// A manifest contributes all of its canvases; a collection contributes the first canvas of each manifest.
+type Provider struct {
+	cfg        *wallpaper.Config
+	httpClient *http.Client
+}
+
+func init() {
+	wallpaper.RegisterProvider("IIIF", func(cfg *wallpaper.Config, client *http.Client) provider.ImageProvider {
+		return NewProvider(cfg, client)
+	})
+}
+
+// NewProvider creates a new IIIF Provider.
+func NewProvider(cfg *wallpaper.Config, client *http.Client) *Provider {
+	return &Provider{
+		cfg:        cfg,
+		httpClient: client,
+	}
+}
+
+func (p *Provider) ID() string {
+	return "IIIF"
+}
+
+func (p *Provider) Name() string {
+	return i18n.T("IIIF Collections")
+}
+
+func (p *Provider) Title() string {
+	return "IIIF"
+}
+
+func (p *Provider) GetProviderIcon() interface{} {
+	return iconData
+}
+
+func (p *Provider) Type() provider.ProviderType {
+	return provider.TypeMuseum
+}
+
+func (p *Provider) HomeURL() string {
+	return "https://iiif.io"
+}
+
+func (p *Provider) GetAttributionType() provider.AttributionType {
+	return provider.AttributionBy
+}
+
+func (p *Provider) SupportsUserQueries() bool {
+	return true
+}
+
+// AcceptsAnyURL implements the CatchAllProvider interface: any http(s) URL may be a manifest.
+func (p *Provider) AcceptsAnyURL() bool {
+	return true
+}
+
+var iiifURLRegex = regexp.MustCompile(IIIFURLRegexp)
+
+// ParseURL validates a manifest or collection URL. Viewer links (Mirador, Universal Viewer, ...)
+// usually carry the manifest in a query parameter, so that is unwrapped when present.
+func (p *Provider) ParseURL(webURL string) (string, error) {
+	webURL = strings.TrimSpace(webURL)
+	if !iiifURLRegex.MatchString(webURL) {
+		return "", errors.New("invalid IIIF manifest URL")
+	}
+
+	u, err := url.Parse(webURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid IIIF manifest URL: %w", err)
+	}
+
+	for _, param := range []string{"manifest", "iiif-content"} {
+		if embedded := u.Query().Get(param); iiifURLRegex.MatchString(embedded) {
+			return embedded, nil
+		}
+	}
+
+	u.Fragment = ""
+	return u.String(), nil
+}
+
+// GetAPIPacing implements the PacedProvider interface to space out manifest requests.
+func (p *Provider) GetAPIPacing() time.Duration {
+	return IIIFAPIPacing
+}
+
+// GetProcessPacing implements the PacedProvider interface to space out image requests.
+func (p *Provider) GetProcessPacing() time.Duration {
+	return IIIFMediaPacing
+}
+
+var fullSizeRegex = regexp.MustCompile(`/full/(?:full|max)/0/default\.jpg$`)
+
+// WithImageSize implements provider.ScalableImageProvider. Image API URLs built by this provider
+// request the native size; this swaps in a bounding box so the server does the downscaling.
+// The box is square so the image's long edge is at least as long as the display's, whatever its orientation.
+func (p *Provider) WithImageSize(imageURL string, width, height int) string {
+	if width <= 0 || height <= 0 || !fullSizeRegex.MatchString(imageURL) {
+		return imageURL
+	}
+	size := max(width, height)
+	return fullSizeRegex.ReplaceAllString(imageURL, fmt.Sprintf("/full/!%d,%d/0/default.jpg", size, size))
+}
+
+// FetchImages loads the manifest or collection and returns one page of images.
+// Pages past the end return no images, which lets the caller wrap around.
+func (p *Provider) FetchImages(ctx context.Context, apiURL string, page int) ([]provider.Image, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	doc, err := p.fetchDocument(ctx, apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []provider.Image
+	if doc.isCollection() {
+		refs := pageOf(doc.manifestRefs(), page, IIIFManifestsPerPage)
+		for _, ref := range refs {
+			if ctx.Err() != nil {
+				return images, ctx.Err()
+			}
+			manifest, err := p.fetchDocument(ctx, ref.id())
+			if err != nil {
+				log.Printf("IIIF: Failed to fetch manifest %s: %v", ref.id(), err)
+				continue
+			}
+			canvases := manifest.canvases()
+			if len(canvases) == 0 {
+				continue
+			}
+			if img, ok := p.mapCanvas(manifest, canvases[0], false); ok {
+				images = append(images, img)
+			}
+		}
+	} else {
+		canvases := doc.canvases()
+		multiple := len(canvases) > 1
+		for _, c := range pageOf(canvases, page, IIIFCanvasesPerPage) {
+			if img, ok := p.mapCanvas(doc, c, multiple); ok {
+				images = append(images, img)
+			}
+		}
+	}
+
+	if len(images) == 0 {
+		log.Debugf("IIIF: No images on page %d of %s", page, apiURL)
+	} else {
+		log.Debugf("Found %d images in IIIF %s", len(images), apiURL)
+	}
+
+	return images, nil
+}
+
+func pageOf[T any](items []T, page, perPage int) []T {
+	start := (page - 1) * perPage
+	if start >= len(items) {
+		return nil
+	}
+	end := min(start+perPage, len(items))
+	return items[start:end]
+}
+
+func (p *Provider) fetchDocument(ctx context.Context, docURL string) (*document, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", docURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", `application/ld+json;profile="http://iiif.io/api/presentation/3/context.json", application/ld+json, application/json;q=0.9`)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+		log.Printf("IIIF Error (%d): %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("IIIF server returned status %d", resp.StatusCode)
+	}
+
+	var doc document
+	if err := json.NewDecoder(io.LimitReader(resp.Body, IIIFMaxBodyBytes)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode IIIF manifest: %w", err)
+	}
+	return &doc, nil
+}
+
+// canvasImage is the image painted onto a canvas, resolved to something downloadable.
+type canvasImage struct {
+	URL           string   // Full-size image URL
+	Service       *service // Image API service, if the server offers one
+	Width, Height int
+}
+
+// resolveCanvas prefers the Image API service (which WithImageSize can later scale)
+// over the static image resource.
+func resolveCanvas(c canvas) (canvasImage, bool) {
+	b, ok := c.image()
+	if !ok {
+		return canvasImage{}, false
+	}
+
+	img := canvasImage{Width: c.Width, Height: c.Height}
+	if b.Width > 0 && b.Height > 0 {
+		img.Width, img.Height = b.Width, b.Height
+	}
+
+	if svc, ok := b.imageService(); ok {
+		img.URL = svc.fullImageURL()
+		img.Service = &svc
+		return img, true
+	}
+	if u, err := url.Parse(b.id()); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		img.URL = b.id()
+		return img, true
+	}
+	return canvasImage{}, false
+}
+
+// mapCanvas converts a canvas into an Image. When a manifest has several canvases (pages, views)
+// the canvas label is appended to the title so they can be told apart.
+func (p *Provider) mapCanvas(doc *document, c canvas, withCanvasLabel bool) (provider.Image, bool) {
+	img, ok := resolveCanvas(c)
+	if !ok {
+		return provider.Image{}, false
+	}
+
+	title := langString(doc.Label)
+	if label := langString(c.Label); withCanvasLabel && label != "" && label != title {
+		title = strings.TrimSpace(title + " - " + label)
+	}
+
+	artist := manifestArtist(doc)
+	attribution := artist
+	if attribution == "" {
+		attribution = doc.rights()
+	}
+
+	viewURL := doc.homepage()
+	if viewURL == "" {
+		viewURL = doc.id()
+	}
+
+	sum := sha256.Sum256([]byte(firstOf(c.id(), img.URL)))
+	return provider.Image{
+		ID:          hex.EncodeToString(sum[:8]),
+		Path:        img.URL,
+		ViewURL:     viewURL,
+		Attribution: attribution,
+		Title:       title,
+		Artist:      artist,
+		Year:        manifestYear(doc),
+		Provider:    p.ID(),
+		FileType:    "image/jpeg",
+		Width:       img.Width,
+		Height:      img.Height,
+	}, true
+}
+
+// Metadata labels are free text chosen by each institution; these cover the common English ones.
+func manifestArtist(doc *document) string {
+	return doc.metadataValue("artist", "creator", "author", "maker", "painter", "photographer")
+}
+
+func manifestYear(doc *document) string {
+	return doc.metadataValue("date", "year", "created", "period")
+}
+
+// EnrichImage is a no-op for IIIF as all metadata comes from the manifest.
+func (p *Provider) EnrichImage(ctx context.Context, img provider.Image) (provider.Image, error) {
+	return img, nil
+}
+
+// FetchThumbnails implements provider.ThumbnailProvider. Each ID is a manifest URL,
+// or a collection URL that is expanded into its manifests.
+func (p *Provider) FetchThumbnails(ctx context.Context, ids []string) ([]provider.Thumbnail, error) {
+	limiter := rate.NewLimiter(rate.Every(p.GetAPIPacing()), 1)
+	fetch := func(docURL string) (*document, bool) {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, false
+		}
+		doc, err := p.fetchDocument(ctx, docURL)
+		if err != nil {
+			log.Printf("IIIF: Failed to fetch %s for thumbnails: %v", docURL, err)
+			return nil, false
+		}
+		return doc, true
+	}
+
+	var thumbnails []provider.Thumbnail
+	for _, id := range ids {
+		doc, ok := fetch(id)
+		if !ok {
+			continue
+		}
+		if !doc.isCollection() {
+			if t, ok := manifestThumbnail(id, doc); ok {
+				thumbnails = append(thumbnails, t)
+			}
+			continue
+		}
+		for _, ref := range pageOf(doc.manifestRefs(), 1, IIIFMaxCollectionThumbnails) {
+			manifest, ok := fetch(ref.id())
+			if !ok {
+				continue
+			}
+			if t, ok := manifestThumbnail(ref.id(), manifest); ok {
+				thumbnails = append(thumbnails, t)
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return thumbnails, err
+	}
+	return thumbnails, nil
+}
+
+func manifestThumbnail(id string, doc *document) (provider.Thumbnail, bool) {
+	canvases := doc.canvases()
+	if len(canvases) == 0 {
+		return provider.Thumbnail{}, false
+	}
+	img, ok := resolveCanvas(canvases[0])
+	if !ok {
+		return provider.Thumbnail{}, false
+	}
+
+	thumb := provider.Thumbnail{
+		ID:      id,
+		URL:     img.URL,
+		ViewURL: firstOf(doc.homepage(), doc.id()),
+		Title:   langString(doc.Label),
+		Artist:  manifestArtist(doc),
+		Year:    manifestYear(doc),
+	}
+	if img.Service != nil {
+		// Level 0 image servers cannot resize; fall back to the native image for those.
+		thumb.URL = img.Service.imageURL(fmt.Sprintf("!%d,%d", IIIFThumbnailSize, IIIFThumbnailSize))
+		thumb.FallbackURL = img.URL
+	}
+	return thumb, true
+}
+
+// --- UI Implementation (Pure Go) ---
+
+// CreateSettingsPanel returns the declarative UI for IIIF settings.
+func (p *Provider) CreateSettingsPanel(sm setting.SettingsManager) *schema.PanelSchema {
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:   i18n.T("IIIF Collections"),
+				Compact: true,
+				Items: []schema.ItemSchema{
+					schema.LabelItem{
+						Text:       i18n.T("Show images from any IIIF manifest or collection published by museums, libraries and archives."),
+						Importance: schema.ImportanceLow,
+					},
+					schema.HyperlinkItem{
+						Text: i18n.T("What is IIIF?"),
+						URL:  "https://iiif.io/get-started/",
+					},
+					schema.BoolItem{
+						Name:         "iiif_museum_framing",
+						Label:        i18n.T("Display as Framed Gallery"),
+						Help:         i18n.T("Present all artwork from this collection inside a virtual museum frame with a dynamic background, regardless of its original dimensions."),
+						InitialValue: p.cfg.GetMuseumFraming(p.ID()),
+						ApplyFunc:    func(val bool) { p.cfg.SetMuseumFraming(p.ID(), val) },
+					},
+				},
+			},
+		},
+	}
+}
+
+// CreateQueryPanel creates the image query management panel.
+func (p *Provider) CreateQueryPanel(sm setting.SettingsManager, pendingUrl string) *schema.PanelSchema {
+	addCfg := schema.AddQueryConfig{
+		Title:           i18n.T("Add IIIF Manifest"),
+		Description:     i18n.T("Paste the URL of a IIIF manifest or collection, or a viewer link that contains one."),
+		URLPlaceholder:  "https://example.org/iiif/manifest.json",
+		URLValidator:    IIIFURLRegexp,
+		URLErrorMsg:     i18n.T("Invalid IIIF manifest URL"),
+		DescPlaceholder: i18n.T("Query Description (e.g. Book of Hours)"),
+		AddHandler: func(desc, url string, active bool) (string, error) {
+			manifestURL, err := p.ParseURL(url)
+			if err != nil {
+				return "", err
+			}
+			return p.cfg.AddIIIFQuery(desc, manifestURL, active)
+		},
+	}
+
+	if pendingUrl != "" {
+		sm.ShowAddQueryDialog(addCfg, pendingUrl, "", sm.RefreshUI)
+	}
+
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:       i18n.T("IIIF Manifests"),
+				Description: i18n.T("Manage your IIIF manifests and collections here."),
+				Items: []schema.ItemSchema{
+					schema.ButtonItem{
+						Name:       "iiif_add",
+						ButtonText: i18n.T("Add IIIF Manifest"),
+						IconName:   "add",
+						OnPressed: func() {
+							sm.ShowAddQueryDialog(addCfg, "", "", sm.RefreshUI)
+						},
+					},
+					schema.QueryListItem{
+						GetQueries: func() []schema.Query {
+							queries := p.cfg.GetIIIFQueries()
+							abstracts := make([]schema.Query, len(queries))
+							for i, q := range queries {
+								abstracts[i] = schema.Query{
+									ID:          q.ID,
+									URL:         q.URL,
+									Description: q.Description,
+									Active:      q.Active,
+									Managed:     q.Managed,
+								}
+							}
+							return abstracts
+						},
+						EnableQuery:  p.cfg.EnableImageQuery,
+						DisableQuery: p.cfg.DisableImageQuery,
+						RemoveQuery:  p.cfg.RemoveImageQuery,
+						GetDisplayURL: func(q schema.Query) *url.URL {
+							u, _ := url.Parse(q.URL)
+							return u
+						},
+					},
+				},
+			},
+		},
+	}
+}
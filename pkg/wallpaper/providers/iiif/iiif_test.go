@@ -0,0 +1,235 @@
+package iiif
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const manifestV3 = `{
+  "@context": "http://iiif.io/api/presentation/3/context.json",
+  "id": "BASE/v3/manifest",
+  "type": "Manifest",
+  "label": {"nl": ["De Nachtwacht"], "en": ["The Night Watch"]},
+  "metadata": [
+    {"label": {"en": ["Artist"]}, "value": {"en": ["<a href='x'>Rembrandt van Rijn</a>"]}},
+    {"label": {"en": ["Date"]}, "value": {"none": ["1642"]}}
+  ],
+  "requiredStatement": {"label": {"en": ["Attribution"]}, "value": {"en": ["Rijksmuseum"]}},
+  "homepage": [{"id": "https://museum.example.com/nightwatch", "type": "Text"}],
+  "items": [
+    {
+      "id": "BASE/v3/canvas/1", "type": "Canvas", "label": {"en": ["Front"]}, "width": 8000, "height": 6000,
+      "items": [{"type": "AnnotationPage", "items": [{
+        "type": "Annotation", "motivation": "painting",
+        "body": {"id": "BASE/img3/front/full/max/0/default.jpg", "type": "Image", "width": 8000, "height": 6000,
+                 "service": [{"id": "BASE/img3/front", "type": "ImageService3", "profile": "level1"}]}
+      }]}]
+    },
+    {
+      "id": "BASE/v3/canvas/2", "type": "Canvas", "label": {"en": ["Detail"]}, "width": 1000, "height": 800,
+      "items": [{"type": "AnnotationPage", "items": [{
+        "type": "Annotation", "motivation": "painting",
+        "body": {"id": "BASE/static/detail.jpg", "type": "Image", "format": "image/jpeg"}
+      }]}]
+    }
+  ]
+}`
+
+const manifestV2 = `{
+  "@context": "http://iiif.io/api/presentation/2/context.json",
+  "@id": "BASE/v2/manifest",
+  "@type": "sc:Manifest",
+  "label": "Book of Hours",
+  "metadata": [
+    {"label": "Creator", "value": [{"@value": "Anonyme", "@language": "fr"}, {"@value": "Anonymous", "@language": "en"}]},
+    {"label": "Date Created", "value": "c. 1450"}
+  ],
+  "attribution": "Example Library",
+  "related": {"@id": "https://library.example.com/hours", "format": "text/html"},
+  "sequences": [{"canvases": [{
+    "@id": "BASE/v2/canvas/1", "@type": "sc:Canvas", "label": "f. 1r", "width": 3000, "height": 4000,
+    "images": [{"resource": {
+      "@id": "BASE/img2/f1r/full/full/0/default.jpg", "@type": "dctypes:Image",
+      "service": {"@context": "http://iiif.io/api/image/2/context.json", "@id": "BASE/img2/f1r", "profile": "http://iiif.io/api/image/2/level2.json"}
+    }}]
+  }]}]
+}`
+
+const collectionV2 = `{
+  "@context": "http://iiif.io/api/presentation/2/context.json",
+  "@id": "BASE/collection",
+  "@type": "sc:Collection",
+  "label": "Highlights",
+  "collections": [{"@id": "BASE/nested", "@type": "sc:Collection"}],
+  "manifests": [
+    {"@id": "BASE/v2/manifest", "@type": "sc:Manifest"},
+    {"@id": "BASE/v3/manifest", "@type": "sc:Manifest"},
+    {"@id": "BASE/missing", "@type": "sc:Manifest"}
+  ]
+}`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	docs := map[string]string{
+		"/v3/manifest": manifestV3,
+		"/v2/manifest": manifestV2,
+		"/collection":  collectionV2,
+	}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc, ok := docs[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/ld+json")
+		_, _ = w.Write([]byte(strings.ReplaceAll(doc, "BASE", server.URL)))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestIIIFParseURL(t *testing.T) {
+	p := &Provider{}
+
+	tests := []struct {
+		input    string
+		expected string
+		hasError bool
+	}{
+		{"https://example.org/iiif/manifest.json", "https://example.org/iiif/manifest.json", false},
+		{"  https://example.org/iiif/collection/top#x ", "https://example.org/iiif/collection/top", false},
+		{"https://projectmirador.org/embed/?iiif-content=https://example.org/m.json", "https://example.org/m.json", false},
+		{"https://uv.example.com/uv.html?manifest=https%3A%2F%2Fexample.org%2Fm2.json", "https://example.org/m2.json", false},
+		{"ftp://example.org/manifest", "", true},
+		{"not a url", "", true},
+		{"", "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			res, err := p.ParseURL(tc.input)
+			if tc.hasError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, res)
+			}
+		})
+	}
+}
+
+func TestIIIFFetchImages_ManifestV3(t *testing.T) {
+	server := newTestServer(t)
+	p := NewProvider(nil, server.Client())
+
+	images, err := p.FetchImages(context.Background(), server.URL+"/v3/manifest", 1)
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+
+	assert.Equal(t, server.URL+"/img3/front/full/max/0/default.jpg", images[0].Path, "v3 services use the max size keyword")
+	assert.Equal(t, "The Night Watch - Front", images[0].Title, "English labels are preferred")
+	assert.Equal(t, "Rembrandt van Rijn", images[0].Artist, "HTML is stripped from metadata values")
+	assert.Equal(t, "Rembrandt van Rijn", images[0].Attribution)
+	assert.Equal(t, "1642", images[0].Year)
+	assert.Equal(t, "https://museum.example.com/nightwatch", images[0].ViewURL)
+	assert.Equal(t, 8000, images[0].Width)
+	assert.Equal(t, 6000, images[0].Height)
+	assert.Equal(t, "IIIF", images[0].Provider)
+	assert.Len(t, images[0].ID, 16)
+
+	assert.Equal(t, server.URL+"/static/detail.jpg", images[1].Path, "images without a service are used as-is")
+	assert.Equal(t, 1000, images[1].Width, "canvas dimensions are used when the image has none")
+	assert.NotEqual(t, images[0].ID, images[1].ID)
+
+	page2, err := p.FetchImages(context.Background(), server.URL+"/v3/manifest", 2)
+	assert.NoError(t, err)
+	assert.Empty(t, page2)
+}
+
+func TestIIIFFetchImages_ManifestV2(t *testing.T) {
+	server := newTestServer(t)
+	p := NewProvider(nil, server.Client())
+
+	images, err := p.FetchImages(context.Background(), server.URL+"/v2/manifest", 1)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+
+	assert.Equal(t, server.URL+"/img2/f1r/full/full/0/default.jpg", images[0].Path, "v2 services use the full size keyword")
+	assert.Equal(t, "Book of Hours", images[0].Title, "single canvases keep the manifest label")
+	assert.Equal(t, "Anonymous", images[0].Artist)
+	assert.Equal(t, "c. 1450", images[0].Year)
+	assert.Equal(t, "https://library.example.com/hours", images[0].ViewURL)
+}
+
+func TestIIIFFetchImages_Collection(t *testing.T) {
+	server := newTestServer(t)
+	p := NewProvider(nil, server.Client())
+
+	images, err := p.FetchImages(context.Background(), server.URL+"/collection", 1)
+	require.NoError(t, err)
+	require.Len(t, images, 2, "nested collections and missing manifests are skipped")
+	assert.Equal(t, "Book of Hours", images[0].Title)
+	assert.Equal(t, "The Night Watch", images[1].Title, "collections use the first canvas of each manifest")
+
+	page2, err := p.FetchImages(context.Background(), server.URL+"/collection", 2)
+	assert.NoError(t, err)
+	assert.Empty(t, page2)
+}
+
+func TestIIIFFetchThumbnails(t *testing.T) {
+	server := newTestServer(t)
+	p := NewProvider(nil, server.Client())
+
+	thumbs, err := p.FetchThumbnails(context.Background(), []string{server.URL + "/collection"})
+	require.NoError(t, err)
+	require.Len(t, thumbs, 2)
+
+	assert.Equal(t, server.URL+"/v2/manifest", thumbs[0].ID)
+	assert.Equal(t, server.URL+"/img2/f1r/full/!800,800/0/default.jpg", thumbs[0].URL)
+	assert.Equal(t, server.URL+"/img2/f1r/full/full/0/default.jpg", thumbs[0].FallbackURL)
+	assert.Equal(t, "Book of Hours", thumbs[0].Title)
+	assert.Equal(t, "c. 1450", thumbs[0].Year)
+	assert.Equal(t, "Rembrandt van Rijn", thumbs[1].Artist)
+}
+
+func TestIIIFWithImageSize(t *testing.T) {
+	p := &Provider{}
+
+	assert.Equal(t, "https://img.example.org/iiif/abc/full/!3840,3840/0/default.jpg",
+		p.WithImageSize("https://img.example.org/iiif/abc/full/max/0/default.jpg", 3840, 2160))
+	assert.Equal(t, "https://img.example.org/iiif/abc/full/!1440,1440/0/default.jpg",
+		p.WithImageSize("https://img.example.org/iiif/abc/full/full/0/default.jpg", 1080, 1440))
+	assert.Equal(t, "https://img.example.org/static.jpg",
+		p.WithImageSize("https://img.example.org/static.jpg", 3840, 2160), "non Image API URLs are left alone")
+	assert.Equal(t, "https://img.example.org/iiif/abc/full/max/0/default.jpg",
+		p.WithImageSize("https://img.example.org/iiif/abc/full/max/0/default.jpg", 0, 0))
+}
+
+func TestLangString(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{`"Plain"`, "Plain"},
+		{`["First", "Second"]`, "First"},
+		{`[{"@value": "Bonjour", "@language": "fr"}, {"@value": "Hello", "@language": "en"}]`, "Hello"},
+		{`{"@value": "Single"}`, "Single"},
+		{`{"de": ["Hallo"], "en": ["Hello", "World"]}`, "Hello; World"},
+		{`{"none": ["1642"]}`, "1642"},
+		{`{"fr": ["Bonjour"], "de": ["Hallo"]}`, "Hallo"},
+		{`"<b>Bold</b> &amp;  spaced"`, "Bold & spaced"},
+		{``, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.raw, func(t *testing.T) {
+			assert.Equal(t, tc.expected, langString(json.RawMessage(tc.raw)))
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package iiif
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// document is the subset of a IIIF Presentation API manifest or collection that Spice needs.
+// Version 2 uses JSON-LD keywords (@id, @type) and sequences of canvases; version 3 uses plain
+// keys and nests everything under items. Both shapes decode into the same struct.
+type document struct {
+	ID2   string `json:"@id"`
+	ID3   string `json:"id"`
+	Type2 string `json:"@type"`
+	Type3 string `json:"type"`
+
+	Label             json.RawMessage `json:"label"`
+	Metadata          []metadataEntry `json:"metadata"`
+	Attribution       json.RawMessage `json:"attribution"`       // v2
+	RequiredStatement *metadataEntry  `json:"requiredStatement"` // v3
+	Related           json.RawMessage `json:"related"`           // v2: string, object or array
+	Homepage          []reference     `json:"homepage"`          // v3
+
+	Sequences []struct {
+		Canvases []canvas `json:"canvases"`
+	} `json:"sequences"` // v2 manifest
+	Manifests []reference     `json:"manifests"` // v2 collection
+	Members   []reference     `json:"members"`   // v2.1 collection
+	Items     json.RawMessage `json:"items"`     // v3: canvases (manifest) or references (collection)
+}
+
+type metadataEntry struct {
+	Label json.RawMessage `json:"label"`
+	Value json.RawMessage `json:"value"`
+}
+
+type reference struct {
+	ID2   string `json:"@id"`
+	ID3   string `json:"id"`
+	Type2 string `json:"@type"`
+	Type3 string `json:"type"`
+}
+
+type canvas struct {
+	ID2    string          `json:"@id"`
+	ID3    string          `json:"id"`
+	Label  json.RawMessage `json:"label"`
+	Width  int             `json:"width"`
+	Height int             `json:"height"`
+
+	Images []struct {
+		Resource body `json:"resource"`
+	} `json:"images"` // v2
+	Items []struct {
+		Items []annotation `json:"items"`
+	} `json:"items"` // v3 annotation pages
+}
+
+type annotation struct {
+	Motivation json.RawMessage `json:"motivation"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// body is a painted resource: an image, or a choice between several images.
+type body struct {
+	ID2     string          `json:"@id"`
+	ID3     string          `json:"id"`
+	Type2   string          `json:"@type"`
+	Type3   string          `json:"type"`
+	Format  string          `json:"format"`
+	Width   int             `json:"width"`
+	Height  int             `json:"height"`
+	Service json.RawMessage `json:"service"`
+	Default *body           `json:"default"` // v2 oa:Choice
+	Items   []body          `json:"items"`   // v3 Choice
+}
+
+type service struct {
+	ID2     string          `json:"@id"`
+	ID3     string          `json:"id"`
+	Type2   string          `json:"@type"`
+	Type3   string          `json:"type"`
+	Context json.RawMessage `json:"@context"`
+	Profile json.RawMessage `json:"profile"`
+}
+
+func firstOf(a, b string) string {
+	if a != "" {
+		return a
+	}
+	return b
+}
+
+// kind strips the JSON-LD prefix so v2 ("sc:Manifest") and v3 ("Manifest") types compare equal.
+func kind(type2, type3 string) string {
+	t := firstOf(type3, type2)
+	if i := strings.LastIndex(t, ":"); i >= 0 {
+		t = t[i+1:]
+	}
+	return t
+}
+
+func (d *document) id() string         { return firstOf(d.ID3, d.ID2) }
+func (d *document) isCollection() bool { return kind(d.Type2, d.Type3) == "Collection" }
+func (r reference) id() string         { return firstOf(r.ID3, r.ID2) }
+func (c canvas) id() string            { return firstOf(c.ID3, c.ID2) }
+func (b body) id() string              { return firstOf(b.ID3, b.ID2) }
+func (s service) id() string           { return firstOf(s.ID3, s.ID2) }
+
+// canvases returns the canvases of a manifest in reading order.
+func (d *document) canvases() []canvas {
+	if len(d.Sequences) > 0 {
+		return d.Sequences[0].Canvases
+	}
+	var items []canvas
+	if len(d.Items) > 0 {
+		_ = json.Unmarshal(d.Items, &items)
+	}
+	return items
+}
+
+// manifestRefs returns the manifests listed by a collection. Nested collections are not followed.
+func (d *document) manifestRefs() []reference {
+	refs := append([]reference{}, d.Manifests...)
+	refs = append(refs, d.Members...)
+	if len(d.Items) > 0 {
+		var items []reference
+		if err := json.Unmarshal(d.Items, &items); err == nil {
+			refs = append(refs, items...)
+		}
+	}
+
+	var manifests []reference
+	seen := make(map[string]bool)
+	for _, r := range refs {
+		if r.id() == "" || seen[r.id()] {
+			continue
+		}
+		if k := kind(r.Type2, r.Type3); k != "" && k != "Manifest" {
+			continue
+		}
+		seen[r.id()] = true
+		manifests = append(manifests, r)
+	}
+	return manifests
+}
+
+// metadataValue returns the value of the first metadata entry whose label contains one of the keywords.
+func (d *document) metadataValue(keywords ...string) string {
+	for _, m := range d.Metadata {
+		label := strings.ToLower(langString(m.Label))
+		for _, k := range keywords {
+			if strings.Contains(label, k) {
+				if v := langString(m.Value); v != "" {
+					return v
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// rights returns the required statement (v3) or attribution (v2) text.
+func (d *document) rights() string {
+	if d.RequiredStatement != nil {
+		if v := langString(d.RequiredStatement.Value); v != "" {
+			return v
+		}
+	}
+	return langString(d.Attribution)
+}
+
+// homepage returns the web page for the object, if the manifest links one.
+func (d *document) homepage() string {
+	for _, h := range d.Homepage {
+		if h.id() != "" {
+			return h.id()
+		}
+	}
+
+	var s string
+	if json.Unmarshal(d.Related, &s) == nil {
+		return s
+	}
+	var refs []reference
+	if json.Unmarshal(d.Related, &refs) == nil && len(refs) > 0 {
+		return refs[0].id()
+	}
+	var ref reference
+	if json.Unmarshal(d.Related, &ref) == nil {
+		return ref.id()
+	}
+	return ""
+}
+
+// image returns the image painted onto the canvas.
+func (c canvas) image() (body, bool) {
+	for _, img := range c.Images {
+		if b := img.Resource.resolveChoice(); b.id() != "" {
+			return b, true
+		}
+	}
+
+	for _, page := range c.Items {
+		for _, a := range page.Items {
+			var motivation string
+			_ = json.Unmarshal(a.Motivation, &motivation)
+			if motivation != "" && motivation != "painting" {
+				continue
+			}
+
+			var bodies []body
+			var single body
+			if err := json.Unmarshal(a.Body, &single); err == nil {
+				bodies = []body{single}
+			} else if err := json.Unmarshal(a.Body, &bodies); err != nil {
+				continue
+			}
+			for _, b := range bodies {
+				if b = b.resolveChoice(); b.id() != "" {
+					return b, true
+				}
+			}
+		}
+	}
+	return body{}, false
+}
+
+// resolveChoice picks the default option when a canvas offers alternative images (e.g. different lighting).
+func (b body) resolveChoice() body {
+	if kind(b.Type2, b.Type3) != "Choice" {
+		return b
+	}
+	if b.Default != nil {
+		return *b.Default
+	}
+	if len(b.Items) > 0 {
+		return b.Items[0]
+	}
+	return body{}
+}
+
+// imageService returns the IIIF Image API service of the resource, if it has one.
+func (b body) imageService() (service, bool) {
+	var services []service
+	var single service
+	if err := json.Unmarshal(b.Service, &single); err == nil {
+		services = []service{single}
+	} else if err := json.Unmarshal(b.Service, &services); err != nil {
+		return service{}, false
+	}
+
+	for _, s := range services {
+		if s.id() == "" {
+			continue
+		}
+		if strings.HasPrefix(kind(s.Type2, s.Type3), "ImageService") ||
+			bytes.Contains(s.Context, []byte("iiif.io/api/image")) ||
+			bytes.Contains(s.Profile, []byte("iiif.io/api/image")) {
+			return s, true
+		}
+	}
+	return service{}, false
+}
+
+// isV3 reports whether the service speaks Image API 3, which renamed the "full" size to "max".
+func (s service) isV3() bool {
+	return kind(s.Type2, s.Type3) == "ImageService3" || bytes.Contains(s.Context, []byte("image/3"))
+}
+
+// imageURL builds an Image API request for the whole image at the given size.
+func (s service) imageURL(size string) string {
+	base := strings.TrimSuffix(strings.TrimSuffix(s.id(), "/info.json"), "/")
+	return fmt.Sprintf("%s/full/%s/0/default.jpg", base, size)
+}
+
+// fullImageURL requests the image at its native size.
+func (s service) fullImageURL() string {
+	if s.isV3() {
+		return s.imageURL("max")
+	}
+	return s.imageURL("full")
+}
+
+var (
+	htmlTagRegex    = regexp.MustCompile("<[^>]*>")
+	whitespaceRegex = regexp.MustCompile(`\s+`)
+)
+
+// langString flattens the many shapes a IIIF label or value can take into plain text:
+// a string, a v2 array of strings or {"@value", "@language"} objects, or a v3 language map.
+// English is preferred; HTML markup (allowed in metadata values) is stripped.
+func langString(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var text string
+	var s string
+	var list []json.RawMessage
+	var langMap map[string][]string
+	var value struct {
+		Value    string `json:"@value"`
+		Language string `json:"@language"`
+	}
+
+	switch {
+	case json.Unmarshal(raw, &s) == nil:
+		text = s
+	case json.Unmarshal(raw, &list) == nil:
+		var values []string
+		for _, item := range list {
+			if json.Unmarshal(item, &s) == nil {
+				values = append(values, s)
+			} else if json.Unmarshal(item, &value) == nil {
+				if strings.HasPrefix(value.Language, "en") {
+					values = []string{value.Value}
+					break
+				}
+				values = append(values, value.Value)
+			}
+		}
+		if len(values) > 0 {
+			text = values[0]
+		}
+	case json.Unmarshal(raw, &langMap) == nil:
+		keys := make([]string, 0, len(langMap))
+		for k := range langMap {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, preferred := range []string{"en", "none", "@none"} {
+			if len(langMap[preferred]) > 0 {
+				keys = []string{preferred}
+				break
+			}
+		}
+		if len(keys) > 0 {
+			text = strings.Join(langMap[keys[0]], "; ")
+		}
+	case json.Unmarshal(raw, &value) == nil:
+		text = value.Value
+	}
+
+	text = html.UnescapeString(htmlTagRegex.ReplaceAllString(text, ""))
+	return strings.TrimSpace(whitespaceRegex.ReplaceAllString(text, " "))
+}
@@ -4,14 +4,17 @@ import (
 	"context"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
+	"github.com/dixieflatline76/Spice/v2/pkg/api"
 	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
 	"github.com/dixieflatline76/Spice/v2/pkg/provider"
 	"github.com/dixieflatline76/Spice/v2/pkg/ui/schema"
@@ -95,43 +98,37 @@ func (p *Provider) EnrichImage(_ context.Context, img provider.Image) (provider.
 	return img, nil
 }
 
-// hasLocalImages checks if the given directory contains at least one image file.
-// It uses os.Open and Readdir to stop searching as soon as it finds a single valid image,
-// making it much faster for large directories.
-func hasLocalImages(dir string) bool {
-	f, err := os.Open(dir)
-	if err != nil {
-		return false
-	}
-	defer f.Close()
+// hasLocalImages checks if the given directory contains at least one image file the scan would list.
+// The walk stops as soon as it finds a single valid image, making it much faster for large directories.
+func hasLocalImages(dir string, opts api.ScanOptions) bool {
+	images, err := api.ScanImages(dir, opts, 1)
+	return err == nil && len(images) > 0
+}
 
-	for {
-		entries, err := f.Readdir(100) // Process in small batches
-		if err != nil {
-			break
-		}
-		for _, e := range entries {
-			if e.IsDir() {
-				continue
-			}
-			ext := strings.ToLower(filepath.Ext(e.Name()))
-			if ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".webp" {
-				return true
-			}
+// scanOptions returns the scan options saved with the Local Folder query for a folder.
+func (p *Provider) scanOptions(folderPath string) api.ScanOptions {
+	for _, q := range p.cfg.GetLocalFolderQueries() {
+		if q.URL == folderPath && q.Scan != nil {
+			return api.ScanOptions{Depth: q.Scan.Depth, Include: q.Scan.Include, Exclude: q.Scan.Exclude}
 		}
 	}
-	return false
+	return api.ScanOptions{}
 }
 
 func (p *Provider) FetchImages(ctx context.Context, folderPath string, page int) ([]provider.Image, error) {
+	opts := p.scanOptions(folderPath)
+
 	// Short-circuit if folder is empty
-	if !hasLocalImages(folderPath) {
+	if !hasLocalImages(folderPath, opts) {
 		return []provider.Image{}, nil
 	}
 
 	collectionID := wallpaper.HashFolderPath(folderPath)
 	host := p.apiHost
-	u := fmt.Sprintf("http://%s/local/%s/%s/images?page=%d", host, wallpaper.LocalFolderNamespace, collectionID, page)
+	params := url.Values{}
+	params.Set("page", strconv.Itoa(page))
+	opts.Encode(params)
+	u := fmt.Sprintf("http://%s/local/%s/%s/images?%s", host, wallpaper.LocalFolderNamespace, collectionID, params.Encode())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
 	if err != nil {
@@ -149,30 +146,74 @@ func (p *Provider) FetchImages(ctx context.Context, folderPath string, page int)
 		return nil, fmt.Errorf("local API returned status: %d", resp.StatusCode)
 	}
 
-	var respData []struct {
-		ID          string `json:"id"`
-		URL         string `json:"url"`
-		Attribution string `json:"attribution"`
-		ProductURL  string `json:"product_url"`
-	}
+	var respData []api.LocalImage
 	if err := json.NewDecoder(resp.Body).Decode(&respData); err != nil {
 		return nil, err
 	}
 
 	images := make([]provider.Image, len(respData))
 	for i, d := range respData {
-		images[i] = provider.Image{
-			ID:            fmt.Sprintf("LocalFolder_%s_%s", collectionID, d.ID),
-			Path:          d.URL,
-			Attribution:   d.Attribution,
-			ViewURL:       d.ProductURL,
-			Provider:      ProviderName,
-			SourceQueryID: wallpaper.GenerateQueryID(wallpaper.LocalFolderProviderID + ":" + folderPath),
-		}
+		images[i] = toImage(folderPath, d)
 	}
 	return images, nil
 }
 
+// toImage converts an entry of the local API listing into an image of the folder's query.
+func toImage(folderPath string, d api.LocalImage) provider.Image {
+	return provider.Image{
+		ID:            fmt.Sprintf("LocalFolder_%s_%s", wallpaper.HashFolderPath(folderPath), d.ID),
+		Path:          d.URL,
+		Attribution:   d.Attribution,
+		ViewURL:       d.ProductURL,
+		Title:         d.Title,
+		Artist:        d.Artist,
+		Year:          d.Year,
+		Camera:        d.Camera,
+		Caption:       d.Caption,
+		Taken:         d.Taken,
+		Provider:      ProviderName,
+		SourceQueryID: wallpaper.GenerateQueryID(wallpaper.LocalFolderProviderID + ":" + folderPath),
+	}
+}
+
+// WatchFolder implements provider.FolderProvider.
+func (p *Provider) WatchFolder(folderPath string) (string, int, bool) {
+	return folderPath, p.scanOptions(folderPath).Depth, true
+}
+
+// ImageForFile implements provider.FolderProvider. The image matches what the local API lists for the file.
+func (p *Provider) ImageForFile(folderPath, path string) (provider.Image, bool) {
+	rel, ok := relativePath(folderPath, path)
+	if !ok || !p.scanOptions(folderPath).Lists(rel) {
+		return provider.Image{}, false
+	}
+	d := api.DescribeLocalImage("http://"+p.apiHost, wallpaper.LocalFolderNamespace, wallpaper.HashFolderPath(folderPath), folderPath, rel)
+	return toImage(folderPath, d), true
+}
+
+// SourceFile implements provider.FolderProvider by mapping the image's asset URL back to the folder.
+func (p *Provider) SourceFile(folderPath string, img provider.Image) (string, bool) {
+	u, err := url.Parse(img.Path)
+	if err != nil {
+		return "", false
+	}
+	prefix := fmt.Sprintf("/local/%s/%s/assets/", wallpaper.LocalFolderNamespace, wallpaper.HashFolderPath(folderPath))
+	rel, ok := strings.CutPrefix(u.Path, prefix)
+	if !ok || rel == "" {
+		return "", false
+	}
+	return filepath.Join(folderPath, filepath.FromSlash(rel)), true
+}
+
+// relativePath returns the slash-separated path of a file inside a folder.
+func relativePath(folderPath, path string) (string, bool) {
+	rel, err := filepath.Rel(folderPath, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
 // CreateSettingsPanel returns the declarative UI for Local Folder settings.
 func (p *Provider) CreateSettingsPanel(_ setting.SettingsManager) *schema.PanelSchema {
 	items := []schema.ItemSchema{
@@ -200,13 +241,106 @@ func (p *Provider) CreateSettingsPanel(_ setting.SettingsManager) *schema.PanelS
 	}
 }
 
+// Names of the scan option fields in the query panel.
+const (
+	fieldDepth   = "local_folder_depth"
+	fieldInclude = "local_folder_include"
+	fieldExclude = "local_folder_exclude"
+)
+
+// parseDepth parses the subfolder levels field. Empty means the folder itself only.
+func parseDepth(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > api.MaxScanDepth {
+		return 0, errors.New(i18n.Tf("Enter a number from 0 to {{.Max}}", map[string]any{"Max": api.MaxScanDepth}))
+	}
+	return n, nil
+}
+
+// parsePatterns splits a comma-separated list of glob patterns.
+func parsePatterns(s string) ([]string, error) {
+	var patterns []string
+	for _, p := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' }) {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if err := api.ValidatePattern(p); err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// describeScan summarizes the scan options of a query for the query list.
+func describeScan(scan *wallpaper.FolderScan) string {
+	if scan == nil {
+		return ""
+	}
+	var parts []string
+	if scan.Depth > 0 {
+		parts = append(parts, i18n.Tf("subfolders: {{.Depth}}", map[string]any{"Depth": scan.Depth}))
+	}
+	if len(scan.Include) > 0 {
+		parts = append(parts, i18n.Tf("include: {{.Patterns}}", map[string]any{"Patterns": strings.Join(scan.Include, ", ")}))
+	}
+	if len(scan.Exclude) > 0 {
+		parts = append(parts, i18n.Tf("exclude: {{.Patterns}}", map[string]any{"Patterns": strings.Join(scan.Exclude, ", ")}))
+	}
+	return strings.Join(parts, "; ")
+}
+
 // CreateQueryPanel creates the image query management panel.
+// The scan options are read when a folder is picked and saved with its query.
 func (p *Provider) CreateQueryPanel(sm setting.SettingsManager, _ string) *schema.PanelSchema {
+	value := func(name string) string {
+		s, _ := sm.GetValue(name).(string)
+		return s
+	}
+	patternValidator := func(s string) error {
+		_, err := parsePatterns(s)
+		return err
+	}
+
 	return &schema.PanelSchema{
 		Sections: []schema.SectionSchema{
 			{
-				Title: i18n.T("Local Folder Sources"),
+				Title:       i18n.T("Local Folder Sources"),
+				Description: i18n.T("Set the scan options first; they are saved with the next folder you add."),
 				Items: []schema.ItemSchema{
+					schema.TextItem{
+						Name:        fieldDepth,
+						Label:       i18n.T("Subfolder Levels:"),
+						Help:        i18n.T("How many levels of subfolders to include, e.g. 3 for Year/Month/Event. Leave empty for the folder itself only."),
+						PlaceHolder: "0",
+						IsNumeric:   true,
+						Validator: func(s string) error {
+							_, err := parseDepth(s)
+							return err
+						},
+						SkipApply: true,
+					},
+					schema.TextItem{
+						Name:        fieldInclude,
+						Label:       i18n.T("Include Only:"),
+						Help:        i18n.T("Optional comma-separated patterns. Only matching images or subfolders are used."),
+						PlaceHolder: "*.jpg, Favorites/*",
+						Validator:   patternValidator,
+						SkipApply:   true,
+					},
+					schema.TextItem{
+						Name:        fieldExclude,
+						Label:       i18n.T("Exclude:"),
+						Help:        i18n.T("Optional comma-separated patterns for images or subfolders to skip."),
+						PlaceHolder: "*/raw/*, *_thumb.jpg",
+						Validator:   patternValidator,
+						SkipApply:   true,
+					},
 					schema.FolderPickerItem{
 						Name:       "local_folder_add",
 						ButtonText: i18n.T("Add Folder"),
@@ -215,7 +349,18 @@ func (p *Provider) CreateQueryPanel(sm setting.SettingsManager, _ string) *schem
 							if len(desc) > 100 {
 								desc = desc[:100]
 							}
-							return p.cfg.AddLocalFolderQuery(desc, path, false)
+							var scan wallpaper.FolderScan
+							var err error
+							if scan.Depth, err = parseDepth(value(fieldDepth)); err != nil {
+								return "", err
+							}
+							if scan.Include, err = parsePatterns(value(fieldInclude)); err != nil {
+								return "", err
+							}
+							if scan.Exclude, err = parsePatterns(value(fieldExclude)); err != nil {
+								return "", err
+							}
+							return p.cfg.AddLocalFolderQuery(desc, path, scan, false)
 						},
 						EnableQuery: p.cfg.EnableImageQuery,
 					},
@@ -224,12 +369,17 @@ func (p *Provider) CreateQueryPanel(sm setting.SettingsManager, _ string) *schem
 							queries := p.cfg.GetLocalFolderQueries()
 							abstracts := make([]schema.Query, len(queries))
 							for i, q := range queries {
+								desc := q.URL // Show full path
+								if summary := describeScan(q.Scan); summary != "" {
+									desc += " (" + summary + ")"
+								}
 								abstracts[i] = schema.Query{
 									ID:          q.ID,
 									URL:         q.URL,
-									Description: q.URL, // Show full path
+									Description: desc,
 									Active:      q.Active,
 									Managed:     q.Managed,
+									Option:      q.Memories,
 								}
 							}
 							return abstracts
@@ -237,6 +387,8 @@ func (p *Provider) CreateQueryPanel(sm setting.SettingsManager, _ string) *schem
 						EnableQuery:  p.cfg.EnableImageQuery,
 						DisableQuery: p.cfg.DisableImageQuery,
 						RemoveQuery:  p.cfg.RemoveLocalFolderQuery,
+						OptionLabel:  i18n.T("On This Day"),
+						SetOption:    p.cfg.SetQueryMemories,
 						GetDisplayURL: func(q schema.Query) *url.URL {
 							// Copy logic from Favorites provider for compatible file URIs
 							absPath, err := filepath.Abs(q.URL)
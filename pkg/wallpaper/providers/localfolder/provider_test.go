@@ -9,7 +9,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/dixieflatline76/Spice/v2/pkg/api"
 	"github.com/dixieflatline76/Spice/v2/pkg/provider"
 	"github.com/dixieflatline76/Spice/v2/pkg/wallpaper"
 	"github.com/stretchr/testify/assert"
@@ -72,15 +74,44 @@ func TestHasLocalImages(t *testing.T) {
 	tempDir := t.TempDir()
 
 	// Empty directory
-	assert.False(t, hasLocalImages(tempDir))
+	assert.False(t, hasLocalImages(tempDir, api.ScanOptions{}))
 
 	// Add a non-image file
 	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "readme.txt"), []byte("text"), 0644))
-	assert.False(t, hasLocalImages(tempDir))
+	assert.False(t, hasLocalImages(tempDir, api.ScanOptions{}))
 
 	// Add an image file
 	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "photo.jpg"), []byte("image"), 0644))
-	assert.True(t, hasLocalImages(tempDir))
+	assert.True(t, hasLocalImages(tempDir, api.ScanOptions{}))
+}
+
+func TestHasLocalImages_Nested(t *testing.T) {
+	tempDir := t.TempDir()
+	nested := filepath.Join(tempDir, "2024", "07")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(nested, "beach.jpg"), []byte("image"), 0644))
+
+	assert.False(t, hasLocalImages(tempDir, api.ScanOptions{}), "subfolders are not scanned by default")
+	assert.False(t, hasLocalImages(tempDir, api.ScanOptions{Depth: 1}))
+	assert.True(t, hasLocalImages(tempDir, api.ScanOptions{Depth: 2}))
+	assert.False(t, hasLocalImages(tempDir, api.ScanOptions{Depth: 2, Exclude: []string{"2024"}}))
+}
+
+func TestParsePatterns(t *testing.T) {
+	patterns, err := parsePatterns(" */raw/*, *_thumb.jpg ,,")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*/raw/*", "*_thumb.jpg"}, patterns)
+
+	_, err = parsePatterns("[raw")
+	assert.Error(t, err)
+
+	depth, err := parseDepth(" 3 ")
+	require.NoError(t, err)
+	assert.Equal(t, 3, depth)
+	_, err = parseDepth("-1")
+	assert.Error(t, err)
+	_, err = parseDepth("99")
+	assert.Error(t, err)
 }
 
 func TestFetchImages(t *testing.T) {
@@ -103,6 +134,9 @@ func TestFetchImages(t *testing.T) {
 				"url":         "http://localhost/local/local_folders/abc/assets/image1.jpg",
 				"attribution": "User",
 				"product_url": "",
+				"title":       "Beach",
+				"camera":      "Canon EOS R5",
+				"taken":       "2019-07-14T18:03:00+02:00",
 			},
 		}
 		_ = json.NewEncoder(w).Encode(resp)
@@ -119,6 +153,10 @@ func TestFetchImages(t *testing.T) {
 	require.Len(t, images, 1)
 	assert.Equal(t, fmt.Sprintf("LocalFolder_%s_image1", collectionID), images[0].ID)
 	assert.Equal(t, ProviderName, images[0].Provider)
+	assert.Equal(t, "Beach", images[0].Title)
+	assert.Equal(t, "Canon EOS R5", images[0].Camera)
+	assert.Equal(t, time.July, images[0].Taken.Month())
+	assert.Equal(t, 2019, images[0].Taken.Year())
 }
 
 func TestFetchImages_EmptyFolder(t *testing.T) {
@@ -155,3 +193,25 @@ func TestDynamicNamespaceResolver(t *testing.T) {
 	_, ok = p.ResolveNamespace(wallpaper.LocalFolderNamespace, "abc123")
 	assert.False(t, ok)
 }
+
+func TestImageForFile_RoundTrip(t *testing.T) {
+	tempDir := t.TempDir()
+	p := NewProvider(&wallpaper.Config{})
+	p.SetTestConfig("localhost:1234")
+
+	img, ok := p.ImageForFile(tempDir, filepath.Join(tempDir, "beach day.jpg"))
+	require.True(t, ok)
+	assert.Equal(t, ProviderName, img.Provider)
+	assert.Contains(t, img.Path, "http://localhost:1234/local/"+wallpaper.LocalFolderNamespace+"/")
+
+	src, ok := p.SourceFile(tempDir, img)
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(tempDir, "beach day.jpg"), src)
+
+	_, ok = p.ImageForFile(tempDir, filepath.Join(tempDir, "2024", "nested.jpg"))
+	assert.False(t, ok, "subfolders are not listed without a scan depth")
+	_, ok = p.ImageForFile(tempDir, filepath.Join(tempDir, "notes.txt"))
+	assert.False(t, ok)
+	_, ok = p.ImageForFile(tempDir, filepath.Join(filepath.Dir(tempDir), "outside.jpg"))
+	assert.False(t, ok)
+}
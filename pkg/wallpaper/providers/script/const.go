@@ -0,0 +1,24 @@
+package script
+
+import "time"
+
+const (
+	// ScriptDefaultTimeout bounds a single script invocation when no timeout is configured.
+	ScriptDefaultTimeout = 60 * time.Second
+
+	// ScriptWaitDelay is how long we wait for stdout/stderr to drain after the script
+	// is killed. Without it, a grandchild process holding the pipes open would block Wait forever.
+	ScriptWaitDelay = 2 * time.Second
+
+	// ScriptMaxOutputBytes caps how much stdout we are willing to buffer and decode.
+	ScriptMaxOutputBytes = 8 << 20 // 8 MiB
+
+	// ScriptAPIPacing spaces out script invocations so a slow scraper is not started in parallel bursts.
+	ScriptAPIPacing = 1 * time.Second
+
+	// ScriptMediaPacing spaces out image downloads from the URLs a script returns.
+	ScriptMediaPacing = 250 * time.Millisecond
+
+	// ScriptQueryRegexp validates the query string handed to the script (any printable text).
+	ScriptQueryRegexp = `^[^\x00-\x1F\x7F]{1,255}$`
+)
@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package script
+
+import "os/exec"
+
+// hideWindow is a no-op outside Windows.
+func hideWindow(_ *exec.Cmd) {}
@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+package script
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// hideWindow prevents a console window from flashing up every time the tray app runs a script.
+func hideWindow(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+}
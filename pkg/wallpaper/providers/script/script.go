@@ -0,0 +1,442 @@
+package script
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/schema"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/setting"
+	"github.com/dixieflatline76/Spice/v2/pkg/wallpaper"
+	"github.com/dixieflatline76/Spice/v2/util/log"
+)
+
+//go:embed Script.png
+var iconData []byte
+
+// Provider implements ImageProvider by running a user-configured executable.
+//
+// Protocol: the executable is invoked as `<executable> <query> <page>` where page is 1-indexed.
+// It must print a JSON array to stdout, one object per image:
+//
+//	[{"id": "...", "url": "https://...", "title": "...", "artist": "...",
+//	  "attribution": "...", "width": 3840, "height": 2160, "view_url": "https://..."}]
+//
+// Only "url" is required. An empty array signals that there are no more pages.
+// Anything written to stderr is forwarded to the Spice log.
+type Provider struct {
+	cfg        *wallpaper.Config
+	httpClient *http.Client
+
+	// Overrides for testing; when empty the values come from the config.
+	testExecutable string
+	testTimeout    time.Duration
+}
+
+// SetExecutableForTesting sets the executable and timeout for testing purposes, overriding the config.
+func (p *Provider) SetExecutableForTesting(path string, timeout time.Duration) {
+	p.testExecutable = path
+	p.testTimeout = timeout
+}
+
+func init() {
+	wallpaper.RegisterProvider("Script", func(cfg *wallpaper.Config, client *http.Client) provider.ImageProvider {
+		return NewProvider(cfg, client)
+	})
+}
+
+// NewProvider creates a new Script Provider.
+func NewProvider(cfg *wallpaper.Config, client *http.Client) *Provider {
+	return &Provider{
+		cfg:        cfg,
+		httpClient: client,
+	}
+}
+
+func (p *Provider) ID() string {
+	return "Script"
+}
+
+func (p *Provider) Name() string {
+	return i18n.T("External Script")
+}
+
+func (p *Provider) Title() string {
+	return "Script"
+}
+
+func (p *Provider) GetProviderIcon() interface{} {
+	return iconData
+}
+
+func (p *Provider) Type() provider.ProviderType {
+	return provider.TypeCommunity
+}
+
+func (p *Provider) HomeURL() string {
+	return ""
+}
+
+func (p *Provider) GetAttributionType() provider.AttributionType {
+	return provider.AttributionBy
+}
+
+func (p *Provider) SupportsUserQueries() bool {
+	return true
+}
+
+// AcceptsAnyURL implements the CatchAllProvider interface: scripts take free-form queries.
+func (p *Provider) AcceptsAnyURL() bool {
+	return true
+}
+
+var scriptQueryRegex = regexp.MustCompile(ScriptQueryRegexp)
+
+// ParseURL accepts any printable, non-empty string. The query is opaque to Spice and
+// handed to the script verbatim, so it may be a URL, a tag, or a source name.
+func (p *Provider) ParseURL(webURL string) (string, error) {
+	query := strings.TrimSpace(webURL)
+	if query == "" {
+		return "", errors.New("query cannot be empty")
+	}
+	if !scriptQueryRegex.MatchString(query) {
+		return "", errors.New("query contains invalid characters or is too long")
+	}
+	return query, nil
+}
+
+// GetAPIPacing implements the PacedProvider interface to space out script invocations.
+func (p *Provider) GetAPIPacing() time.Duration {
+	return ScriptAPIPacing
+}
+
+// GetProcessPacing implements the PacedProvider interface to space out image downloads.
+func (p *Provider) GetProcessPacing() time.Duration {
+	return ScriptMediaPacing
+}
+
+// FetchImages runs the configured script for the given query and page and decodes its stdout.
+func (p *Provider) FetchImages(ctx context.Context, apiURL string, page int) ([]provider.Image, error) {
+	executable := p.getExecutable()
+	if executable == "" {
+		return nil, fmt.Errorf("script executable is not configured")
+	}
+
+	timeout := p.getTimeout()
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout, err := p.run(runCtx, executable, apiURL, page)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("script timed out after %s", timeout)
+		}
+		return nil, err
+	}
+
+	images, err := p.parseOutput(stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(images) == 0 {
+		log.Printf("Script query returned 0 images for query: %s (page %d)", apiURL, page)
+	} else {
+		log.Debugf("Found %d images from script", len(images))
+	}
+
+	return images, nil
+}
+
+// run executes the script and returns its stdout. Stderr is always forwarded to the log,
+// even when the script fails, since that is usually where the explanation is.
+func (p *Provider) run(ctx context.Context, executable, query string, page int) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, executable, query, strconv.Itoa(page)) //nolint:gosec // Executable is explicitly configured by the user
+	cmd.WaitDelay = ScriptWaitDelay
+	hideWindow(cmd)
+
+	stdout := &limitedBuffer{limit: ScriptMaxOutputBytes}
+	var stderr bytes.Buffer
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+
+	log.Debugf("Running script: %s %q %d", executable, query, page)
+	err := cmd.Run()
+	logStderr(stderr.Bytes())
+
+	if err != nil {
+		return nil, fmt.Errorf("script failed: %w", err)
+	}
+	if stdout.overflow {
+		return nil, fmt.Errorf("script output exceeds %d bytes", ScriptMaxOutputBytes)
+	}
+	return stdout.Bytes(), nil
+}
+
+func logStderr(data []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			log.Printf("Script stderr: %s", line)
+		}
+	}
+}
+
+func (p *Provider) parseOutput(stdout []byte) ([]provider.Image, error) {
+	trimmed := bytes.TrimSpace(stdout)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+
+	var items []ScriptImage
+	if err := json.Unmarshal(trimmed, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode script output: %w", err)
+	}
+
+	images := make([]provider.Image, 0, len(items))
+	for _, item := range items {
+		img, ok := p.mapScriptImage(item)
+		if !ok {
+			log.Debugf("Script: skipping entry with invalid url %q", item.URL)
+			continue
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+var invalidIDChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+func (p *Provider) mapScriptImage(item ScriptImage) (provider.Image, bool) {
+	u, err := url.Parse(item.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return provider.Image{}, false
+	}
+
+	// IDs become file names, so anything outside a conservative character set is replaced.
+	// Scripts that omit the ID get one derived from the URL so re-runs stay deduplicated.
+	id := invalidIDChars.ReplaceAllString(item.ID, "_")
+	if strings.Trim(id, "_") == "" {
+		sum := sha256.Sum256([]byte(item.URL))
+		id = hex.EncodeToString(sum[:8])
+	}
+
+	attribution := item.Attribution
+	if attribution == "" {
+		attribution = item.Artist
+	}
+
+	return provider.Image{
+		ID:          id,
+		Path:        item.URL,
+		ViewURL:     item.ViewURL,
+		Attribution: attribution,
+		Title:       item.Title,
+		Artist:      item.Artist,
+		Provider:    p.ID(),
+		Width:       item.Width,
+		Height:      item.Height,
+	}, true
+}
+
+// EnrichImage is a no-op for scripts as all metadata comes from stdout.
+func (p *Provider) EnrichImage(ctx context.Context, img provider.Image) (provider.Image, error) {
+	return img, nil
+}
+
+func (p *Provider) getExecutable() string {
+	if p.testExecutable != "" {
+		return p.testExecutable
+	}
+	return p.cfg.GetScriptExecutable()
+}
+
+func (p *Provider) getTimeout() time.Duration {
+	if p.testTimeout > 0 {
+		return p.testTimeout
+	}
+	if secs := p.cfg.GetScriptTimeout(); secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return ScriptDefaultTimeout
+}
+
+// ScriptImage is a single entry of the JSON array a script prints to stdout.
+type ScriptImage struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Artist      string `json:"artist"`
+	Attribution string `json:"attribution"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ViewURL     string `json:"view_url"`
+}
+
+// limitedBuffer buffers up to limit bytes and silently discards the rest.
+// Discarding (rather than failing the write) lets the script exit normally instead of dying on a broken pipe.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(data []byte) (int, error) {
+	if remaining := b.limit - b.Len(); remaining < len(data) {
+		b.overflow = true
+		if remaining > 0 {
+			b.Buffer.Write(data[:remaining])
+		}
+		return len(data), nil
+	}
+	return b.Buffer.Write(data)
+}
+
+// --- UI Implementation (Pure Go) ---
+
+const (
+	scriptExecIdent    = "scriptExecutable"
+	scriptTimeoutIdent = "scriptTimeout"
+)
+
+// CreateSettingsPanel returns the declarative UI for Script settings.
+func (p *Provider) CreateSettingsPanel(sm setting.SettingsManager) *schema.PanelSchema {
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:   i18n.T("External Script"),
+				Compact: true,
+				Items: []schema.ItemSchema{
+					schema.LabelItem{
+						Text:       i18n.T("Runs your own program with the query and page number, and reads a JSON list of images from its output."),
+						Importance: schema.ImportanceLow,
+					},
+					schema.TextItem{
+						Name:         scriptExecIdent,
+						Label:        i18n.T("Executable:"),
+						Help:         i18n.T("Absolute path to the script or program to run."),
+						InitialValue: p.cfg.GetScriptExecutable(),
+						PlaceHolder:  "/path/to/script",
+						Validator: func(s string) error {
+							if s == "" {
+								return nil // Clearing disables the provider
+							}
+							if info, err := os.Stat(s); err != nil || info.IsDir() {
+								return errors.New(i18n.T("Executable not found"))
+							}
+							return nil
+						},
+						ApplyFunc: func(s string) {
+							p.cfg.SetScriptExecutable(strings.TrimSpace(s))
+						},
+					},
+					schema.TextItem{
+						Name:         scriptTimeoutIdent,
+						Label:        i18n.T("Timeout (Seconds):"),
+						Help:         i18n.T("Stops the script if it runs longer than this. Set to 0 for the default (60 seconds)."),
+						InitialValue: strconv.Itoa(p.cfg.GetScriptTimeout()),
+						IsNumeric:    true,
+						Validator: func(s string) error {
+							val, err := strconv.Atoi(s)
+							if err != nil || val < 0 {
+								return errors.New(i18n.T("Must be a positive integer or 0"))
+							}
+							return nil
+						},
+						ApplyFunc: func(s string) {
+							val, _ := strconv.Atoi(s)
+							p.cfg.SetScriptTimeout(val)
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// CreateQueryPanel creates the image query management panel.
+func (p *Provider) CreateQueryPanel(sm setting.SettingsManager, pendingUrl string) *schema.PanelSchema {
+	addCfg := schema.AddQueryConfig{
+		Title:           i18n.T("Add Script Query"),
+		Description:     i18n.T("The query is passed to your script as its first argument."),
+		URLPlaceholder:  "https://intranet.example.com/gallery",
+		URLValidator:    ScriptQueryRegexp,
+		URLErrorMsg:     i18n.T("Invalid script query"),
+		DescPlaceholder: i18n.T("Query Description (e.g. Team Photos)"),
+		AddHandler: func(desc, url string, active bool) (string, error) {
+			query, err := p.ParseURL(url)
+			if err != nil {
+				return "", err
+			}
+			return p.cfg.AddScriptQuery(desc, query, active)
+		},
+	}
+
+	if pendingUrl != "" {
+		sm.ShowAddQueryDialog(addCfg, pendingUrl, "", sm.RefreshUI)
+	}
+
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:       i18n.T("Script Queries"),
+				Description: i18n.T("Manage the queries passed to your script here."),
+				Items: []schema.ItemSchema{
+					schema.ButtonItem{
+						Name:       "script_add",
+						ButtonText: i18n.T("Add New Query"),
+						IconName:   "add",
+						OnPressed: func() {
+							sm.ShowAddQueryDialog(addCfg, "", "", sm.RefreshUI)
+						},
+					},
+					schema.QueryListItem{
+						GetQueries: func() []schema.Query {
+							queries := p.cfg.GetScriptQueries()
+							abstracts := make([]schema.Query, len(queries))
+							for i, q := range queries {
+								abstracts[i] = schema.Query{
+									ID:          q.ID,
+									URL:         q.URL,
+									Description: q.Description,
+									Active:      q.Active,
+									Managed:     q.Managed,
+								}
+							}
+							return abstracts
+						},
+						EnableQuery:  p.cfg.EnableImageQuery,
+						DisableQuery: p.cfg.DisableImageQuery,
+						RemoveQuery:  p.cfg.RemoveImageQuery,
+						GetDisplayURL: func(q schema.Query) *url.URL {
+							// Only link queries that are actually web addresses.
+							if u, err := url.Parse(q.URL); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+								return u
+							}
+							return nil
+						},
+					},
+				},
+			},
+		},
+	}
+}
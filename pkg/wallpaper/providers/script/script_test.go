@@ -0,0 +1,166 @@
+package script
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// helperModeEnv switches the test binary into a fake script. The provider execs os.Args[0]
+// with "<query> <page>" and the child inherits this variable, so no shell is needed and the
+// tests run the same way on every platform.
+const helperModeEnv = "SPICE_SCRIPT_HELPER_MODE"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(helperModeEnv); mode != "" {
+		os.Exit(runHelper(mode, os.Args[1:]))
+	}
+	os.Exit(m.Run())
+}
+
+func runHelper(mode string, args []string) int {
+	query, page := "", ""
+	if len(args) >= 2 {
+		query, page = args[0], args[1]
+	}
+	switch mode {
+	case "ok":
+		fmt.Fprintln(os.Stderr, "fetching", query, "page", page)
+		fmt.Printf(`[
+			{"id": "a1", "url": "https://example.com/a.jpg", "title": "%s", "artist": "Ann", "width": 3840, "height": 2160},
+			{"id": "b/../2", "url": "https://example.com/b.jpg", "attribution": "Team Photos", "view_url": "https://example.com/b"},
+			{"url": "https://example.com/c.jpg"},
+			{"id": "bad", "url": "file:///etc/passwd"}
+		]`, query+"#"+page)
+		return 0
+	case "empty":
+		return 0
+	case "garbage":
+		fmt.Print("not json")
+		return 0
+	case "fail":
+		fmt.Fprintln(os.Stderr, "boom")
+		return 3
+	case "hang":
+		time.Sleep(30 * time.Second)
+		return 0
+	}
+	return 1
+}
+
+func newTestProvider(t *testing.T, mode string, timeout time.Duration) *Provider {
+	t.Setenv(helperModeEnv, mode)
+	p := &Provider{}
+	p.SetExecutableForTesting(os.Args[0], timeout)
+	return p
+}
+
+func TestScriptParseURL(t *testing.T) {
+	p := &Provider{}
+
+	tests := []struct {
+		input    string
+		expected string
+		hasError bool
+	}{
+		{"https://intranet.example.com/gallery", "https://intranet.example.com/gallery", false},
+		{"  team-photos  ", "team-photos", false},
+		{"tag:sunset", "tag:sunset", false},
+		{"", "", true},
+		{"   ", "", true},
+		{"bad\x00query", "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			res, err := p.ParseURL(tc.input)
+			if tc.hasError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, res)
+			}
+		})
+	}
+}
+
+func TestScriptFetchImages(t *testing.T) {
+	p := newTestProvider(t, "ok", 10*time.Second)
+
+	images, err := p.FetchImages(context.Background(), "team-photos", 2)
+	require.NoError(t, err)
+	require.Len(t, images, 3, "entries with non-http URLs should be skipped")
+
+	assert.Equal(t, "a1", images[0].ID)
+	assert.Equal(t, "https://example.com/a.jpg", images[0].Path)
+	assert.Equal(t, "team-photos#2", images[0].Title, "query and page should be passed as arguments")
+	assert.Equal(t, "Ann", images[0].Artist)
+	assert.Equal(t, "Ann", images[0].Attribution, "artist should be used when attribution is missing")
+	assert.Equal(t, 3840, images[0].Width)
+	assert.Equal(t, 2160, images[0].Height)
+	assert.Equal(t, "Script", images[0].Provider)
+
+	assert.Equal(t, "b_2", images[1].ID, "path characters must be stripped from IDs")
+	assert.Equal(t, "Team Photos", images[1].Attribution)
+	assert.Equal(t, "https://example.com/b", images[1].ViewURL)
+
+	assert.Len(t, images[2].ID, 16, "missing IDs should be derived from the URL")
+	again, err := p.FetchImages(context.Background(), "team-photos", 2)
+	require.NoError(t, err)
+	assert.Equal(t, images[2].ID, again[2].ID, "derived IDs must be stable across runs")
+}
+
+func TestScriptFetchImages_Empty(t *testing.T) {
+	p := newTestProvider(t, "empty", 10*time.Second)
+
+	images, err := p.FetchImages(context.Background(), "q", 1)
+	assert.NoError(t, err)
+	assert.Empty(t, images)
+}
+
+func TestScriptFetchImages_Errors(t *testing.T) {
+	t.Run("InvalidJSON", func(t *testing.T) {
+		p := newTestProvider(t, "garbage", 10*time.Second)
+		_, err := p.FetchImages(context.Background(), "q", 1)
+		assert.ErrorContains(t, err, "decode")
+	})
+
+	t.Run("NonZeroExit", func(t *testing.T) {
+		p := newTestProvider(t, "fail", 10*time.Second)
+		_, err := p.FetchImages(context.Background(), "q", 1)
+		assert.ErrorContains(t, err, "script failed")
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		p := newTestProvider(t, "hang", 200*time.Millisecond)
+		start := time.Now()
+		_, err := p.FetchImages(context.Background(), "q", 1)
+		assert.ErrorContains(t, err, "timed out")
+		assert.Less(t, time.Since(start), 10*time.Second)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		p := newTestProvider(t, "hang", 10*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		_, err := p.FetchImages(ctx, "q", 1)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{limit: 4}
+	n, err := b.Write([]byte("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	n, err = b.Write([]byte("def"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.True(t, b.overflow)
+	assert.Equal(t, "abcd", b.String())
+}
@@ -0,0 +1,43 @@
+package selfhosted
+
+import "time"
+
+const (
+	// ImmichProviderName is the unique identifier of the Immich provider.
+	ImmichProviderName = "Immich"
+	// ImmichHomeURL is the Immich project website.
+	ImmichHomeURL = "https://immich.app"
+	// ImmichScheme prefixes stored Immich queries: immich://album/<id> or immich://person/<id>.
+	ImmichScheme = "immich"
+
+	// PhotoPrismProviderName is the unique identifier of the PhotoPrism provider.
+	PhotoPrismProviderName = "PhotoPrism"
+	// PhotoPrismHomeURL is the PhotoPrism project website.
+	PhotoPrismHomeURL = "https://www.photoprism.app"
+	// PhotoPrismScheme prefixes stored PhotoPrism queries: photoprism://album/<uid> or photoprism://label/<slug>.
+	PhotoPrismScheme = "photoprism"
+
+	// Query kinds. They are the host part of a stored query URL.
+	KindAlbum  = "album"
+	KindPerson = "person"
+	KindLabel  = "label"
+
+	// ServerURLRegexp validates the base URL of a self-hosted server.
+	ServerURLRegexp = `^(?i)https?://[^\s/?#]+(/[^\s?#]*)?$`
+
+	// SelfHostedPageSize is the number of assets requested per page.
+	SelfHostedPageSize = 100
+
+	// SelfHostedMaxChoices caps the number of albums, people or labels listed in the query picker.
+	SelfHostedMaxChoices = 500
+
+	// SelfHostedAPIPacing spaces out API requests. The server is usually on the local network,
+	// but may be a small home server that also transcodes and runs machine learning jobs.
+	SelfHostedAPIPacing = 250 * time.Millisecond
+
+	// SelfHostedMediaPacing spaces out original downloads.
+	SelfHostedMediaPacing = 500 * time.Millisecond
+
+	// SelfHostedChoicesTimeout bounds the background refresh of the query picker.
+	SelfHostedChoicesTimeout = 15 * time.Second
+)
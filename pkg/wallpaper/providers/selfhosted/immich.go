@@ -0,0 +1,194 @@
+package selfhosted
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/dixieflatline76/Spice/v2/pkg/wallpaper"
+)
+
+// immichServer talks to the Immich REST API (https://immich.app/docs/api).
+type immichServer struct{}
+
+type immichAlbum struct {
+	ID         string `json:"id"`
+	AlbumName  string `json:"albumName"`
+	AssetCount int    `json:"assetCount"`
+}
+
+type immichPeople struct {
+	People []struct {
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		IsHidden bool   `json:"isHidden"`
+	} `json:"people"`
+}
+
+type immichSearchResponse struct {
+	Assets struct {
+		Items []immichAsset `json:"items"`
+	} `json:"assets"`
+}
+
+type immichAsset struct {
+	ID               string `json:"id"`
+	Type             string `json:"type"`
+	OriginalFileName string `json:"originalFileName"`
+	OriginalMimeType string `json:"originalMimeType"`
+	LocalDateTime    string `json:"localDateTime"`
+	IsTrashed        bool   `json:"isTrashed"`
+	ExifInfo         *struct {
+		ExifImageWidth  int    `json:"exifImageWidth"`
+		ExifImageHeight int    `json:"exifImageHeight"`
+		Orientation     string `json:"orientation"`
+		Description     string `json:"description"`
+	} `json:"exifInfo"`
+}
+
+func (s *immichServer) id() string      { return ImmichProviderName }
+func (s *immichServer) name() string    { return i18n.T("Immich") }
+func (s *immichServer) homeURL() string { return ImmichHomeURL }
+func (s *immichServer) scheme() string  { return ImmichScheme }
+func (s *immichServer) kinds() []string { return []string{KindAlbum, KindPerson} }
+
+func (s *immichServer) serverURL(cfg *wallpaper.Config) string {
+	return cfg.GetImmichServerURL()
+}
+
+func (s *immichServer) setServerURL(cfg *wallpaper.Config, serverURL string) {
+	cfg.SetImmichServerURL(serverURL)
+}
+
+func (s *immichServer) apiKey(cfg *wallpaper.Config) string {
+	return cfg.GetImmichAPIKey()
+}
+
+func (s *immichServer) setAPIKey(cfg *wallpaper.Config, apiKey string) {
+	cfg.SetImmichAPIKey(apiKey)
+}
+
+func (s *immichServer) authHeaders(apiKey string) map[string]string {
+	return map[string]string{"x-api-key": apiKey}
+}
+
+// parseWebPath accepts /albums/<id> and /people/<id> links from the Immich web interface.
+func (s *immichServer) parseWebPath(path string, _ url.Values) (string, string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 || parts[1] == "" {
+		return "", "", false
+	}
+	switch parts[0] {
+	case "albums":
+		return KindAlbum, parts[1], true
+	case "people":
+		return KindPerson, parts[1], true
+	}
+	return "", "", false
+}
+
+func (s *immichServer) webURL(baseURL, kind, id string) string {
+	if kind == KindPerson {
+		return baseURL + "/people/" + url.PathEscape(id)
+	}
+	return baseURL + "/albums/" + url.PathEscape(id)
+}
+
+func (s *immichServer) verify(ctx context.Context, c *apiClient) error {
+	var me struct {
+		ID string `json:"id"`
+	}
+	_, err := c.do(ctx, http.MethodGet, "/api/users/me", nil, nil, &me)
+	return err
+}
+
+// listChoices lists the user's albums followed by the named, visible people.
+func (s *immichServer) listChoices(ctx context.Context, c *apiClient) ([]choice, error) {
+	var albums []immichAlbum
+	if _, err := c.do(ctx, http.MethodGet, "/api/albums", nil, nil, &albums); err != nil {
+		return nil, err
+	}
+	var people immichPeople
+	params := url.Values{"withHidden": {"false"}, "size": {strconv.Itoa(SelfHostedMaxChoices)}}
+	if _, err := c.do(ctx, http.MethodGet, "/api/people", params, nil, &people); err != nil {
+		return nil, err
+	}
+
+	var choices []choice
+	for _, a := range albums {
+		choices = append(choices, choice{kind: KindAlbum, id: a.ID, name: a.AlbumName, count: a.AssetCount})
+	}
+	for _, person := range people.People {
+		if person.Name == "" || person.IsHidden {
+			continue // Unnamed faces are not useful picker entries
+		}
+		choices = append(choices, choice{kind: KindPerson, id: person.ID, name: person.Name})
+	}
+	if len(choices) > SelfHostedMaxChoices {
+		choices = choices[:SelfHostedMaxChoices]
+	}
+	return choices, nil
+}
+
+// fetch pages through the assets of an album or person with the metadata search endpoint, newest first.
+func (s *immichServer) fetch(ctx context.Context, c *apiClient, kind, id string, page int) ([]provider.Image, error) {
+	body := map[string]any{
+		"type":     "IMAGE",
+		"page":     page,
+		"size":     SelfHostedPageSize,
+		"withExif": true,
+		"order":    "desc",
+	}
+	if kind == KindPerson {
+		body["personIds"] = []string{id}
+	} else {
+		body["albumIds"] = []string{id}
+	}
+
+	var result immichSearchResponse
+	if _, err := c.do(ctx, http.MethodPost, "/api/search/metadata", nil, body, &result); err != nil {
+		return nil, err
+	}
+
+	var images []provider.Image
+	for _, a := range result.Assets.Items {
+		if img, ok := s.toImage(c.baseURL, a); ok {
+			images = append(images, img)
+		}
+	}
+	return images, nil
+}
+
+func (s *immichServer) toImage(baseURL string, a immichAsset) (provider.Image, bool) {
+	if a.ID == "" || a.IsTrashed || (a.Type != "" && a.Type != "IMAGE") {
+		return provider.Image{}, false
+	}
+	mimeType, ok := supportedMimeType(a.OriginalMimeType, a.OriginalFileName)
+	if !ok {
+		return provider.Image{}, false
+	}
+
+	img := provider.Image{
+		ID:       ImmichProviderName + "_" + a.ID,
+		Path:     baseURL + "/api/assets/" + url.PathEscape(a.ID) + "/original",
+		ViewURL:  baseURL + "/photos/" + url.PathEscape(a.ID),
+		Title:    a.OriginalFileName,
+		FileType: mimeType,
+		Provider: ImmichProviderName,
+	}
+	if len(a.LocalDateTime) >= 4 {
+		img.Year = a.LocalDateTime[:4]
+	}
+	if a.ExifInfo != nil {
+		if a.ExifInfo.Description != "" {
+			img.Title = a.ExifInfo.Description
+		}
+		img.Width, img.Height = orientedSize(a.ExifInfo.ExifImageWidth, a.ExifInfo.ExifImageHeight, a.ExifInfo.Orientation)
+	}
+	img.Attribution = img.Title
+	return img, true
+}
@@ -0,0 +1,192 @@
+package selfhosted
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/dixieflatline76/Spice/v2/pkg/wallpaper"
+)
+
+// photoprismServer talks to the PhotoPrism REST API (https://docs.photoprism.app/developer-guide/api/).
+type photoprismServer struct{}
+
+type photoprismAlbum struct {
+	UID        string `json:"UID"`
+	Title      string `json:"Title"`
+	PhotoCount int    `json:"PhotoCount"`
+}
+
+type photoprismLabel struct {
+	UID        string `json:"UID"`
+	Slug       string `json:"Slug"`
+	Name       string `json:"Name"`
+	PhotoCount int    `json:"PhotoCount"`
+}
+
+// photoprismPhoto is a search result. Unless merged, each photo carries the fields of its primary file.
+type photoprismPhoto struct {
+	UID         string `json:"UID"`
+	Type        string `json:"Type"`
+	Title       string `json:"Title"`
+	Description string `json:"Description"`
+	TakenAt     string `json:"TakenAt"`
+	Hash        string `json:"Hash"`
+	Mime        string `json:"Mime"`
+	FileName    string `json:"FileName"`
+	Width       int    `json:"Width"`
+	Height      int    `json:"Height"`
+	Orientation int    `json:"Orientation"`
+}
+
+func (s *photoprismServer) id() string      { return PhotoPrismProviderName }
+func (s *photoprismServer) name() string    { return i18n.T("PhotoPrism") }
+func (s *photoprismServer) homeURL() string { return PhotoPrismHomeURL }
+func (s *photoprismServer) scheme() string  { return PhotoPrismScheme }
+func (s *photoprismServer) kinds() []string { return []string{KindAlbum, KindLabel} }
+
+func (s *photoprismServer) serverURL(cfg *wallpaper.Config) string {
+	return cfg.GetPhotoPrismServerURL()
+}
+
+func (s *photoprismServer) setServerURL(cfg *wallpaper.Config, serverURL string) {
+	cfg.SetPhotoPrismServerURL(serverURL)
+}
+
+func (s *photoprismServer) apiKey(cfg *wallpaper.Config) string {
+	return cfg.GetPhotoPrismAPIKey()
+}
+
+func (s *photoprismServer) setAPIKey(cfg *wallpaper.Config, apiKey string) {
+	cfg.SetPhotoPrismAPIKey(apiKey)
+}
+
+func (s *photoprismServer) authHeaders(apiKey string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + apiKey}
+}
+
+// parseWebPath accepts /library/albums/<uid>/... links and label searches (/library/browse?q=label:<slug>).
+func (s *photoprismServer) parseWebPath(path string, params url.Values) (string, string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) >= 3 && parts[0] == "library" && parts[1] == "albums" && parts[2] != "" {
+		return KindAlbum, parts[2], true
+	}
+	if len(parts) >= 1 && parts[0] == "library" {
+		if slug, ok := strings.CutPrefix(strings.TrimSpace(params.Get("q")), "label:"); ok && slug != "" && !strings.ContainsAny(slug, " /") {
+			return KindLabel, slug, true
+		}
+	}
+	return "", "", false
+}
+
+func (s *photoprismServer) webURL(baseURL, kind, id string) string {
+	if kind == KindLabel {
+		return baseURL + "/library/browse?" + url.Values{"q": {"label:" + id}}.Encode()
+	}
+	return baseURL + "/library/albums/" + url.PathEscape(id) + "/view"
+}
+
+func (s *photoprismServer) verify(ctx context.Context, c *apiClient) error {
+	var albums []photoprismAlbum
+	_, err := c.do(ctx, http.MethodGet, "/api/v1/albums", url.Values{"count": {"1"}, "type": {"album"}}, nil, &albums)
+	return err
+}
+
+// listChoices lists the user's albums followed by the labels that have photos.
+func (s *photoprismServer) listChoices(ctx context.Context, c *apiClient) ([]choice, error) {
+	count := strconv.Itoa(SelfHostedMaxChoices)
+
+	var albums []photoprismAlbum
+	params := url.Values{"count": {count}, "offset": {"0"}, "type": {"album"}, "order": {"name"}}
+	if _, err := c.do(ctx, http.MethodGet, "/api/v1/albums", params, nil, &albums); err != nil {
+		return nil, err
+	}
+	var labels []photoprismLabel
+	params = url.Values{"count": {count}, "offset": {"0"}}
+	if _, err := c.do(ctx, http.MethodGet, "/api/v1/labels", params, nil, &labels); err != nil {
+		return nil, err
+	}
+
+	var choices []choice
+	for _, a := range albums {
+		choices = append(choices, choice{kind: KindAlbum, id: a.UID, name: a.Title, count: a.PhotoCount})
+	}
+	for _, l := range labels {
+		if l.Slug == "" || l.PhotoCount == 0 {
+			continue
+		}
+		choices = append(choices, choice{kind: KindLabel, id: l.Slug, name: l.Name, count: l.PhotoCount})
+	}
+	if len(choices) > SelfHostedMaxChoices {
+		choices = choices[:SelfHostedMaxChoices]
+	}
+	return choices, nil
+}
+
+// fetch pages through the photos of an album or label with count/offset, newest first.
+// Originals are served by the download endpoint, which expects the download token that
+// PhotoPrism returns in the X-Download-Token header of search results.
+func (s *photoprismServer) fetch(ctx context.Context, c *apiClient, kind, id string, page int) ([]provider.Image, error) {
+	params := url.Values{
+		"count":  {strconv.Itoa(SelfHostedPageSize)},
+		"offset": {strconv.Itoa((page - 1) * SelfHostedPageSize)},
+		"order":  {"newest"},
+		"photo":  {"true"},
+	}
+	if kind == KindLabel {
+		params.Set("q", "label:"+id)
+	} else {
+		params.Set("s", id)
+	}
+
+	var photos []photoprismPhoto
+	header, err := c.do(ctx, http.MethodGet, "/api/v1/photos", params, nil, &photos)
+	if err != nil {
+		return nil, err
+	}
+	token := header.Get("X-Download-Token")
+
+	var images []provider.Image
+	for _, ph := range photos {
+		if img, ok := s.toImage(c.baseURL, token, ph); ok {
+			images = append(images, img)
+		}
+	}
+	return images, nil
+}
+
+func (s *photoprismServer) toImage(baseURL, token string, ph photoprismPhoto) (provider.Image, bool) {
+	if ph.UID == "" || ph.Hash == "" || ph.Type == "video" {
+		return provider.Image{}, false
+	}
+	mimeType, ok := supportedMimeType(ph.Mime, ph.FileName)
+	if !ok {
+		return provider.Image{}, false
+	}
+
+	download := baseURL + "/api/v1/dl/" + url.PathEscape(ph.Hash)
+	if token != "" {
+		download += "?" + url.Values{"t": {token}}.Encode()
+	}
+	img := provider.Image{
+		ID:       PhotoPrismProviderName + "_" + ph.UID,
+		Path:     download,
+		ViewURL:  baseURL + "/library/browse?" + url.Values{"q": {"uid:" + ph.UID}}.Encode(),
+		Title:    ph.Title,
+		FileType: mimeType,
+		Provider: PhotoPrismProviderName,
+	}
+	if len(ph.TakenAt) >= 4 {
+		img.Year = ph.TakenAt[:4]
+	}
+	if img.Title == "" {
+		img.Title = ph.Description
+	}
+	img.Width, img.Height = orientedSize(ph.Width, ph.Height, strconv.Itoa(ph.Orientation))
+	img.Attribution = img.Title
+	return img, true
+}
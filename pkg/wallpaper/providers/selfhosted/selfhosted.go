@@ -0,0 +1,672 @@
+package selfhosted
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/imagecodec"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/schema"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/setting"
+	"github.com/dixieflatline76/Spice/v2/pkg/wallpaper"
+	"github.com/dixieflatline76/Spice/v2/util/log"
+)
+
+//go:embed SelfHosted.png
+var iconData []byte
+
+// server is the API of a self-hosted photo server.
+type server interface {
+	id() string
+	name() string
+	homeURL() string
+	scheme() string
+
+	// kinds lists the query kinds the server supports (albums first).
+	kinds() []string
+
+	serverURL(cfg *wallpaper.Config) string
+	setServerURL(cfg *wallpaper.Config, serverURL string)
+	apiKey(cfg *wallpaper.Config) string
+	setAPIKey(cfg *wallpaper.Config, apiKey string)
+
+	// authHeaders returns the headers that authenticate API requests and original downloads.
+	authHeaders(apiKey string) map[string]string
+
+	// parseWebPath converts a web interface URL, relative to the server URL, into a query kind and ID.
+	parseWebPath(path string, params url.Values) (kind, id string, ok bool)
+
+	// webURL returns the web interface page of a query.
+	webURL(baseURL, kind, id string) string
+
+	// verify checks that the server accepts the credentials.
+	verify(ctx context.Context, c *apiClient) error
+
+	// listChoices returns the albums, people or labels the user can add as queries.
+	listChoices(ctx context.Context, c *apiClient) ([]choice, error)
+
+	// fetch returns one page of the images of a query. Pages beyond the last one are empty.
+	fetch(ctx context.Context, c *apiClient, kind, id string, page int) ([]provider.Image, error)
+}
+
+// choice is an album, person or label offered in the query picker.
+type choice struct {
+	kind  string
+	id    string
+	name  string
+	count int
+}
+
+// Provider implements ImageProvider for a self-hosted photo server. Queries are albums, people or
+// labels on that server, stored as <scheme>://<kind>/<id> so they survive a change of server URL.
+type Provider struct {
+	cfg        *wallpaper.Config
+	httpClient *http.Client
+	srv        server
+
+	// baseURL and key override the configured server URL and API key (tests).
+	baseURL string
+	key     string
+
+	mu         sync.Mutex
+	choices    []choice
+	refreshing bool
+}
+
+var serverURLRegex = regexp.MustCompile(ServerURLRegexp)
+
+func init() {
+	wallpaper.RegisterProvider(ImmichProviderName, func(cfg *wallpaper.Config, client *http.Client) provider.ImageProvider {
+		return NewImmichProvider(cfg, client)
+	})
+	wallpaper.RegisterProvider(PhotoPrismProviderName, func(cfg *wallpaper.Config, client *http.Client) provider.ImageProvider {
+		return NewPhotoPrismProvider(cfg, client)
+	})
+}
+
+// NewImmichProvider creates a provider for an Immich server.
+func NewImmichProvider(cfg *wallpaper.Config, client *http.Client) *Provider {
+	return newProvider(cfg, client, &immichServer{})
+}
+
+// NewPhotoPrismProvider creates a provider for a PhotoPrism server.
+func NewPhotoPrismProvider(cfg *wallpaper.Config, client *http.Client) *Provider {
+	return newProvider(cfg, client, &photoprismServer{})
+}
+
+func newProvider(cfg *wallpaper.Config, client *http.Client, srv server) *Provider {
+	return &Provider{
+		cfg:        cfg,
+		httpClient: client,
+		srv:        srv,
+	}
+}
+
+func (p *Provider) ID() string {
+	return p.srv.id()
+}
+
+func (p *Provider) Name() string {
+	return p.srv.name()
+}
+
+func (p *Provider) Title() string {
+	return p.srv.id()
+}
+
+func (p *Provider) GetProviderIcon() interface{} {
+	return iconData
+}
+
+func (p *Provider) Type() provider.ProviderType {
+	return provider.TypePersonal
+}
+
+func (p *Provider) GetAttributionType() provider.AttributionType {
+	return provider.AttributionIn
+}
+
+// HomeURL returns the user's server once configured, and the project website before that.
+func (p *Provider) HomeURL() string {
+	if base := p.serverURL(); base != "" {
+		return base
+	}
+	return p.srv.homeURL()
+}
+
+func (p *Provider) SupportsUserQueries() bool {
+	return true
+}
+
+// GetAPIPacing implements the PacedProvider interface to space out API requests.
+func (p *Provider) GetAPIPacing() time.Duration {
+	return SelfHostedAPIPacing
+}
+
+// GetProcessPacing implements the PacedProvider interface to space out original downloads.
+func (p *Provider) GetProcessPacing() time.Duration {
+	return SelfHostedMediaPacing
+}
+
+// GetDownloadHeaders implements HeaderProvider. Originals are only served to authenticated clients.
+func (p *Provider) GetDownloadHeaders() map[string]string {
+	key := p.apiKey()
+	if key == "" {
+		return nil
+	}
+	return p.srv.authHeaders(key)
+}
+
+// ParseURL accepts a stored query URL (e.g. immich://album/<id>) or a link to an album, person or
+// label copied from the web interface of the configured server.
+func (p *Provider) ParseURL(webURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(webURL))
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+
+	if u.Scheme == p.srv.scheme() {
+		kind, id, err := p.parseQuery(u)
+		if err != nil {
+			return "", err
+		}
+		return p.queryURL(kind, id), nil
+	}
+
+	base := p.serverURL()
+	if base == "" {
+		return "", fmt.Errorf("%s server is not configured", p.ID())
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid server URL: %w", err)
+	}
+	basePath := strings.TrimSuffix(b.Path, "/")
+	if !strings.EqualFold(u.Host, b.Host) || !strings.HasPrefix(u.Path, basePath+"/") {
+		return "", fmt.Errorf("URL is not on the configured %s server", p.ID())
+	}
+
+	kind, id, ok := p.srv.parseWebPath(strings.TrimPrefix(u.Path, basePath), u.Query())
+	if !ok {
+		return "", fmt.Errorf("URL is not a %s album", p.ID())
+	}
+	return p.queryURL(kind, id), nil
+}
+
+// queryURL returns the stored form of a query.
+func (p *Provider) queryURL(kind, id string) string {
+	return p.srv.scheme() + "://" + kind + "/" + url.PathEscape(id)
+}
+
+// parseQuery splits a stored query URL into its kind and ID.
+func (p *Provider) parseQuery(u *url.URL) (kind, id string, err error) {
+	if u.Scheme != p.srv.scheme() {
+		return "", "", fmt.Errorf("invalid scheme: %s", u.Scheme)
+	}
+	kind, id = u.Host, strings.Trim(u.Path, "/")
+	if !slices.Contains(p.srv.kinds(), kind) || id == "" || strings.Contains(id, "/") {
+		return "", "", fmt.Errorf("invalid %s query: %s", p.ID(), u.String())
+	}
+	return kind, id, nil
+}
+
+// FetchImages returns one page of the assets of an album, person or label.
+func (p *Provider) FetchImages(ctx context.Context, apiURL string, page int) ([]provider.Image, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	kind, id, err := p.parseQuery(u)
+	if err != nil {
+		return nil, err
+	}
+	c, err := p.client()
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := p.srv.fetch(ctx, c, kind, id, page)
+	if err != nil {
+		return nil, err
+	}
+
+	// Credit the album or person the user named the query after ("In Summer 2024").
+	desc := p.queryDescription(apiURL)
+	for i := range images {
+		images[i].Provider = p.ID()
+		if desc != "" {
+			images[i].Attribution = desc
+		}
+	}
+	log.Debugf("[%s] %s %s page %d: %d images", p.ID(), kind, id, page, len(images))
+	return images, nil
+}
+
+// EnrichImage is a no-op — all metadata comes from the asset listing.
+func (p *Provider) EnrichImage(ctx context.Context, img provider.Image) (provider.Image, error) {
+	return img, nil
+}
+
+// queryDescription returns the description of the stored query with the given URL.
+func (p *Provider) queryDescription(queryURL string) string {
+	if p.cfg == nil {
+		return ""
+	}
+	for _, q := range p.cfg.GetSelfHostedQueries(p.ID()) {
+		if q.URL == queryURL {
+			return q.Description
+		}
+	}
+	return ""
+}
+
+func (p *Provider) serverURL() string {
+	if p.baseURL != "" {
+		return p.baseURL
+	}
+	if p.cfg == nil {
+		return ""
+	}
+	return normalizeServerURL(p.srv.serverURL(p.cfg))
+}
+
+func (p *Provider) apiKey() string {
+	if p.key != "" {
+		return p.key
+	}
+	if p.cfg == nil {
+		return ""
+	}
+	return p.srv.apiKey(p.cfg)
+}
+
+// client returns an API client for the configured server.
+func (p *Provider) client() (*apiClient, error) {
+	base, key := p.serverURL(), p.apiKey()
+	if base == "" || key == "" {
+		return nil, fmt.Errorf("%s server is not configured", p.ID())
+	}
+	return p.newClient(base, key), nil
+}
+
+func (p *Provider) newClient(baseURL, apiKey string) *apiClient {
+	return &apiClient{
+		httpClient: p.httpClient,
+		baseURL:    baseURL,
+		headers:    p.srv.authHeaders(apiKey),
+	}
+}
+
+// normalizeServerURL trims whitespace and trailing slashes from a server URL.
+func normalizeServerURL(serverURL string) string {
+	return strings.TrimRight(strings.TrimSpace(serverURL), "/")
+}
+
+// supportedMimeType reports whether an original can be decoded by the wallpaper pipeline.
+// RAW and video assets are skipped.
+func supportedMimeType(mimeType, fileName string) (string, bool) {
+	if mimeType == "" {
+		mimeType = imagecodec.ContentType(fileName)
+	}
+	mimeType = imagecodec.Normalize(mimeType)
+	return mimeType, mimeType != ""
+}
+
+// orientedSize returns the displayed size of an image whose EXIF orientation rotates it by 90°.
+func orientedSize(width, height int, orientation string) (int, int) {
+	switch orientation {
+	case "5", "6", "7", "8":
+		return height, width
+	}
+	return width, height
+}
+
+// apiClient performs authenticated requests against a server.
+type apiClient struct {
+	httpClient *http.Client
+	baseURL    string
+	headers    map[string]string
+}
+
+var errUnauthorized = errors.New("the server rejected the API key")
+
+// do sends a request to the server and decodes the JSON response into v. The response headers are returned
+// because some servers hand out download tokens in them.
+func (c *apiClient) do(ctx context.Context, method, apiPath string, params url.Values, body, v any) (http.Header, error) {
+	reqURL := c.baseURL + apiPath
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("encoding request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reader)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, val := range c.headers {
+		req.Header.Set(k, val)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, errUnauthorized
+	default:
+		return nil, fmt.Errorf("%s %s returned %s", method, apiPath, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return resp.Header, nil
+}
+
+// refreshChoices reloads the query picker in the background. The settings panels are built without
+// network access, so the picker shows what the previous refresh found.
+func (p *Provider) refreshChoices() {
+	p.mu.Lock()
+	if p.refreshing {
+		p.mu.Unlock()
+		return
+	}
+	p.refreshing = true
+	p.mu.Unlock()
+
+	go func() {
+		defer func() {
+			p.mu.Lock()
+			p.refreshing = false
+			p.mu.Unlock()
+		}()
+
+		c, err := p.client()
+		if err != nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), SelfHostedChoicesTimeout)
+		defer cancel()
+
+		choices, err := p.srv.listChoices(ctx, c)
+		if err != nil {
+			log.Printf("[%s] Failed to list albums: %v", p.ID(), err)
+			return
+		}
+		p.mu.Lock()
+		p.choices = choices
+		p.mu.Unlock()
+	}()
+}
+
+func (p *Provider) cachedChoices() []choice {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.choices
+}
+
+// choiceLabel is the picker entry of a choice, e.g. "Album: Summer 2024 (120)".
+func choiceLabel(c choice) string {
+	var kind string
+	switch c.kind {
+	case KindAlbum:
+		kind = i18n.T("Album")
+	case KindPerson:
+		kind = i18n.T("Person")
+	case KindLabel:
+		kind = i18n.T("Label")
+	}
+	if c.count > 0 {
+		return fmt.Sprintf("%s: %s (%d)", kind, c.name, c.count)
+	}
+	return fmt.Sprintf("%s: %s", kind, c.name)
+}
+
+// --- UI Implementation (Pure Go) ---
+
+func (p *Provider) serverURLIdent() string { return strings.ToLower(p.ID()) + "ServerURL" }
+func (p *Provider) apiKeyIdent() string    { return strings.ToLower(p.ID()) + "APIKey" }
+
+// CreateSettingsPanel returns the server connection settings.
+func (p *Provider) CreateSettingsPanel(sm setting.SettingsManager) *schema.PanelSchema {
+	urlIdent, keyIdent := p.serverURLIdent(), p.apiKeyIdent()
+
+	// pendingServerURL lets the key be verified against a server URL that has not been applied yet.
+	pendingServerURL := func() string {
+		if v, ok := sm.GetValue(urlIdent).(string); ok && v != "" {
+			return normalizeServerURL(v)
+		}
+		return p.serverURL()
+	}
+
+	var description, keyLabel, keyPlaceholder string
+	switch p.srv.(type) {
+	case *immichServer:
+		description = i18n.T("Show photos from your own Immich server. Create an API key under Account Settings > API Keys with read access to assets, albums and people.")
+		keyLabel = i18n.T("Immich API Key:")
+		keyPlaceholder = i18n.T("Enter your Immich API Key")
+	default:
+		description = i18n.T("Show photos from your own PhotoPrism server. Create an app password under Settings > Account > Apps and Devices.")
+		keyLabel = i18n.T("PhotoPrism App Password:")
+		keyPlaceholder = i18n.T("Enter your PhotoPrism App Password")
+	}
+
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:   p.Name(),
+				Compact: true,
+				Items: []schema.ItemSchema{
+					schema.LabelItem{
+						Text:       description,
+						Importance: schema.ImportanceLow,
+					},
+					schema.TextItem{
+						Name:         urlIdent,
+						Label:        i18n.T("Server URL:"),
+						InitialValue: p.serverURL(),
+						PlaceHolder:  "https://photos.example.com",
+						Validator: func(s string) error {
+							if s = strings.TrimSpace(s); s != "" && !serverURLRegex.MatchString(s) {
+								return errors.New(i18n.T("Invalid server URL"))
+							}
+							return nil
+						},
+						ApplyFunc: func(s string) {
+							p.srv.setServerURL(p.cfg, normalizeServerURL(s))
+							p.refreshChoices()
+						},
+					},
+					schema.SecretItem{
+						Name:         keyIdent,
+						Label:        keyLabel,
+						InitialValue: p.srv.apiKey(p.cfg),
+						Placeholder:  keyPlaceholder,
+						OnVerify: func(key string) error {
+							base := pendingServerURL()
+							if base == "" {
+								return errors.New(i18n.T("Enter the server URL first"))
+							}
+							ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+							defer cancel()
+							return p.srv.verify(ctx, p.newClient(base, key))
+						},
+						ApplyFunc: func(key string) {
+							p.srv.setAPIKey(p.cfg, key)
+							p.refreshChoices()
+						},
+						OnClear: func() {
+							p.srv.setAPIKey(p.cfg, "")
+							sm.ResetSettings(
+								setting.SettingReset{Name: keyIdent, Value: ""},
+							)
+						},
+					},
+					schema.ButtonItem{
+						Name:       strings.ToLower(p.ID()) + "_web",
+						ButtonText: i18n.T("Visit Website"),
+						IconName:   "Home",
+						OnPressed:  func() { sm.OpenURL(p.srv.homeURL()) },
+					},
+				},
+			},
+		},
+	}
+}
+
+// CreateQueryPanel lets the user pick albums, people or labels from the server, or paste a link to one.
+func (p *Provider) CreateQueryPanel(sm setting.SettingsManager, pendingUrl string) *schema.PanelSchema {
+	sampleURL := p.srv.webURL("https://photos.example.com", KindAlbum, "…")
+	addCfg := schema.AddQueryConfig{
+		Title:           i18n.Tf("Add {{.Name}} Album", map[string]any{"Name": p.Title()}),
+		Description:     i18n.T("Paste a link copied from the web interface of your server."),
+		URLPlaceholder:  sampleURL,
+		DescPlaceholder: i18n.T("Query Description (e.g. Summer Vacation)"),
+		ValidateFunc: func(url, desc string) error {
+			_, err := p.ParseURL(url)
+			return err
+		},
+		AddHandler: func(desc, url string, active bool) (string, error) {
+			queryURL, err := p.ParseURL(url)
+			if err != nil {
+				return "", err
+			}
+			return p.cfg.AddSelfHostedQuery(desc, queryURL, p.ID(), active)
+		},
+	}
+
+	if pendingUrl != "" {
+		sm.ShowAddQueryDialog(addCfg, pendingUrl, "", sm.RefreshUI)
+	}
+
+	var pickerItems []schema.ItemSchema
+	choices := p.cachedChoices()
+	switch {
+	case p.serverURL() == "" || p.apiKey() == "":
+		pickerItems = append(pickerItems, schema.LabelItem{
+			Text:       i18n.T("Connect your server above to pick from its albums."),
+			Importance: schema.ImportanceLow,
+		})
+	case len(choices) == 0:
+		pickerItems = append(pickerItems, schema.LabelItem{
+			Text:       i18n.T("Loading albums from your server. Reopen this page to pick from them, or paste a link instead."),
+			Importance: schema.ImportanceLow,
+		})
+	default:
+		options := make([]string, len(choices))
+		for i, c := range choices {
+			options[i] = choiceLabel(c)
+		}
+		selected := 0
+		pickerLabel := i18n.T("Album or person:")
+		if slices.Contains(p.srv.kinds(), KindLabel) {
+			pickerLabel = i18n.T("Album or label:")
+		}
+		pickerItems = append(pickerItems,
+			schema.SelectItem{
+				Name:         strings.ToLower(p.ID()) + "_picker",
+				Label:        pickerLabel,
+				Options:      options,
+				InitialValue: 0,
+				OnChanged: func(_ string, val interface{}) {
+					if i, ok := val.(int); ok && i >= 0 {
+						selected = i
+					}
+				},
+				ApplyFunc: func(interface{}) {}, // Picking only preselects the Add dialog
+			},
+			schema.ButtonItem{
+				Name:       strings.ToLower(p.ID()) + "_add_selected",
+				ButtonText: i18n.T("Add Selected"),
+				IconName:   "add",
+				OnPressed: func() {
+					c := choices[selected]
+					sm.ShowAddQueryDialog(addCfg, p.queryURL(c.kind, c.id), c.name, sm.RefreshUI)
+				},
+			},
+		)
+	}
+	// Keep the picker current for the next time the panel is built.
+	p.refreshChoices()
+
+	items := append(pickerItems,
+		schema.ButtonItem{
+			Name:       strings.ToLower(p.ID()) + "_add",
+			ButtonText: i18n.T("Paste Link"),
+			IconName:   "add",
+			OnPressed: func() {
+				sm.ShowAddQueryDialog(addCfg, "", "", sm.RefreshUI)
+			},
+		},
+		schema.QueryListItem{
+			GetQueries: func() []schema.Query {
+				queries := p.cfg.GetSelfHostedQueries(p.ID())
+				abstracts := make([]schema.Query, len(queries))
+				for i, q := range queries {
+					abstracts[i] = schema.Query{
+						ID:          q.ID,
+						URL:         q.URL,
+						Description: q.Description,
+						Active:      q.Active,
+						Managed:     q.Managed,
+					}
+				}
+				return abstracts
+			},
+			EnableQuery:  p.cfg.EnableImageQuery,
+			DisableQuery: p.cfg.DisableImageQuery,
+			RemoveQuery:  p.cfg.RemoveImageQuery,
+			GetDisplayURL: func(q schema.Query) *url.URL {
+				u, err := url.Parse(q.URL)
+				if err != nil {
+					return nil
+				}
+				kind, id, err := p.parseQuery(u)
+				base := p.serverURL()
+				if err != nil || base == "" {
+					return nil
+				}
+				web, _ := url.Parse(p.srv.webURL(base, kind, id))
+				return web
+			},
+		},
+	)
+
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:       i18n.Tf("My {{.Name}} Albums", map[string]any{"Name": p.Title()}),
+				Description: i18n.T("Pick albums from your server, or paste a link copied from its web interface."),
+				Items:       items,
+			},
+		},
+	}
+}
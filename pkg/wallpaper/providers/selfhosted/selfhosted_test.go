@@ -0,0 +1,216 @@
+package selfhosted
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "secret-key"
+
+func newTestProvider(serverURL string, srv server) *Provider {
+	p := newProvider(nil, http.DefaultClient, srv)
+	p.baseURL = serverURL
+	p.key = testKey
+	return p
+}
+
+// immichMock mimics the album, people and metadata search endpoints of an Immich server.
+func immichMock(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != testKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/albums":
+			_, _ = w.Write([]byte(`[{"id":"album-1","albumName":"Summer 2024","assetCount":2}]`))
+		case "/api/people":
+			assert.Equal(t, "false", r.URL.Query().Get("withHidden"))
+			_, _ = w.Write([]byte(`{"people":[{"id":"person-1","name":"Alice"},{"id":"person-2","name":""}]}`))
+		case "/api/search/metadata":
+			require.Equal(t, http.MethodPost, r.Method)
+			var body map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, []any{"album-1"}, body["albumIds"])
+			assert.Equal(t, "IMAGE", body["type"])
+			assert.EqualValues(t, SelfHostedPageSize, body["size"])
+			if body["page"] != float64(1) {
+				_, _ = w.Write([]byte(`{"assets":{"items":[],"nextPage":null}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"assets":{"items":[
+				{"id":"a1","type":"IMAGE","originalFileName":"IMG_0001.jpg","originalMimeType":"image/jpeg","localDateTime":"2024-07-14T18:03:00.000Z",
+				 "exifInfo":{"exifImageWidth":4032,"exifImageHeight":3024,"orientation":"6","description":"Sunset at the lake"}},
+				{"id":"a2","type":"IMAGE","originalFileName":"IMG_0002.HEIC","originalMimeType":"image/heic"},
+				{"id":"a4","type":"IMAGE","originalFileName":"IMG_0004.DNG","originalMimeType":"image/x-adobe-dng"},
+				{"id":"a3","type":"VIDEO","originalFileName":"MOV_0003.mp4","originalMimeType":"video/mp4"}
+			],"nextPage":"2"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestImmich_FetchImages(t *testing.T) {
+	ts := immichMock(t)
+	defer ts.Close()
+
+	p := newTestProvider(ts.URL, &immichServer{})
+	images, err := p.FetchImages(context.Background(), "immich://album/album-1", 1)
+	require.NoError(t, err)
+	require.Len(t, images, 2, "RAW originals and videos are skipped")
+	assert.Equal(t, "image/heic", images[1].FileType)
+
+	img := images[0]
+	assert.Equal(t, "Immich_a1", img.ID)
+	assert.Equal(t, ts.URL+"/api/assets/a1/original", img.Path)
+	assert.Equal(t, ts.URL+"/photos/a1", img.ViewURL)
+	assert.Equal(t, "Sunset at the lake", img.Title)
+	assert.Equal(t, "2024", img.Year)
+	assert.Equal(t, "image/jpeg", img.FileType)
+	assert.Equal(t, 3024, img.Width, "rotated photos report their displayed size")
+	assert.Equal(t, 4032, img.Height)
+	assert.Equal(t, ImmichProviderName, img.Provider)
+
+	images, err = p.FetchImages(context.Background(), "immich://album/album-1", 2)
+	require.NoError(t, err)
+	assert.Empty(t, images, "pages beyond the last one are empty")
+
+	assert.Equal(t, map[string]string{"x-api-key": testKey}, p.GetDownloadHeaders())
+}
+
+func TestImmich_ListChoices(t *testing.T) {
+	ts := immichMock(t)
+	defer ts.Close()
+
+	p := newTestProvider(ts.URL, &immichServer{})
+	c, err := p.client()
+	require.NoError(t, err)
+	choices, err := p.srv.listChoices(context.Background(), c)
+	require.NoError(t, err)
+	assert.Equal(t, []choice{
+		{kind: KindAlbum, id: "album-1", name: "Summer 2024", count: 2},
+		{kind: KindPerson, id: "person-1", name: "Alice"},
+	}, choices, "unnamed people are not offered")
+	assert.Equal(t, "Album: Summer 2024 (2)", choiceLabel(choices[0]))
+}
+
+func TestImmich_InvalidKey(t *testing.T) {
+	ts := immichMock(t)
+	defer ts.Close()
+
+	p := newTestProvider(ts.URL, &immichServer{})
+	p.key = "wrong"
+	_, err := p.FetchImages(context.Background(), "immich://person/person-1", 1)
+	assert.ErrorIs(t, err, errUnauthorized)
+	assert.ErrorIs(t, p.srv.verify(context.Background(), p.newClient(ts.URL, "wrong")), errUnauthorized)
+}
+
+func TestPhotoPrism_FetchImages(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer "+testKey, r.Header.Get("Authorization"))
+		require.Equal(t, "/api/v1/photos", r.URL.Path)
+		q := r.URL.Query()
+		assert.Equal(t, "label:sunset", q.Get("q"))
+		assert.Equal(t, "100", q.Get("offset"), "page 2 starts after the first page")
+		assert.Equal(t, "100", q.Get("count"))
+		assert.Equal(t, "true", q.Get("photo"))
+		w.Header().Set("X-Download-Token", "dl-token")
+		_, _ = w.Write([]byte(`[
+			{"UID":"pq1","Type":"image","Title":"Sunset / Lake","TakenAt":"2023-08-01T19:00:00Z","Hash":"abc123","Mime":"image/jpeg","FileName":"2023/08/IMG_1.jpg","Width":6000,"Height":4000,"Orientation":1},
+			{"UID":"pq2","Type":"raw","Title":"Raw only","Hash":"def456","Mime":"image/x-canon-cr2","FileName":"2023/08/IMG_2.CR2"},
+			{"UID":"pq3","Type":"video","Title":"Clip","Hash":"ghi789","Mime":"video/mp4"}
+		]`))
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.URL, &photoprismServer{})
+	images, err := p.FetchImages(context.Background(), "photoprism://label/sunset", 2)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+
+	img := images[0]
+	assert.Equal(t, "PhotoPrism_pq1", img.ID)
+	assert.Equal(t, ts.URL+"/api/v1/dl/abc123?t=dl-token", img.Path)
+	assert.Equal(t, "Sunset / Lake", img.Title)
+	assert.Equal(t, "2023", img.Year)
+	assert.Equal(t, 6000, img.Width)
+
+	assert.Equal(t, map[string]string{"Authorization": "Bearer " + testKey}, p.GetDownloadHeaders())
+}
+
+func TestPhotoPrism_AlbumQuery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/photos":
+			assert.Equal(t, "aq1", r.URL.Query().Get("s"))
+			assert.Equal(t, "0", r.URL.Query().Get("offset"))
+			_, _ = w.Write([]byte(`[]`))
+		case "/api/v1/albums":
+			_, _ = w.Write([]byte(`[{"UID":"aq1","Title":"Family","PhotoCount":12}]`))
+		case "/api/v1/labels":
+			_, _ = w.Write([]byte(`[{"UID":"lq1","Slug":"sunset","Name":"Sunset","PhotoCount":5},{"UID":"lq2","Slug":"empty","Name":"Empty"}]`))
+		}
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.URL, &photoprismServer{})
+	images, err := p.FetchImages(context.Background(), "photoprism://album/aq1", 1)
+	require.NoError(t, err)
+	assert.Empty(t, images)
+
+	c, err := p.client()
+	require.NoError(t, err)
+	choices, err := p.srv.listChoices(context.Background(), c)
+	require.NoError(t, err)
+	assert.Equal(t, []choice{
+		{kind: KindAlbum, id: "aq1", name: "Family", count: 12},
+		{kind: KindLabel, id: "sunset", name: "Sunset", count: 5},
+	}, choices, "labels without photos are not offered")
+}
+
+func TestParseURL(t *testing.T) {
+	immich := newTestProvider("https://photos.example.com", &immichServer{})
+	photoprism := newTestProvider("https://home.example.com/photoprism", &photoprismServer{})
+
+	tests := []struct {
+		name    string
+		p       *Provider
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"Immich album link", immich, "https://photos.example.com/albums/0b9c1e2f-aaaa?foo=bar", "immich://album/0b9c1e2f-aaaa", false},
+		{"Immich person link", immich, "https://photos.example.com/people/1234", "immich://person/1234", false},
+		{"Immich stored query", immich, "immich://person/1234", "immich://person/1234", false},
+		{"Immich photo link", immich, "https://photos.example.com/photos/1234", "", true},
+		{"Immich other server", immich, "https://evil.example.com/albums/1234", "", true},
+		{"Immich unknown kind", immich, "immich://label/sunset", "", true},
+		{"PhotoPrism album under a path", photoprism, "https://home.example.com/photoprism/library/albums/aq1/view", "photoprism://album/aq1", false},
+		{"PhotoPrism label search", photoprism, "https://home.example.com/photoprism/library/browse?q=label%3Asunset", "photoprism://label/sunset", false},
+		{"PhotoPrism outside the base path", photoprism, "https://home.example.com/library/albums/aq1/view", "", true},
+		{"Other scheme", photoprism, "immich://album/1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.p.ParseURL(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Stored queries round-trip to the web interface.
+	assert.Equal(t, "https://home.example.com/photoprism/library/browse?q=label%3Asunset",
+		photoprism.srv.webURL(photoprism.serverURL(), KindLabel, "sunset"))
+}
@@ -0,0 +1,30 @@
+package smithsonian
+
+const (
+	// ProviderName is the unique identifier for config
+	ProviderName = "Smithsonian"
+
+	// ProviderTitle is the user-facing name
+	ProviderTitle = "Smithsonian"
+
+	// APIBaseURL is the base for all Open Access API calls (api.data.gov key required)
+	APIBaseURL = "https://api.si.edu/openaccess/api/v1.0"
+
+	// WebBaseURL is the public-facing website
+	WebBaseURL = "https://www.si.edu"
+
+	// ObjectBaseURL is the collection search detail page, followed by the record's EDAN URL (e.g. edanmdm:saam_1977.107.1)
+	ObjectBaseURL = "https://collections.si.edu/search/detail/"
+
+	// IDSDeliveryURL serves Smithsonian images and scales them with the max parameter
+	IDSDeliveryURL = "https://ids.si.edu/ids/deliveryService"
+
+	// DemoAPIKey is api.data.gov's shared key, used until the user adds their own.
+	DemoAPIKey = "DEMO_KEY" //nolint:gosec // Public demo key
+
+	// SearchFilters restricts every search to CC0 records with images.
+	SearchFilters = "online_media_type:Images AND media_usage:CC0"
+
+	// Collection keys
+	CollectionHighlights = "si_highlights"
+)
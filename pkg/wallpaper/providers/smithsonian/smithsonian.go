@@ -0,0 +1,463 @@
+package smithsonian
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/curation"
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/schema"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/setting"
+	"github.com/dixieflatline76/Spice/v2/pkg/wallpaper"
+	"github.com/dixieflatline76/Spice/v2/util/log"
+)
+
+//go:embed Smithsonian.png
+var iconData []byte
+
+// pageSize is the number of objects per page for both curated and search collections.
+const pageSize = 20
+
+// Provider implements the Smithsonian Open Access wallpaper provider.
+// Records come from the Open Access API (api.data.gov key); images are served by the IDS image server.
+type Provider struct {
+	cfg    *wallpaper.Config
+	client *http.Client
+	apiURL string
+}
+
+// API response types
+
+type searchResponse struct {
+	Response struct {
+		Rows     []apiRow `json:"rows"`
+		RowCount int      `json:"rowCount"`
+	} `json:"response"`
+}
+
+type contentResponse struct {
+	Response apiRow `json:"response"`
+}
+
+type apiRow struct {
+	ID      string `json:"id"` // e.g. edanmdm-saam_1977.107.1
+	Title   string `json:"title"`
+	URL     string `json:"url"` // e.g. edanmdm:saam_1977.107.1
+	Content struct {
+		DescriptiveNonRepeating struct {
+			RecordLink  string `json:"record_link"`
+			OnlineMedia struct {
+				Media []apiMedia `json:"media"`
+			} `json:"online_media"`
+		} `json:"descriptiveNonRepeating"`
+		Freetext struct {
+			Name []apiLabeled `json:"name"`
+			Date []apiLabeled `json:"date"`
+		} `json:"freetext"`
+	} `json:"content"`
+}
+
+type apiMedia struct {
+	Type      string `json:"type"`
+	IDSID     string `json:"idsId"`
+	Content   string `json:"content"`
+	Thumbnail string `json:"thumbnail"`
+	Usage     struct {
+		Access string `json:"access"`
+	} `json:"usage"`
+	Resources []apiResource `json:"resources"`
+}
+
+type apiResource struct {
+	Label  string    `json:"label"`
+	URL    string    `json:"url"`
+	Width  dimension `json:"width"`
+	Height dimension `json:"height"`
+}
+
+type apiLabeled struct {
+	Label   string `json:"label"`
+	Content string `json:"content"`
+}
+
+// dimension accepts pixel sizes sent either as numbers or as strings.
+type dimension int
+
+func (d *dimension) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*d = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		*d = 0
+		return nil // Unknown sizes are not fatal
+	}
+	*d = dimension(f)
+	return nil
+}
+
+func init() {
+	wallpaper.RegisterProvider(ProviderName, func(cfg *wallpaper.Config, client *http.Client) provider.ImageProvider {
+		return NewProvider(cfg, client)
+	})
+}
+
+// NewProvider creates a new Smithsonian provider.
+func NewProvider(cfg *wallpaper.Config, client *http.Client) *Provider {
+	return &Provider{
+		cfg:    cfg,
+		client: client,
+		apiURL: APIBaseURL,
+	}
+}
+
+func (p *Provider) ID() string      { return ProviderName }
+func (p *Provider) HomeURL() string { return WebBaseURL }
+
+func (p *Provider) Name() string {
+	return i18n.T("Smithsonian Institution")
+}
+
+func (p *Provider) Title() string { return ProviderTitle }
+
+func (p *Provider) GetProviderIcon() interface{} { return iconData }
+
+func (p *Provider) Type() provider.ProviderType {
+	return provider.TypeMuseum
+}
+
+func (p *Provider) GetAttributionType() provider.AttributionType {
+	return provider.AttributionBy
+}
+
+func (p *Provider) SupportsUserQueries() bool { return false }
+
+// GetAPIPacing implements PacedProvider.
+func (p *Provider) GetAPIPacing() time.Duration { return 1000 * time.Millisecond }
+
+// GetProcessPacing implements PacedProvider.
+func (p *Provider) GetProcessPacing() time.Duration { return 1500 * time.Millisecond }
+
+// ParseURL rejects user URLs; Smithsonian collections are curated.
+func (p *Provider) ParseURL(webURL string) (string, error) {
+	return "", fmt.Errorf("user queries not supported for Smithsonian")
+}
+
+// FetchImages fetches wallpaper candidates.
+func (p *Provider) FetchImages(ctx context.Context, query string, page int) ([]provider.Image, error) {
+	entry := curation.GetManager().GetEntry(p.ID(), query)
+	if entry == nil {
+		log.Printf("Smithsonian: Unknown collection key %q, falling back to highlights", query)
+		entry = curation.GetManager().GetEntry(p.ID(), CollectionHighlights)
+		if entry == nil {
+			return nil, fmt.Errorf("no collection entries available")
+		}
+	}
+
+	switch entry.Type {
+	case "curated":
+		return p.fetchCurated(ctx, entry, page)
+	case "search":
+		return p.fetchSearch(ctx, entry, page)
+	default:
+		return nil, fmt.Errorf("unknown collection type: %s", entry.Type)
+	}
+}
+
+// fetchCurated fetches one page of a curated collection, one record per request.
+func (p *Provider) fetchCurated(ctx context.Context, entry *curation.CollectionEntry, page int) ([]provider.Image, error) {
+	start := (page - 1) * pageSize
+	if start >= len(entry.IDs) {
+		return nil, nil
+	}
+	end := start + pageSize
+	if end > len(entry.IDs) {
+		end = len(entry.IDs)
+	}
+
+	var images []provider.Image
+	for _, id := range entry.IDs[start:end] {
+		row, err := p.fetchContent(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return images, ctx.Err()
+			}
+			log.Debugf("Smithsonian: Error fetching record %s: %v", id, err)
+			continue
+		}
+		if img := p.rowToImage(row); img != nil {
+			images = append(images, *img)
+		}
+	}
+	log.Debugf("Smithsonian: Curated page %d: %d images from %d IDs", page, len(images), end-start)
+	return images, nil
+}
+
+// fetchSearch streams a search collection using the API's native start/rows pagination.
+// The query from the collection JSON is always narrowed to CC0 records with images.
+func (p *Provider) fetchSearch(ctx context.Context, entry *curation.CollectionEntry, page int) ([]provider.Image, error) {
+	params := url.Values{}
+	params.Set("q", fmt.Sprintf("(%s) AND %s", entry.Query, SearchFilters))
+	params.Set("start", strconv.Itoa((page-1)*pageSize))
+	params.Set("rows", strconv.Itoa(pageSize))
+	params.Set("sort", "id") // Stable order across pages
+
+	var result searchResponse
+	if err := p.getJSON(ctx, "/search", params, &result); err != nil {
+		return nil, err
+	}
+
+	var images []provider.Image
+	for i := range result.Response.Rows {
+		if img := p.rowToImage(&result.Response.Rows[i]); img != nil {
+			images = append(images, *img)
+		}
+	}
+	log.Debugf("Smithsonian: Search %q page %d: %d images from %d rows (%d total)", entry.Key, page, len(images), len(result.Response.Rows), result.Response.RowCount)
+	return images, nil
+}
+
+// fetchContent fetches a single record by its Open Access ID.
+func (p *Provider) fetchContent(ctx context.Context, id string) (*apiRow, error) {
+	var result contentResponse
+	if err := p.getJSON(ctx, "/content/"+url.PathEscape(id), url.Values{}, &result); err != nil {
+		return nil, err
+	}
+	if result.Response.ID == "" {
+		return nil, fmt.Errorf("no record found for id %s", id)
+	}
+	return &result.Response, nil
+}
+
+// getJSON performs an authenticated GET against the Open Access API.
+func (p *Provider) getJSON(ctx context.Context, path string, params url.Values, v any) error {
+	params.Set("api_key", p.apiKey())
+	req, err := http.NewRequestWithContext(ctx, "GET", p.apiURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		// Avoid leaking the API key through the request URL in url.Error.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusTooManyRequests:
+		return errors.New("smithsonian API rate limit exceeded (add your own api.data.gov key to raise the limit)")
+	case http.StatusForbidden:
+		return errors.New("smithsonian API rejected the API key")
+	default:
+		return fmt.Errorf("API returned %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// apiKey returns the user's api.data.gov key, or the shared demo key.
+func (p *Provider) apiKey() string {
+	if p.cfg != nil {
+		if key := p.cfg.GetSmithsonianAPIKey(); key != "" {
+			return key
+		}
+	}
+	return DemoAPIKey
+}
+
+// bestMedia returns the first CC0 image of a record. Records can mix CC0 and restricted media.
+func bestMedia(row *apiRow) *apiMedia {
+	for i := range row.Content.DescriptiveNonRepeating.OnlineMedia.Media {
+		m := &row.Content.DescriptiveNonRepeating.OnlineMedia.Media[i]
+		if m.Type == "Images" && strings.EqualFold(m.Usage.Access, "CC0") && (m.IDSID != "" || m.Content != "") {
+			return m
+		}
+	}
+	return nil
+}
+
+// largestResource returns the biggest resource with known dimensions, if any.
+func largestResource(m *apiMedia) *apiResource {
+	var best *apiResource
+	for i := range m.Resources {
+		r := &m.Resources[i]
+		if r.Width <= 0 || r.Height <= 0 {
+			continue
+		}
+		if best == nil || r.Width*r.Height > best.Width*best.Height {
+			best = r
+		}
+	}
+	return best
+}
+
+// imageURL returns the IDS delivery URL of a media item, which WithImageSize can scale.
+func imageURL(m *apiMedia) string {
+	if m.IDSID != "" {
+		return IDSDeliveryURL + "?id=" + url.QueryEscape(m.IDSID)
+	}
+	return m.Content
+}
+
+// rowToImage converts a record to a provider.Image, skipping records without a usable CC0 image.
+func (p *Provider) rowToImage(row *apiRow) *provider.Image {
+	media := bestMedia(row)
+	if media == nil {
+		return nil
+	}
+
+	width, height := 0, 0
+	if r := largestResource(media); r != nil {
+		width, height = int(r.Width), int(r.Height)
+		if !isUsableShape(float64(width), float64(height)) {
+			return nil
+		}
+	}
+
+	artist := ""
+	for _, n := range row.Content.Freetext.Name {
+		if n.Content != "" {
+			artist = n.Content
+			if strings.EqualFold(n.Label, "Artist") {
+				break
+			}
+		}
+	}
+	year := ""
+	if len(row.Content.Freetext.Date) > 0 {
+		year = row.Content.Freetext.Date[0].Content
+	}
+
+	attribution := row.Title
+	if artist != "" {
+		attribution = fmt.Sprintf("%s - %s", artist, row.Title)
+	}
+
+	return &provider.Image{
+		ID:          row.ID,
+		Path:        imageURL(media),
+		ViewURL:     viewURL(row),
+		Attribution: attribution,
+		Title:       row.Title,
+		Artist:      artist,
+		Year:        year,
+		Width:       width,
+		Height:      height,
+		Provider:    ProviderName,
+		FileType:    "image/jpeg",
+	}
+}
+
+func viewURL(row *apiRow) string {
+	if link := row.Content.DescriptiveNonRepeating.RecordLink; link != "" {
+		return link
+	}
+	return ObjectBaseURL + row.URL
+}
+
+// isUsableShape rejects extreme panoramas and slivers.
+func isUsableShape(width, height float64) bool {
+	if width <= 0 || height <= 0 {
+		return false
+	}
+	ratio := width / height
+	return ratio <= 3.0 && ratio >= 0.33
+}
+
+// WithImageSize implements provider.ScalableImageProvider. IDS scales the longest side to max.
+func (p *Provider) WithImageSize(imageURL string, width, height int) string {
+	if !strings.HasPrefix(imageURL, IDSDeliveryURL) {
+		return imageURL
+	}
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return imageURL
+	}
+	q := u.Query()
+	q.Set("max", strconv.Itoa(max(width, height)))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// EnrichImage is a no-op — all metadata comes from the initial fetch.
+func (p *Provider) EnrichImage(_ context.Context, img provider.Image) (provider.Image, error) {
+	img.Provider = ProviderName
+	return img, nil
+}
+
+// FetchThumbnails implements provider.ThumbnailProvider.
+func (p *Provider) FetchThumbnails(ctx context.Context, ids []string) ([]provider.Thumbnail, error) {
+	limiter := rate.NewLimiter(rate.Every(p.GetAPIPacing()), 1)
+
+	var thumbnails []provider.Thumbnail
+	for _, id := range ids {
+		if err := limiter.Wait(ctx); err != nil {
+			return thumbnails, err
+		}
+		row, err := p.fetchContent(ctx, id)
+		if err != nil {
+			log.Printf("Smithsonian: Failed to fetch %s for thumbnails: %v", id, err)
+			continue
+		}
+		img := p.rowToImage(row)
+		if img == nil {
+			continue
+		}
+		thumbnails = append(thumbnails, provider.Thumbnail{
+			ID:      id,
+			URL:     p.WithImageSize(img.Path, 800, 800),
+			ViewURL: img.ViewURL,
+			Title:   img.Title,
+			Artist:  img.Artist,
+			Year:    img.Year,
+		})
+	}
+	return thumbnails, nil
+}
+
+// --- UI Implementation ---
+
+// CreateSettingsPanel returns the museum info panel with the optional api.data.gov key.
+func (p *Provider) CreateSettingsPanel(sm setting.SettingsManager) *schema.PanelSchema {
+	return schema.CreateMuseumSettingsPanel(schema.MuseumSettingsConfig{
+		MuseumFramingGetFunc: func() bool { return p.cfg.GetMuseumFraming(p.ID()) },
+		MuseumFramingSetFunc: func(val bool) { p.cfg.SetMuseumFraming(p.ID(), val) },
+		ID:                   "Smithsonian",
+		Title:                i18n.T("Smithsonian Institution"),
+		Location:             i18n.T("Washington, DC, USA"),
+		LicenseURL:           "https://www.si.edu/openaccess",
+		Description:          i18n.T("The world's largest museum, education, and research complex. Its Open Access initiative releases millions of images from 21 museums, the National Zoo and research centers into the public domain."),
+		MapQuery:             "Smithsonian Institution Building",
+		WebsiteURL:           WebBaseURL,
+		DonateURL:            "https://www.si.edu/giving",
+		APIKeyLabel:          i18n.T("api.data.gov API Key (optional):"),
+		APIKeyHelp:           i18n.T("Without a key, the shared demo key is used and only a few collections load per hour."),
+		RegistrationURL:      "https://api.data.gov/signup/",
+		APIKeyGetFunc:        p.cfg.GetSmithsonianAPIKey,
+		APIKeySetFunc:        p.cfg.SetSmithsonianAPIKey,
+	}, sm.OpenURL)
+}
+
+// CreateQueryPanel creates the collection toggle panel.
+func (p *Provider) CreateQueryPanel(sm setting.SettingsManager, _ string) *schema.PanelSchema {
+	return wallpaper.CreateCuratedQueryPanel(p, sm, p.cfg)
+}
@@ -0,0 +1,143 @@
+package smithsonian
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/curation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cc0Row = `{
+	"id": "edanmdm-saam_1977.107.1",
+	"title": "Among the Sierra Nevada, California",
+	"url": "edanmdm:saam_1977.107.1",
+	"content": {
+		"descriptiveNonRepeating": {
+			"record_link": "http://americanart.si.edu/collections/search/artwork/?id=1234",
+			"online_media": {"media": [
+				{"type": "Images", "idsId": "SAAM-1977.107.1_2", "usage": {"access": "CC0"},
+				 "resources": [
+					{"label": "Screen Image", "url": "https://ids.si.edu/ids/download?id=SAAM-1977.107.1_2_screen", "width": 1000, "height": 609},
+					{"label": "High-resolution JPEG", "url": "https://ids.si.edu/ids/download?id=SAAM-1977.107.1_2.jpg", "width": "6000", "height": "3650"}
+				 ]}
+			]}
+		},
+		"freetext": {
+			"name": [{"label": "Artist", "content": "Albert Bierstadt, born Solingen, Germany 1830-died New York City 1902"}],
+			"date": [{"label": "Date", "content": "1868"}]
+		}
+	}
+}`
+
+const restrictedRow = `{
+	"id": "edanmdm-npg_NPG.65.1",
+	"title": "Restricted portrait",
+	"url": "edanmdm:npg_NPG.65.1",
+	"content": {"descriptiveNonRepeating": {"online_media": {"media": [
+		{"type": "Images", "idsId": "NPG-NPG_65_1", "usage": {"access": "Usage conditions apply"}}
+	]}}}
+}`
+
+func newTestProvider(serverURL string) *Provider {
+	p := NewProvider(nil, http.DefaultClient)
+	p.apiURL = serverURL
+	return p
+}
+
+func TestFetchSearch_FiltersAndPagination(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assert.Equal(t, "/search", r.URL.Path)
+		assert.Equal(t, `(unit_code:SAAM) AND online_media_type:Images AND media_usage:CC0`, q.Get("q"))
+		assert.Equal(t, "20", q.Get("start"), "page 2 starts after the first page")
+		assert.Equal(t, "20", q.Get("rows"))
+		assert.Equal(t, DemoAPIKey, q.Get("api_key"))
+		_, _ = w.Write([]byte(`{"status":200,"response":{"rowCount":2,"rows":[` + cc0Row + `,` + restrictedRow + `]}}`))
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.URL)
+	entry := &curation.CollectionEntry{Key: "si_test", Type: "search", Query: "unit_code:SAAM"}
+	images, err := p.fetchSearch(context.Background(), entry, 2)
+	require.NoError(t, err)
+	require.Len(t, images, 1, "records without a CC0 image are skipped")
+
+	img := images[0]
+	assert.Equal(t, "edanmdm-saam_1977.107.1", img.ID)
+	assert.Equal(t, IDSDeliveryURL+"?id=SAAM-1977.107.1_2", img.Path)
+	assert.Equal(t, "http://americanart.si.edu/collections/search/artwork/?id=1234", img.ViewURL)
+	assert.Equal(t, "Albert Bierstadt, born Solingen, Germany 1830-died New York City 1902 - Among the Sierra Nevada, California", img.Attribution)
+	assert.Equal(t, "1868", img.Year)
+	assert.Equal(t, 6000, img.Width, "the largest resource provides the dimensions")
+	assert.Equal(t, ProviderName, img.Provider)
+}
+
+func TestFetchCurated(t *testing.T) {
+	var requested []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		if r.URL.Path == "/content/edanmdm-saam_1977.107.1" {
+			_, _ = w.Write([]byte(`{"status":200,"response":` + cc0Row + `}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.URL)
+	entry := &curation.CollectionEntry{Key: "si_test", Type: "curated", IDs: []string{"edanmdm-saam_1977.107.1", "edanmdm-missing"}}
+
+	images, err := p.fetchCurated(context.Background(), entry, 1)
+	require.NoError(t, err)
+	require.Len(t, images, 1, "missing records are skipped")
+	assert.Equal(t, []string{"/content/edanmdm-saam_1977.107.1", "/content/edanmdm-missing"}, requested)
+
+	images, err = p.fetchCurated(context.Background(), entry, 2)
+	require.NoError(t, err)
+	assert.Empty(t, images, "pages beyond the list are empty")
+}
+
+func TestRateLimited(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.URL)
+	entry := &curation.CollectionEntry{Key: "si_test", Type: "search", Query: "unit_code:SAAM"}
+	_, err := p.fetchSearch(context.Background(), entry, 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rate limit")
+	assert.NotContains(t, err.Error(), DemoAPIKey)
+}
+
+func TestFetchThumbnails(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":200,"response":` + cc0Row + `}`))
+	}))
+	defer ts.Close()
+
+	p := newTestProvider(ts.URL)
+	thumbnails, err := p.FetchThumbnails(context.Background(), []string{"edanmdm-saam_1977.107.1"})
+	require.NoError(t, err)
+	require.Len(t, thumbnails, 1)
+	assert.Equal(t, "edanmdm-saam_1977.107.1", thumbnails[0].ID)
+	assert.Equal(t, IDSDeliveryURL+"?id=SAAM-1977.107.1_2&max=800", thumbnails[0].URL)
+	assert.Equal(t, "Among the Sierra Nevada, California", thumbnails[0].Title)
+}
+
+func TestWithImageSize(t *testing.T) {
+	p := &Provider{}
+	assert.Equal(t, IDSDeliveryURL+"?id=SAAM-1&max=3840", p.WithImageSize(IDSDeliveryURL+"?id=SAAM-1", 3840, 2160))
+	assert.Equal(t, "https://example.com/image.jpg", p.WithImageSize("https://example.com/image.jpg", 3840, 2160))
+}
+
+func TestParseURL(t *testing.T) {
+	p := &Provider{}
+	_, err := p.ParseURL("https://www.si.edu/object/saam_1977.107.1")
+	assert.Error(t, err, "collections are curated")
+}
@@ -0,0 +1,28 @@
+package unsplash
+
+import "time"
+
+// Unsplash API URLs
+const (
+	UnsplashAPIBaseURL       = "https://api.unsplash.com"
+	UnsplashAPISearchURL     = UnsplashAPIBaseURL + "/search/photos"
+	UnsplashAPICollectionURL = UnsplashAPIBaseURL + "/collections/%s/photos"
+	UnsplashAPITopicURL      = UnsplashAPIBaseURL + "/topics/%s/photos"
+	UnsplashAPIUserLikesURL  = UnsplashAPIBaseURL + "/users/%s/likes"
+
+	// UnsplashURLRegexp validates Unsplash URLs (search, collections, topics, user likes).
+	// Matches: https://unsplash.com/s/photos/..., https://unsplash.com/collections/..., https://unsplash.com/t/..., https://unsplash.com/@user/likes
+	UnsplashURLRegexp = `^https://(?:www\.|api\.)?unsplash\.com/(?:s/photos/|collections/|t/|@[^/]+/likes|search/photos|topics/|users/).*$`
+
+	// UnsplashPerPage is the page size requested from the API (the API maximum).
+	UnsplashPerPage = 30
+
+	// UnsplashReferralParams must be appended to every link back to Unsplash (API guidelines).
+	UnsplashReferralParams = "utm_source=spice&utm_medium=referral"
+
+	// UnsplashAPIPacing spaces out API calls. Demo applications are limited to 50 requests per hour.
+	UnsplashAPIPacing = 2 * time.Second
+
+	// UnsplashMediaPacing spaces out image downloads. Images are served from the imgix CDN, which is not rate limited.
+	UnsplashMediaPacing = 250 * time.Millisecond
+)
@@ -0,0 +1,38 @@
+package unsplash
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// CheckUnsplashAccessKeyWithContext verifies if the given access key is valid using the provided context.
+func CheckUnsplashAccessKeyWithContext(ctx context.Context, accessKey string) error {
+	if len(accessKey) < 10 {
+		return fmt.Errorf("invalid Unsplash access key (too short)")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", UnsplashAPIBaseURL+"/photos?per_page=1", nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Client-ID "+accessKey)
+	req.Header.Set("Accept-Version", "v1")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("network error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("invalid Unsplash access key")
+	}
+
+	return fmt.Errorf("Unsplash API verification failed (status %d)", resp.StatusCode)
+}
@@ -0,0 +1,608 @@
+package unsplash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "embed"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/i18n"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/schema"
+	"github.com/dixieflatline76/Spice/v2/pkg/ui/setting"
+	"github.com/dixieflatline76/Spice/v2/pkg/wallpaper"
+	"github.com/dixieflatline76/Spice/v2/util/log"
+)
+
+//go:embed Unsplash.png
+var iconData []byte
+
+// Provider implements ImageProvider for Unsplash.
+type Provider struct {
+	cfg        *wallpaper.Config
+	httpClient *http.Client
+	testToken  string
+
+	mu      sync.Mutex
+	tracked map[string]bool // Image IDs whose download has already been reported this session
+}
+
+// SetTokenForTesting sets an access key for testing purposes, overriding the config.
+func (p *Provider) SetTokenForTesting(token string) {
+	p.testToken = token
+}
+
+func init() {
+	wallpaper.RegisterProvider("Unsplash", func(cfg *wallpaper.Config, client *http.Client) provider.ImageProvider {
+		return NewProvider(cfg, client)
+	})
+}
+
+// NewProvider creates a new Unsplash Provider.
+func NewProvider(cfg *wallpaper.Config, client *http.Client) *Provider {
+	return &Provider{
+		cfg:        cfg,
+		httpClient: client,
+		tracked:    make(map[string]bool),
+	}
+}
+
+func (p *Provider) ID() string {
+	return "Unsplash"
+}
+
+func (p *Provider) Name() string {
+	return i18n.T("Unsplash")
+}
+
+func (p *Provider) Title() string {
+	return "Unsplash"
+}
+
+func (p *Provider) GetProviderIcon() interface{} {
+	return iconData
+}
+
+func (p *Provider) Type() provider.ProviderType {
+	return provider.TypeCommunity
+}
+
+func (p *Provider) HomeURL() string {
+	return "https://unsplash.com/?" + UnsplashReferralParams
+}
+
+func (p *Provider) GetAttributionType() provider.AttributionType {
+	return provider.AttributionBy
+}
+
+func (p *Provider) SupportsUserQueries() bool {
+	return true
+}
+
+// GetAPIPacing implements the PacedProvider interface to space out API calls.
+func (p *Provider) GetAPIPacing() time.Duration {
+	return UnsplashAPIPacing
+}
+
+// GetProcessPacing implements the PacedProvider interface to space out image downloads.
+func (p *Provider) GetProcessPacing() time.Duration {
+	return UnsplashMediaPacing
+}
+
+// Regex patterns for Unsplash web URL paths
+var (
+	// Matches /s/photos/{query}
+	unsplashSearchRegex = regexp.MustCompile(`^/s/photos/([^/]+)/?$`)
+	// Matches /collections/{id} and /collections/{id}/{slug}
+	unsplashCollectionRegex = regexp.MustCompile(`^/collections/([a-zA-Z0-9_-]+)(?:/[^/]*)?/?$`)
+	// Matches /t/{slug}
+	unsplashTopicRegex = regexp.MustCompile(`^/t/([a-zA-Z0-9_-]+)/?$`)
+	// Matches /@{username}/likes
+	unsplashLikesRegex = regexp.MustCompile(`^/@([a-zA-Z0-9_.-]+)/likes/?$`)
+)
+
+// searchFilters are the web search parameters that the API understands as well.
+var searchFilters = []string{"orientation", "color", "order_by", "content_filter"}
+
+// ParseURL converts an Unsplash web URL (search, collection, topic or user likes) to an API URL.
+func (p *Provider) ParseURL(webURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(webURL))
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host != "unsplash.com" && !strings.HasSuffix(host, ".unsplash.com") {
+		return "", fmt.Errorf("not an Unsplash URL")
+	}
+
+	// Idempotency: API URLs are already in the right shape
+	if host == "api.unsplash.com" {
+		return u.String(), nil
+	}
+
+	if m := unsplashSearchRegex.FindStringSubmatch(u.Path); m != nil {
+		apiURL, _ := url.Parse(UnsplashAPISearchURL)
+		q := url.Values{}
+		q.Set("query", m[1])
+		for _, key := range searchFilters {
+			if v := u.Query().Get(key); v != "" {
+				q.Set(key, v)
+			}
+		}
+		apiURL.RawQuery = q.Encode()
+		return apiURL.String(), nil
+	}
+
+	if m := unsplashCollectionRegex.FindStringSubmatch(u.Path); m != nil {
+		return fmt.Sprintf(UnsplashAPICollectionURL, m[1]), nil
+	}
+
+	if m := unsplashTopicRegex.FindStringSubmatch(u.Path); m != nil {
+		return fmt.Sprintf(UnsplashAPITopicURL, m[1]), nil
+	}
+
+	if m := unsplashLikesRegex.FindStringSubmatch(u.Path); m != nil {
+		return fmt.Sprintf(UnsplashAPIUserLikesURL, m[1]), nil
+	}
+
+	return "", fmt.Errorf("unsupported Unsplash URL format")
+}
+
+// WithResolution implements ResolutionAwareProvider. API URLs get an orientation filter matching the
+// display (unless one was chosen already); image URLs are resized by the Unsplash image CDN.
+func (p *Provider) WithResolution(rawURL string, width, height int) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || width <= 0 || height <= 0 {
+		return rawURL
+	}
+
+	switch u.Hostname() {
+	case "images.unsplash.com":
+		return p.WithImageSize(rawURL, width, height)
+	case "api.unsplash.com":
+		q := u.Query()
+		if q.Get("orientation") != "" {
+			return rawURL
+		}
+		switch {
+		case width > height:
+			q.Set("orientation", "landscape")
+		case width < height:
+			q.Set("orientation", "portrait")
+		default:
+			q.Set("orientation", "squarish")
+		}
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	return rawURL
+}
+
+// WithImageSize implements ScalableImageProvider. The image is bounded by a square of the display's
+// longer side so that it still covers the display after cropping, whatever its aspect ratio.
+func (p *Provider) WithImageSize(imageURL string, width, height int) string {
+	u, err := url.Parse(imageURL)
+	if err != nil || u.Hostname() != "images.unsplash.com" || width <= 0 || height <= 0 {
+		return imageURL
+	}
+
+	size := strconv.Itoa(max(width, height))
+	q := u.Query()
+	q.Set("w", size)
+	q.Set("h", size)
+	q.Set("fit", "max")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// FetchImages fetches a page of photos from the Unsplash API.
+func (p *Provider) FetchImages(ctx context.Context, apiURL string, page int) ([]provider.Image, error) {
+	// Robustness: Check if we have a web URL and convert it on the fly
+	if !strings.Contains(apiURL, "api.unsplash.com") && strings.Contains(apiURL, "unsplash.com") {
+		converted, err := p.ParseURL(apiURL)
+		if err == nil {
+			log.Printf("Unsplash: Converted Web URL to API URL: %s", converted)
+			apiURL = converted
+		} else {
+			log.Printf("Unsplash: Warning - Failed to convert URL %s: %v", apiURL, err)
+		}
+	}
+
+	fullURL, err := p.buildAPIURL(apiURL, page)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.executeRequest(ctx, fullURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-Ratelimit-Remaining") == "0" {
+			return nil, fmt.Errorf("unsplash rate limit exceeded")
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if len(body) > 500 {
+			log.Printf("Unsplash API Error (%d): %s...", resp.StatusCode, string(body[:500]))
+		} else {
+			log.Printf("Unsplash API Error (%d): %s", resp.StatusCode, string(body))
+		}
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	images, err := p.parseResponse(resp.Body, fullURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(images) == 0 {
+		log.Printf("Unsplash query returned 0 images for URL: %s", fullURL)
+	} else {
+		log.Debugf("Found %d images from Unsplash", len(images))
+	}
+
+	return images, nil
+}
+
+func (p *Provider) buildAPIURL(apiURL string, page int) (string, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid API URL: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("page", strconv.Itoa(page))
+	q.Set("per_page", strconv.Itoa(UnsplashPerPage))
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+func (p *Provider) accessKey() string {
+	if p.testToken != "" {
+		return p.testToken
+	}
+	if p.cfg == nil {
+		return ""
+	}
+	return p.cfg.GetUnsplashAccessKey()
+}
+
+func (p *Provider) executeRequest(ctx context.Context, fullURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	accessKey := p.accessKey()
+	if accessKey == "" {
+		return nil, fmt.Errorf("unsplash access key is missing")
+	}
+	req.Header.Set("Authorization", "Client-ID "+accessKey)
+	req.Header.Set("Accept-Version", "v1")
+
+	log.Debugf("Fetching Unsplash images from: %s", fullURL)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	return resp, nil
+}
+
+func (p *Provider) parseResponse(body io.Reader, urlStr string) ([]provider.Image, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API URL: %w", err)
+	}
+
+	var photos []UnsplashPhoto
+	if strings.HasPrefix(u.Path, "/search/") {
+		var searchResp UnsplashSearchResponse
+		if err := json.NewDecoder(body).Decode(&searchResp); err != nil {
+			return nil, fmt.Errorf("failed to decode search response: %w", err)
+		}
+		photos = searchResp.Results
+	} else {
+		// Collection, topic and likes endpoints return a bare list of photos
+		if err := json.NewDecoder(body).Decode(&photos); err != nil {
+			return nil, fmt.Errorf("failed to decode photo list: %w", err)
+		}
+	}
+
+	images := make([]provider.Image, 0, len(photos))
+	for _, photo := range photos {
+		// Unsplash+ photos require a paid license and are not served to API clients
+		if photo.Premium || photo.Plus {
+			continue
+		}
+		if photo.URLs.Full == "" && photo.URLs.Raw == "" {
+			continue
+		}
+		images = append(images, p.mapUnsplashImage(photo))
+	}
+	return images, nil
+}
+
+// EnrichImage is a no-op for Unsplash as attribution is included in API results.
+func (p *Provider) EnrichImage(ctx context.Context, img provider.Image) (provider.Image, error) {
+	return img, nil
+}
+
+func (p *Provider) mapUnsplashImage(photo UnsplashPhoto) provider.Image {
+	// 'full' is the original size as a high quality JPEG; 'raw' is the untouched upload.
+	imagePath := photo.URLs.Full
+	if imagePath == "" {
+		imagePath = photo.URLs.Raw
+	}
+
+	title := strings.TrimSpace(photo.Description)
+	if title == "" {
+		title = strings.TrimSpace(photo.AltDescription)
+	}
+
+	return provider.Image{
+		ID:               photo.ID,
+		Path:             imagePath,
+		ViewURL:          withReferral(photo.Links.HTML),
+		Attribution:      photo.User.Name,
+		Artist:           photo.User.Name,
+		Title:            title,
+		Provider:         p.ID(),
+		FileType:         "image/jpeg",
+		DownloadLocation: photo.Links.DownloadLocation,
+		Width:            photo.Width,
+		Height:           photo.Height,
+	}
+}
+
+// withReferral appends the referral parameters that the API guidelines require on links back to Unsplash.
+func withReferral(link string) string {
+	if link == "" {
+		return ""
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	q := u.Query()
+	referral, _ := url.ParseQuery(UnsplashReferralParams)
+	for key := range referral {
+		q.Set(key, referral.Get(key))
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// TrackDownload implements DownloadTracker. The API guidelines require calling the photo's
+// download_location whenever a photo is used, which for Spice means set as the wallpaper.
+// Each photo is reported at most once per session.
+func (p *Provider) TrackDownload(ctx context.Context, img provider.Image) error {
+	if img.DownloadLocation == "" {
+		return nil
+	}
+
+	p.mu.Lock()
+	if p.tracked[img.ID] {
+		p.mu.Unlock()
+		return nil
+	}
+	p.tracked[img.ID] = true
+	p.mu.Unlock()
+
+	resp, err := p.executeRequest(ctx, img.DownloadLocation)
+	if err != nil {
+		p.untrack(img.ID)
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		p.untrack(img.ID)
+		return fmt.Errorf("download tracking returned status %d", resp.StatusCode)
+	}
+
+	log.Debugf("Unsplash: Reported download of %s", img.ID)
+	return nil
+}
+
+func (p *Provider) untrack(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.tracked, id)
+}
+
+// Unsplash JSON Structures
+
+type UnsplashSearchResponse struct {
+	Total      int             `json:"total"`
+	TotalPages int             `json:"total_pages"`
+	Results    []UnsplashPhoto `json:"results"`
+}
+
+type UnsplashPhoto struct {
+	ID             string        `json:"id"`
+	Width          int           `json:"width"`
+	Height         int           `json:"height"`
+	Description    string        `json:"description"`
+	AltDescription string        `json:"alt_description"`
+	URLs           UnsplashURLs  `json:"urls"`
+	Links          UnsplashLinks `json:"links"`
+	User           UnsplashUser  `json:"user"`
+	Premium        bool          `json:"premium"`
+	Plus           bool          `json:"plus"`
+}
+
+type UnsplashURLs struct {
+	Raw     string `json:"raw"`
+	Full    string `json:"full"`
+	Regular string `json:"regular"`
+}
+
+type UnsplashLinks struct {
+	HTML             string `json:"html"`
+	DownloadLocation string `json:"download_location"`
+}
+
+type UnsplashUser struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+// --- UI Implementation (Pure Go) ---
+
+const unsplashAccessKeyIdent = "unsplashAccessKey"
+
+// CreateSettingsPanel returns the declarative UI for Unsplash settings.
+func (p *Provider) CreateSettingsPanel(sm setting.SettingsManager) *schema.PanelSchema {
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:   i18n.T("Unsplash"),
+				Compact: true,
+				Items: []schema.ItemSchema{
+					schema.LabelItem{
+						Text:       i18n.T("Unsplash provides freely usable photos from photographers around the world. Photos are credited to their photographer on Unsplash."),
+						Importance: schema.ImportanceLow,
+					},
+					schema.SecretItem{
+						Name:         unsplashAccessKeyIdent,
+						Label:        i18n.T("Unsplash Access Key:"),
+						InitialValue: p.cfg.GetUnsplashAccessKey(),
+						Placeholder:  i18n.T("Enter your Unsplash Access Key"),
+						OnVerify: func(key string) error {
+							ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+							defer cancel()
+							return CheckUnsplashAccessKeyWithContext(ctx, key)
+						},
+						ApplyFunc: func(key string) {
+							p.cfg.SetUnsplashAccessKey(key)
+						},
+						OnClear: func() {
+							p.cfg.SetUnsplashAccessKey("")
+							sm.ResetSettings(
+								setting.SettingReset{Name: unsplashAccessKeyIdent, Value: ""},
+							)
+						},
+					},
+					schema.HyperlinkItem{
+						Text: i18n.T("Register a free Unsplash application to get an Access Key."),
+						URL:  "https://unsplash.com/oauth/applications",
+					},
+				},
+			},
+		},
+	}
+}
+
+// CreateQueryPanel creates the image query management panel.
+func (p *Provider) CreateQueryPanel(sm setting.SettingsManager, pendingUrl string) *schema.PanelSchema {
+	addCfg := schema.AddQueryConfig{
+		Title:           i18n.T("Add Unsplash Query"),
+		Description:     i18n.T("Paste an Unsplash search, collection, topic or user likes URL."),
+		URLPlaceholder:  "https://unsplash.com/s/photos/...",
+		URLValidator:    UnsplashURLRegexp,
+		URLErrorMsg:     i18n.T("Invalid Unsplash URL"),
+		DescPlaceholder: i18n.T("Collection Description (e.g. Nature)"),
+		AddHandler: func(desc, url string, active bool) (string, error) {
+			apiURL, err := p.ParseURL(url)
+			if err != nil {
+				return "", err
+			}
+			return p.cfg.AddUnsplashQuery(desc, apiURL, active)
+		},
+	}
+
+	if pendingUrl != "" {
+		sm.ShowAddQueryDialog(addCfg, pendingUrl, "", sm.RefreshUI)
+	}
+
+	return &schema.PanelSchema{
+		Sections: []schema.SectionSchema{
+			{
+				Title:       i18n.T("Unsplash Queries"),
+				Description: i18n.T("Manage your Unsplash image queries here."),
+				Items: []schema.ItemSchema{
+					schema.ButtonItem{
+						Name:       "unsplash_add",
+						ButtonText: i18n.T("Add New Query"),
+						IconName:   "add",
+						OnPressed: func() {
+							sm.ShowAddQueryDialog(addCfg, "", "", sm.RefreshUI)
+						},
+					},
+					schema.QueryListItem{
+						GetQueries: func() []schema.Query {
+							queries := p.cfg.GetUnsplashQueries()
+							abstracts := make([]schema.Query, len(queries))
+							for i, q := range queries {
+								abstracts[i] = schema.Query{
+									ID:          q.ID,
+									URL:         q.URL,
+									Description: q.Description,
+									Active:      q.Active,
+									Managed:     q.Managed,
+								}
+							}
+							return abstracts
+						},
+						EnableQuery:  p.cfg.EnableUnsplashQuery,
+						DisableQuery: p.cfg.DisableUnsplashQuery,
+						RemoveQuery:  p.cfg.RemoveUnsplashQuery,
+						GetDisplayURL: func(q schema.Query) *url.URL {
+							return p.getDisplayURL(q)
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Regex patterns for Unsplash API URL paths, used to link queries back to the website
+var (
+	unsplashAPICollectionRegex = regexp.MustCompile(`^/collections/([^/]+)/photos$`)
+	unsplashAPITopicRegex      = regexp.MustCompile(`^/topics/([^/]+)/photos$`)
+	unsplashAPILikesRegex      = regexp.MustCompile(`^/users/([^/]+)/likes$`)
+)
+
+func (p *Provider) getDisplayURL(q schema.Query) *url.URL {
+	u, err := url.Parse(q.URL)
+	if err != nil {
+		return nil
+	}
+	if u.Hostname() != "api.unsplash.com" {
+		return u
+	}
+
+	var displayURL string
+	switch {
+	case u.Path == "/search/photos":
+		displayURL = "https://unsplash.com/s/photos/" + url.PathEscape(u.Query().Get("query"))
+	case unsplashAPICollectionRegex.MatchString(u.Path):
+		displayURL = "https://unsplash.com/collections/" + unsplashAPICollectionRegex.FindStringSubmatch(u.Path)[1]
+	case unsplashAPITopicRegex.MatchString(u.Path):
+		displayURL = "https://unsplash.com/t/" + unsplashAPITopicRegex.FindStringSubmatch(u.Path)[1]
+	case unsplashAPILikesRegex.MatchString(u.Path):
+		displayURL = "https://unsplash.com/@" + unsplashAPILikesRegex.FindStringSubmatch(u.Path)[1] + "/likes"
+	default:
+		return u
+	}
+
+	res, _ := url.Parse(withReferral(displayURL))
+	return res
+}
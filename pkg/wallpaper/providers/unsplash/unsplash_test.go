@@ -0,0 +1,210 @@
+package unsplash
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const photoJSON = `{
+  "id": "%ID%",
+  "width": 6000,
+  "height": 4000,
+  "description": "%DESC%",
+  "alt_description": "green mountains under a blue sky",
+  "urls": {
+    "raw": "https://images.unsplash.com/photo-%ID%?ixid=abc",
+    "full": "https://images.unsplash.com/photo-%ID%?ixid=abc&fm=jpg&q=85",
+    "regular": "https://images.unsplash.com/photo-%ID%?ixid=abc&w=1080"
+  },
+  "links": {
+    "html": "https://unsplash.com/photos/%ID%",
+    "download_location": "BASE/photos/%ID%/download?ixid=abc"
+  },
+  "user": {"username": "jane", "name": "Jane Doe"},
+  "premium": %PREMIUM%
+}`
+
+func photo(id, desc string, premium bool) string {
+	r := strings.NewReplacer("%ID%", id, "%DESC%", desc, "%PREMIUM%", map[bool]string{true: "true", false: "false"}[premium])
+	return r.Replace(photoJSON)
+}
+
+type testServer struct {
+	*httptest.Server
+	downloads atomic.Int32
+}
+
+func newTestServer(t *testing.T) *testServer {
+	ts := &testServer{}
+	mux := http.NewServeMux()
+	write := func(w http.ResponseWriter, body string) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(strings.ReplaceAll(body, "BASE", ts.URL)))
+	}
+	mux.HandleFunc("/search/photos", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "mountains", r.URL.Query().Get("query"))
+		assert.Equal(t, "30", r.URL.Query().Get("per_page"))
+		if r.URL.Query().Get("page") != "1" {
+			write(w, `{"total": 2, "total_pages": 1, "results": []}`)
+			return
+		}
+		write(w, `{"total": 2, "total_pages": 1, "results": [`+photo("a1", "Alps", false)+`,`+photo("p1", "Plus", true)+`]}`)
+	})
+	mux.HandleFunc("/collections/", func(w http.ResponseWriter, r *http.Request) {
+		write(w, `[`+photo("c1", "", false)+`,`+photo("c2", "Lake", false)+`]`)
+	})
+	mux.HandleFunc("/photos/", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/download") {
+			http.NotFound(w, r)
+			return
+		}
+		assert.Equal(t, "Client-ID test-key", r.Header.Get("Authorization"))
+		ts.downloads.Add(1)
+		write(w, `{"url": "https://images.unsplash.com/photo-a1"}`)
+	})
+	mux.HandleFunc("/ratelimited", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Remaining", "0")
+		http.Error(w, "Rate Limit Exceeded", http.StatusForbidden)
+	})
+	mux.HandleFunc("/unauthorized", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"errors":["OAuth error: The access token is invalid"]}`, http.StatusUnauthorized)
+	})
+	ts.Server = httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func newTestProvider(server *testServer) *Provider {
+	p := NewProvider(nil, server.Client())
+	p.SetTokenForTesting("test-key")
+	return p
+}
+
+func TestUnsplashParseURL(t *testing.T) {
+	p := &Provider{}
+
+	tests := []struct {
+		input    string
+		expected string
+		hasError bool
+	}{
+		{"https://unsplash.com/s/photos/mountains", "https://api.unsplash.com/search/photos?query=mountains", false},
+		{"https://unsplash.com/s/photos/blue-sky?orientation=landscape&license=free", "https://api.unsplash.com/search/photos?orientation=landscape&query=blue-sky", false},
+		{"https://unsplash.com/collections/8961198/wallpapers", "https://api.unsplash.com/collections/8961198/photos", false},
+		{"https://unsplash.com/collections/8961198", "https://api.unsplash.com/collections/8961198/photos", false},
+		{"https://unsplash.com/t/nature", "https://api.unsplash.com/topics/nature/photos", false},
+		{"https://unsplash.com/@jane.doe/likes", "https://api.unsplash.com/users/jane.doe/likes", false},
+		{"https://api.unsplash.com/topics/nature/photos", "https://api.unsplash.com/topics/nature/photos", false},
+		{"https://unsplash.com/@jane.doe", "", true},
+		{"https://unsplash.com/photos/abc", "", true},
+		{"https://notunsplash.com/s/photos/mountains", "", true},
+		{"https://www.pexels.com/search/mountains/", "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			res, err := p.ParseURL(tc.input)
+			if tc.hasError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, res)
+			}
+		})
+	}
+}
+
+func TestUnsplashFetchImages_Search(t *testing.T) {
+	server := newTestServer(t)
+	p := newTestProvider(server)
+
+	images, err := p.FetchImages(context.Background(), server.URL+"/search/photos?query=mountains", 1)
+	require.NoError(t, err)
+	require.Len(t, images, 1, "Unsplash+ photos should be skipped")
+
+	img := images[0]
+	assert.Equal(t, "a1", img.ID)
+	assert.Equal(t, "https://images.unsplash.com/photo-a1?ixid=abc&fm=jpg&q=85", img.Path)
+	assert.Equal(t, "https://unsplash.com/photos/a1?utm_medium=referral&utm_source=spice", img.ViewURL)
+	assert.Equal(t, "Jane Doe", img.Attribution)
+	assert.Equal(t, "Alps", img.Title)
+	assert.Equal(t, server.URL+"/photos/a1/download?ixid=abc", img.DownloadLocation)
+	assert.Equal(t, 6000, img.Width)
+	assert.Equal(t, "Unsplash", img.Provider)
+
+	page2, err := p.FetchImages(context.Background(), server.URL+"/search/photos?query=mountains", 2)
+	assert.NoError(t, err)
+	assert.Empty(t, page2)
+}
+
+func TestUnsplashFetchImages_PhotoList(t *testing.T) {
+	server := newTestServer(t)
+	p := newTestProvider(server)
+
+	images, err := p.FetchImages(context.Background(), server.URL+"/collections/8961198/photos", 1)
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	assert.Equal(t, "green mountains under a blue sky", images[0].Title, "alt description is used when there is no description")
+	assert.Equal(t, "Lake", images[1].Title)
+}
+
+func TestUnsplashFetchImages_Errors(t *testing.T) {
+	server := newTestServer(t)
+	p := newTestProvider(server)
+
+	_, err := p.FetchImages(context.Background(), server.URL+"/ratelimited", 1)
+	assert.ErrorContains(t, err, "rate limit")
+
+	_, err = p.FetchImages(context.Background(), server.URL+"/unauthorized", 1)
+	assert.ErrorContains(t, err, "401")
+
+	noKey := NewProvider(nil, server.Client())
+	_, err = noKey.FetchImages(context.Background(), server.URL+"/search/photos?query=mountains", 1)
+	assert.ErrorContains(t, err, "access key is missing")
+}
+
+func TestUnsplashTrackDownload(t *testing.T) {
+	server := newTestServer(t)
+	p := newTestProvider(server)
+
+	images, err := p.FetchImages(context.Background(), server.URL+"/search/photos?query=mountains", 1)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+
+	require.NoError(t, p.TrackDownload(context.Background(), images[0]))
+	require.NoError(t, p.TrackDownload(context.Background(), images[0]))
+	assert.Equal(t, int32(1), server.downloads.Load(), "each photo is reported once per session")
+
+	assert.NoError(t, p.TrackDownload(context.Background(), provider.Image{ID: "local"}))
+	assert.Equal(t, int32(1), server.downloads.Load(), "images without a download location are ignored")
+
+	failed := provider.Image{ID: "gone", DownloadLocation: server.URL + "/photos/gone"}
+	assert.Error(t, p.TrackDownload(context.Background(), failed))
+	failed.DownloadLocation = server.URL + "/photos/gone/download"
+	assert.NoError(t, p.TrackDownload(context.Background(), failed), "failed reports are retried")
+	assert.Equal(t, int32(2), server.downloads.Load())
+}
+
+func TestUnsplashWithResolution(t *testing.T) {
+	p := &Provider{}
+
+	assert.Equal(t, "https://api.unsplash.com/search/photos?orientation=landscape&query=sea",
+		p.WithResolution("https://api.unsplash.com/search/photos?query=sea", 3840, 2160))
+	assert.Equal(t, "https://api.unsplash.com/topics/nature/photos?orientation=portrait",
+		p.WithResolution("https://api.unsplash.com/topics/nature/photos", 1080, 1920))
+	assert.Equal(t, "https://api.unsplash.com/search/photos?orientation=squarish&query=sea",
+		p.WithResolution("https://api.unsplash.com/search/photos?orientation=squarish&query=sea", 3840, 2160),
+		"an explicit orientation is kept")
+	assert.Equal(t, "https://images.unsplash.com/photo-a1?fit=max&fm=jpg&h=3840&q=85&w=3840",
+		p.WithResolution("https://images.unsplash.com/photo-a1?fm=jpg&q=85", 3840, 2160))
+	assert.Equal(t, "https://example.com/photo.jpg",
+		p.WithImageSize("https://example.com/photo.jpg", 3840, 2160), "foreign URLs are left alone")
+}
@@ -0,0 +1,87 @@
+package wallpaper
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLowQualityReason(t *testing.T) {
+	tuning := DefaultTuningConfig()
+
+	assert.Empty(t, lowQualityReason(provider.QualityScore{Sharpness: 300, Noise: 1, Blockiness: 1.2}, tuning))
+	assert.Empty(t, lowQualityReason(provider.QualityScore{Sharpness: 0, Noise: 1}, tuning), "flat art has no sharpness to judge")
+	assert.Equal(t, "blur", lowQualityReason(provider.QualityScore{Sharpness: 10, Noise: 1}, tuning))
+	assert.Equal(t, "noise", lowQualityReason(provider.QualityScore{Sharpness: 300, Noise: 9}, tuning))
+	assert.Equal(t, "blockiness", lowQualityReason(provider.QualityScore{Sharpness: 300, Noise: 1, Blockiness: 5}, tuning))
+
+	tuning.QualityMinSharpness = 0
+	assert.Empty(t, lowQualityReason(provider.QualityScore{Sharpness: 10, Noise: 1}, tuning), "a zero threshold disables the check")
+}
+
+func TestQualityRejection(t *testing.T) {
+	assert.Empty(t, qualityRejection(provider.Image{}))
+	assert.Empty(t, qualityRejection(provider.Image{ProcessingFlags: map[string]bool{"lowquality:noise": false}}))
+	assert.Equal(t, "noise", qualityRejection(provider.Image{ProcessingFlags: map[string]bool{
+		"SmartFit":          true,
+		"lowquality:noise":  true,
+		"incompatible:1x1":  true,
+		"VirtualFramed:1x1": false,
+	}}))
+}
+
+func TestCurrentQualityRejection(t *testing.T) {
+	wp := &Plugin{cfg: &Config{Tuning: DefaultTuningConfig()}}
+	soft := provider.Image{Quality: &provider.QualityScore{Sharpness: 10, Noise: 1}, ProcessingFlags: map[string]bool{"lowquality:blur": true}}
+
+	assert.Equal(t, "blur", wp.currentQualityRejection(soft))
+	assert.Equal(t, "noise", wp.currentQualityRejection(provider.Image{ProcessingFlags: map[string]bool{"lowquality:noise": true}}), "unscored images keep their tag")
+
+	// Relaxing the threshold brings the image back, though its tag is only cleared on reprocessing
+	wp.cfg.Tuning.QualityMinSharpness = 5
+	assert.Empty(t, wp.currentQualityRejection(soft))
+	assert.Equal(t, "blur", qualityRejection(soft))
+}
+
+func TestCheckImageQuality(t *testing.T) {
+	// A detailed scene blurred well beyond what a sharp photo shows
+	src := image.NewNRGBA(image.Rect(0, 0, 640, 480))
+	for y := range 480 {
+		for x := range 640 {
+			v := uint8(40)
+			if (x/16+y/16)%2 == 0 {
+				v = 215
+			}
+			src.Set(x, y, color.Gray{Y: v})
+		}
+	}
+	masterPath := filepath.Join(t.TempDir(), "master.jpg")
+	require.NoError(t, imaging.Save(imaging.Blur(src, 4), masterPath, imaging.JPEGQuality(95)))
+
+	wp := &Plugin{cfg: &Config{Tuning: DefaultTuningConfig()}}
+	resolutions := []Resolution{{Width: 640, Height: 480}}
+	community := &MockPacedProvider{id: "community"}
+
+	img := provider.Image{ID: "soft"}
+	require.NoError(t, wp.checkImageQuality(context.Background(), &img, nil, masterPath, resolutions))
+	assert.Nil(t, img.Quality, "only community sources are scored")
+
+	require.NoError(t, wp.checkImageQuality(context.Background(), &img, community, masterPath, resolutions))
+	require.NotNil(t, img.Quality)
+	assert.Less(t, img.Quality.Sharpness, wp.cfg.Tuning.QualityMinSharpness)
+	assert.Equal(t, "blur", qualityRejection(img))
+
+	// A scored image is judged again without decoding, so changed thresholds apply
+	require.NoError(t, os.Remove(masterPath))
+	wp.cfg.Tuning.QualityMinSharpness = 0
+	require.NoError(t, wp.checkImageQuality(context.Background(), &img, community, masterPath, resolutions))
+	assert.Empty(t, qualityRejection(img))
+}
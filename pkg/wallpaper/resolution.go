@@ -58,6 +58,21 @@ func GetUniqueResolutions(monitors []Monitor) []Resolution {
 	return unique
 }
 
+// LargestResolution returns the resolution with the most pixels.
+// It returns false if the list is empty.
+func LargestResolution(resolutions []Resolution) (Resolution, bool) {
+	if len(resolutions) == 0 {
+		return Resolution{}, false
+	}
+	largest := resolutions[0]
+	for _, res := range resolutions[1:] {
+		if res.Width*res.Height > largest.Width*largest.Height {
+			largest = res
+		}
+	}
+	return largest, true
+}
+
 // GetDerivativePath returns the calculated path for a specific resolution.
 // Format: .../fitted/{Width}x{Height}/{ID}.jpg
 func (wp *Plugin) GetDerivativePath(id string, w, h int) string {
@@ -55,6 +55,20 @@ func TestGetUniqueResolutions(t *testing.T) {
 	assert.True(t, found4K, "Should contain 3840x2160")
 }
 
+func TestLargestResolution(t *testing.T) {
+	_, ok := LargestResolution(nil)
+	assert.False(t, ok)
+
+	res, ok := LargestResolution([]Resolution{
+		{Width: 1920, Height: 1080},
+		{Width: 2560, Height: 1440},
+		{Width: 1080, Height: 1920},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, 2560, res.Width)
+	assert.Equal(t, 1440, res.Height)
+}
+
 // Ensure GetDerivativePath generates correct resolution-based paths
 func TestGetDerivativePath(t *testing.T) {
 	// Setup
@@ -78,6 +78,13 @@ func (wp *Plugin) checkAndRunRefresh(now time.Time, lastRefreshDay int, isInitia
 			reason = fmt.Sprintf("Initial check: Current time (%s) is not post-midnight. Setting last refresh day to %d.", now.Format(time.Kitchen), today)
 			log.Debugf("%s", reason)
 			lastRefreshDay = today // IMPORTANT: Set lastRefreshDay here for non-midnight starts
+
+			// The regular refresh waits for tomorrow, but daily providers still need today's image now.
+			if wp.isNetworkAvailable() {
+				wp.FetchDailyImages()
+			} else {
+				log.Print("Initial refresh check: Network appears to be unavailable. Skipping daily images.")
+			}
 		}
 	}
 
@@ -107,8 +114,13 @@ func (wp *Plugin) checkAndRunRefresh(now time.Time, lastRefreshDay int, isInitia
 		// Cleanup Orphans (Delete unknown files)
 		// We get known IDs from store (thread-safe)
 		wp.fm.CleanupOrphans(wp.store.GetKnownIDs())
+
 		log.Print("Nightly Maintenance: Grooming Finished.")
 
+		// Integrity Check (Repair what Sync and CleanupOrphans cannot see). It runs on its own,
+		// so a long check does not count against the hang timeout of this refresh check.
+		go wp.nightlyCacheCheck()
+
 		// Trigger app update check (callback handles preference check + network call)
 		if wp.updateCallback != nil {
 			log.Print("Nightly Maintenance: Triggering application update check...")
@@ -142,6 +154,10 @@ func (wp *Plugin) checkAndRunRefresh(now time.Time, lastRefreshDay int, isInitia
 			}
 		}
 
+		// Always: Fetch the new day's image for daily providers and move pinned monitors onto it
+		log.Print("Nightly Maintenance: Fetching today's images from daily providers...")
+		wp.FetchDailyImages()
+
 		log.Print("Nightly Maintenance: All background tasks finished.")
 
 		// Conditional: Image Refresh
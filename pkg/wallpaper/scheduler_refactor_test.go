@@ -53,6 +53,7 @@ func newTestPlugin(t *testing.T) *Plugin {
 
 	cachePath := filepath.Join(tempDir, "image_cache_map.json")
 	wp.store.SetFileManager(wp.fm, cachePath)
+	t.Cleanup(wp.store.Close)
 
 	return wp
 }
@@ -32,6 +32,7 @@ func TestCheckAndRunRefresh_Logic(t *testing.T) {
 	// Setup Store for Maintenance
 	cachePath := filepath.Join(tempDir, "image_cache_map.json")
 	wp.store.SetFileManager(wp.fm, cachePath)
+	t.Cleanup(wp.store.Close)
 
 	// Initially, it should NOT run unless it's midnight
 	t1 := time.Date(2026, 3, 24, 10, 0, 0, 0, time.UTC)
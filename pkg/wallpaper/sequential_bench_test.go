@@ -118,6 +118,7 @@ func BenchmarkSequentialSwitch(b *testing.B) {
 	store.SetAsyncSave(true) // Enable the feature we just added
 	store.SetDebounceDuration(1 * time.Second)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	b.Cleanup(store.Close)
 
 	// Create Plugin (Minimal)
 	wp := &Plugin{
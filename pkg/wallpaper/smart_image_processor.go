@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/disintegration/imaging"
+	"github.com/dixieflatline76/Spice/v2/pkg/imagecodec"
 	"github.com/dixieflatline76/Spice/v2/pkg/provider"
 	"github.com/dixieflatline76/Spice/v2/util/log"
 	pigo "github.com/esimov/pigo/core"
@@ -60,15 +61,13 @@ func (c *SmartImageProcessor) DecodeImage(ctx context.Context, imgBytes []byte,
 		return nil, "", err
 	}
 
+	// Decode upright and in sRGB so cropping and face detection see the image as displayed.
+	img, ext, err = imagecodec.Decode(bytes.NewReader(imgBytes))
 	switch contentType {
 	case "image/png":
-		img, err = png.Decode(bytes.NewReader(imgBytes))
 		ext = "png"
 	case "image/jpeg":
-		img, err = jpeg.Decode(bytes.NewReader(imgBytes))
 		ext = "jpg"
-	default:
-		img, ext, err = image.Decode(bytes.NewReader(imgBytes))
 	}
 	if err != nil {
 		return nil, ext, fmt.Errorf("decoding image: %w", err)
@@ -226,7 +225,7 @@ func (c *SmartImageProcessor) FitImage(ctx context.Context, img image.Image, tar
 		if resizedImg == nil {
 			return nil, ctx.Err()
 		}
-		return resizedImg, nil
+		return c.sharpenUpscaled(ctx, resizedImg, imageWidth, imageHeight), nil
 	}
 
 	// --- CROP LOGIC START ---
@@ -253,7 +252,33 @@ func (c *SmartImageProcessor) FitImage(ctx context.Context, img image.Image, tar
 	strategy := c.selectStrategy(img, faceFound, faceBox, energy, energyErr, opts.Anchor)
 
 	// 4. Execution
-	return strategy.Apply(ctx, img, systemWidth, systemHeight, c)
+	fitted, err := strategy.Apply(ctx, img, systemWidth, systemHeight, c)
+	if err != nil {
+		return nil, err
+	}
+	return c.sharpenUpscaled(ctx, fitted, imageWidth, imageHeight), nil
+}
+
+// sharpenUpscaled mildly sharpens a fitted image whose source had to be enlarged by no more than
+// the maximum upscale factor, when upscaling is enabled, and reports the upscale through the
+// provider.UpscaledKey context value. Images enlarged further are left as they are: sharpening
+// would only bring out their resampling artifacts.
+func (c *SmartImageProcessor) sharpenUpscaled(ctx context.Context, fitted image.Image, srcWidth, srcHeight int) image.Image {
+	if !c.config.GetUpscaleEnabled() {
+		return fitted
+	}
+	if factor := upscaleFactor(srcWidth, srcHeight, fitted.Bounds().Dx(), fitted.Bounds().Dy()); factor <= 1 || factor > c.config.GetUpscaleMaxFactor() {
+		return fitted
+	}
+	if ptr, ok := ctx.Value(provider.UpscaledKey).(*bool); ok && ptr != nil {
+		*ptr = true
+	}
+	return imaging.Sharpen(fitted, UpscaleSharpenSigma)
+}
+
+// upscaleFactor returns how much an image must be enlarged for a crop of it to cover the target.
+func upscaleFactor(imgWidth, imgHeight, targetWidth, targetHeight int) float64 {
+	return math.Max(float64(targetWidth)/float64(imgWidth), float64(targetHeight)/float64(imgHeight))
 }
 
 func (c *SmartImageProcessor) analyzeFace(img image.Image) (bool, image.Rectangle, float32, error) {
@@ -696,3 +696,69 @@ func TestFitImage_CropAnchor_VisualVerification(t *testing.T) {
 	t.Logf("\n\n📊 VISUAL REPORT: file:///%s\n", filepath.ToSlash(absReport))
 	t.Logf("Open the report in a browser to visually verify anchor shifts.\n")
 }
+
+func TestSmartImageProcessor_CheckCompatibility_Resolution(t *testing.T) {
+	ResetConfig()
+	prefs := NewMockPreferences()
+	cfg := GetConfig(prefs)
+	processor := &SmartImageProcessor{config: cfg, resampler: imaging.Lanczos}
+
+	// Targets a 4K screen: exact, slightly small (~1.13x), far too small (1.5x and 3x), and some that
+	// fail on aspect ratio instead
+	sizes := [][2]int{{3840, 2160}, {3400, 1913}, {2560, 1440}, {1280, 720}, {3000, 3000}, {1080, 1920}, {2000, 500}}
+	for _, mode := range []SmartFitMode{SmartFitNormal, SmartFitAggressive} {
+		cfg.SetSmartFitMode(mode)
+		for _, size := range sizes {
+			cfg.SetUpscaleEnabled(false)
+			off := processor.CheckCompatibility(size[0], size[1], 3840, 2160)
+			if size[0]*9 == size[1]*16 {
+				assert.NoError(t, off, "mode %d: %v is never rejected for its resolution", mode, size)
+			}
+
+			cfg.SetUpscaleEnabled(true)
+			for _, maxFactor := range []float64{1.05, DefaultUpscaleMaxFactor, 2} {
+				cfg.SetUpscaleMaxFactor(maxFactor)
+				on := processor.CheckCompatibility(size[0], size[1], 3840, 2160)
+				assert.Equal(t, off == nil, on == nil, "mode %d: %v at %.2fx: upscaling must not change what is accepted", mode, size, maxFactor)
+			}
+		}
+	}
+}
+
+func TestSmartImageProcessor_FitImage_Upscale(t *testing.T) {
+	ResetConfig()
+	prefs := NewMockPreferences()
+	cfg := GetConfig(prefs)
+	cfg.SetSmartFitMode(SmartFitNormal)
+	processor := &SmartImageProcessor{config: cfg, resampler: imaging.Lanczos}
+
+	// A soft vertical edge, so sharpening has something to act on
+	src := image.NewRGBA(image.Rect(0, 0, 160, 90))
+	for x := 0; x < 160; x++ {
+		v := uint8(min(max((x-70)*12, 0), 255))
+		draw.Draw(src, image.Rect(x, 0, x+1, 90), &image.Uniform{color.RGBA{v, v, v, 255}}, image.Point{}, draw.Src)
+	}
+
+	fit := func() (image.Image, bool) {
+		var upscaled bool
+		ctx := context.WithValue(context.Background(), provider.UpscaledKey, &upscaled)
+		out, err := processor.FitImage(ctx, src, 192, 108, provider.TuningOptions{})
+		require.NoError(t, err)
+		require.Equal(t, image.Rect(0, 0, 192, 108), out.Bounds())
+		return out, upscaled
+	}
+
+	plain, upscaled := fit()
+	assert.False(t, upscaled, "not reported while upscaling is off")
+
+	cfg.SetUpscaleEnabled(true)
+	sharpened, upscaled := fit()
+	assert.True(t, upscaled)
+	assert.Equal(t, imaging.Sharpen(plain, UpscaleSharpenSigma), sharpened)
+
+	// 1.2x is beyond this limit: the image is still used, just not sharpened
+	cfg.SetUpscaleMaxFactor(1.1)
+	out, upscaled := fit()
+	assert.False(t, upscaled)
+	assert.Equal(t, plain, out)
+}
@@ -1,19 +1,26 @@
 package wallpaper
 
 import (
-	"encoding/json"
+	"cmp"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/dixieflatline76/Spice/v2/pkg/imagecodec"
+	"github.com/dixieflatline76/Spice/v2/pkg/imagehash"
 	"github.com/dixieflatline76/Spice/v2/pkg/provider"
 	"github.com/dixieflatline76/Spice/v2/util/log"
 )
 
-// ImageStore is a thread-safe storage for wallpaper images.
+// ImageStore is a thread-safe storage for wallpaper images. Writers serialise on mu; readers use
+// the copy-on-write snapshot and never block.
 type ImageStore struct {
 	mu       sync.RWMutex
+	snapshot atomic.Pointer[storeSnapshot]
 	images   []provider.Image
 	idSet    map[string]bool
 	pathSet  map[string]int // filePath -> index for O(1) MarkSeen
@@ -21,13 +28,26 @@ type ImageStore struct {
 
 	seenCount int // Pre-calculated O(1) SeenCount
 
-	cachePath string
+	cachePath string // Legacy JSON cache, imported into db once
+	db        *imageDB
 	fm        *FileManager
 	asyncSave bool
 
 	saveTimer *time.Timer
 	saveMu    sync.Mutex
 
+	// Persistence: IDs added, changed or removed since the last save, or rewrite for bulk changes.
+	// flushMu keeps saves in the order their snapshots were taken; it is only acquired under mu.
+	// persisted is the snapshot whose images the database holds, or nil while a save is running,
+	// so readers may use the database indexes while it is current.
+	dirty     map[string]bool
+	rewrite   bool
+	flushMu   sync.Mutex
+	persisted atomic.Pointer[storeSnapshot]
+
+	// IDs changed since the last publish, whose snapshot copies must be refreshed
+	unpublished map[string]bool
+
 	// Testing hook
 	saveFunc func()
 
@@ -38,6 +58,18 @@ type ImageStore struct {
 	// Map "WidthxHeight" -> List of Image IDs compatible with that resolution
 	resolutionBuckets map[string][]string
 
+	// Cache Eviction: Sync keeps the store within the size limit and this disk quota (0 = none),
+	// evicting images in the policy's order but never favorites or the images inUseFunc reports.
+	diskQuota int64
+	eviction  EvictionPolicy
+	inUseFunc func() map[string]bool
+	usageMu   sync.Mutex
+	usage     map[string]usageEntry // Disk usage per image ID as last measured, see measureUsage
+
+	// Duplicate Index: ID -> perceptual hash of every hashed image that is not itself a rejected copy
+	hashes               map[string]uint64
+	duplicateMaxDistance int
+
 	debounceDuration time.Duration
 	os               OS
 
@@ -55,7 +87,9 @@ func NewImageStore() *ImageStore {
 		debounceDuration:  2 * time.Second,
 		updateCh:          make(chan struct{}),
 		resolutionBuckets: make(map[string][]string),
+		hashes:            make(map[string]uint64),
 	}
+	store.snapshot.Store(&storeSnapshot{})
 	return store
 }
 
@@ -65,6 +99,35 @@ func (s *ImageStore) SetDebounceDuration(d time.Duration) {
 	s.debounceDuration = d
 }
 
+// SetDiskQuota sets how many bytes the masters and derivatives of cached images may use. 0 means no quota.
+func (s *ImageStore) SetDiskQuota(bytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.diskQuota = bytes
+}
+
+// SetEvictionPolicy sets the order in which Sync evicts images to stay within the cache limits.
+func (s *ImageStore) SetEvictionPolicy(policy EvictionPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eviction = policy
+}
+
+// SetInUseFunc sets the callback that reports the IDs of images on screen, which are never evicted.
+func (s *ImageStore) SetInUseFunc(fn func() map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inUseFunc = fn
+}
+
+// SetDuplicateMaxDistance sets how many perceptual hash bits two images may differ by and still be
+// treated as copies of one picture. 0 disables duplicate detection.
+func (s *ImageStore) SetDuplicateMaxDistance(d int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.duplicateMaxDistance = d
+}
+
 // SetQueryActiveFunc sets the callback used to check if an image's source query is still active
 func (s *ImageStore) SetQueryActiveFunc(fn func(string) bool) {
 	s.mu.Lock()
@@ -92,11 +155,23 @@ func (s *ImageStore) SetAsyncSave(enabled bool) {
 	s.asyncSave = enabled
 }
 
+// SetFileManager sets the FileManager and where the store is persisted. cacheFile is the JSON
+// cache of earlier versions; the database is kept beside it with a .db extension.
 func (s *ImageStore) SetFileManager(fm *FileManager, cacheFile string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.fm = fm
 	s.cachePath = cacheFile
+	if s.db != nil {
+		if err := s.db.Close(); err != nil {
+			log.Printf("Store: Failed to close the image database: %v", err)
+		}
+	}
+	s.db = nil
+	s.persisted.Store(nil)
+	if cacheFile != "" {
+		s.db = &imageDB{path: dbPathFor(cacheFile)}
+	}
 }
 
 // replace performs a full struct replacement for an existing image.
@@ -129,11 +204,17 @@ func (s *ImageStore) replace(img provider.Image) bool {
 			} else {
 				img.Tuning = nil
 			}
-			// Incremental bucket update: remove old entries, add new ones
-			s.removeFromBucketsLocked(existing.ID, existing.DerivativePaths)
+			// Usage is tracked by the store, not the pipeline
+			img.Fetched, img.LastShown, img.ShowCount = existing.Fetched, existing.LastShown, existing.ShowCount
+			// Backlog healing may hash an image for the first time
+			if existing.PerceptualHash == 0 {
+				img = s.resolveDuplicateLocked(img)
+			}
+			// Incremental bucket update: only the resolutions gained or lost
+			s.updateBucketsLocked(img.ID, existing.DerivativePaths, img.DerivativePaths)
 			s.images[i] = img
-			s.addToBucketsLocked(img.ID, img.DerivativePaths)
-			s.scheduleSaveLocked()
+			s.indexHashLocked(img)
+			s.scheduleSaveLocked(img.ID)
 			return true
 		}
 	}
@@ -147,7 +228,7 @@ func (s *ImageStore) SetFavorited(id string, favorited bool) bool {
 	for i := range s.images {
 		if s.images[i].ID == id {
 			s.images[i].IsFavorited = favorited
-			s.scheduleSaveLocked()
+			s.scheduleSaveLocked(id)
 			return true
 		}
 	}
@@ -161,17 +242,43 @@ func (s *ImageStore) SetTuningOptions(id string, resKey string, opts provider.Tu
 	defer s.mu.Unlock()
 	for i := range s.images {
 		if s.images[i].ID == id {
-			if s.images[i].Tuning == nil {
-				s.images[i].Tuning = make(map[string]provider.TuningOptions)
+			// Copy-on-write: published snapshots may share the old map
+			tuning := maps.Clone(s.images[i].Tuning)
+			if tuning == nil {
+				tuning = make(map[string]provider.TuningOptions)
 			}
 			// If it's the default/empty state, remove it from the map to clean up JSON
 			defaultOpts := provider.TuningOptions{Anchor: provider.AnchorAuto}
 			if opts == defaultOpts {
-				delete(s.images[i].Tuning, resKey)
+				delete(tuning, resKey)
 			} else {
-				s.images[i].Tuning[resKey] = opts
+				tuning[resKey] = opts
 			}
-			s.scheduleSaveLocked()
+			s.images[i].Tuning = tuning
+			s.scheduleSaveLocked(id)
+			return true
+		}
+	}
+	return false
+}
+
+// SetResultFlags records how an image was last processed: each result tag in flags (e.g.
+// "Upscaled:1920x1080") is set if true and removed if false.
+func (s *ImageStore) SetResultFlags(id string, flags map[string]bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.images {
+		if s.images[i].ID == id {
+			// Copy-on-write: published snapshots may share the old map
+			updated := maps.Clone(s.images[i].ProcessingFlags)
+			if updated == nil {
+				updated = make(map[string]bool)
+			}
+			for key, on := range flags {
+				setResultFlag(updated, key, on)
+			}
+			s.images[i].ProcessingFlags = updated
+			s.scheduleSaveLocked(id)
 			return true
 		}
 	}
@@ -184,12 +291,15 @@ func (s *ImageStore) SetDerivativePath(id string, resKey string, path string) bo
 	defer s.mu.Unlock()
 	for i := range s.images {
 		if s.images[i].ID == id {
-			if s.images[i].DerivativePaths == nil {
-				s.images[i].DerivativePaths = make(map[string]string)
+			// Copy-on-write: published snapshots may share the old map
+			paths := maps.Clone(s.images[i].DerivativePaths)
+			if paths == nil {
+				paths = make(map[string]string)
 			}
-			s.images[i].DerivativePaths[resKey] = path
-			s.addToBucketsLocked(id, map[string]string{resKey: path})
-			s.scheduleSaveLocked()
+			paths[resKey] = path
+			s.updateBucketsLocked(id, s.images[i].DerivativePaths, paths)
+			s.images[i].DerivativePaths = paths
+			s.scheduleSaveLocked(id)
 			return true
 		}
 	}
@@ -207,7 +317,7 @@ func (s *ImageStore) ClearDerivatives(id string) bool {
 			s.removeFromBucketsLocked(id, s.images[i].DerivativePaths)
 			s.images[i].DerivativePaths = make(map[string]string)
 			s.images[i].ProcessingFlags = make(map[string]bool)
-			s.scheduleSaveLocked()
+			s.scheduleSaveLocked(id)
 			return true
 		}
 	}
@@ -235,6 +345,22 @@ func (s *ImageStore) addToBucketsLocked(id string, derivativePaths map[string]st
 	}
 }
 
+// updateBucketsLocked moves an image between resolution buckets as its derivatives change from
+// oldPaths to newPaths. The image keeps its place in the buckets it stays in.
+// CALLER MUST HOLD s.mu.Lock().
+func (s *ImageStore) updateBucketsLocked(id string, oldPaths, newPaths map[string]string) {
+	for res := range oldPaths {
+		if _, ok := newPaths[res]; !ok {
+			s.removeFromBucketsLocked(id, map[string]string{res: ""})
+		}
+	}
+	for res := range newPaths {
+		if _, ok := oldPaths[res]; !ok {
+			s.addToBucketsLocked(id, map[string]string{res: ""})
+		}
+	}
+}
+
 // removeFromBucketsLocked removes a single image's entries from the resolution buckets.
 // CALLER MUST HOLD s.mu.Lock().
 func (s *ImageStore) removeFromBucketsLocked(id string, derivativePaths map[string]string) {
@@ -242,7 +368,8 @@ func (s *ImageStore) removeFromBucketsLocked(id string, derivativePaths map[stri
 		ids := s.resolutionBuckets[res]
 		for j, bucketID := range ids {
 			if bucketID == id {
-				s.resolutionBuckets[res] = append(ids[:j], ids[j+1:]...)
+				// A fresh slice: published snapshots may share the old one
+				s.resolutionBuckets[res] = slices.Delete(slices.Clone(ids), j, j+1)
 				break
 			}
 		}
@@ -253,14 +380,31 @@ func (s *ImageStore) removeFromBucketsLocked(id string, derivativePaths map[stri
 	}
 }
 
-// scheduleSaveLocked handles persistence.
+// scheduleSaveLocked handles persistence. Only the images with the given IDs (added, changed or
+// removed) are written; pass none to rewrite the whole database after a bulk change. As every
+// change ends here, it also publishes the new state to readers.
 // CALLER MUST HOLD s.mu.Lock()
-func (s *ImageStore) scheduleSaveLocked() {
+func (s *ImageStore) scheduleSaveLocked(ids ...string) {
+	s.markDirtyLocked(ids...)
+	if len(ids) == 0 {
+		s.rewrite = true
+	}
+	s.publishLocked(len(ids) == 0)
+
 	if !s.asyncSave {
 		// Sync mode: snapshot while locked and save immediately.
-		snapshot := make([]provider.Image, len(s.images))
-		copy(snapshot, s.images)
-		s.saveCacheInternal(snapshot)
+		puts, deletes, rewrite := s.takeChangesLocked()
+		snap := s.view()
+		s.flushMu.Lock()
+		s.persisted.Store(nil)
+		err := s.saveCacheInternal(puts, deletes, rewrite)
+		if err == nil {
+			s.persisted.Store(snap)
+		}
+		s.flushMu.Unlock()
+		if err != nil {
+			s.rewrite = true
+		}
 		return
 	}
 
@@ -323,7 +467,7 @@ func (s *ImageStore) Add(img provider.Image) bool {
 						s.pathSet[img.FilePath] = i
 					}
 
-					s.scheduleSaveLocked()
+					s.scheduleSaveLocked(img.ID)
 					return true
 				}
 			}
@@ -343,6 +487,8 @@ func (s *ImageStore) Add(img provider.Image) bool {
 		return false
 	}
 
+	img = s.resolveDuplicateLocked(img)
+
 	s.images = append(s.images, img)
 	s.idSet[img.ID] = true
 	if img.FilePath != "" {
@@ -356,86 +502,95 @@ func (s *ImageStore) Add(img provider.Image) bool {
 	for res := range img.DerivativePaths {
 		s.resolutionBuckets[res] = append(s.resolutionBuckets[res], img.ID)
 	}
+	s.indexHashLocked(img)
 
-	s.scheduleSaveLocked()
+	s.scheduleSaveLocked(img.ID)
 	s.notifyUpdateLocked()
 	return true
 }
 
 // Get returns the image at the given index from the global list. Returns false if index is out of bounds.
 func (s *ImageStore) Get(index int) (provider.Image, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	if index >= 0 && index < len(s.images) {
-		return s.images[index], true
+	snap := s.view()
+	if index >= 0 && index < len(snap.images) {
+		return *snap.images[index], true
 	}
 	return provider.Image{}, false
 }
 
 // GetByID returns the image with the given ID. Returns false if not found.
 func (s *ImageStore) GetByID(id string) (provider.Image, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	for _, img := range s.images {
-		if img.ID == id {
-			return img, true
-		}
+	snap := s.view()
+	if i := snap.indexOf(id); i >= 0 {
+		return *snap.images[i], true
 	}
 	return provider.Image{}, false
 }
 
 // Exists checks if an image ID is already in the store.
 func (s *ImageStore) Exists(id string) bool {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.idSet[id]
+	return s.view().indexOf(id) >= 0
 }
 
 func (s *ImageStore) Contains(id string) bool {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.idSet[id]
+	return s.Exists(id)
 }
 
 // Count returns the total number of images in the store.
 func (s *ImageStore) Count() int {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return len(s.images)
+	return len(s.view().images)
 }
 
+// MarkSeen records that the image with the given file was set as a wallpaper. The file may be the
+// image's FilePath or, on other monitors, any of its derivatives.
 func (s *ImageStore) MarkSeen(filePath string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	idx, exists := s.pathSet[filePath]
 	if !exists {
-		// Fallback: This might happen if FilePath changed or wasn't indexed.
-		// We still want to be robust but indexed is preferred.
-		for i, img := range s.images {
+		// Fallback: This might happen if FilePath changed or wasn't indexed, or for the
+		// derivative shown on another monitor. We still want to be robust but indexed is preferred.
+		idx = slices.IndexFunc(s.images, func(img provider.Image) bool {
 			if img.FilePath == filePath {
-				if !s.images[i].Seen {
-					s.images[i].Seen = true
-					s.seenCount++
-					s.scheduleSaveLocked()
+				return true
+			}
+			for _, path := range img.DerivativePaths {
+				if path == filePath {
+					return true
 				}
-				return
 			}
+			return false
+		})
+		if idx < 0 {
+			return
 		}
-		return
 	}
 
-	if !s.images[idx].Seen {
-		s.images[idx].Seen = true
+	img := &s.images[idx]
+	if !img.Seen {
+		img.Seen = true
 		s.seenCount++
-		s.scheduleSaveLocked()
 	}
+	// Usage for cache eviction
+	img.LastShown = time.Now()
+	img.ShowCount++
+	s.scheduleSaveLocked(img.ID)
 }
 
 // Remove deletes an image from the store by its ID. It also deletes physical files asynchronously.
+// The image is blocked so later fetches do not bring it back.
 func (s *ImageStore) Remove(id string) (provider.Image, bool) {
+	return s.remove(id, true)
+}
+
+// Forget deletes an image whose source no longer exists, together with its physical files.
+// Unlike Remove it does not block the image, so it is fetched again if its source comes back.
+func (s *ImageStore) Forget(id string) (provider.Image, bool) {
+	return s.remove(id, false)
+}
+
+func (s *ImageStore) remove(id string, avoid bool) (provider.Image, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -471,8 +626,11 @@ func (s *ImageStore) Remove(id string) (provider.Image, bool) {
 	}
 
 	s.removeFromBucketsLocked(id, img.DerivativePaths) // Incremental bucket removal
-	s.avoidSet[id] = true
-	s.scheduleSaveLocked()
+	delete(s.hashes, id)
+	if avoid {
+		s.avoidSet[id] = true
+	}
+	s.scheduleSaveLocked(id)
 
 	if s.fm != nil {
 		s.fm.AsyncDeepDelete(id)
@@ -483,9 +641,7 @@ func (s *ImageStore) Remove(id string) (provider.Image, bool) {
 
 // SeenCount returns the number of images that have been marked as seen.
 func (s *ImageStore) SeenCount() int {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.seenCount
+	return s.view().seenCount
 }
 
 // Clear resets the store to an empty state, but preserves the avoid set (blocklist).
@@ -496,6 +652,7 @@ func (s *ImageStore) Clear() {
 	s.idSet = make(map[string]bool)
 	s.pathSet = make(map[string]int)
 	s.resolutionBuckets = make(map[string][]string)
+	s.hashes = make(map[string]uint64)
 	s.seenCount = 0
 	s.scheduleSaveLocked()
 }
@@ -505,10 +662,16 @@ func (s *ImageStore) ResetFavorites() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	var changed []string
 	for i := range s.images {
-		s.images[i].IsFavorited = false
+		if s.images[i].IsFavorited {
+			s.images[i].IsFavorited = false
+			changed = append(changed, s.images[i].ID)
+		}
+	}
+	if len(changed) > 0 {
+		s.scheduleSaveLocked(changed...)
 	}
-	s.scheduleSaveLocked()
 }
 
 func (s *ImageStore) Wipe() {
@@ -522,11 +685,10 @@ func (s *ImageStore) Wipe() {
 }
 
 func (s *ImageStore) GetKnownIDs() map[string]bool {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	known := make(map[string]bool, len(s.idSet))
-	for k, v := range s.idSet {
-		known[k] = v
+	snap := s.view()
+	known := make(map[string]bool, len(snap.images))
+	for _, img := range snap.images {
+		known[img.ID] = true
 	}
 	return known
 }
@@ -541,6 +703,7 @@ func (s *ImageStore) RemoveByQueryID(queryID string) {
 		if img.SourceQueryID == queryID {
 			toDelete = append(toDelete, img)
 			delete(s.idSet, img.ID)
+			delete(s.hashes, img.ID)
 			log.Debugf("[RemoveByQueryID] Matched %s (Source: %s). Queueing for deletion.", img.ID, img.SourceQueryID)
 			if img.FilePath != "" {
 				delete(s.pathSet, img.FilePath)
@@ -564,23 +727,26 @@ func (s *ImageStore) RemoveByQueryID(queryID string) {
 	}
 	s.rebuildBucketsLocked() // Ensure buckets are updated after batch removal
 
-	s.scheduleSaveLocked()
+	ids := make([]string, len(toDelete))
+	for i, img := range toDelete {
+		ids[i] = img.ID
+	}
+	if len(ids) > 0 {
+		s.scheduleSaveLocked(ids...)
+	}
 	s.mu.Unlock()
 
 	if s.fm != nil {
-		ids := make([]string, len(toDelete))
-		for i, img := range toDelete {
-			ids[i] = img.ID
-		}
 		s.fm.AsyncDeepDeleteBatch(ids)
 	}
 }
 
 func (s *ImageStore) List() []provider.Image {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	res := make([]provider.Image, len(s.images))
-	copy(res, s.images)
+	snap := s.view()
+	res := make([]provider.Image, len(snap.images))
+	for i, img := range snap.images {
+		res[i] = *img
+	}
 	return res
 }
 
@@ -597,121 +763,206 @@ func (s *ImageStore) LoadCache() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.cachePath == "" {
+	if s.db == nil {
 		return nil
 	}
 
-	file, err := os.Open(s.cachePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
+	var images []provider.Image
+	var err error
+	imported := false
+	if s.db.Exists() {
+		if images, err = s.db.Load(); err != nil {
+			return err
 		}
-		return err
+	} else {
+		// Database Migration: Import the JSON cache of earlier versions once
+		if images, err = readLegacyCache(s.cachePath); err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		imported = true
 	}
-	defer file.Close()
-
-	if err := json.NewDecoder(file).Decode(&s.images); err != nil {
-		return err
+	s.images = images
+	if s.images == nil {
+		s.images = make([]provider.Image, 0)
 	}
 
 	// Namespacing Migration: Upgrade legacy IDs to namespaced format
 	migrationOccurred := s.migrateLegacyIDsLocked()
 
-	if migrationOccurred {
+	// Whether the database holds exactly s.images, so its indexes can be used
+	inSync := !imported && !migrationOccurred
+	if imported {
+		// Written before the JSON cache is retired, so a failure leaves it to import next time
+		if err := s.db.Rewrite(s.images); err != nil {
+			log.Printf("Migration: Failed to import %s into the database: %v", s.cachePath, err)
+			s.scheduleSaveLocked()
+		} else {
+			inSync = true
+			log.Printf("Migration: Imported %d images from %s into the database.", len(s.images), s.cachePath)
+			if err := os.Rename(s.cachePath, s.cachePath+".migrated"); err != nil {
+				log.Printf("Migration: Failed to retire %s: %v", s.cachePath, err)
+			}
+		}
+	} else if migrationOccurred {
 		log.Printf("Migration: Namespacing upgrade complete. Saving updated cache.")
 		// No lock needed as we're inside LoadCache which holds mu.Lock()
 		s.scheduleSaveLocked()
 	}
 
+	// Populate buckets from the database's resolution index, or from the images if it is stale
+	var buckets map[string][]string
+	if inSync {
+		if buckets, err = s.db.ResolutionBuckets(); err != nil {
+			log.Printf("Store: Failed to read the resolution index, rebuilding it: %v", err)
+			buckets = nil
+		}
+	}
+
 	s.idSet = make(map[string]bool)
 	s.pathSet = make(map[string]int)
 	s.resolutionBuckets = make(map[string][]string)
+	s.hashes = make(map[string]uint64)
 	s.seenCount = 0
+	positions := make(map[string]int, len(s.images))
 	for i, img := range s.images {
 		s.idSet[img.ID] = true
+		positions[img.ID] = i
 		if img.FilePath != "" {
 			s.pathSet[img.FilePath] = i
 		}
 		if img.Seen {
 			s.seenCount++
 		}
-		// Populate buckets from cache
-		for res := range img.DerivativePaths {
-			s.resolutionBuckets[res] = append(s.resolutionBuckets[res], img.ID)
+		if buckets == nil {
+			for res := range img.DerivativePaths {
+				s.resolutionBuckets[res] = append(s.resolutionBuckets[res], img.ID)
+			}
 		}
+		s.indexHashLocked(img)
+	}
+	for res, ids := range buckets {
+		// The index is ordered by ID; buckets are kept in store order
+		ids = slices.DeleteFunc(ids, func(id string) bool {
+			_, ok := positions[id]
+			return !ok
+		})
+		slices.SortFunc(ids, func(a, b string) int {
+			return cmp.Compare(positions[a], positions[b])
+		})
+		s.resolutionBuckets[res] = ids
+	}
+	s.publishLocked(true)
+	if inSync {
+		s.persisted.Store(s.view())
 	}
 	return nil
 }
 
+// SaveCache writes the changes made since the last save to the database.
 func (s *ImageStore) SaveCache() {
-	s.mu.RLock()
-	snapshot := make([]provider.Image, len(s.images))
-	for i, img := range s.images {
-		// Deep copy the struct to avoid shared map references during JSON encoding
-		snapshot[i] = img
-		if img.ProcessingFlags != nil {
-			flags := make(map[string]bool)
-			for k, v := range img.ProcessingFlags {
-				flags[k] = v
-			}
-			snapshot[i].ProcessingFlags = flags
-		}
-		if img.DerivativePaths != nil {
-			paths := make(map[string]string)
-			for k, v := range img.DerivativePaths {
-				paths[k] = v
-			}
-			snapshot[i].DerivativePaths = paths
+	s.mu.Lock()
+	puts, deletes, rewrite := s.takeChangesLocked()
+	snap := s.view()
+	// Taken before unlocking, so saves land in the order their snapshots were taken
+	s.flushMu.Lock()
+	s.mu.Unlock()
+
+	s.persisted.Store(nil)
+	err := s.saveCacheInternal(puts, deletes, rewrite)
+	if err == nil {
+		s.persisted.Store(snap)
+	}
+	s.flushMu.Unlock()
+
+	if err != nil {
+		// The changes are lost from the dirty set; rewrite everything next time
+		s.mu.Lock()
+		s.rewrite = true
+		s.mu.Unlock()
+	}
+}
+
+// markDirtyLocked records that the images with the given IDs must be written (or, if no longer in
+// the store, deleted) by the next save, and copied into the next snapshot.
+// CALLER MUST HOLD s.mu.Lock().
+func (s *ImageStore) markDirtyLocked(ids ...string) {
+	if s.dirty == nil {
+		s.dirty = make(map[string]bool)
+	}
+	if s.unpublished == nil {
+		s.unpublished = make(map[string]bool)
+	}
+	for _, id := range ids {
+		s.dirty[id] = true
+		s.unpublished[id] = true
+	}
+}
+
+// takeChangesLocked returns copies of the images to write and the IDs to delete since the last
+// save, or every image if the database must be rewritten, and resets the change tracking.
+// CALLER MUST HOLD s.mu.Lock().
+func (s *ImageStore) takeChangesLocked() (puts []provider.Image, deletes []string, rewrite bool) {
+	defer func() {
+		s.dirty = nil
+		s.rewrite = false
+	}()
+	if s.db == nil {
+		return nil, nil, false
+	}
+
+	rewrite = s.rewrite
+	for _, img := range s.images {
+		if rewrite || s.dirty[img.ID] {
+			puts = append(puts, cloneImage(img))
 		}
-		if img.Tuning != nil {
-			tuning := make(map[string]provider.TuningOptions)
-			for k, v := range img.Tuning {
-				tuning[k] = v
+	}
+	if !rewrite {
+		for id := range s.dirty {
+			if !s.idSet[id] {
+				deletes = append(deletes, id)
 			}
-			snapshot[i].Tuning = tuning
 		}
 	}
-	s.mu.RUnlock()
+	return puts, deletes, rewrite
+}
 
-	s.saveCacheInternal(snapshot)
+// cloneImage deep copies the maps of img, so it can be encoded while the store keeps changing.
+func cloneImage(img provider.Image) provider.Image {
+	img.ProcessingFlags = maps.Clone(img.ProcessingFlags)
+	img.DerivativePaths = maps.Clone(img.DerivativePaths)
+	img.Tuning = maps.Clone(img.Tuning)
+	return img
 }
 
-func (s *ImageStore) saveCacheInternal(images []provider.Image) {
+// saveCacheInternal writes a change set to the database in one transaction.
+// CALLER MUST HOLD s.flushMu.
+func (s *ImageStore) saveCacheInternal(puts []provider.Image, deletes []string, rewrite bool) error {
 	if s.saveFunc != nil {
 		s.saveFunc()
 	}
 
-	if s.cachePath == "" {
-		return
+	if s.db == nil || (!rewrite && len(puts) == 0 && len(deletes) == 0) {
+		return nil
 	}
 
-	tmp := s.cachePath + ".tmp"
-	file, err := os.Create(tmp)
+	var err error
+	if rewrite {
+		err = s.db.Rewrite(puts)
+	} else {
+		err = s.db.Apply(puts, deletes)
+	}
 	if err != nil {
 		log.Printf("Store: Failed to save cache: %v", err)
-		return
-	}
-
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(images); err != nil {
-		file.Close()
-		log.Printf("Store: Failed to encode cache: %v", err)
-		return
-	}
-	file.Close()
-
-	if err := os.Rename(tmp, s.cachePath); err != nil {
-		log.Printf("Store: Failed to rename cache: %v", err)
 	}
+	return err
 }
 
 // GetIDsForResolution returns a list of IDs compatible with the given resolution.
 func (s *ImageStore) GetIDsForResolution(resolution string) []string {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	ids, exists := s.resolutionBuckets[resolution]
+	ids, exists := s.view().buckets[resolution]
 	if !exists {
 		return nil
 	}
@@ -723,9 +974,154 @@ func (s *ImageStore) GetIDsForResolution(resolution string) []string {
 
 // GetBucketSize returns the number of images available for a specific resolution.
 func (s *ImageStore) GetBucketSize(resolution string) int {
+	return len(s.view().buckets[resolution])
+}
+
+// FindDuplicate returns the stored image that img is a near-duplicate of, judged by perceptual hash.
+// Copies already rejected as duplicates or by the quality gate are never returned.
+//
+// Unlike the other readers it takes the read lock: it searches the hash index, which is writer
+// state that snapshots do not carry. Only the download pipeline calls it, once per new image, so
+// the UI never waits on it.
+func (s *ImageStore) FindDuplicate(img provider.Image) (provider.Image, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return len(s.resolutionBuckets[resolution])
+	if i := s.findDuplicateLocked(img); i >= 0 {
+		return s.images[i], true
+	}
+	return provider.Image{}, false
+}
+
+// findDuplicateLocked returns the index of the indexed image closest to img within the duplicate
+// distance, or -1. Two favorites are never duplicates of each other, so neither is ever dropped.
+// CALLER MUST HOLD s.mu (read or write).
+func (s *ImageStore) findDuplicateLocked(img provider.Image) int {
+	if s.duplicateMaxDistance <= 0 || !isDuplicateCandidate(img) {
+		return -1
+	}
+	bestID, best := "", s.duplicateMaxDistance+1
+	for id, hash := range s.hashes {
+		if id == img.ID {
+			continue
+		}
+		// Ties go to the lowest ID so the result does not depend on map order
+		if d := imagehash.Distance(img.PerceptualHash, hash); d < best || (d == best && id < bestID) {
+			bestID, best = id, d
+		}
+	}
+	if bestID == "" {
+		return -1
+	}
+	for i := range s.images {
+		if s.images[i].ID == bestID {
+			if isFavoriteImage(img) && isFavoriteImage(s.images[i]) {
+				return -1
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// resolveDuplicateLocked keeps a single copy when img is a near-duplicate of a stored image: the
+// favorite, else the one with more pixels, else the one stored first. The other copy is tagged
+// "duplicate:<kept ID>" and its files are deleted, but its entry stays so fetches skip it. The kept
+// copy takes over the other's favorite and tuning. Returns img as it should be stored.
+// CALLER MUST HOLD s.mu.Lock().
+func (s *ImageStore) resolveDuplicateLocked(img provider.Image) provider.Image {
+	i := s.findDuplicateLocked(img)
+	if i < 0 {
+		return img
+	}
+	existing := s.images[i]
+
+	if !preferredCopy(img, existing) {
+		log.Debugf("Store: %s is a copy of %s. Keeping %s.", img.ID, existing.ID, existing.ID)
+		s.images[i] = inheritCopy(existing, img)
+		s.markDirtyLocked(existing.ID)
+		if s.fm != nil {
+			s.fm.AsyncDeepDelete(img.ID)
+		}
+		return markDuplicate(img, existing.ID)
+	}
+
+	log.Debugf("Store: %s is a copy of %s. Keeping %s.", img.ID, existing.ID, img.ID)
+	s.removeFromBucketsLocked(existing.ID, existing.DerivativePaths)
+	delete(s.hashes, existing.ID)
+	s.images[i] = markDuplicate(existing, img.ID)
+	s.markDirtyLocked(existing.ID)
+	if s.fm != nil {
+		s.fm.AsyncDeepDelete(existing.ID)
+	}
+	return inheritCopy(img, existing)
+}
+
+// indexHashLocked adds img to the duplicate index, or drops it if it is not a duplicate candidate.
+// CALLER MUST HOLD s.mu.Lock().
+func (s *ImageStore) indexHashLocked(img provider.Image) {
+	if isDuplicateCandidate(img) {
+		s.hashes[img.ID] = img.PerceptualHash
+	} else {
+		delete(s.hashes, img.ID)
+	}
+}
+
+// isDuplicateCandidate reports whether img takes part in duplicate detection: it is hashed, and
+// was rejected neither as a copy nor by the quality gate.
+func isDuplicateCandidate(img provider.Image) bool {
+	return img.PerceptualHash != 0 && duplicateOf(img) == "" && qualityRejection(img) == ""
+}
+
+// isFavoriteImage reports whether img is a favorite, which cache pruning and duplicate merging never drop.
+func isFavoriteImage(img provider.Image) bool {
+	return img.IsFavorited || img.Provider == "Favorites" || strings.Contains(img.ID, "_favorite_images_")
+}
+
+// preferredCopy reports whether a is a better copy of a picture than b: favorites first, then more pixels.
+func preferredCopy(a, b provider.Image) bool {
+	if fa, fb := isFavoriteImage(a), isFavoriteImage(b); fa != fb {
+		return fa
+	}
+	return a.Width*a.Height > b.Width*b.Height
+}
+
+// inheritCopy returns keeper with the favorite and per-resolution tuning of other, a copy of the
+// same picture being dropped. Tuning the keeper already has wins.
+func inheritCopy(keeper, other provider.Image) provider.Image {
+	keeper.IsFavorited = keeper.IsFavorited || other.IsFavorited
+	if len(other.Tuning) > 0 {
+		tuning := make(map[string]provider.TuningOptions, len(keeper.Tuning)+len(other.Tuning))
+		for k, v := range other.Tuning {
+			tuning[k] = v
+		}
+		for k, v := range keeper.Tuning {
+			tuning[k] = v
+		}
+		keeper.Tuning = tuning
+	}
+	return keeper
+}
+
+// markDuplicate returns img tagged as a copy of keeperID, without derivatives.
+func markDuplicate(img provider.Image, keeperID string) provider.Image {
+	flags := make(map[string]bool, len(img.ProcessingFlags)+1)
+	for k, v := range img.ProcessingFlags {
+		flags[k] = v
+	}
+	flags["duplicate:"+keeperID] = true
+	img.ProcessingFlags = flags
+	img.DerivativePaths = nil
+	return img
+}
+
+// duplicateOf returns the ID of the image that img was found to be a copy of, or "".
+func duplicateOf(img provider.Image) string {
+	for k, v := range img.ProcessingFlags {
+		if keeperID, ok := strings.CutPrefix(k, "duplicate:"); ok && v {
+			return keeperID
+		}
+	}
+	return ""
 }
 
 // ImageSyncAction defines the cleanup action required for an image.
@@ -749,15 +1145,30 @@ func (s *ImageStore) Sync(limit int, targetFlags map[string]bool, activeQueryIDs
 	s.mu.RLock()
 	candidates := make([]provider.Image, len(s.images))
 	copy(candidates, s.images)
+	inUseFunc := s.inUseFunc
+	diskQuota := s.diskQuota
 	s.mu.RUnlock()
 
+	// Gathered before locking: both touch other subsystems (disk, monitors)
+	var usage map[string]int64
+	if diskQuota > 0 {
+		usage = s.measureUsage(candidates)
+	}
+	var inUse map[string]bool
+	if inUseFunc != nil {
+		inUse = inUseFunc()
+	}
+
 	badIDs := make(map[string]ImageSyncAction)
+	zombies := make(map[string]bool)
 
 	// 1. Determine actions for all candidates
 	for _, img := range candidates {
 		action := s.determineSyncAction(img, activeQueryIDs, targetFlags)
 		if action != ImageActionKeep {
 			badIDs[img.ID] = action
+		} else if s.hasIncompatibleFlags(img) && !s.masterFileExists(img.ID) {
+			zombies[img.ID] = true
 		}
 	}
 
@@ -790,21 +1201,20 @@ func (s *ImageStore) Sync(limit int, targetFlags map[string]bool, activeQueryIDs
 		}
 	}
 
-	// 3. Prune Limit
-	if len(finalImages) > limit {
-		excess := len(finalImages) - limit
-		toPrune := finalImages[:excess]
-		finalImages = finalImages[excess:]
-		for _, img := range toPrune {
-			idsToDelete = append(idsToDelete, img.ID)
-			delete(s.idSet, img.ID)
-		}
+	// 3. Evict to the size limit and disk quota
+	finalImages, evicted := s.evictLocked(finalImages, limit, usage, inUse, zombies)
+	for _, id := range evicted {
+		idsToDelete = append(idsToDelete, id)
+		delete(s.idSet, id)
 	}
 
 	// 4. Update State
 	s.images = finalImages
 	s.rebuildInternalStateLocked()
-	s.scheduleSaveLocked()
+	// scheduleSaveLocked publishes the changed images, and any that moved, as it saves them
+	if changed := slices.Concat(idsToDelete, idsToInvalidate); len(changed) > 0 {
+		s.scheduleSaveLocked(changed...)
+	}
 	s.mu.Unlock()
 
 	// 5. Async Cleanup
@@ -815,9 +1225,65 @@ func (s *ImageStore) Sync(limit int, targetFlags map[string]bool, activeQueryIDs
 	s.performAsyncCleanup(idsToDelete, idsToInvalidate)
 }
 
+// evictLocked removes images, in the eviction policy's order, until at most limit remain and their
+// files fit the disk quota. Favorites and images in use are never evicted, even if that leaves the
+// cache over its limits. Zombies, entries kept without their files (see determineSyncAction), take
+// no space and are neither counted nor evicted. Returns the images kept, in store order, and the
+// IDs evicted.
+// CALLER MUST HOLD s.mu.Lock().
+func (s *ImageStore) evictLocked(images []provider.Image, limit int, usage map[string]int64, inUse, zombies map[string]bool) ([]provider.Image, []string) {
+	count, bytes := 0, int64(0)
+	for _, img := range images {
+		if !zombies[img.ID] {
+			count++
+			bytes += usage[img.ID]
+		}
+	}
+	over := func() bool {
+		return count > limit || (s.diskQuota > 0 && bytes > s.diskQuota)
+	}
+	if !over() {
+		return images, nil
+	}
+
+	var entries []CacheEntry
+	for _, img := range images {
+		if !isFavoriteImage(img) && !inUse[img.ID] && !zombies[img.ID] {
+			entries = append(entries, CacheEntry{Image: img, Bytes: usage[img.ID]})
+		}
+	}
+	policy := s.eviction
+	if policy == nil {
+		policy = OldestFetchedPolicy{}
+	}
+
+	evict := make(map[string]bool)
+	var evicted []string
+	for _, e := range policy.Order(entries) {
+		if !over() {
+			break
+		}
+		evict[e.Image.ID] = true
+		evicted = append(evicted, e.Image.ID)
+		count--
+		bytes -= e.Bytes
+	}
+	if over() {
+		log.Printf("Store: Cache is still over its limits (%d images, %d MB) after evicting everything but favorites and images on screen.", count, bytes>>20)
+	}
+
+	kept := images[:0]
+	for _, img := range images {
+		if !evict[img.ID] {
+			kept = append(kept, img)
+		}
+	}
+	return kept, evicted
+}
+
 // determineSyncAction decides what to do with an image during sync.
 func (s *ImageStore) determineSyncAction(img provider.Image, activeQueryIDs map[string]bool, targetFlags map[string]bool) ImageSyncAction {
-	isProtected := img.IsFavorited || img.Provider == "Favorites" || strings.Contains(img.ID, "_favorite_images_")
+	isProtected := isFavoriteImage(img)
 
 	// Strict Mode Check
 	if activeQueryIDs != nil && !isProtected {
@@ -856,7 +1322,7 @@ func (s *ImageStore) determineSyncAction(img provider.Image, activeQueryIDs map[
 
 func (s *ImageStore) hasIncompatibleFlags(img provider.Image) bool {
 	for k, v := range img.ProcessingFlags {
-		if v && strings.HasPrefix(k, "incompatible:") {
+		if v && (strings.HasPrefix(k, "incompatible:") || strings.HasPrefix(k, "lowquality:") || strings.HasPrefix(k, "duplicate:")) {
 			return true
 		}
 	}
@@ -866,33 +1332,42 @@ func (s *ImageStore) hasIncompatibleFlags(img provider.Image) bool {
 func (s *ImageStore) masterFileExists(id string) bool {
 	statFunc := s.getStatFunc()
 
-	// Check JPG
-	masterPath, err := s.fm.GetMasterPath(id, ".jpg")
-	if err == nil {
-		if _, err := statFunc(masterPath); err == nil {
-			return true
+	// Masters keep the extension of their source format (e.g. .jpeg for Pexels, .heic for local photos)
+	for _, ext := range imagecodec.SourceExts() {
+		masterPath, err := s.fm.GetMasterPath(id, ext)
+		if err != nil {
+			return false
 		}
-	}
-
-	// Check JPEG (Critical for Pexels which uses .jpeg)
-	masterPath, err = s.fm.GetMasterPath(id, ".jpeg")
-	if err == nil {
 		if _, err := statFunc(masterPath); err == nil {
 			return true
 		}
 	}
 
-	// Check PNG
-	masterPath, err = s.fm.GetMasterPath(id, ".png")
-	if err == nil {
-		if _, err := statFunc(masterPath); err == nil {
+	return false
+}
+
+// resultTagPrefixes start the tags (e.g. "Upscaled:1920x1080", "lowquality:blur") that record how
+// an image or its derivatives came out rather than which processing mode made them.
+var resultTagPrefixes = []string{"incompatible:", "VirtualFramed:", "Upscaled:", "lowquality:", "duplicate:"}
+
+func isResultTag(key string) bool {
+	for _, prefix := range resultTagPrefixes {
+		if strings.HasPrefix(key, prefix) {
 			return true
 		}
 	}
-
 	return false
 }
 
+// setResultFlag records a per-resolution result tag, or removes it when it no longer applies.
+func setResultFlag(flags map[string]bool, key string, on bool) {
+	if on {
+		flags[key] = true
+	} else {
+		delete(flags, key)
+	}
+}
+
 func (s *ImageStore) flagsMatch(img provider.Image, target map[string]bool) bool {
 	// Compare only the processing-mode keys from `target`.
 	// img.ProcessingFlags may also contain "incompatible:<WxH>" metadata tags
@@ -906,7 +1381,7 @@ func (s *ImageStore) flagsMatch(img provider.Image, target map[string]bool) bool
 	// Reverse check: ensure the image doesn't have processing-mode keys
 	// that aren't in target (guards against future flag additions).
 	for k, v := range img.ProcessingFlags {
-		if strings.HasPrefix(k, "incompatible:") {
+		if isResultTag(k) {
 			continue // skip metadata tags
 		}
 		if target[k] != v {
@@ -920,6 +1395,7 @@ func (s *ImageStore) rebuildInternalStateLocked() {
 	s.idSet = make(map[string]bool)
 	s.pathSet = make(map[string]int)
 	s.resolutionBuckets = make(map[string][]string)
+	s.hashes = make(map[string]uint64)
 	s.seenCount = 0
 	for i, img := range s.images {
 		s.idSet[img.ID] = true
@@ -932,6 +1408,7 @@ func (s *ImageStore) rebuildInternalStateLocked() {
 		for res := range img.DerivativePaths {
 			s.resolutionBuckets[res] = append(s.resolutionBuckets[res], img.ID)
 		}
+		s.indexHashLocked(img)
 	}
 }
 
@@ -955,10 +1432,23 @@ func (s *ImageStore) WaitTimeout(timeout time.Duration) bool {
 	return true
 }
 
-// Close closes the underlying FileManager if present.
+// Close writes any pending changes and closes the database; a later change opens it again. The
+// FileManager is left to its owner.
 func (s *ImageStore) Close() {
-	if s.fm != nil {
-		s.fm.Close()
+	s.saveMu.Lock()
+	if s.saveTimer != nil {
+		s.saveTimer.Stop()
+	}
+	s.saveMu.Unlock()
+	s.SaveCache()
+
+	s.mu.RLock()
+	db := s.db
+	s.mu.RUnlock()
+	if db != nil {
+		if err := db.Close(); err != nil {
+			log.Printf("Store: Failed to close the image database: %v", err)
+		}
 	}
 }
 
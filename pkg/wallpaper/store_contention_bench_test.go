@@ -0,0 +1,93 @@
+package wallpaper
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+)
+
+var benchResolutions = []string{"1920x1080", "2560x1440", "3440x1440", "3840x2160"}
+
+// newBenchStore returns an in-memory store holding n images with derivatives for every benchmark resolution.
+func newBenchStore(n int) *ImageStore {
+	store := NewImageStore()
+	store.SetAsyncSave(false)
+	for i := range n {
+		store.Add(benchImage(fmt.Sprintf("bench_%d", i)))
+	}
+	return store
+}
+
+func benchImage(id string) provider.Image {
+	paths := make(map[string]string, len(benchResolutions))
+	for _, res := range benchResolutions {
+		paths[res] = "/fitted/" + res + "/" + id + ".jpg"
+	}
+	return provider.Image{ID: id, Provider: "wallhaven", FilePath: "/" + id + ".jpg", DerivativePaths: paths}
+}
+
+// BenchmarkStoreContention measures what a MonitorController pays to pick its next wallpaper
+// (GetIDsForResolution, then GetByID) while a fast-fetching provider keeps the pipeline writing:
+// new images arrive and existing ones are re-processed without pause. Each op is one pick.
+func BenchmarkStoreContention(b *testing.B) {
+	for _, monitors := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("monitors=%d", monitors), func(b *testing.B) {
+			store := newBenchStore(2000)
+
+			var stop atomic.Bool
+			var writes atomic.Int64
+			var writer sync.WaitGroup
+			writer.Add(1)
+			go func() {
+				defer writer.Done()
+				for i := 0; !stop.Load(); i++ {
+					if i%2 == 0 {
+						store.Add(benchImage(fmt.Sprintf("fetched_%d", i)))
+					} else {
+						store.replace(benchImage(fmt.Sprintf("bench_%d", i%2000)))
+					}
+					writes.Add(1)
+				}
+			}()
+
+			b.ResetTimer()
+			var readers sync.WaitGroup
+			for m := range monitors {
+				readers.Add(1)
+				go func() {
+					defer readers.Done()
+					res := benchResolutions[m%len(benchResolutions)]
+					for i := m; i < b.N; i += monitors {
+						ids := store.GetIDsForResolution(res)
+						if _, ok := store.GetByID(ids[i%len(ids)]); !ok {
+							b.Errorf("image %s missing", ids[i%len(ids)])
+							return
+						}
+					}
+				}()
+			}
+			readers.Wait()
+			b.StopTimer()
+
+			stop.Store(true)
+			writer.Wait()
+			b.ReportMetric(float64(writes.Load())/b.Elapsed().Seconds(), "writes/s")
+		})
+	}
+}
+
+// BenchmarkStoreWrite measures the cost of a pipeline write, including publishing the snapshot.
+func BenchmarkStoreWrite(b *testing.B) {
+	for _, size := range []int{500, 5000} {
+		b.Run(fmt.Sprintf("images=%d", size), func(b *testing.B) {
+			store := newBenchStore(size)
+			b.ResetTimer()
+			for i := range b.N {
+				store.replace(benchImage(fmt.Sprintf("bench_%d", i%size)))
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package wallpaper
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Buckets of the image database. Each index bucket holds one nested bucket per provider, source
+// query or resolution, whose keys are the IDs of the images in it.
+var (
+	imagesBucket    = []byte("images")
+	providerIndex   = []byte("idx_provider")
+	queryIndex      = []byte("idx_query")
+	resolutionIndex = []byte("idx_resolution")
+)
+
+// imageDBLockTimeout bounds how long opening the database waits for another process holding it.
+const imageDBLockTimeout = 5 * time.Second
+
+// imageDB persists the ImageStore in an embedded bbolt database: one record per image, so a save
+// writes only the images that changed, plus secondary indexes by provider, source query and
+// resolution that are kept in step within the same transaction.
+//
+// The database is opened on first use and held until Close, which the store calls on shutdown.
+// bbolt locks the file while it is open, so only one store can use it at a time.
+type imageDB struct {
+	path string
+
+	mu sync.Mutex
+	db *bolt.DB // nil until first use and after Close
+}
+
+// imageRecord is the stored form of an image. Seq preserves the store's order across restarts.
+type imageRecord struct {
+	Seq   uint64         `json:"seq"`
+	Image provider.Image `json:"image"`
+}
+
+// dbPathFor returns where the database for the given legacy JSON cache file lives: beside it, with
+// a .db extension.
+func dbPathFor(cacheFile string) string {
+	return strings.TrimSuffix(cacheFile, filepath.Ext(cacheFile)) + ".db"
+}
+
+// Exists reports whether the database file has been created.
+func (d *imageDB) Exists() bool {
+	_, err := os.Stat(d.path)
+	return err == nil
+}
+
+// open returns the database handle, opening the file on first use. Unless create is set, a
+// database that does not exist yet is left uncreated and nil is returned.
+func (d *imageDB) open(create bool) (*bolt.DB, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.db != nil {
+		return d.db, nil
+	}
+	if !create && !d.Exists() {
+		return nil, nil
+	}
+	db, err := bolt.Open(d.path, 0600, &bolt.Options{Timeout: imageDBLockTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("open image database: %w", err)
+	}
+	d.db = db
+	return db, nil
+}
+
+// Close closes the database handle, if open. The next transaction opens it again.
+func (d *imageDB) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.db == nil {
+		return nil
+	}
+	err := d.db.Close()
+	d.db = nil
+	return err
+}
+
+func (d *imageDB) update(fn func(tx *bolt.Tx) error) error {
+	db, err := d.open(true)
+	if err != nil {
+		return err
+	}
+	return db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{imagesBucket, providerIndex, queryIndex, resolutionIndex} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return fn(tx)
+	})
+}
+
+// view runs fn in a read-only transaction. A database that does not exist yet reads as empty.
+func (d *imageDB) view(fn func(tx *bolt.Tx) error) error {
+	db, err := d.open(false)
+	if err != nil || db == nil {
+		return err
+	}
+	return db.View(fn)
+}
+
+// Load returns all stored images in store order.
+func (d *imageDB) Load() ([]provider.Image, error) {
+	var records []imageRecord
+	err := d.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket(imagesBucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var rec imageRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("decode image %s: %w", k, err)
+			}
+			records = append(records, rec)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(records, func(a, b imageRecord) int {
+		return cmp.Compare(a.Seq, b.Seq)
+	})
+	images := make([]provider.Image, len(records))
+	for i, rec := range records {
+		images[i] = rec.Image
+	}
+	return images, nil
+}
+
+// Apply writes puts and removes the images with the given IDs in one transaction. A new image is
+// ordered after every image already stored; an updated one keeps its place.
+func (d *imageDB) Apply(puts []provider.Image, deletes []string) error {
+	return d.update(func(tx *bolt.Tx) error {
+		for _, id := range deletes {
+			if err := deleteImage(tx, id); err != nil {
+				return err
+			}
+		}
+		for _, img := range puts {
+			if err := putImage(tx, img, 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Rewrite replaces the whole database with images, in the given order.
+func (d *imageDB) Rewrite(images []provider.Image) error {
+	return d.update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{imagesBucket, providerIndex, queryIndex, resolutionIndex} {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		for i, img := range images {
+			if err := putImage(tx, img, uint64(i+1)); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(imagesBucket).SetSequence(uint64(len(images)))
+	})
+}
+
+// IDsByProvider returns the IDs of the stored images from the given provider.
+func (d *imageDB) IDsByProvider(name string) ([]string, error) {
+	return d.indexLookup(providerIndex, name)
+}
+
+// IDsByQuery returns the IDs of the stored images fetched by the given source query.
+func (d *imageDB) IDsByQuery(queryID string) ([]string, error) {
+	return d.indexLookup(queryIndex, queryID)
+}
+
+// IDsByResolution returns the IDs of the stored images with a derivative for the given "WxH" resolution.
+func (d *imageDB) IDsByResolution(resolution string) ([]string, error) {
+	return d.indexLookup(resolutionIndex, resolution)
+}
+
+func (d *imageDB) indexLookup(index []byte, value string) ([]string, error) {
+	var ids []string
+	err := d.view(func(tx *bolt.Tx) error {
+		idx := tx.Bucket(index)
+		if idx == nil {
+			return nil
+		}
+		b := idx.Bucket([]byte(value))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, _ []byte) error {
+			ids = append(ids, string(k))
+			return nil
+		})
+	})
+	return ids, err
+}
+
+// ResolutionBuckets returns the IDs of the stored images for every "WxH" resolution in the index.
+func (d *imageDB) ResolutionBuckets() (map[string][]string, error) {
+	buckets := make(map[string][]string)
+	err := d.view(func(tx *bolt.Tx) error {
+		idx := tx.Bucket(resolutionIndex)
+		if idx == nil {
+			return nil
+		}
+		return idx.ForEachBucket(func(res []byte) error {
+			return idx.Bucket(res).ForEach(func(k, _ []byte) error {
+				buckets[string(res)] = append(buckets[string(res)], string(k))
+				return nil
+			})
+		})
+	})
+	return buckets, err
+}
+
+// putImage stores img and indexes it, replacing any earlier record with the same ID. seq 0 keeps
+// the earlier record's place, or orders a new image last.
+func putImage(tx *bolt.Tx, img provider.Image, seq uint64) error {
+	b := tx.Bucket(imagesBucket)
+	if old, ok, err := getRecord(b, img.ID); err != nil {
+		return err
+	} else if ok {
+		if seq == 0 {
+			seq = old.Seq
+		}
+		if err := updateIndexes(tx, old.Image, (*bolt.Bucket).Delete); err != nil {
+			return err
+		}
+	}
+	if seq == 0 {
+		var err error
+		if seq, err = b.NextSequence(); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(imageRecord{Seq: seq, Image: img})
+	if err != nil {
+		return fmt.Errorf("encode image %s: %w", img.ID, err)
+	}
+	if err := b.Put([]byte(img.ID), data); err != nil {
+		return err
+	}
+	return updateIndexes(tx, img, func(b *bolt.Bucket, id []byte) error {
+		return b.Put(id, []byte{})
+	})
+}
+
+// deleteImage removes the record and index entries of the image with the given ID, if stored.
+func deleteImage(tx *bolt.Tx, id string) error {
+	b := tx.Bucket(imagesBucket)
+	old, ok, err := getRecord(b, id)
+	if err != nil || !ok {
+		return err
+	}
+	if err := updateIndexes(tx, old.Image, (*bolt.Bucket).Delete); err != nil {
+		return err
+	}
+	return b.Delete([]byte(id))
+}
+
+func getRecord(b *bolt.Bucket, id string) (imageRecord, bool, error) {
+	data := b.Get([]byte(id))
+	if data == nil {
+		return imageRecord{}, false, nil
+	}
+	var rec imageRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return imageRecord{}, false, fmt.Errorf("decode image %s: %w", id, err)
+	}
+	return rec, true, nil
+}
+
+// updateIndexes applies op to img's ID in every index bucket img belongs to. Nested buckets are
+// created on demand and dropped once empty.
+func updateIndexes(tx *bolt.Tx, img provider.Image, op func(b *bolt.Bucket, id []byte) error) error {
+	entries := map[string][]string{
+		string(providerIndex):   {img.Provider},
+		string(queryIndex):      {img.SourceQueryID},
+		string(resolutionIndex): nil,
+	}
+	for res := range img.DerivativePaths {
+		entries[string(resolutionIndex)] = append(entries[string(resolutionIndex)], res)
+	}
+
+	for index, values := range entries {
+		idx := tx.Bucket([]byte(index))
+		for _, value := range values {
+			if value == "" {
+				continue
+			}
+			b, err := idx.CreateBucketIfNotExists([]byte(value))
+			if err != nil {
+				return err
+			}
+			if err := op(b, []byte(img.ID)); err != nil {
+				return err
+			}
+			if k, _ := b.Cursor().First(); k == nil {
+				if err := idx.DeleteBucket([]byte(value)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readLegacyCache decodes the JSON cache file the store was persisted in before the database.
+func readLegacyCache(path string) ([]provider.Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var images []provider.Image
+	if err := json.Unmarshal(data, &images); err != nil {
+		return nil, fmt.Errorf("decode legacy cache: %w", err)
+	}
+	return images, nil
+}
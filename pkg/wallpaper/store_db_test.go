@@ -0,0 +1,237 @@
+package wallpaper
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestImageDB_ApplyAndIndexes(t *testing.T) {
+	db := &imageDB{path: filepath.Join(t.TempDir(), "images.db")}
+	assert.False(t, db.Exists())
+	images, err := db.Load()
+	require.NoError(t, err)
+	assert.Empty(t, images, "a missing database reads as empty")
+
+	require.NoError(t, db.Apply([]provider.Image{
+		{ID: "Wallhaven_1", Provider: "Wallhaven", SourceQueryID: "q1", DerivativePaths: map[string]string{"1920x1080": "/a.jpg"}},
+		{ID: "Wallhaven_2", Provider: "Wallhaven", SourceQueryID: "q2"},
+		{ID: "NASA_1", Provider: "NASA", SourceQueryID: "q3", DerivativePaths: map[string]string{"1920x1080": "/c.jpg", "3440x1440": "/c2.jpg"}},
+	}, nil))
+	assert.True(t, db.Exists())
+
+	ids, err := db.IDsByProvider("Wallhaven")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Wallhaven_1", "Wallhaven_2"}, ids)
+	ids, _ = db.IDsByQuery("q3")
+	assert.Equal(t, []string{"NASA_1"}, ids)
+	ids, _ = db.IDsByResolution("1920x1080")
+	assert.ElementsMatch(t, []string{"Wallhaven_1", "NASA_1"}, ids)
+
+	// An update moves the image between indexes but keeps its place; a delete drops it everywhere
+	require.NoError(t, db.Apply([]provider.Image{
+		{ID: "Wallhaven_1", Provider: "Wallhaven", SourceQueryID: "q1", DerivativePaths: map[string]string{"3440x1440": "/a2.jpg"}},
+		{ID: "Unsplash_1", Provider: "Unsplash"},
+	}, []string{"NASA_1", "unknown"}))
+
+	ids, _ = db.IDsByResolution("1920x1080")
+	assert.Empty(t, ids)
+	ids, _ = db.IDsByResolution("3440x1440")
+	assert.Equal(t, []string{"Wallhaven_1"}, ids)
+	ids, _ = db.IDsByProvider("NASA")
+	assert.Empty(t, ids)
+
+	images, err = db.Load()
+	require.NoError(t, err)
+	require.Len(t, images, 3)
+	assert.Equal(t, []string{"Wallhaven_1", "Wallhaven_2", "Unsplash_1"}, []string{images[0].ID, images[1].ID, images[2].ID})
+	assert.Equal(t, "/a2.jpg", images[0].DerivativePaths["3440x1440"])
+}
+
+func TestImageDB_Rewrite(t *testing.T) {
+	db := &imageDB{path: filepath.Join(t.TempDir(), "images.db")}
+	require.NoError(t, db.Apply([]provider.Image{{ID: "a", Provider: "P"}, {ID: "b", Provider: "P"}}, nil))
+
+	require.NoError(t, db.Rewrite([]provider.Image{{ID: "c", Provider: "Q"}, {ID: "a", Provider: "Q"}}))
+	images, err := db.Load()
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	assert.Equal(t, "c", images[0].ID)
+	assert.Equal(t, "a", images[1].ID)
+	ids, _ := db.IDsByProvider("P")
+	assert.Empty(t, ids)
+
+	// New images still go last after a rewrite
+	require.NoError(t, db.Apply([]provider.Image{{ID: "d"}}, nil))
+	images, _ = db.Load()
+	assert.Equal(t, "d", images[2].ID)
+
+	require.NoError(t, db.Rewrite(nil))
+	images, _ = db.Load()
+	assert.Empty(t, images)
+}
+
+func TestStore_PersistsChangesPerImage(t *testing.T) {
+	tmpDir := t.TempDir()
+	cacheFile := filepath.Join(tmpDir, "cache.json")
+	store := NewImageStore()
+	store.SetAsyncSave(false)
+	store.SetFileManager(NewFileManager(tmpDir), cacheFile)
+	t.Cleanup(store.Close)
+
+	for _, id := range []string{"img1", "img2", "img3"} {
+		require.True(t, store.Add(provider.Image{ID: id, SourceQueryID: "q"}))
+	}
+	store.SetFavorited("img2", true)
+	store.SetTuningOptions("img3", "1920x1080", provider.TuningOptions{TightCrop: true})
+	store.Remove("img1")
+	store.Close()
+
+	reloaded := NewImageStore()
+	reloaded.SetFileManager(NewFileManager(tmpDir), cacheFile)
+	t.Cleanup(reloaded.Close)
+	require.NoError(t, reloaded.LoadCache())
+	require.Equal(t, 2, reloaded.Count())
+	first, _ := reloaded.Get(0)
+	assert.Equal(t, "img2", first.ID)
+	assert.True(t, first.IsFavorited)
+	img3, _ := reloaded.GetByID("img3")
+	assert.True(t, img3.GetTuning("1920x1080").TightCrop)
+
+	ids, err := reloaded.db.IDsByQuery("q")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"img2", "img3"}, ids)
+
+	// Clear empties the database
+	reloaded.Close()
+	store.Clear()
+	store.Close()
+	reloaded = NewImageStore()
+	reloaded.SetFileManager(NewFileManager(tmpDir), cacheFile)
+	t.Cleanup(reloaded.Close)
+	require.NoError(t, reloaded.LoadCache())
+	assert.Zero(t, reloaded.Count())
+}
+
+func TestStore_DebouncedSaveWritesLatestState(t *testing.T) {
+	tmpDir := t.TempDir()
+	cacheFile := filepath.Join(tmpDir, "cache.json")
+	store := NewImageStore()
+	store.SetDebounceDuration(time.Hour) // Saved explicitly below
+	store.SetFileManager(NewFileManager(tmpDir), cacheFile)
+	t.Cleanup(store.Close)
+
+	require.True(t, store.Add(provider.Image{ID: "img1"}))
+	require.True(t, store.Add(provider.Image{ID: "img2"}))
+	store.MarkSeen("unknown.jpg")
+	store.SetFavorited("img1", true)
+	store.Remove("img2")
+	store.SaveCache()
+
+	images, err := store.db.Load()
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	assert.Equal(t, "img1", images[0].ID)
+	assert.True(t, images[0].IsFavorited)
+}
+
+func TestStore_MigratesLegacyJSONCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	cacheFile := filepath.Join(tmpDir, "image_cache_map.json")
+	legacy := []provider.Image{
+		{ID: "Wallhaven_1", Provider: "Wallhaven", DerivativePaths: map[string]string{"1920x1080": "/a.jpg"}},
+		{ID: "NASA_1", Provider: "NASA", Seen: true},
+	}
+	data, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cacheFile, data, 0644))
+
+	store := NewImageStore()
+	store.SetFileManager(NewFileManager(tmpDir), cacheFile)
+	t.Cleanup(store.Close)
+	require.NoError(t, store.LoadCache())
+	assert.Equal(t, 2, store.Count())
+	assert.Equal(t, 1, store.SeenCount())
+	assert.Equal(t, []string{"Wallhaven_1"}, store.GetIDsForResolution("1920x1080"))
+
+	_, err = os.Stat(cacheFile)
+	assert.True(t, os.IsNotExist(err), "the JSON cache is retired once imported")
+	_, err = os.Stat(cacheFile + ".migrated")
+	assert.NoError(t, err)
+
+	// The next start reads the database
+	store.Close()
+	reloaded := NewImageStore()
+	reloaded.SetFileManager(NewFileManager(tmpDir), cacheFile)
+	t.Cleanup(reloaded.Close)
+	require.NoError(t, reloaded.LoadCache())
+	assert.Equal(t, legacy[0].ID, reloaded.List()[0].ID)
+	assert.Equal(t, legacy[1].ID, reloaded.List()[1].ID)
+}
+
+func TestStore_QueryAndBucketsUseDatabaseIndexes(t *testing.T) {
+	tmpDir := t.TempDir()
+	cacheFile := filepath.Join(tmpDir, "cache.json")
+	store := NewImageStore()
+	store.SetAsyncSave(false)
+	store.SetFileManager(NewFileManager(tmpDir), cacheFile)
+	t.Cleanup(store.Close)
+	for _, img := range []provider.Image{
+		{ID: "Wallhaven_b", Provider: "Wallhaven", SourceQueryID: "q1", DerivativePaths: map[string]string{"1920x1080": "/b.jpg"}},
+		{ID: "Wallhaven_a", Provider: "Wallhaven", SourceQueryID: "q2", DerivativePaths: map[string]string{"1920x1080": "/a.jpg"}},
+		{ID: "NASA_c", Provider: "NASA", SourceQueryID: "q1"},
+	} {
+		require.True(t, store.Add(img))
+	}
+	require.Same(t, store.view(), store.persisted.Load())
+
+	ids := func(q StoreQuery) []string {
+		var ids []string
+		for _, img := range store.Query(q).Images {
+			ids = append(ids, img.ID)
+		}
+		return ids
+	}
+	// Index hits come back in store order, not the index's ID order
+	assert.Equal(t, []string{"Wallhaven_b", "Wallhaven_a"}, ids(StoreQuery{Provider: "Wallhaven"}))
+	assert.Equal(t, []string{"Wallhaven_b", "NASA_c"}, ids(StoreQuery{SourceQueryID: "q1"}))
+	assert.Equal(t, []string{"Wallhaven_b", "Wallhaven_a"}, ids(StoreQuery{Resolution: "1920x1080"}))
+	assert.Equal(t, []string{"NASA_c"}, ids(StoreQuery{SourceQueryID: "q1", Provider: "NASA"}))
+
+	// While the database is current, the index answers: an entry taken out of it is not found
+	require.NoError(t, store.db.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(providerIndex).Bucket([]byte("NASA")).Delete([]byte("NASA_c"))
+	}))
+	assert.Empty(t, ids(StoreQuery{Provider: "NASA"}))
+
+	// Changes that are not saved yet are found by scanning
+	store.SetAsyncSave(true)
+	store.SetDebounceDuration(time.Hour)
+	store.SetFavorited("Wallhaven_a", true)
+	assert.Equal(t, []string{"NASA_c"}, ids(StoreQuery{Provider: "NASA"}))
+
+	// A restart takes the resolution buckets from the index, in store order
+	store.Close()
+	reloaded := NewImageStore()
+	reloaded.SetFileManager(NewFileManager(tmpDir), cacheFile)
+	t.Cleanup(reloaded.Close)
+	require.NoError(t, reloaded.LoadCache())
+	assert.Equal(t, []string{"Wallhaven_b", "Wallhaven_a"}, reloaded.GetIDsForResolution("1920x1080"))
+	assert.Same(t, reloaded.view(), reloaded.persisted.Load())
+}
+
+func TestStore_LoadCacheWithoutAnyCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := NewImageStore()
+	store.SetFileManager(NewFileManager(tmpDir), filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
+	require.NoError(t, store.LoadCache())
+	assert.Zero(t, store.Count())
+}
@@ -0,0 +1,143 @@
+package wallpaper
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const paintingHash = 0xA5A5_F00F_1234_5678
+
+func newDuplicateStore() *ImageStore {
+	store := NewImageStore()
+	store.SetAsyncSave(false)
+	store.SetDuplicateMaxDistance(4)
+	return store
+}
+
+func TestStoreDuplicates_KeepsLargerCopy(t *testing.T) {
+	store := newDuplicateStore()
+
+	met := provider.Image{
+		ID: "MetMuseum_1", Provider: "MetMuseum", Width: 2000, Height: 1500, PerceptualHash: paintingHash,
+		DerivativePaths: map[string]string{"1920x1080": "/met.jpg"},
+		Tuning:          map[string]provider.TuningOptions{"1920x1080": {Anchor: provider.AnchorTopCenter}},
+	}
+	require.True(t, store.Add(met))
+
+	// A larger copy differing in two bits replaces it and takes over its tuning
+	wiki := provider.Image{
+		ID: "Wikimedia_1", Provider: "Wikimedia", Width: 6000, Height: 4500, PerceptualHash: paintingHash ^ 0b11,
+		DerivativePaths: map[string]string{"1920x1080": "/wiki.jpg"},
+	}
+	require.True(t, store.Add(wiki))
+
+	kept, _ := store.GetByID("Wikimedia_1")
+	assert.Empty(t, duplicateOf(kept))
+	assert.Equal(t, provider.AnchorTopCenter, kept.GetAnchor("1920x1080"))
+
+	dropped, _ := store.GetByID("MetMuseum_1")
+	assert.Equal(t, "Wikimedia_1", duplicateOf(dropped), "the dropped copy stays as a tagged entry")
+	assert.Empty(t, dropped.DerivativePaths)
+	assert.Equal(t, []string{"Wikimedia_1"}, store.GetIDsForResolution("1920x1080"))
+
+	// A smaller copy arriving later is the one dropped
+	wallhaven := provider.Image{
+		ID: "wallhaven_1", Provider: "wallhaven", Width: 1920, Height: 1080, PerceptualHash: paintingHash ^ 0b1000,
+		DerivativePaths: map[string]string{"1920x1080": "/wallhaven.jpg"},
+	}
+	require.True(t, store.Add(wallhaven))
+	dropped, _ = store.GetByID("wallhaven_1")
+	assert.Equal(t, "Wikimedia_1", duplicateOf(dropped))
+	assert.Equal(t, []string{"Wikimedia_1"}, store.GetIDsForResolution("1920x1080"))
+
+	found, ok := store.FindDuplicate(provider.Image{ID: "new", PerceptualHash: paintingHash ^ 0b1111})
+	require.True(t, ok)
+	assert.Equal(t, "Wikimedia_1", found.ID, "only kept copies are matched")
+
+	_, ok = store.FindDuplicate(provider.Image{ID: "new", PerceptualHash: paintingHash ^ 0b11111_00})
+	assert.False(t, ok, "seven bits apart is a different picture")
+}
+
+func TestStoreDuplicates_KeepsFavorite(t *testing.T) {
+	store := newDuplicateStore()
+
+	fav := provider.Image{ID: "MetMuseum_1", Width: 1000, Height: 800, PerceptualHash: paintingHash, IsFavorited: true}
+	require.True(t, store.Add(fav))
+	require.True(t, store.Add(provider.Image{
+		ID: "Wikimedia_1", Width: 6000, Height: 4800, PerceptualHash: paintingHash,
+		Tuning: map[string]provider.TuningOptions{"3440x1440": {TightCrop: true}},
+	}))
+
+	kept, _ := store.GetByID("MetMuseum_1")
+	assert.True(t, kept.IsFavorited)
+	assert.Empty(t, duplicateOf(kept))
+	assert.True(t, kept.GetTuning("3440x1440").TightCrop, "the favorite takes over the other copy's tuning")
+
+	dropped, _ := store.GetByID("Wikimedia_1")
+	assert.Equal(t, "MetMuseum_1", duplicateOf(dropped))
+}
+
+func TestStoreDuplicates_TwoFavoritesKept(t *testing.T) {
+	store := newDuplicateStore()
+	require.True(t, store.Add(provider.Image{ID: "a", PerceptualHash: paintingHash, IsFavorited: true}))
+	require.True(t, store.Add(provider.Image{ID: "b", PerceptualHash: paintingHash, IsFavorited: true, Width: 100, Height: 100}))
+
+	for _, id := range []string{"a", "b"} {
+		img, _ := store.GetByID(id)
+		assert.Empty(t, duplicateOf(img), id)
+	}
+}
+
+func TestStoreDuplicates_Disabled(t *testing.T) {
+	store := newDuplicateStore()
+	store.SetDuplicateMaxDistance(0)
+	require.True(t, store.Add(provider.Image{ID: "a", PerceptualHash: paintingHash}))
+	require.True(t, store.Add(provider.Image{ID: "b", PerceptualHash: paintingHash, Width: 100, Height: 100}))
+
+	img, _ := store.GetByID("a")
+	assert.Empty(t, duplicateOf(img))
+
+	// Unhashed images are never duplicates
+	store.SetDuplicateMaxDistance(4)
+	_, ok := store.FindDuplicate(provider.Image{ID: "c"})
+	assert.False(t, ok)
+}
+
+func TestStoreDuplicates_IndexSurvivesReloadAndRemove(t *testing.T) {
+	tmpDir := t.TempDir()
+	cacheFile := filepath.Join(tmpDir, "cache.json")
+	store := newDuplicateStore()
+	store.SetFileManager(NewFileManager(tmpDir), cacheFile)
+	t.Cleanup(store.Close)
+	require.True(t, store.Add(provider.Image{ID: "a", PerceptualHash: paintingHash}))
+	require.True(t, store.Add(provider.Image{ID: "rejected", PerceptualHash: paintingHash ^ 1, ProcessingFlags: map[string]bool{"lowquality:blur": true}}))
+	store.Close()
+
+	reloaded := newDuplicateStore()
+	reloaded.SetFileManager(NewFileManager(tmpDir), cacheFile)
+	t.Cleanup(reloaded.Close)
+	require.NoError(t, reloaded.LoadCache())
+	found, ok := reloaded.FindDuplicate(provider.Image{ID: "new", PerceptualHash: paintingHash ^ 1})
+	require.True(t, ok)
+	assert.Equal(t, "a", found.ID, "images rejected by the quality gate are not matched")
+
+	reloaded.Remove("a")
+	_, ok = reloaded.FindDuplicate(provider.Image{ID: "new", PerceptualHash: paintingHash})
+	assert.False(t, ok)
+}
+
+func TestPreferredCopy(t *testing.T) {
+	small := provider.Image{Width: 1920, Height: 1080}
+	large := provider.Image{Width: 3840, Height: 2160}
+	assert.True(t, preferredCopy(large, small))
+	assert.False(t, preferredCopy(small, large))
+	assert.False(t, preferredCopy(small, small), "ties keep the stored copy")
+
+	small.IsFavorited = true
+	assert.True(t, preferredCopy(small, large))
+	assert.True(t, preferredCopy(provider.Image{Provider: "Favorites"}, large))
+}
@@ -0,0 +1,195 @@
+package wallpaper
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+)
+
+// BoolFilter restricts a query on a yes/no property of an image.
+type BoolFilter int
+
+const (
+	FilterAny BoolFilter = iota // Do not filter
+	FilterYes                   // Only images where the property is set
+	FilterNo                    // Only images where it is not
+)
+
+// matches reports whether v passes the filter.
+func (f BoolFilter) matches(v bool) bool {
+	switch f {
+	case FilterYes:
+		return v
+	case FilterNo:
+		return !v
+	default:
+		return true
+	}
+}
+
+// ImageSort is the order of query results.
+type ImageSort int
+
+const (
+	SortStoreOrder ImageSort = iota // Order the images entered the store
+	SortFetched                     // Fetch time; images fetched before it was recorded come first
+	SortTitle                       // Title, case-insensitive; untitled images come first
+)
+
+// StoreQuery selects, orders and pages images from the store. The zero value matches every image
+// in store order.
+type StoreQuery struct {
+	Provider      string          // Provider name, e.g. "Wallhaven"
+	SourceQueryID string          // ID of the query that fetched the image
+	Favorited     BoolFilter      // IsFavorited
+	Seen          BoolFilter      // Seen
+	Flags         map[string]bool // Processing flags the image must have with these values; false also matches an absent flag
+	Resolution    string          // "WxH": only images with a derivative for it
+	Text          string          // Case-insensitive substring of the Title or Artist
+
+	Sort       ImageSort
+	Descending bool // Reverse the sort; images that sort equal keep store order
+
+	Offset int // Matching images to skip
+	Limit  int // Maximum images to return (0 = no limit)
+}
+
+// StoreQueryResult is one page of query results.
+type StoreQueryResult struct {
+	Images []provider.Image
+	Total  int // Images matching the query, across all pages
+}
+
+// Query returns the images matching q, in q's order and page. Like the other readers, it works on
+// the current snapshot and never blocks writers.
+func (s *ImageStore) Query(q StoreQuery) StoreQueryResult {
+	snap := s.view()
+
+	text := strings.ToLower(q.Text)
+	var matched []*provider.Image
+	for _, img := range s.candidates(snap, &q) {
+		if q.matches(img, text) {
+			matched = append(matched, img)
+		}
+	}
+
+	if cmpFn := q.compare(); cmpFn != nil {
+		slices.SortStableFunc(matched, func(a, b *provider.Image) int {
+			if q.Descending {
+				return cmpFn(b, a)
+			}
+			return cmpFn(a, b)
+		})
+	} else if q.Descending {
+		slices.Reverse(matched)
+	}
+
+	result := StoreQueryResult{Total: len(matched)}
+	page := matched[min(max(q.Offset, 0), len(matched)):]
+	if q.Limit > 0 && q.Limit < len(page) {
+		page = page[:q.Limit]
+	}
+	result.Images = make([]provider.Image, len(page))
+	for i, img := range page {
+		result.Images[i] = *img
+	}
+	return result
+}
+
+// candidates returns the images of snap that may match q, in store order. While the database holds
+// exactly snap's images, a source query, resolution or provider filter is looked up in its index
+// instead of scanning every image.
+func (s *ImageStore) candidates(snap *storeSnapshot, q *StoreQuery) []*provider.Image {
+	if snap.db == nil || s.persisted.Load() != snap {
+		return snap.images
+	}
+	var ids []string
+	var err error
+	switch {
+	case q.SourceQueryID != "":
+		ids, err = snap.db.IDsByQuery(q.SourceQueryID)
+	case q.Resolution != "":
+		ids, err = snap.db.IDsByResolution(q.Resolution)
+	case q.Provider != "":
+		ids, err = snap.db.IDsByProvider(q.Provider)
+	default:
+		return snap.images
+	}
+	// A save that started meanwhile may have changed what the index returned
+	if err != nil || s.persisted.Load() != snap {
+		return snap.images
+	}
+
+	positions := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if i := snap.indexOf(id); i >= 0 {
+			positions = append(positions, i)
+		}
+	}
+	slices.Sort(positions)
+	images := make([]*provider.Image, len(positions))
+	for j, i := range positions {
+		images[j] = snap.images[i]
+	}
+	return images
+}
+
+// matches reports whether img passes every filter of q. text is q.Text in lower case.
+func (q *StoreQuery) matches(img *provider.Image, text string) bool {
+	if q.Provider != "" && img.Provider != q.Provider {
+		return false
+	}
+	if q.SourceQueryID != "" && img.SourceQueryID != q.SourceQueryID {
+		return false
+	}
+	if !q.Favorited.matches(img.IsFavorited) || !q.Seen.matches(img.Seen) {
+		return false
+	}
+	for flag, want := range q.Flags {
+		if img.ProcessingFlags[flag] != want {
+			return false
+		}
+	}
+	if q.Resolution != "" {
+		if _, ok := img.DerivativePaths[q.Resolution]; !ok {
+			return false
+		}
+	}
+	if text != "" && !strings.Contains(strings.ToLower(img.Title), text) && !strings.Contains(strings.ToLower(img.Artist), text) {
+		return false
+	}
+	return true
+}
+
+// compare returns the ascending order of q.Sort, or nil for store order.
+func (q *StoreQuery) compare() func(a, b *provider.Image) int {
+	switch q.Sort {
+	case SortFetched:
+		return func(a, b *provider.Image) int {
+			return a.Fetched.Compare(b.Fetched)
+		}
+	case SortTitle:
+		return func(a, b *provider.Image) int {
+			return compareFold(a.Title, b.Title)
+		}
+	default:
+		return nil
+	}
+}
+
+// compareFold compares a and b like strings.Compare, ignoring case, without allocating.
+func compareFold(a, b string) int {
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if c := cmp.Compare(unicode.ToLower(ra), unicode.ToLower(rb)); c != 0 {
+			return c
+		}
+		a, b = a[na:], b[nb:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
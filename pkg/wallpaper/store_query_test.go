@@ -0,0 +1,231 @@
+package wallpaper
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	syntheticProviders = []string{"Wallhaven", "Unsplash", "Pexels", "NASA", "Favorites"}
+	syntheticTitles    = []string{"Mountain Lake", "city at night", "Aurora", "", "Desert Dunes", "aurora borealis", "Forest"}
+	syntheticArtists   = []string{"Ansel", "Hokusai", "", "Monet", "Van Gogh"}
+)
+
+// newSyntheticStore returns an in-memory store of n varied images, loaded in one go as LoadCache does.
+func newSyntheticStore(n int) *ImageStore {
+	r := rand.New(rand.NewPCG(1, 2))
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	images := make([]provider.Image, n)
+	for i := range images {
+		img := provider.Image{
+			ID:            fmt.Sprintf("img_%d", i),
+			Provider:      syntheticProviders[r.IntN(len(syntheticProviders))],
+			SourceQueryID: fmt.Sprintf("q%d", r.IntN(20)),
+			Title:         syntheticTitles[r.IntN(len(syntheticTitles))],
+			Artist:        syntheticArtists[r.IntN(len(syntheticArtists))],
+			IsFavorited:   r.IntN(10) == 0,
+			Seen:          r.IntN(2) == 0,
+			Fetched:       base.Add(time.Duration(r.IntN(1000)) * time.Hour),
+		}
+		if r.IntN(3) > 0 {
+			img.ProcessingFlags = map[string]bool{"SmartFit": true, "FaceCrop": r.IntN(2) == 0}
+		}
+		img.DerivativePaths = make(map[string]string)
+		for _, res := range benchResolutions {
+			if r.IntN(2) == 0 {
+				img.DerivativePaths[res] = "/fitted/" + res + "/" + img.ID + ".jpg"
+			}
+		}
+		images[i] = img
+	}
+
+	store := NewImageStore()
+	store.mu.Lock()
+	store.images = images
+	store.rebuildInternalStateLocked()
+	store.publishLocked(true)
+	store.mu.Unlock()
+	return store
+}
+
+// referenceQuery answers q the slow way, over List, to check Query against.
+func referenceQuery(store *ImageStore, q StoreQuery) StoreQueryResult {
+	bools := func(f BoolFilter, v bool) bool {
+		return f == FilterAny || (f == FilterYes) == v
+	}
+	var matched []provider.Image
+	for _, img := range store.List() {
+		_, hasRes := img.DerivativePaths[q.Resolution]
+		flagsOK := true
+		for flag, want := range q.Flags {
+			flagsOK = flagsOK && img.ProcessingFlags[flag] == want
+		}
+		text := strings.ToLower(q.Text)
+		if (q.Provider == "" || img.Provider == q.Provider) &&
+			(q.SourceQueryID == "" || img.SourceQueryID == q.SourceQueryID) &&
+			bools(q.Favorited, img.IsFavorited) && bools(q.Seen, img.Seen) && flagsOK &&
+			(q.Resolution == "" || hasRes) &&
+			(strings.Contains(strings.ToLower(img.Title), text) || strings.Contains(strings.ToLower(img.Artist), text)) {
+			matched = append(matched, img)
+		}
+	}
+
+	sortKey := func(img provider.Image) string {
+		switch q.Sort {
+		case SortFetched:
+			return img.Fetched.Format(time.RFC3339)
+		case SortTitle:
+			return strings.ToLower(img.Title)
+		}
+		return ""
+	}
+	slices.SortStableFunc(matched, func(a, b provider.Image) int {
+		c := strings.Compare(sortKey(a), sortKey(b))
+		if q.Descending {
+			c = -c
+		}
+		return c
+	})
+	if q.Sort == SortStoreOrder && q.Descending {
+		slices.Reverse(matched)
+	}
+
+	total := len(matched)
+	matched = matched[min(q.Offset, total):]
+	if q.Limit > 0 && q.Limit < len(matched) {
+		matched = matched[:q.Limit]
+	}
+	return StoreQueryResult{Images: matched, Total: total}
+}
+
+func TestStoreQuery_MatchesReferenceOnLargeStore(t *testing.T) {
+	store := newSyntheticStore(20000)
+	require.Equal(t, 20000, store.Count())
+
+	r := rand.New(rand.NewPCG(3, 4))
+	pick := func(values []string) string {
+		if r.IntN(2) == 0 {
+			return ""
+		}
+		return values[r.IntN(len(values))]
+	}
+	for i := range 100 {
+		q := StoreQuery{
+			Provider:   pick(syntheticProviders),
+			Favorited:  BoolFilter(r.IntN(3)),
+			Seen:       BoolFilter(r.IntN(3)),
+			Resolution: pick(benchResolutions),
+			Text:       pick([]string{"aurora", "LAKE", "gogh", "zzz"}),
+			Sort:       ImageSort(r.IntN(3)),
+			Descending: r.IntN(2) == 0,
+			Offset:     r.IntN(3) * 50,
+			Limit:      r.IntN(3) * 25,
+		}
+		if r.IntN(3) == 0 {
+			q.SourceQueryID = fmt.Sprintf("q%d", r.IntN(20))
+		}
+		if r.IntN(3) == 0 {
+			q.Flags = map[string]bool{"FaceCrop": r.IntN(2) == 0}
+		}
+
+		want := referenceQuery(store, q)
+		got := store.Query(q)
+		require.Equal(t, want.Total, got.Total, "query %d: %+v", i, q)
+		require.Equal(t, len(want.Images), len(got.Images), "query %d: %+v", i, q)
+		for j := range want.Images {
+			require.Equal(t, want.Images[j].ID, got.Images[j].ID, "query %d: %+v", i, q)
+		}
+	}
+}
+
+func TestStoreQuery_Filters(t *testing.T) {
+	store := NewImageStore()
+	store.SetAsyncSave(false)
+	store.Add(provider.Image{ID: "a", Provider: "Wallhaven", SourceQueryID: "q1", Title: "Aurora", ProcessingFlags: map[string]bool{"SmartFit": true}, DerivativePaths: map[string]string{"1920x1080": "/a.jpg"}})
+	store.Add(provider.Image{ID: "b", Provider: "NASA", SourceQueryID: "q2", Artist: "Aurora Hunter", IsFavorited: true})
+	store.Add(provider.Image{ID: "c", Provider: "Wallhaven", SourceQueryID: "q1", Title: "Dunes", Seen: true, ProcessingFlags: map[string]bool{"SmartFit": false}})
+
+	ids := func(q StoreQuery) []string {
+		var ids []string
+		for _, img := range store.Query(q).Images {
+			ids = append(ids, img.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"a", "b", "c"}, ids(StoreQuery{}))
+	assert.Equal(t, []string{"a", "c"}, ids(StoreQuery{Provider: "Wallhaven"}))
+	assert.Equal(t, []string{"b"}, ids(StoreQuery{SourceQueryID: "q2"}))
+	assert.Equal(t, []string{"b"}, ids(StoreQuery{Favorited: FilterYes}))
+	assert.Equal(t, []string{"a", "b"}, ids(StoreQuery{Seen: FilterNo}))
+	assert.Equal(t, []string{"a"}, ids(StoreQuery{Flags: map[string]bool{"SmartFit": true}}))
+	assert.Equal(t, []string{"b", "c"}, ids(StoreQuery{Flags: map[string]bool{"SmartFit": false}}), "false also matches a missing flag")
+	assert.Equal(t, []string{"a"}, ids(StoreQuery{Resolution: "1920x1080"}))
+	assert.Empty(t, ids(StoreQuery{Resolution: "3440x1440"}))
+	assert.Equal(t, []string{"a", "b"}, ids(StoreQuery{Text: "aURORA"}), "matches Title or Artist, ignoring case")
+	assert.Equal(t, []string{"c"}, ids(StoreQuery{Provider: "Wallhaven", Seen: FilterYes}))
+}
+
+func TestStoreQuery_SortAndPage(t *testing.T) {
+	store := NewImageStore()
+	store.SetAsyncSave(false)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.Add(provider.Image{ID: "a", Title: "beta", Fetched: base.Add(2 * time.Hour)})
+	store.Add(provider.Image{ID: "b", Title: "Alpha", Fetched: base})
+	store.Add(provider.Image{ID: "c", Title: "alpha", Fetched: base.Add(2 * time.Hour)})
+	store.Add(provider.Image{ID: "d", Title: "Émile", Fetched: base.Add(time.Hour)})
+
+	ids := func(res StoreQueryResult) []string {
+		var ids []string
+		for _, img := range res.Images {
+			ids = append(ids, img.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"b", "d", "a", "c"}, ids(store.Query(StoreQuery{Sort: SortFetched})))
+	assert.Equal(t, []string{"a", "c", "d", "b"}, ids(store.Query(StoreQuery{Sort: SortFetched, Descending: true})), "ties keep store order")
+	assert.Equal(t, []string{"b", "c", "a", "d"}, ids(store.Query(StoreQuery{Sort: SortTitle})))
+	assert.Equal(t, []string{"d", "c", "b", "a"}, ids(store.Query(StoreQuery{Descending: true})))
+
+	page := store.Query(StoreQuery{Sort: SortTitle, Offset: 1, Limit: 2})
+	assert.Equal(t, []string{"c", "a"}, ids(page))
+	assert.Equal(t, 4, page.Total)
+
+	page = store.Query(StoreQuery{Offset: 10, Limit: 2})
+	assert.Empty(t, page.Images)
+	assert.Equal(t, 4, page.Total)
+}
+
+func TestCompareFold(t *testing.T) {
+	assert.Zero(t, compareFold("Aurora", "aURORA"))
+	assert.Negative(t, compareFold("alpha", "Beta"))
+	assert.Negative(t, compareFold("Alp", "alpha"))
+	assert.Positive(t, compareFold("émile", "Zed"))
+	assert.Zero(t, compareFold("", ""))
+}
+
+func BenchmarkStoreQuery(b *testing.B) {
+	store := newSyntheticStore(50000)
+	queries := map[string]StoreQuery{
+		"all_page":       {Limit: 50},
+		"filtered":       {Provider: "Wallhaven", Seen: FilterNo, Resolution: "3440x1440", Limit: 50},
+		"text_by_title":  {Text: "aurora", Sort: SortTitle, Limit: 50},
+		"fetched_offset": {Sort: SortFetched, Descending: true, Offset: 10000, Limit: 50},
+	}
+	for name, q := range queries {
+		b.Run(name, func(b *testing.B) {
+			for b.Loop() {
+				store.Query(q)
+			}
+		})
+	}
+}
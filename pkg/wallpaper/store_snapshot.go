@@ -0,0 +1,85 @@
+package wallpaper
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+)
+
+// storeSnapshot is an immutable view of the ImageStore. Writers publish a new one after every
+// change (see publishLocked), and readers load the current one without taking the store lock, so
+// MonitorControllers and the tray never wait behind the pipeline.
+//
+// Nothing reachable from a published snapshot is modified afterwards: images point at copies
+// taken on publish, the bucket map is copied, and writers replace (never edit) an image's maps and
+// a bucket's ID slice.
+type storeSnapshot struct {
+	version   uint64
+	images    []*provider.Image   // Shared with the next snapshot while unchanged
+	buckets   map[string][]string // "WxH" -> IDs, as resolutionBuckets
+	seenCount int
+	db        *imageDB // Where the store is persisted, or nil
+
+	// Built on first lookup, by whichever reader needs it
+	indexOnce sync.Once
+	index     map[string]int // ID -> position in images
+}
+
+// indexOf returns the position of the image with the given ID, or -1.
+func (snap *storeSnapshot) indexOf(id string) int {
+	snap.indexOnce.Do(func() {
+		snap.index = make(map[string]int, len(snap.images))
+		for i, img := range snap.images {
+			snap.index[img.ID] = i
+		}
+	})
+	if i, ok := snap.index[id]; ok {
+		return i
+	}
+	return -1
+}
+
+// publishLocked makes the current state visible to readers as a new snapshot. Images marked
+// changed since the last publish (see markDirtyLocked), or no longer at the same position, are
+// copied; the rest are shared with the previous snapshot, so a write costs one pointer per image.
+// Pass all after changes that were not marked, such as a reload or a bulk reconcile.
+// CALLER MUST HOLD s.mu.Lock().
+func (s *ImageStore) publishLocked(all bool) {
+	prev := s.snapshot.Load()
+	var version uint64
+	if prev != nil {
+		version = prev.version + 1
+	}
+
+	images := make([]*provider.Image, len(s.images))
+	for i := range s.images {
+		id := s.images[i].ID
+		if !all && prev != nil && i < len(prev.images) && prev.images[i].ID == id && (len(s.unpublished) == 0 || !s.unpublished[id]) {
+			images[i] = prev.images[i]
+			continue
+		}
+		img := s.images[i]
+		images[i] = &img
+	}
+	s.unpublished = nil
+
+	s.snapshot.Store(&storeSnapshot{
+		version:   version,
+		images:    images,
+		buckets:   maps.Clone(s.resolutionBuckets),
+		seenCount: s.seenCount,
+		db:        s.db,
+	})
+}
+
+// view returns the current snapshot.
+func (s *ImageStore) view() *storeSnapshot {
+	return s.snapshot.Load()
+}
+
+// Version returns a number that increases with every change to the store, so callers can tell
+// whether anything changed since they last looked.
+func (s *ImageStore) Version() uint64 {
+	return s.view().version
+}
@@ -0,0 +1,153 @@
+package wallpaper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/dixieflatline76/Spice/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreSnapshot_ReadsAreIsolatedFromLaterWrites(t *testing.T) {
+	store := NewImageStore()
+	store.SetAsyncSave(false)
+	require.True(t, store.Add(provider.Image{ID: "a", DerivativePaths: map[string]string{"1920x1080": "/a.jpg"}}))
+	require.True(t, store.Add(provider.Image{ID: "b", DerivativePaths: map[string]string{"1920x1080": "/b.jpg"}}))
+	store.SetTuningOptions("a", "1920x1080", provider.TuningOptions{TightCrop: true})
+
+	before := store.view()
+	a, _ := store.GetByID("a")
+	ids := store.GetIDsForResolution("1920x1080")
+
+	// Writes after the reads touch the same maps and buckets in the writer's state
+	store.SetTuningOptions("a", "1920x1080", provider.TuningOptions{Anchor: provider.AnchorAuto})
+	store.SetDerivativePath("a", "3440x1440", "/a_uw.jpg")
+	store.Remove("a")
+	require.True(t, store.Add(provider.Image{ID: "c", DerivativePaths: map[string]string{"1920x1080": "/c.jpg"}}))
+
+	assert.True(t, a.GetTuning("1920x1080").TightCrop)
+	assert.NotContains(t, a.DerivativePaths, "3440x1440")
+	assert.Equal(t, []string{"a", "b"}, ids)
+	assert.Equal(t, []string{"a", "b"}, before.buckets["1920x1080"])
+	assert.Len(t, before.images, 2)
+
+	// New reads see the new state
+	_, ok := store.GetByID("a")
+	assert.False(t, ok)
+	assert.False(t, store.Exists("a"))
+	assert.True(t, store.Contains("c"))
+	assert.Equal(t, []string{"b", "c"}, store.GetIDsForResolution("1920x1080"))
+	assert.Equal(t, 2, store.Count())
+	assert.Greater(t, store.Version(), before.version)
+}
+
+func TestStoreSnapshot_VersionAndCounts(t *testing.T) {
+	store := NewImageStore()
+	store.SetAsyncSave(false)
+	assert.Zero(t, store.Version())
+	assert.Empty(t, store.List())
+	assert.Nil(t, store.GetIDsForResolution("1920x1080"))
+
+	require.True(t, store.Add(provider.Image{ID: "a", FilePath: "/a.jpg"}))
+	v := store.Version()
+	assert.Positive(t, v)
+
+	store.MarkSeen("/a.jpg")
+	assert.Greater(t, store.Version(), v)
+	assert.Equal(t, 1, store.SeenCount())
+	assert.Equal(t, map[string]bool{"a": true}, store.GetKnownIDs())
+
+	// Failed writes publish nothing
+	v = store.Version()
+	assert.False(t, store.SetFavorited("missing", true))
+	assert.Equal(t, v, store.Version())
+}
+
+func TestStoreSnapshot_SetResultFlags(t *testing.T) {
+	store := NewImageStore()
+	store.SetAsyncSave(false)
+	require.True(t, store.Add(provider.Image{ID: "a", ProcessingFlags: map[string]bool{"SmartFit": true, "Upscaled:1920x1080": true}}))
+	before, _ := store.GetByID("a")
+
+	assert.True(t, store.SetResultFlags("a", map[string]bool{"Upscaled:1920x1080": false, "VirtualFramed:1920x1080": true}))
+	assert.False(t, store.SetResultFlags("missing", map[string]bool{"Upscaled:1920x1080": true}))
+
+	after, _ := store.GetByID("a")
+	assert.Equal(t, map[string]bool{"SmartFit": true, "VirtualFramed:1920x1080": true}, after.ProcessingFlags)
+	assert.Equal(t, map[string]bool{"SmartFit": true, "Upscaled:1920x1080": true}, before.ProcessingFlags, "earlier reads keep their map")
+}
+
+func TestStoreSnapshot_SyncPublishesOnce(t *testing.T) {
+	tmpDir := t.TempDir()
+	fm := NewFileManager(tmpDir)
+	require.NoError(t, fm.EnsureDirs())
+	store := NewImageStore()
+	store.SetAsyncSave(false)
+	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
+	for _, id := range []string{"a", "b", "c"} {
+		masterPath, _ := fm.GetMasterPath(id, ".jpg")
+		require.NoError(t, os.WriteFile(masterPath, []byte("x"), 0644))
+		require.True(t, store.Add(provider.Image{ID: id, FilePath: masterPath}))
+	}
+
+	v := store.Version()
+	store.Sync(2, nil, nil)
+	assert.Equal(t, v+1, store.Version())
+	assert.Equal(t, 2, store.Count())
+	assert.Len(t, store.List(), 2)
+
+	// Nothing to prune, nothing to publish
+	store.Sync(2, nil, nil)
+	assert.Equal(t, v+1, store.Version())
+}
+
+// TestStoreSnapshot_ConcurrentReadersAndWriters is meant for `go test -race`.
+func TestStoreSnapshot_ConcurrentReadersAndWriters(t *testing.T) {
+	store := NewImageStore()
+	store.SetAsyncSave(false)
+	for i := range 50 {
+		store.Add(benchImage(fmt.Sprintf("bench_%d", i)))
+	}
+
+	var wg sync.WaitGroup
+	for w := range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				id := fmt.Sprintf("bench_%d", (i+w)%50)
+				store.SetTuningOptions(id, benchResolutions[0], provider.TuningOptions{TightCrop: i%2 == 0})
+				store.SetDerivativePath(id, benchResolutions[1], "/new.jpg")
+				store.replace(benchImage(id))
+				store.Add(benchImage(fmt.Sprintf("new_%d_%d", w, i)))
+				store.Remove(fmt.Sprintf("new_%d_%d", w, i))
+			}
+		}()
+	}
+	for r := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 500 {
+				for _, id := range store.GetIDsForResolution(benchResolutions[(r+i)%len(benchResolutions)]) {
+					if img, ok := store.GetByID(id); ok {
+						_ = img.GetTuning(benchResolutions[0])
+						_ = len(img.DerivativePaths)
+					}
+				}
+				_ = store.List()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 50, store.Count())
+	for _, res := range benchResolutions {
+		assert.Len(t, store.GetIDsForResolution(res), 50, res)
+	}
+}
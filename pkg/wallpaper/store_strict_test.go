@@ -19,6 +19,7 @@ func TestStrictSync_LegacySafety(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, cacheFile)
+	t.Cleanup(store.Close)
 
 	// Setup: Image with a SourceID
 	img1 := provider.Image{ID: "img1", SourceQueryID: "q1", FilePath: filepath.Join(tmpDir, "img1.jpg"), DerivativePaths: map[string]string{"1920x1080": "d1.jpg"}}
@@ -47,6 +48,7 @@ func TestStrictSync_UncheckAll(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, cacheFile)
+	t.Cleanup(store.Close)
 
 	// img1: From q1
 	img1 := provider.Image{ID: "img1", SourceQueryID: "q1", FilePath: filepath.Join(tmpDir, "img1.jpg"), DerivativePaths: map[string]string{"1920x1080": "d1.jpg"}}
@@ -84,6 +86,7 @@ func TestStrictSync_MixedState(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, cacheFile)
+	t.Cleanup(store.Close)
 
 	// Setup 4 images
 	// 1. qA (Active) -> Keep
@@ -18,6 +18,7 @@ func TestStorePersistence(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, cacheFile)
+	t.Cleanup(store.Close)
 
 	img1 := provider.Image{ID: "img1", Path: "http://example.com/1.jpg", FilePath: filepath.Join(tmpDir, "1.jpg")}
 	img2 := provider.Image{ID: "img2", Path: "http://example.com/2.jpg", FilePath: filepath.Join(tmpDir, "2.jpg")}
@@ -27,15 +28,17 @@ func TestStorePersistence(t *testing.T) {
 	store.Add(img2)
 	store.SaveCache()
 
-	// Verify file exists
-	if _, err := os.Stat(cacheFile); os.IsNotExist(err) {
-		t.Fatal("Cache file not created")
+	// Verify the database exists
+	if _, err := os.Stat(dbPathFor(cacheFile)); os.IsNotExist(err) {
+		t.Fatal("Cache database not created")
 	}
 
 	// Test Load
+	store.Close()
 	store2 := NewImageStore()
 	store2.SetAsyncSave(false)
 	store2.SetFileManager(fm, cacheFile)
+	t.Cleanup(store2.Close)
 	err := store2.LoadCache()
 	assert.NoError(t, err)
 	assert.Equal(t, 2, store2.Count())
@@ -139,6 +142,7 @@ func TestStoreSync_Validation(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, cacheFile)
+	t.Cleanup(store.Close)
 
 	// Setup:
 	// img1: Master Exists.
@@ -176,6 +180,7 @@ func TestStoreSync_Grooming(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, cacheFile)
+	t.Cleanup(store.Close)
 
 	// Add 3 images
 	// All have masters
@@ -216,6 +221,7 @@ func TestStoreSync_CacheInvalidation(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, cacheFile)
+	t.Cleanup(store.Close)
 
 	// img1: Processed with SmartFit=true
 	img1 := provider.Image{
@@ -259,6 +265,7 @@ func TestStoreSync_IncompatibleTagsPreserved(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, cacheFile)
+	t.Cleanup(store.Close)
 
 	// Full processing flags as set by downloader.go, PLUS an incompatibility tag
 	imgFlags := map[string]bool{
@@ -268,6 +275,7 @@ func TestStoreSync_IncompatibleTagsPreserved(t *testing.T) {
 		"FaceCrop":               false,
 		"FaceBoost":              false,
 		"incompatible:1920x1080": true, // metadata tag from rejection tagging
+		"Upscaled:3440x1440":     true, // result tag from derivative generation
 	}
 
 	img1 := provider.Image{
@@ -295,7 +303,7 @@ func TestStoreSync_IncompatibleTagsPreserved(t *testing.T) {
 	store.Sync(100, targetFlags, nil)
 
 	// Image should be KEPT (not invalidated), because the processing flags match.
-	// The "incompatible:1920x1080" tag should be ignored by flagsMatch.
+	// The "incompatible:1920x1080" and "Upscaled:3440x1440" tags should be ignored by flagsMatch.
 	assert.Equal(t, 1, store.Count())
 	got, _ := store.Get(0)
 	assert.NotEmpty(t, got.DerivativePaths, "DerivativePaths should be preserved when flags match")
@@ -324,6 +332,7 @@ func TestRemoveByQueryID(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 
 	// Create test images
 	// q1: img1, img3
@@ -372,6 +381,7 @@ func TestStoreSync_StrictPruning(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, cacheFile)
+	t.Cleanup(store.Close)
 
 	// img1: Active Query "qA"
 	img1 := provider.Image{ID: "img1", SourceQueryID: "qA", FilePath: filepath.Join(tmpDir, "img1.jpg"), DerivativePaths: map[string]string{"1920x1080": "d1.jpg"}}
@@ -566,6 +576,7 @@ func TestNightlyGroomingSimulation(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, cacheFile)
+	t.Cleanup(store.Close)
 
 	// Current user config: SmartFit=true, Aggressive mode, FaceCrop=false, FaceBoost=false
 	currentMode := SmartFitAggressive
@@ -776,6 +787,7 @@ func TestNightlyGrooming_RegressionGuard_IncompleteFlags(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 
 	// Image with full downloader flags (as set in production)
 	fullFlags := makeDownloaderFlags(true, SmartFitAggressive, false, false)
@@ -867,6 +879,7 @@ func TestRemoveByQueryID_ResolutionBuckets(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 
 	img1 := provider.Image{
 		ID:            "q1_img1",
@@ -978,6 +991,7 @@ func TestBlocklist_RemoveAutoPopulates(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 
 	img := provider.Image{
 		ID: "bad_image",
@@ -1010,6 +1024,7 @@ func TestBlocklist_SyncPrunesBlockedImages(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 
 	// Add images with DerivativePaths (required to avoid zombie recovery deletion)
 	img1 := provider.Image{ID: "good_img", SourceQueryID: "q1", DerivativePaths: map[string]string{"1920x1080": "good.jpg"}}
@@ -1053,6 +1068,7 @@ func TestBlocklist_InteractionWithQueryRemoval(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 
 	// Phase 1: Add images from query q1
 	store.Add(provider.Image{ID: "q1_a", SourceQueryID: "q1"})
@@ -1091,6 +1107,7 @@ func TestBlocklist_SurvivesPersistence(t *testing.T) {
 	store1 := NewImageStore()
 	store1.SetAsyncSave(false)
 	store1.SetFileManager(fm, cacheFile)
+	t.Cleanup(store1.Close)
 
 	store1.Add(provider.Image{ID: "img_a"})
 	store1.Add(provider.Image{ID: "img_b"})
@@ -1098,9 +1115,11 @@ func TestBlocklist_SurvivesPersistence(t *testing.T) {
 	store1.SaveCache()
 
 	// Simulate app restart: Create new store, load cache
+	store1.Close()
 	store2 := NewImageStore()
 	store2.SetAsyncSave(false)
 	store2.SetFileManager(fm, cacheFile)
+	t.Cleanup(store2.Close)
 	err := store2.LoadCache()
 	assert.NoError(t, err)
 
@@ -1136,6 +1155,7 @@ func TestBlocklist_ResolutionBucketsAfterSyncPrune(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 
 	img1 := provider.Image{
 		ID: "clean_img",
@@ -1238,6 +1258,7 @@ func TestNightlyCycle_ProcessImageJobDoesNotClobber(t *testing.T) {
 	store := NewImageStore()
 	store.SetAsyncSave(false)
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 
 	flags := makeDownloaderFlags(true, SmartFitAggressive, true, false)
 	target := makeGroomingTarget(true, SmartFitAggressive, true, false)
@@ -2014,6 +2035,7 @@ func TestStore_MigrateLegacyCropAnchorsToTuning(t *testing.T) {
 	tmpDir := t.TempDir()
 	store := NewImageStore()
 	store.SetFileManager(NewFileManager(tmpDir), tmpFile.Name())
+	t.Cleanup(store.Close)
 	err = store.LoadCache()
 	assert.NoError(t, err)
 
@@ -2077,6 +2099,7 @@ func TestStore_PersistenceAcrossRestarts(t *testing.T) {
 	store1 := NewImageStore()
 	store1.SetAsyncSave(false)
 	store1.SetFileManager(NewFileManager(t.TempDir()), tmpPath)
+	t.Cleanup(store1.Close)
 	store1.Add(provider.Image{ID: "persist_test"})
 
 	opts := provider.TuningOptions{
@@ -2089,8 +2112,10 @@ func TestStore_PersistenceAcrossRestarts(t *testing.T) {
 	store1.SetTuningOptions("persist_test", "3440x1440", opts)
 
 	// Step 2: "Restart" - read from disk into a fresh store
+	store1.Close()
 	store2 := NewImageStore()
 	store2.SetFileManager(NewFileManager(t.TempDir()), tmpPath)
+	t.Cleanup(store2.Close)
 	err = store2.LoadCache()
 	assert.NoError(t, err)
 
@@ -2111,6 +2136,7 @@ func TestStore_DetermineSyncAction(t *testing.T) {
 	fm := NewFileManager(tmpDir)
 	store := NewImageStore()
 	store.SetFileManager(fm, filepath.Join(tmpDir, "cache.json"))
+	t.Cleanup(store.Close)
 
 	// Create a dummy master file so masterFileExists returns true
 	dummyID := "test_image"
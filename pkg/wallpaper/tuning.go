@@ -34,6 +34,14 @@ type TuningConfig struct {
 	// Crop Anchor
 	AnchorBlendFace   float64 `json:"anchor_blend_face"`    // Default: 0.6 (60% anchor, 40% face)
 	AnchorBlendNoFace float64 `json:"anchor_blend_no_face"` // Default: 0.85 (85% anchor, 15% center)
+
+	// Quality Gate (community sources; 0 disables a check)
+	QualityMinSharpness  float64 `json:"quality_min_sharpness"`  // Default: 25 (Laplacian variance of the most detailed areas)
+	QualityMaxNoise      float64 `json:"quality_max_noise"`      // Default: 5 (Noise standard deviation, in 8-bit luma levels)
+	QualityMaxBlockiness float64 `json:"quality_max_blockiness"` // Default: 3 (JPEG block-edge step ratio; clean images score ~1)
+
+	// Duplicate Detection (0 disables)
+	DuplicateMaxDistance int `json:"duplicate_max_distance"` // Default: 4 (Differing perceptual hash bits, out of 64, for two copies of one picture)
 }
 
 // DefaultTuningConfig returns the standard values for Spice 1.6.2.
@@ -61,5 +69,9 @@ func DefaultTuningConfig() TuningConfig {
 		EncodingQuality:               95,
 		AnchorBlendFace:               0.6,
 		AnchorBlendNoFace:             0.85,
+		QualityMinSharpness:           25,
+		QualityMaxNoise:               5,
+		QualityMaxBlockiness:          3,
+		DuplicateMaxDistance:          4,
 	}
 }
@@ -19,4 +19,10 @@ func TestDefaultTuningConfig(t *testing.T) {
 	// Verify other critical defaults
 	assert.Equal(t, 0.05, cfg.MinEnergyThreshold, "MinEnergyThreshold should be 0.05")
 	assert.Equal(t, 0.2, cfg.FeetGuardHighEnergyThreshold, "FeetGuardHighEnergyThreshold should be 0.2")
+
+	// Quality gate defaults
+	assert.Equal(t, 25.0, cfg.QualityMinSharpness, "QualityMinSharpness should be 25")
+	assert.Equal(t, 5.0, cfg.QualityMaxNoise, "QualityMaxNoise should be 5")
+	assert.Equal(t, 3.0, cfg.QualityMaxBlockiness, "QualityMaxBlockiness should be 3")
+	assert.Equal(t, 4, cfg.DuplicateMaxDistance, "DuplicateMaxDistance should be 4")
 }
@@ -21,6 +21,7 @@ func (wp *Plugin) CreateTrayMenu() *schema.MenuSchema {
 		image       provider.Image
 		initialized bool
 		paused      bool
+		pinned      string
 		displayName string
 	}
 
@@ -34,6 +35,7 @@ func (wp *Plugin) CreateTrayMenu() *schema.MenuSchema {
 			image:       mc.State.CurrentImage,
 			initialized: mc.State.CurrentID != "" || mc.State.CurrentImage.ID != "",
 			paused:      mc.State.Paused,
+			pinned:      mc.State.PinnedDaily,
 		}
 		mc.mu.RUnlock()
 
@@ -82,11 +84,7 @@ func (wp *Plugin) CreateTrayMenu() *schema.MenuSchema {
 		providerIcon := ""
 		var providerIconBytes []byte
 		if isInitialized {
-			attribution := SanitizeMenuString(currentImage.Attribution)
-			runes := []rune(attribution)
-			if len(runes) > 20 {
-				attribution = string(runes[:17]) + "..."
-			}
+			attribution := truncateMenuString(currentImage.Attribution)
 			providerLabel = i18n.Tf("Source: {{.Provider}}", map[string]any{"Provider": wp.GetProviderTitle(currentImage.Provider)})
 
 			attrType := provider.AttributionBy
@@ -102,7 +100,10 @@ func (wp *Plugin) CreateTrayMenu() *schema.MenuSchema {
 				}
 			}
 
-			if currentImage.Attribution == "" {
+			if attrType == provider.AttributionIn && currentImage.Artist != "" {
+				// Personal photos name their folder or album; the photographer from the file's metadata says more.
+				artistLabel = i18n.Tf("attribution_by", map[string]any{"Attribution": truncateMenuString(currentImage.Artist)})
+			} else if currentImage.Attribution == "" {
 				artistLabel = i18n.T("By: Unknown")
 			} else {
 				key := "attribution_by"
@@ -176,6 +177,19 @@ func (wp *Plugin) CreateTrayMenu() *schema.MenuSchema {
 		if wp.cfg.GetWallpaperChangeFrequency() != FrequencyNever {
 			res = append(res, pauseItem)
 		}
+		if _, isDaily := wp.dailyImageDay(currentImage); snap.pinned != "" || (isInitialized && isDaily) {
+			pinLabel := i18n.T("Pin Today's Image")
+			if snap.pinned != "" {
+				pinLabel = i18n.T("Unpin Today's Image")
+			}
+			res = append(res, schema.MenuItemSchema{
+				Label:    pinLabel,
+				IconName: "anchor.png",
+				Action: func() {
+					go wp.TogglePinDailyAction(mID)
+				},
+			})
+		}
 		res = append(res, shuffleItem)
 		res = append(res, schema.MenuItemSchema{IsSeparator: true})
 		res = append(res, providerMenuItem)
@@ -114,6 +114,26 @@ func (b *PrefsPanelBuilder) BuildGeneralTabSchema() *schema.PanelSchema {
 							b.plugin.cfg.SetCacheSize(size)
 						},
 					},
+					schema.SelectItem{
+						Name:         "diskQuota",
+						Label:        i18n.T("Disk Quota:"),
+						Help:         i18n.T("Set how much disk space cached images may use, counting originals and resized copies. Favorites and the wallpapers on screen are always kept."),
+						Options:      setting.StringOptions(GetDiskQuotas()),
+						InitialValue: int(b.plugin.cfg.GetDiskQuota()),
+						ApplyFunc: func(val interface{}) {
+							b.plugin.cfg.SetDiskQuota(DiskQuota(val.(int)))
+						},
+					},
+					schema.SelectItem{
+						Name:         "evictionMode",
+						Label:        i18n.T("When the Cache Is Full:"),
+						Help:         i18n.T("Choose which images are deleted first when the cache is over its size or disk quota:\n- Oldest First: Images downloaded longest ago.\n- Least Recently Shown: Images that have not been on screen for the longest time.\n- Least Shown: Images shown the fewest times.\n- Fair Across Sources: Images from the source using the most space."),
+						Options:      GetEvictionModes(),
+						InitialValue: int(b.plugin.cfg.GetEvictionMode()),
+						ApplyFunc: func(val interface{}) {
+							b.plugin.cfg.SetEvictionMode(EvictionMode(val.(int)))
+						},
+					},
 				},
 			},
 			{
@@ -182,6 +202,41 @@ func (b *PrefsPanelBuilder) BuildGeneralTabSchema() *schema.PanelSchema {
 							}
 						},
 					},
+					schema.BoolItem{
+						Name:         "upscale",
+						Label:        i18n.T("Upscale Near-Miss Images:"),
+						Help:         i18n.T("Lightly sharpen images that had to be enlarged slightly to fit your screen. Images are never skipped for being too small."),
+						InitialValue: b.plugin.cfg.GetUpscaleEnabled(),
+						NeedsRefresh: true,
+						EnabledIf: func() bool {
+							val := b.sm.GetValue("smartFitMode")
+							if val == nil {
+								return true
+							}
+							return SmartFitMode(val.(int)) != SmartFitOff
+						},
+						ApplyFunc: func(val bool) {
+							b.plugin.cfg.SetUpscaleEnabled(val)
+						},
+					},
+					schema.SliderItem{
+						Name:         "upscaleMaxFactor",
+						Label:        i18n.T("Maximum Upscale (%):"),
+						Help:         i18n.T("The largest enlargement that is sharpened. Images that need more are still used, without sharpening."),
+						Min:          105,
+						Max:          200,
+						Step:         5,
+						Format:       "%.0f%%",
+						InitialValue: b.plugin.cfg.GetUpscaleMaxFactor() * 100.0,
+						NeedsRefresh: true,
+						EnabledIf: func() bool {
+							val := b.sm.GetValue("upscale")
+							return val == nil || val.(bool)
+						},
+						ApplyFunc: func(val float64) {
+							b.plugin.cfg.SetUpscaleMaxFactor(val / 100.0)
+						},
+					},
 				},
 			},
 			{
@@ -288,6 +343,15 @@ func (b *PrefsPanelBuilder) BuildGeneralTabSchema() *schema.PanelSchema {
 							b.plugin.SyncMonitors(true)
 						},
 					},
+					schema.ButtonItem{
+						Name:       "checkCache",
+						Label:      i18n.T("Cache Integrity:"),
+						Help:       i18n.T("Check downloaded wallpapers for missing or leftover files and repair what is found. Nightly maintenance repairs them too, but only this button restores missing favorites."),
+						ButtonText: i18n.T("Check & Repair"),
+						OnPressed: func() {
+							go b.plugin.RepairCache()
+						},
+					},
 					schema.ConfirmButtonItem{
 						Name:           "clearCache",
 						Label:          i18n.T("Clear Wallpaper Cache:"),
@@ -437,8 +501,9 @@ func (b *PrefsPanelBuilder) createProviderAccordionItem(p provider.ImageProvider
 	isPending := false
 	pendingURL := ""
 
-	// Check pending state
-	if b.plugin.pendingAddUrl != "" {
+	// Check pending state. The URL belongs to the provider OpenAddCollectionUI matched; catch-all
+	// providers sorted before it would otherwise accept it as well.
+	if b.plugin.pendingAddUrl != "" && (b.plugin.focusProviderName == "" || b.plugin.focusProviderName == p.ID()) {
 		if _, err := p.ParseURL(b.plugin.pendingAddUrl); err == nil {
 			pendingURL = b.plugin.pendingAddUrl
 			isPending = true
@@ -381,6 +381,29 @@ func TestReactiveAccordionTitle(t *testing.T) {
 	assert.Contains(t, titleFunc(), "(1 active)")
 }
 
+func TestPendingURLGoesToFocusedProvider(t *testing.T) {
+	wp := &Plugin{providers: make(map[string]provider.ImageProvider)}
+	feed := &MockProvider{IDVal: "Feed", TitleVal: "Feeds"} // ParseURL accepts anything
+	pexels := &MockProvider{IDVal: "Pexels", TitleVal: "Pexels"}
+	wp.providers["Feed"] = feed
+	wp.providers["Pexels"] = pexels
+
+	wp.pendingAddUrl = "https://www.pexels.com/search/nature/"
+	wp.focusProviderName = "Pexels"
+
+	builder := NewPrefsPanelBuilder(wp, NewMockSettingsManager())
+
+	// "Feed" sorts first and would accept the URL, but OpenAddCollectionUI matched Pexels.
+	_, tabIdx := builder.createProviderAccordionItem(feed)
+	assert.Equal(t, 0, tabIdx)
+	assert.Equal(t, "https://www.pexels.com/search/nature/", wp.pendingAddUrl, "pending URL must not be consumed by another provider")
+
+	_, tabIdx = builder.createProviderAccordionItem(pexels)
+	assert.Equal(t, 2, tabIdx)
+	assert.Empty(t, wp.pendingAddUrl)
+	assert.Empty(t, wp.focusProviderName)
+}
+
 // Minimal MockProvider for testing
 type MockProvider struct {
 	mock.Mock
@@ -14,6 +14,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -77,6 +78,7 @@ type Plugin struct {
 	fm           *FileManager
 	pipeline     *Pipeline
 	jobSubmitter JobSubmitter // Interface for testing
+	folders      *folderWatcher
 
 	// Configuration and State
 	fetchingInProgress *util.SafeFlag
@@ -116,6 +118,9 @@ type Plugin struct {
 	// Timer Interrupt
 	resetTimerCh chan struct{}
 	tickerCancel context.CancelFunc
+
+	// Serializes cache integrity checks; the nightly one is skipped while another runs
+	cacheCheckMu sync.Mutex
 }
 
 var (
@@ -308,10 +313,12 @@ func (wp *Plugin) Init(manager ui.PluginManager) {
 	downloadsPath := filepath.Join(config.GetWorkingDir(), strings.ToLower(pluginName)+"_downloads")
 	wp.fm = NewFileManager(downloadsPath)
 
-	cachePath := filepath.Join(downloadsPath, "image_cache_map.json")
+	cachePath := filepath.Join(downloadsPath, "image_cache_map.json") // Legacy JSON cache; the store database lives beside it
 	wp.store.SetFileManager(wp.fm, cachePath)
 	wp.store.SetAsyncSave(true)
 	wp.store.SetDebounceDuration(1 * time.Second)
+	wp.store.SetDuplicateMaxDistance(wp.cfg.Tuning.DuplicateMaxDistance)
+	wp.store.SetInUseFunc(wp.imagesOnScreen)
 
 	wp.store.SetQueryActiveFunc(func(queryID string) bool {
 		if queryID == "Favorites" {
@@ -417,12 +424,16 @@ func (wp *Plugin) Activate() {
 		mc.OnWallpaperChanged = func(img provider.Image, monitorID int) {
 			go wp.updateTrayMenuUI(img, monitorID)
 		}
+		mc.OnImageDisplayed = func(img provider.Image) {
+			go wp.trackDownload(img)
+		}
 		mc.OnFavoriteRequest = func(img provider.Image) {
 			go wp.ToggleFavorite(img) // Defensive: ensure never called under mc.mu
 		}
 		mc.OnFetchRequest = func() {
 			wp.RequestFetch()
 		}
+		mc.ImageDay = wp.dailyImageDay
 		mc.Start()
 		wp.Monitors[m.ID] = mc
 		log.Printf("Monitor Actor %d started: %s %v", m.ID, m.Name, m.Rect)
@@ -459,6 +470,9 @@ func (wp *Plugin) Activate() {
 	// Start Pipeline
 	wp.pipeline.Start(workers)
 
+	// Watch local folder queries so added and deleted files show up without waiting for a fetch
+	wp.startFolderWatcher()
+
 	// Nightly scheduler now runs unconditionally to handle metadata syncs, updates, etc.
 	// Actual image downloading is gated within the scheduler by GetNightlyRefresh().
 	go wp.StartNightlyRefresh()
@@ -518,6 +532,8 @@ func (wp *Plugin) Deactivate() {
 
 	wp.stopAllWorkers()
 
+	wp.stopFolderWatcher()
+
 	// Stop Pipeline and clear the submitter so any fetch goroutine racing past the
 	// context cancellation bails out via the nil guard instead of using a stopped pipeline.
 	wp.downloadMutex.Lock()
@@ -537,6 +553,11 @@ func (wp *Plugin) Deactivate() {
 	}
 	wp.monMu.Unlock()
 
+	// Write pending store changes and close its database
+	if wp.store != nil {
+		wp.store.Close()
+	}
+
 	// Safely drain background file operations with bounded timeout
 	if wp.fm != nil {
 		wp.fm.Close()
@@ -669,6 +690,30 @@ func (wp *Plugin) IsMonitorPaused(monitorID int) bool {
 	return false
 }
 
+// TogglePinDailyAction pins a monitor to the daily provider of its current image, or unpins it.
+// A pinned monitor shows the provider's newest image and only changes at local midnight.
+func (wp *Plugin) TogglePinDailyAction(monitorID int) {
+	willBePinned := wp.GetMonitorDailyPin(monitorID) == ""
+	wp.dispatch(monitorID, CmdPinDaily)
+
+	msg := i18n.Tf("Display {{.ID}}: Back to rotation", map[string]any{"ID": monitorID + 1})
+	if willBePinned {
+		msg = i18n.Tf("Display {{.ID}}: Showing today's image", map[string]any{"ID": monitorID + 1})
+	}
+	wp.manager.NotifyUser(i18n.T("Daily Image"), msg)
+}
+
+// GetMonitorDailyPin returns the ID of the daily provider a monitor is pinned to, or "" if it rotates normally.
+// NOTE: Like IsMonitorPaused, this reads actor state without the actor lock; it is for UI display only.
+func (wp *Plugin) GetMonitorDailyPin(monitorID int) string {
+	wp.monMu.RLock()
+	defer wp.monMu.RUnlock()
+	if mc, ok := wp.Monitors[monitorID]; ok {
+		return mc.State.PinnedDaily
+	}
+	return ""
+}
+
 // GetShortcutsDisabled returns whether hotkeys are disabled.
 func (wp *Plugin) GetShortcutsDisabled() bool {
 	return wp.cfg.GetShortcutsDisabled()
@@ -1038,12 +1083,16 @@ func (wp *Plugin) SyncMonitors(force bool) {
 			mc.OnWallpaperChanged = func(img provider.Image, monitorID int) {
 				go wp.updateTrayMenuUI(img, monitorID)
 			}
+			mc.OnImageDisplayed = func(img provider.Image) {
+				go wp.trackDownload(img)
+			}
 			mc.OnFavoriteRequest = func(img provider.Image) {
 				go wp.ToggleFavorite(img) // Defensive: ensure never called under mc.mu
 			}
 			mc.OnFetchRequest = func() {
 				wp.RequestFetch()
 			}
+			mc.ImageDay = wp.dailyImageDay
 			mc.Start()
 			wp.Monitors[m.ID] = mc
 			changed = true
@@ -1109,6 +1158,31 @@ func (wp *Plugin) updateTrayMenuUI(img provider.Image, monitorID int) {
 	}
 }
 
+// trackDownload reports a displayed image to its provider when the provider's terms require it.
+func (wp *Plugin) trackDownload(img provider.Image) {
+	if img.DownloadLocation == "" {
+		return
+	}
+	tracker, ok := wp.providers[img.Provider].(provider.DownloadTracker)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := tracker.TrackDownload(ctx, img); err != nil {
+		log.Printf("[%s] Failed to track download for %s: %v", img.Provider, img.ID, err)
+	}
+}
+
+// dailyImageDay resolves the publication day of an image from a DailyProvider.
+func (wp *Plugin) dailyImageDay(img provider.Image) (time.Time, bool) {
+	dp, ok := wp.providers[img.Provider].(provider.DailyProvider)
+	if !ok {
+		return time.Time{}, false
+	}
+	return dp.ImageDay(img)
+}
+
 // loadQueryPages reads the persistent query pagination state from disk.
 func (wp *Plugin) loadQueryPages() {
 	pagesPath := filepath.Join(config.GetWorkingDir(), strings.ToLower(pluginName)+"_downloads", "query_pages.json")
@@ -1157,6 +1231,7 @@ func (wp *Plugin) saveQueryPages() {
 
 func (wp *Plugin) onQueryRemoved(queryID string) {
 	log.Debugf("Plugin: Query %s removed. Clearing...", queryID)
+	wp.unwatchQueryFolder(queryID)
 	wp.CancelQueryContext(queryID)
 	wp.store.RemoveByQueryID(queryID)
 	wp.downloadMutex.Lock()
@@ -1166,6 +1241,7 @@ func (wp *Plugin) onQueryRemoved(queryID string) {
 
 func (wp *Plugin) onQueryDisabled(queryID string) {
 	log.Debugf("[Plugin] Query %s disabled. Clearing from cache/rotation...", queryID)
+	wp.unwatchQueryFolder(queryID)
 	wp.CancelQueryContext(queryID)
 	wp.store.RemoveByQueryID(queryID)
 
@@ -1181,9 +1257,58 @@ func (wp *Plugin) onQueryDisabled(queryID string) {
 
 func (wp *Plugin) onQueryEnabled(queryID string) {
 	log.Debugf("[Plugin] Query %s enabled. Triggering immediate fetch...", queryID)
+	wp.watchQueryFolder(queryID)
 	go wp.RequestFetch()
 }
 
+// startFolderWatcher starts watching the folders of all active folder queries.
+func (wp *Plugin) startFolderWatcher() {
+	wp.stopFolderWatcher()
+	fw, err := newFolderWatcher(wp.ctx, wp)
+	if err != nil {
+		log.Printf("Folder watching unavailable, local folders update on the next fetch: %v", err)
+		return
+	}
+	wp.downloadMutex.Lock()
+	wp.folders = fw
+	wp.downloadMutex.Unlock()
+	for _, q := range wp.cfg.GetActiveQueries() {
+		fw.Watch(q)
+	}
+}
+
+// stopFolderWatcher removes all folder watches.
+func (wp *Plugin) stopFolderWatcher() {
+	wp.downloadMutex.Lock()
+	fw := wp.folders
+	wp.folders = nil
+	wp.downloadMutex.Unlock()
+	if fw != nil {
+		fw.Close()
+	}
+}
+
+func (wp *Plugin) watchQueryFolder(queryID string) {
+	wp.downloadMutex.RLock()
+	fw := wp.folders
+	wp.downloadMutex.RUnlock()
+	if fw == nil {
+		return
+	}
+	if q, ok := wp.cfg.GetQuery(queryID); ok && q.Active {
+		fw.Watch(q)
+	}
+}
+
+func (wp *Plugin) unwatchQueryFolder(queryID string) {
+	wp.downloadMutex.RLock()
+	fw := wp.folders
+	wp.downloadMutex.RUnlock()
+	if fw != nil {
+		fw.Unwatch(queryID)
+	}
+}
+
 func (wp *Plugin) ResetFavorites() {
 	log.Debugf("[Plugin] Resetting all favorites in store...")
 	wp.store.ResetFavorites()
@@ -1209,12 +1334,31 @@ func (wp *Plugin) syncStoreWithConfig() {
 		"FrameFallback":  wp.cfg.VirtualFramingFallback,
 		"WallNeutral":    wp.cfg.VirtualWallColor == WallNeutral,
 		"Matting":        wp.cfg.VirtualPaperMatting,
+		"Upscale":        wp.cfg.GetUpscaleEnabled(),
 		fmt.Sprintf("FrameSize_%.2f", wp.cfg.VirtualFrameSize): true,
+		upscaleMaxFlag(wp.cfg.GetUpscaleMaxFactor()):           wp.cfg.GetUpscaleEnabled(),
 	}
 
+	wp.store.SetDiskQuota(wp.cfg.GetDiskQuota().Bytes())
+	wp.store.SetEvictionPolicy(wp.cfg.GetEvictionMode().Policy())
 	wp.store.Sync(int(wp.cfg.GetCacheSize().Size()), targetFlags, wp.cfg.GetActiveQueryIDs())
 }
 
+// imagesOnScreen returns the IDs of the images currently set as wallpaper on any monitor.
+func (wp *Plugin) imagesOnScreen() map[string]bool {
+	ids := make(map[string]bool)
+	wp.monMu.RLock()
+	defer wp.monMu.RUnlock()
+	for _, mc := range wp.Monitors {
+		mc.mu.RLock()
+		if mc.State.CurrentID != "" {
+			ids[mc.State.CurrentID] = true
+		}
+		mc.mu.RUnlock()
+	}
+	return ids
+}
+
 func (wp *Plugin) GetProviderTitle(providerID string) string {
 	if p, ok := wp.providers[providerID]; ok {
 		return p.Title()
@@ -1234,18 +1378,31 @@ func (wp *Plugin) startEnrichmentWorker() {
 
 // OpenAddCollectionUI parses the given URL and opens the settings panel to add it as a query.
 func (wp *Plugin) OpenAddCollectionUI(testURL string) error {
-	for name, p := range wp.providers {
-		if !p.SupportsUserQueries() {
-			continue
-		}
-		result, err := p.ParseURL(testURL)
-		if err == nil && result != "" {
-			wp.pendingAddUrl = testURL
-			wp.focusProviderName = name
-			if wp.manager != nil {
-				wp.manager.OpenPreferences("Wallpaper")
+	names := make([]string, 0, len(wp.providers))
+	for name := range wp.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Site-specific providers get the first chance; catch-all providers only claim URLs nobody else recognises.
+	for _, catchAllPass := range []bool{false, true} {
+		for _, name := range names {
+			p := wp.providers[name]
+			if !p.SupportsUserQueries() {
+				continue
+			}
+			if ca, ok := p.(provider.CatchAllProvider); (ok && ca.AcceptsAnyURL()) != catchAllPass {
+				continue
+			}
+			result, err := p.ParseURL(testURL)
+			if err == nil && result != "" {
+				wp.pendingAddUrl = testURL
+				wp.focusProviderName = name
+				if wp.manager != nil {
+					wp.manager.OpenPreferences("Wallpaper")
+				}
+				return nil
 			}
-			return nil
 		}
 	}
 	return fmt.Errorf("no provider found that can handle this URL")
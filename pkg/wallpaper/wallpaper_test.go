@@ -118,6 +118,7 @@ func TestDownloadAllImages(t *testing.T) {
 	wp.fm = NewFileManager(wp.downloadedDir)
 	assert.NoError(t, wp.fm.EnsureDirs())
 	wp.store.SetFileManager(wp.fm, wp.downloadedDir+"/cache.json")
+	t.Cleanup(wp.store.Close)
 	wp.pipeline = NewPipeline(context.Background(), wp.cfg, wp.store.(*ImageStore), wp.ProcessImageJob, nil, nil)
 	wp.jobSubmitter = wp.pipeline
 	wp.pipeline.Start(1)
@@ -239,6 +240,7 @@ func TestDownloadAllImages_EnrichmentFailure(t *testing.T) {
 	wp.fm = NewFileManager(wp.downloadedDir)
 	assert.NoError(t, wp.fm.EnsureDirs())
 	wp.store.SetFileManager(wp.fm, wp.downloadedDir+"/cache.json")
+	t.Cleanup(wp.store.Close)
 	wp.pipeline = NewPipeline(context.Background(), wp.cfg, wp.store.(*ImageStore), wp.ProcessImageJob, nil, nil)
 	wp.jobSubmitter = wp.pipeline
 	wp.pipeline.Start(1)
@@ -282,6 +284,7 @@ func TestNavigation(t *testing.T) {
 	wp.fm = NewFileManager(wp.downloadedDir)
 	assert.NoError(t, wp.fm.EnsureDirs())
 	wp.store.SetFileManager(wp.fm, wp.downloadedDir+"/cache.json")
+	t.Cleanup(wp.store.Close)
 
 	// Setup Images
 	tempDir := t.TempDir()
@@ -448,6 +451,7 @@ func TestDeleteCurrentImage_PersistsBlock(t *testing.T) {
 	wp.fm = NewFileManager(wp.downloadedDir)
 	assert.NoError(t, wp.fm.EnsureDirs())
 	wp.store.SetFileManager(wp.fm, wp.downloadedDir+"/cache.json")
+	t.Cleanup(wp.store.Close)
 
 	// Create a dummy image file to delete
 	imgID := "delete_me"
@@ -608,6 +612,49 @@ func TestOpenAddCollectionUI_CuratedSkipped(t *testing.T) {
 	mockPM.AssertNotCalled(t, "OpenPreferences")
 }
 
+type CatchAllMockProvider struct {
+	MockImageProvider
+}
+
+func (m *CatchAllMockProvider) AcceptsAnyURL() bool {
+	return true
+}
+
+func TestOpenAddCollectionUI_CatchAllLast(t *testing.T) {
+	mockPM := new(MockPluginManager)
+	catchAll := new(CatchAllMockProvider)
+	specific := new(MockImageProvider)
+	wp := &Plugin{
+		manager:   mockPM,
+		providers: make(map[string]provider.ImageProvider),
+	}
+
+	testURL := "https://www.pexels.com/search/nature/"
+	wp.providers["Feed"] = catchAll
+	wp.providers["Pexels"] = specific
+
+	catchAll.On("SupportsUserQueries").Return(true)
+	specific.On("SupportsUserQueries").Return(true)
+	specific.On("ParseURL", testURL).Return("https://api.pexels.com/v1/search?query=nature", nil)
+	mockPM.On("OpenPreferences", "Wallpaper").Return()
+
+	err := wp.OpenAddCollectionUI(testURL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Pexels", wp.focusProviderName, "site-specific providers should win over catch-all ones")
+	catchAll.AssertNotCalled(t, "ParseURL", mock.Anything)
+
+	// A URL no site-specific provider recognises still reaches the catch-all provider.
+	feedURL := "https://blog.example.com/feed.xml"
+	specific.On("ParseURL", feedURL).Return("", assert.AnError)
+	catchAll.On("ParseURL", feedURL).Return(feedURL, nil)
+
+	err = wp.OpenAddCollectionUI(feedURL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Feed", wp.focusProviderName)
+}
+
 func TestGetProviderTitle(t *testing.T) {
 	// Setup
 	mockProvider := new(MockImageProvider)
@@ -2045,9 +2045,13 @@ func (sm *SettingsManager) renderQueryList(v schema.QueryListItem) *widget.List
 		// CreateItem — builds the cell template (no data binding here)
 		func() fyne.CanvasObject {
 			urlLink := widget.NewHyperlink(i18n.T("Placeholder"), nil)
+			optionCheck := widget.NewCheck(v.OptionLabel, nil)
+			if v.OptionLabel == "" {
+				optionCheck.Hide()
+			}
 			activeCheck := widget.NewCheck(i18n.T("Active"), nil)
 			deleteButton := widget.NewButton(i18n.T("Delete"), func() {})
-			return container.NewHBox(urlLink, layout.NewSpacer(), activeCheck, deleteButton)
+			return container.NewHBox(urlLink, layout.NewSpacer(), optionCheck, activeCheck, deleteButton)
 		},
 		// UpdateItem — binds data to a recycled cell (scroll-safe)
 		func(i int, o fyne.CanvasObject) {
@@ -2060,8 +2064,9 @@ func (sm *SettingsManager) renderQueryList(v schema.QueryListItem) *widget.List
 
 			c := o.(*fyne.Container)
 			urlLink := c.Objects[0].(*widget.Hyperlink)
-			activeCheck := c.Objects[2].(*widget.Check)
-			deleteButton := c.Objects[3].(*widget.Button)
+			optionCheck := c.Objects[2].(*widget.Check)
+			activeCheck := c.Objects[3].(*widget.Check)
+			deleteButton := c.Objects[4].(*widget.Button)
 
 			// Set display text
 			if v.GetDisplayText != nil {
@@ -2121,6 +2126,34 @@ func (sm *SettingsManager) renderQueryList(v schema.QueryListItem) *widget.List
 				sm.RefreshUI() // Update UI states (accordion titles) without triggering wallpaper changes
 			}
 
+			// Wire the per-query option like the Active check box; it applies without a refresh
+			if v.OptionLabel != "" {
+				optionKey := queryKey + ":option"
+				if sm.GetBaseline(optionKey) == nil {
+					sm.SeedBaseline(optionKey, query.Option)
+				}
+				optionCheck.OnChanged = nil
+				if sm.HasPendingChange(optionKey) {
+					optionCheck.SetChecked(!sm.GetBaseline(optionKey).(bool))
+				} else {
+					optionCheck.SetChecked(query.Option)
+				}
+				optionCheck.OnChanged = func(b bool) {
+					if b != sm.GetBaseline(optionKey).(bool) {
+						sm.SetSettingChangedCallback(optionKey, func() {
+							if err := v.SetOption(query.ID, b); err != nil {
+								utilLog.Printf("Failed to update query option: %v", err)
+							} else {
+								sm.SeedBaseline(optionKey, b)
+							}
+						})
+					} else {
+						sm.RemoveSettingChangedCallback(optionKey)
+					}
+					sm.checkAndEnableApply()
+				}
+			}
+
 			// Wire action button
 			label := i18n.T("Delete")
 			if v.DeleteLabel != "" {